@@ -1,97 +1,123 @@
 package appconfig
 
 import (
+    "context"
     "fmt"
 
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/appconfig"
+    "github.com/aws/aws-sdk-go/service/appconfig/appconfigiface"
 )
 
 // AppConfigService provides operations for AWS AppConfig resources.
 type AppConfigService struct {
-    Client *appconfig.AppConfig
+    Client appconfigiface.AppConfigAPI
 }
 
-// NewAppConfigService creates a new AppConfigService.
-func NewAppConfigService(sess *session.Session) *AppConfigService {
+// NewAppConfigService creates a new AppConfigService. cfgs are applied on top of the
+// session configuration.
+func NewAppConfigService(sess *session.Session, cfgs ...*aws.Config) *AppConfigService {
     return &AppConfigService{
-        Client: appconfig.New(sess),
+        Client: appconfig.New(sess, cfgs...),
     }
 }
 
 // CreateConfigurationProfile creates a new configuration profile in AppConfig.
-func (s *AppConfigService) CreateConfigurationProfile(applicationID, name, locationURI string) (*appconfig.CreateConfigurationProfileOutput, error) {
+func (s *AppConfigService) CreateConfigurationProfile(ctx context.Context, applicationID, name, locationURI string) (*appconfig.CreateConfigurationProfileOutput, error) {
     input := &appconfig.CreateConfigurationProfileInput{
         ApplicationId: aws.String(applicationID),
         Name:          aws.String(name),
         LocationUri:   aws.String(locationURI),
     }
 
-    result, err := s.Client.CreateConfigurationProfile(input)
+    result, err := s.Client.CreateConfigurationProfileWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to create configuration profile: %v", err)
+        return nil, fmt.Errorf("failed to create configuration profile: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
 // GetConfiguration retrieves the configuration for the specified profile.
-func (s *AppConfigService) GetConfiguration(applicationID, environmentID, configurationProfileID, clientID string) (*appconfig.GetConfigurationOutput, error) {
+func (s *AppConfigService) GetConfiguration(ctx context.Context, applicationID, environmentID, configurationProfileID, clientID string) (*appconfig.GetConfigurationOutput, error) {
     input := &appconfig.GetConfigurationInput{
-        ApplicationId:         aws.String(applicationID),
-        EnvironmentId:         aws.String(environmentID),
-        ConfigurationProfileId: aws.String(configurationProfileID),
-        ClientId:              aws.String(clientID),
+        Application:   aws.String(applicationID),
+        Environment:   aws.String(environmentID),
+        Configuration: aws.String(configurationProfileID),
+        ClientId:      aws.String(clientID),
     }
 
-    result, err := s.Client.GetConfiguration(input)
+    result, err := s.Client.GetConfigurationWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to get configuration: %v", err)
+        return nil, fmt.Errorf("failed to get configuration: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
-func (s *AppConfigService) UpdateConfigurationProfile(applicationID, profileID, name, locationURI string) (*appconfig.UpdateConfigurationProfileOutput, error) {
+// UpdateConfigurationProfile renames a configuration profile.
+//
+// Deprecated: The location of a profile cannot be changed once it is
+// created, so locationURI must be empty or the current location of the
+// profile. Use RenameConfigurationProfile.
+func (s *AppConfigService) UpdateConfigurationProfile(ctx context.Context, applicationID, profileID, name, locationURI string) (*appconfig.UpdateConfigurationProfileOutput, error) {
+    if locationURI != "" {
+        current, err := s.Client.GetConfigurationProfileWithContext(ctx, &appconfig.GetConfigurationProfileInput{
+            ApplicationId:          aws.String(applicationID),
+            ConfigurationProfileId: aws.String(profileID),
+        })
+        if err != nil {
+            return nil, fmt.Errorf("failed to update configuration profile: %w", helper.AWSError(err))
+        }
+        if aws.StringValue(current.LocationUri) != locationURI {
+            return nil, fmt.Errorf("failed to update configuration profile: its location %q cannot be changed to %q: %w", aws.StringValue(current.LocationUri), locationURI, cloud.ErrInvalidArgument)
+        }
+    }
+    return s.RenameConfigurationProfile(ctx, applicationID, profileID, name)
+}
+
+// RenameConfigurationProfile renames a configuration profile.
+func (s *AppConfigService) RenameConfigurationProfile(ctx context.Context, applicationID, profileID, name string) (*appconfig.UpdateConfigurationProfileOutput, error) {
     input := &appconfig.UpdateConfigurationProfileInput{
-        ApplicationId:         aws.String(applicationID),
+        ApplicationId:          aws.String(applicationID),
         ConfigurationProfileId: aws.String(profileID),
-        Name:                  aws.String(name),
-        LocationUri:           aws.String(locationURI),
+        Name:                   aws.String(name),
     }
 
-    result, err := s.Client.UpdateConfigurationProfile(input)
+    result, err := s.Client.UpdateConfigurationProfileWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to update configuration profile: %v", err)
+        return nil, fmt.Errorf("failed to update configuration profile: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
 // CreateEnvironment creates a new environment in AppConfig.
-func (s *AppConfigService) CreateEnvironment(applicationID, name, description string) (*appconfig.CreateEnvironmentOutput, error) {
+func (s *AppConfigService) CreateEnvironment(ctx context.Context, applicationID, name, description string) (*appconfig.CreateEnvironmentOutput, error) {
     input := &appconfig.CreateEnvironmentInput{
         ApplicationId: aws.String(applicationID),
         Name:          aws.String(name),
         Description:   aws.String(description),
     }
 
-    result, err := s.Client.CreateEnvironment(input)
+    result, err := s.Client.CreateEnvironmentWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to create environment: %v", err)
+        return nil, fmt.Errorf("failed to create environment: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
 // ValidateConfiguration validates a configuration against a schema or a set of rules.
-func (s *AppConfigService) ValidateConfiguration(applicationID, configurationProfileID, configurationVersion string) (*appconfig.ValidateConfigurationOutput, error) {
+func (s *AppConfigService) ValidateConfiguration(ctx context.Context, applicationID, configurationProfileID, configurationVersion string) (*appconfig.ValidateConfigurationOutput, error) {
     input := &appconfig.ValidateConfigurationInput{
-        ApplicationId:         aws.String(applicationID),
+        ApplicationId:          aws.String(applicationID),
         ConfigurationProfileId: aws.String(configurationProfileID),
         ConfigurationVersion:   aws.String(configurationVersion),
     }
 
-    result, err := s.Client.ValidateConfiguration(input)
+    result, err := s.Client.ValidateConfigurationWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to validate configuration: %v", err)
+        return nil, fmt.Errorf("failed to validate configuration: %w", helper.AWSError(err))
     }
     return result, nil
 }
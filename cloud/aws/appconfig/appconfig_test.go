@@ -0,0 +1,48 @@
+package appconfig_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+	"github.com/aws/aws-sdk-go/aws"
+	awsappconfig "github.com/aws/aws-sdk-go/service/appconfig"
+)
+
+func TestUpdateConfigurationProfile(t *testing.T) {
+	ctx := context.Background()
+	p := fake.NewProvider(nil)
+	app, err := p.AppConfig.CreateApplicationWithContext(ctx, &awsappconfig.CreateApplicationInput{Name: aws.String("web")})
+	if err != nil {
+		t.Fatalf("CreateApplication: %v", err)
+	}
+	appID := aws.StringValue(app.Id)
+	profile, err := p.AppConfigService.CreateConfigurationProfile(ctx, appID, "flags", "hosted")
+	if err != nil {
+		t.Fatalf("CreateConfigurationProfile: %v", err)
+	}
+	profileID := aws.StringValue(profile.Id)
+
+	renamed, err := p.AppConfigService.RenameConfigurationProfile(ctx, appID, profileID, "features")
+	if err != nil || aws.StringValue(renamed.Name) != "features" {
+		t.Fatalf("RenameConfigurationProfile = %v, %v", renamed, err)
+	}
+
+	// The deprecated form accepts no location or the current one.
+	for _, location := range []string{"", "hosted"} {
+		updated, err := p.AppConfigService.UpdateConfigurationProfile(ctx, appID, profileID, "flags-"+location, location)
+		if err != nil || aws.StringValue(updated.Name) != "flags-"+location || aws.StringValue(updated.LocationUri) != "hosted" {
+			t.Errorf("UpdateConfigurationProfile(%q) = %v, %v", location, updated, err)
+		}
+	}
+	_, err = p.AppConfigService.UpdateConfigurationProfile(ctx, appID, profileID, "moved", "s3://config/flags.json")
+	if !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("UpdateConfigurationProfile to a new location: got %v, want ErrInvalidArgument", err)
+	}
+	_, err = p.AppConfigService.UpdateConfigurationProfile(ctx, appID, "missing", "moved", "hosted")
+	if !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("UpdateConfigurationProfile of a missing profile: got %v, want ErrNotFound", err)
+	}
+}
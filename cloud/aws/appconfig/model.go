@@ -0,0 +1,46 @@
+package appconfig
+
+import (
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appconfig"
+)
+
+// ToConfigurationProfile converts a created configuration profile to a
+// model.ConfigurationProfile. An updated profile has the same fields and
+// converts with appconfig.CreateConfigurationProfileOutput(*out).
+func ToConfigurationProfile(p *appconfig.CreateConfigurationProfileOutput) *model.ConfigurationProfile {
+	return &model.ConfigurationProfile{
+		ID:          aws.StringValue(p.Id),
+		Application: aws.StringValue(p.ApplicationId),
+		Name:        aws.StringValue(p.Name),
+		Description: aws.StringValue(p.Description),
+		LocationURI: aws.StringValue(p.LocationUri),
+		Type:        aws.StringValue(p.Type),
+		Raw:         p,
+	}
+}
+
+// environmentStates maps AppConfig environment states to normalized states.
+var environmentStates = map[string]model.EnvironmentState{
+	appconfig.EnvironmentStateReadyForDeployment: model.EnvironmentReady,
+	appconfig.EnvironmentStateDeploying:          model.EnvironmentDeploying,
+	appconfig.EnvironmentStateRollingBack:        model.EnvironmentRollingBack,
+	appconfig.EnvironmentStateRolledBack:         model.EnvironmentRolledBack,
+}
+
+// ToEnvironment converts a created environment to a model.Environment.
+func ToEnvironment(e *appconfig.CreateEnvironmentOutput) *model.Environment {
+	env := &model.Environment{
+		ID:          aws.StringValue(e.Id),
+		Application: aws.StringValue(e.ApplicationId),
+		Name:        aws.StringValue(e.Name),
+		Description: aws.StringValue(e.Description),
+		State:       model.EnvironmentUnknown,
+		Raw:         e,
+	}
+	if state, ok := environmentStates[aws.StringValue(e.State)]; ok {
+		env.State = state
+	}
+	return env
+}
@@ -0,0 +1,88 @@
+package appconfig_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/appconfig"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	awsappconfig "github.com/aws/aws-sdk-go/service/appconfig"
+)
+
+func TestToConfigurationProfile(t *testing.T) {
+	created := &awsappconfig.CreateConfigurationProfileOutput{
+		Id:            aws.String("prof-1"),
+		ApplicationId: aws.String("app-1"),
+		Name:          aws.String("flags"),
+		Description:   aws.String("feature flags"),
+		LocationUri:   aws.String("hosted"),
+		Type:          aws.String("AWS.AppConfig.FeatureFlags"),
+	}
+	updated := awsappconfig.CreateConfigurationProfileOutput(awsappconfig.UpdateConfigurationProfileOutput{
+		Id:            aws.String("prof-1"),
+		ApplicationId: aws.String("app-1"),
+		Name:          aws.String("settings"),
+	})
+	tests := []struct {
+		name string
+		in   *awsappconfig.CreateConfigurationProfileOutput
+		want *model.ConfigurationProfile
+	}{
+		{
+			name: "created",
+			in:   created,
+			want: &model.ConfigurationProfile{
+				ID:          "prof-1",
+				Application: "app-1",
+				Name:        "flags",
+				Description: "feature flags",
+				LocationURI: "hosted",
+				Type:        "AWS.AppConfig.FeatureFlags",
+			},
+		},
+		{
+			name: "updated",
+			in:   &updated,
+			want: &model.ConfigurationProfile{ID: "prof-1", Application: "app-1", Name: "settings"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := appconfig.ToConfigurationProfile(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToConfigurationProfile = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToEnvironment(t *testing.T) {
+	tests := []struct {
+		state string
+		want  model.EnvironmentState
+	}{
+		{awsappconfig.EnvironmentStateReadyForDeployment, model.EnvironmentReady},
+		{awsappconfig.EnvironmentStateDeploying, model.EnvironmentDeploying},
+		{awsappconfig.EnvironmentStateRollingBack, model.EnvironmentRollingBack},
+		{awsappconfig.EnvironmentStateRolledBack, model.EnvironmentRolledBack},
+		{"", model.EnvironmentUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			in := &awsappconfig.CreateEnvironmentOutput{
+				Id:            aws.String("env-1"),
+				ApplicationId: aws.String("app-1"),
+				Name:          aws.String("prod"),
+				Description:   aws.String("production"),
+			}
+			if tt.state != "" {
+				in.State = aws.String(tt.state)
+			}
+			want := &model.Environment{ID: "env-1", Application: "app-1", Name: "prod", Description: "production", State: tt.want, Raw: in}
+			if got := appconfig.ToEnvironment(in); !reflect.DeepEqual(got, want) {
+				t.Errorf("ToEnvironment = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
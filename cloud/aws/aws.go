@@ -1,22 +1,39 @@
 package aws
 
 import (
+    "context"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "time"
+
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
     "github.com/Akshay-Verma-CS/c2loud/cloud/aws/appconfig"
     "github.com/Akshay-Verma-CS/c2loud/cloud/aws/ec2"
     "github.com/Akshay-Verma-CS/c2loud/cloud/aws/iam"
     "github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
     "github.com/Akshay-Verma-CS/c2loud/cloud/aws/sns"
     "github.com/Akshay-Verma-CS/c2loud/cloud/aws/sqs"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/cache"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+    "github.com/aws/aws-sdk-go/aws/defaults"
     "github.com/aws/aws-sdk-go/aws/session"
-	"sync"
+    sdkappconfig "github.com/aws/aws-sdk-go/service/appconfig"
+    sdkec2 "github.com/aws/aws-sdk-go/service/ec2"
+    sdkiam "github.com/aws/aws-sdk-go/service/iam"
+    sdksns "github.com/aws/aws-sdk-go/service/sns"
+    sdksqs "github.com/aws/aws-sdk-go/service/sqs"
+    "github.com/prometheus/client_golang/prometheus"
+    "go.opentelemetry.io/otel/trace"
 )
 
-var (
-    // instances holds the map of AWSProvider instances
-    instances = make(map[string]*AWSProvider)
-    // mutex is used to ensure thread-safe access to the instances map
-    mutex sync.Mutex
-)
+// instances caches the AWSProvider instances returned by GetInstance.
+var instances = NewCache()
+
+func init() {
+    cloud.Register(cloud.AWSProvider, newCloudProvider)
+}
 
 // AWSProvider holds the clients for various AWS services.
 type AWSProvider struct {
@@ -26,6 +43,11 @@ type AWSProvider struct {
     IAMService       *iam.IAMService
     EC2Service       *ec2.EC2Service
     AppConfigService *appconfig.AppConfigService
+
+    sess *session.Session
+    // httpClient is the HTTP client created for the TLS settings of the
+    // config, or nil when the provider uses the caller's or the default one.
+    httpClient *http.Client
 }
 
 // AWSConfig defines the configuration for AWSProvider.
@@ -36,243 +58,320 @@ type AWSConfig struct {
     IAMRoleARN     string
     SessionName    string
     Region         string
-}
 
+    // Profile selects the shared config and credentials profile. It defaults
+    // to $AWS_PROFILE, then "default". The profile can also set the region.
+    Profile string
+    // ExternalID, MFASerial and RoleDuration apply when assuming IAMRoleARN.
+    ExternalID   string
+    MFASerial    string
+    RoleDuration time.Duration
+    // RoleChain lists further roles assumed, in order, after IAMRoleARN.
+    RoleChain []AssumeRole
+    // MFATokenProvider returns the current code of the MFA device for roles
+    // that require one.
+    MFATokenProvider func() (string, error)
+    // CredentialsExpiryWindow is how long before expiry temporary credentials
+    // are refreshed. Zero means DefaultCredentialsExpiryWindow. EC2 instance
+    // role and ECS container credentials are always refreshed five minutes
+    // before they expire, as the SDK does.
+    CredentialsExpiryWindow time.Duration
+
+    // Endpoints overrides service endpoints, such as a LocalStack or MinIO
+    // URL. Keys are those of ServiceRetry, plus "sts" and "ec2metadata" for
+    // credential retrieval.
+    Endpoints map[string]string
+    // S3ForcePathStyle addresses buckets as http://host/bucket rather than
+    // http://bucket.host, as most S3 emulators require.
+    S3ForcePathStyle bool
+    // CABundle is the path of a PEM file of certificate authorities trusted
+    // in addition to the system roots.
+    CABundle string
+    // InsecureSkipVerify disables TLS certificate verification. It is meant
+    // for local development only.
+    InsecureSkipVerify bool
+    // HTTPClient replaces the default HTTP client of all services.
+    HTTPClient *http.Client
+
+    // Retry is the retry policy for all services. Nil means
+    // retry.DefaultPolicy().
+    Retry *retry.Policy
+    // ServiceRetry overrides Retry per service. Keys are "s3", "sqs", "sns",
+    // "iam", "ec2" and "appconfig".
+    ServiceRetry map[string]*retry.Policy
+
+    // TracerProvider, when set, traces every request to AWS, including those
+    // retrieving credentials from STS. See package telemetry.
+    TracerProvider trace.TracerProvider
+    // MetricsRegisterer, when set, is where the metrics of requests to AWS
+    // are registered. See package telemetry.
+    MetricsRegisterer prometheus.Registerer
+    // Logger, when set, logs every request to AWS. ServiceLogLevels raises
+    // the minimum level per service, keyed like ServiceRetry, and LogBodies
+    // adds request and response bodies at debug level. Secrets and message
+    // bodies are redacted. See package telemetry.
+    Logger           *slog.Logger
+    ServiceLogLevels map[string]slog.Level
+    LogBodies        bool
+}
+
+// NewAWSProvider creates a new AWSProvider with all the necessary service
+// clients.
 func NewAWSProvider(config *AWSConfig) (*AWSProvider, error) {
-    var sess *session.Session
-    var err error
-
-    if config.UseIAMRole {
-        // Assume an IAM role if specified
-        sess, err = session.NewSession(&aws.Config{Region: aws.String(config.Region)})
-        if err == nil {
-            creds := stscreds.NewCredentials(sess, config.IAMRoleARN, func(p *stscreds.AssumeRoleProvider) {
-                p.RoleSessionName = config.SessionName
-            })
-            sess.Config.Credentials = creds
-        }
-    } else {
-        // Use access keys if provided
-        sess, err = session.NewSession(&aws.Config{
-            Region:      aws.String(config.Region),
-            Credentials: credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, ""),
-        })
+    in, err := telemetry.New(cloud.AWSProvider, config.telemetryOptions())
+    if err != nil {
+        return nil, err
+    }
+    handlers := defaults.Handlers()
+    instrument(&handlers, in)
+    sess, err := newSession(config, handlers)
+    if err != nil {
+        return nil, err
     }
 
+    creds, err := newCredentials(sess, config)
     if err != nil {
         return nil, err
     }
+    sess.Config.Credentials = creds
 
     return &AWSProvider{
-        S3Service:        s3.NewS3Service(sess),
-        SQSService:       sqs.NewSQSService(sess),
-        SNSService:       sns.NewSNSService(sess),
-        IAMService:       iam.NewIAMService(sess),
-        EC2Service:       ec2.NewEC2Service(sess),
-        AppConfigService: appconfig.NewAppConfigService(sess),
+        S3Service:        s3.NewS3Service(sess, config.serviceConfigs("s3")...),
+        SQSService:       sqs.NewSQSService(sess, config.serviceConfigs("sqs")...),
+        SNSService:       sns.NewSNSService(sess, config.serviceConfigs("sns")...),
+        IAMService:       iam.NewIAMService(sess, config.serviceConfigs("iam")...),
+        EC2Service:       ec2.NewEC2Service(sess, config.serviceConfigs("ec2")...),
+        AppConfigService: appconfig.NewAppConfigService(sess, config.serviceConfigs("appconfig")...),
+        sess:             sess,
+        httpClient:       config.ownHTTPClient(sess),
     }, nil
 }
 
-func GetInstance(key string) (*AWSProvider, error) {
-    mutex.Lock()
-    defer mutex.Unlock()
+// Close releases the idle connections of the HTTP client that the provider
+// created for CABundle or InsecureSkipVerify. AWSConfig.HTTPClient and the
+// default client are left open, as they may be shared. The AWS clients hold
+// no other resources.
+func (p *AWSProvider) Close() error {
+    if p.httpClient != nil {
+        p.httpClient.CloseIdleConnections()
+    }
+    return nil
+}
 
-    if instance, exists := instances[key]; exists {
-        return instance, nil
+// newCloudProvider is the cloud.Factory for AWS. It accepts an *AWSConfig.
+func newCloudProvider(ctx context.Context, config interface{}) (*cloud.CloudProvider, error) {
+    cfg, ok := config.(*AWSConfig)
+    if !ok || cfg == nil {
+        return nil, fmt.Errorf("aws: expected *AWSConfig, got %T", config)
     }
 
-    // Create a new instance if it does not exist
-    instance, err := newAWSProvider()
+    provider, err := NewAWSProvider(cfg)
     if err != nil {
         return nil, err
     }
+    return &cloud.CloudProvider{
+        ObjectStore: s3.NewObjectStore(provider.S3Service),
+        Queue:       sqs.NewQueue(provider.SQSService),
+        Topic:       sns.NewTopic(provider.SNSService),
+        Compute:     ec2.NewCompute(provider.EC2Service),
+        Native:      provider,
+        Closer:      provider,
+    }, nil
+}
+
+// ProviderCache caches AWSProvider instances by key.
+type ProviderCache = cache.Cache[*AWSConfig, *AWSProvider]
+
+// NewCache returns an empty ProviderCache, for callers that manage provider
+// lifetimes themselves rather than through GetInstance.
+func NewCache() *ProviderCache {
+    return cache.New(func(ctx context.Context, config *AWSConfig) (*AWSProvider, error) {
+        return NewAWSProvider(config)
+    })
+}
+
+// GetInstance returns the AWSProvider cached under key, creating it from config
+// on first use. It fails with cache.ErrConfigMismatch if key was created from
+// a different config.
+//
+// Configs are compared by value, except for MFATokenProvider, HTTPClient,
+// TracerProvider, MetricsRegisterer, Logger and the Retryable functions of
+// the retry policies, which are compared by identity: pass the same ones to
+// get the cached provider. Nothing in the config is left out of the
+// comparison.
+func GetInstance(key string, config *AWSConfig) (*AWSProvider, error) {
+    return instances.Get(context.Background(), key, config)
+}
 
-    instances[key] = instance
-    return instance, nil
+// RefreshInstance replaces the AWSProvider cached under key with one created
+// from config, such as after rotating its access keys, and closes the old
+// one.
+func RefreshInstance(key string, config *AWSConfig) (*AWSProvider, error) {
+    return instances.Refresh(context.Background(), key, config)
 }
 
-func (p *AWSProvider) LaunchEC2Instance(imageID, instanceType string) (*ec2.Reservation, error) {
-    return p.EC2Service.LaunchInstance(imageID, instanceType)
+// EvictInstance removes the AWSProvider cached under key and closes it.
+func EvictInstance(key string) error {
+    return instances.Evict(key)
+}
+
+// CloseInstances closes and removes every cached AWSProvider.
+func CloseInstances() error {
+    return instances.Close()
+}
+
+// LaunchEC2Instance launches a new EC2 instance.
+func (p *AWSProvider) LaunchEC2Instance(ctx context.Context, imageID, instanceType string) (*sdkec2.Reservation, error) {
+    return p.EC2Service.LaunchInstance(ctx, imageID, instanceType)
 }
 
 // CreateS3Bucket creates a new S3 bucket.
-func (p *AWSProvider) CreateS3Bucket(bucketName string) error {
-    _, err := p.S3Service.CreateBucket(bucketName)
-    return err
+func (p *AWSProvider) CreateS3Bucket(ctx context.Context, bucketName string) error {
+    return p.S3Service.CreateBucket(ctx, bucketName)
 }
 
 // SendMessageToSQS sends a message to the specified SQS queue.
-func (p *AWSProvider) SendMessageToSQS(queueURL, messageBody string) error {
-    return p.SQSService.SendMessage(queueURL, messageBody)
+func (p *AWSProvider) SendMessageToSQS(ctx context.Context, queueURL, messageBody string) error {
+    return p.SQSService.SendMessage(ctx, queueURL, messageBody)
 }
 
 // CreateSNSTopic creates a new SNS topic.
-func (p *AWSProvider) CreateSNSTopic(topicName string) (string, error) {
-    return p.SNSService.CreateTopic(topicName)
+func (p *AWSProvider) CreateSNSTopic(ctx context.Context, topicName string) (string, error) {
+    return p.SNSService.CreateTopic(ctx, topicName)
 }
 
 // CreateUserIAM creates a new IAM user.
-func (p *AWSProvider) CreateUserIAM(userName string) error {
-    _, err := p.IAMService.CreateUser(userName)
+func (p *AWSProvider) CreateUserIAM(ctx context.Context, userName string) error {
+    _, err := p.IAMService.CreateUser(ctx, userName)
     return err
 }
 
 // GetAppConfig retrieves the configuration for a specified AppConfig profile.
-func (p *AWSProvider) GetAppConfig(applicationID, environmentID, configurationProfileID, clientID string) (*appconfig.GetConfigurationOutput, error) {
-    return p.AppConfigService.GetConfiguration(applicationID, environmentID, configurationProfileID, clientID)
+func (p *AWSProvider) GetAppConfig(ctx context.Context, applicationID, environmentID, configurationProfileID, clientID string) (*sdkappconfig.GetConfigurationOutput, error) {
+    return p.AppConfigService.GetConfiguration(ctx, applicationID, environmentID, configurationProfileID, clientID)
 }
 
-func (p *AWSProvider) DeleteEC2Instance(instanceID string) error {
-    _, err := p.EC2Service.TerminateInstances([]string{instanceID})
+// DeleteEC2Instance terminates an EC2 instance by its ID.
+func (p *AWSProvider) DeleteEC2Instance(ctx context.Context, instanceID string) error {
+    _, err := p.EC2Service.TerminateInstances(ctx, []string{instanceID})
     return err
 }
 
 // ListS3Buckets lists all S3 buckets.
-func (p *AWSProvider) ListS3Buckets() ([]string, error) {
-    return p.S3Service.ListBuckets()
+func (p *AWSProvider) ListS3Buckets(ctx context.Context) ([]string, error) {
+    return p.S3Service.ListBuckets(ctx)
 }
 
 // ReceiveMessageFromSQS receives messages from the specified SQS queue.
-func (p *AWSProvider) ReceiveMessageFromSQS(queueURL string) ([]*sqs.Message, error) {
-    return p.SQSService.ReceiveMessage(queueURL)
+func (p *AWSProvider) ReceiveMessageFromSQS(ctx context.Context, queueURL string) ([]*sdksqs.Message, error) {
+    return p.SQSService.ReceiveMessage(ctx, queueURL)
 }
 
 // DeleteSNSTopic deletes an SNS topic by its ARN.
-func (p *AWSProvider) DeleteSNSTopic(topicArn string) error {
-    return p.SNSService.DeleteTopic(topicArn)
+func (p *AWSProvider) DeleteSNSTopic(ctx context.Context, topicArn string) error {
+    return p.SNSService.DeleteTopic(ctx, topicArn)
 }
 
 // AttachPolicyToUserIAM attaches a policy to an IAM user.
-func (p *AWSProvider) AttachPolicyToUserIAM(userName, policyArn string) error {
-    return p.IAMService.AttachUserPolicy(userName, policyArn)
+func (p *AWSProvider) AttachPolicyToUserIAM(ctx context.Context, userName, policyArn string) error {
+    return p.IAMService.AttachUserPolicy(ctx, userName, policyArn)
 }
 
 // UpdateAppConfigProfile updates a configuration profile in AppConfig.
-func (p *AWSProvider) UpdateAppConfigProfile(applicationID, profileID, name, locationURI string) error {
-    _, err := p.AppConfigService.UpdateConfigurationProfile(applicationID, profileID, name, locationURI)
+//
+// Deprecated: The location of a profile cannot be changed, so locationURI
+// must be empty or the current location of the profile. Use
+// RenameAppConfigProfile.
+func (p *AWSProvider) UpdateAppConfigProfile(ctx context.Context, applicationID, profileID, name, locationURI string) error {
+    _, err := p.AppConfigService.UpdateConfigurationProfile(ctx, applicationID, profileID, name, locationURI)
+    return err
+}
+
+// RenameAppConfigProfile renames a configuration profile in AppConfig.
+func (p *AWSProvider) RenameAppConfigProfile(ctx context.Context, applicationID, profileID, name string) error {
+    _, err := p.AppConfigService.RenameConfigurationProfile(ctx, applicationID, profileID, name)
     return err
 }
 
 // ListIAMUsers lists all IAM users.
-func (p *AWSProvider) ListIAMUsers() ([]*iam.User, error) {
-    result, err := p.IAMService.ListUsers(&iam.ListUsersInput{})
-    if err != nil {
-        return nil, err
-    }
-    return result.Users, nil
+func (p *AWSProvider) ListIAMUsers(ctx context.Context) ([]*sdkiam.User, error) {
+    return p.IAMService.ListUsers(ctx)
 }
 
 // PublishMessageToSNS publishes a message to the specified SNS topic.
-func (p *AWSProvider) PublishMessageToSNS(topicArn, message string) error {
-    _, err := p.SNSService.Publish(topicArn, message)
+func (p *AWSProvider) PublishMessageToSNS(ctx context.Context, topicArn, message string) error {
+    _, err := p.SNSService.Publish(ctx, topicArn, message)
     return err
 }
 
 // StartEC2Instance starts an EC2 instance by its ID.
-func (p *AWSProvider) StartEC2Instance(instanceID string) error {
-    _, err := p.EC2Service.StartInstances(&ec2.StartInstancesInput{
-        InstanceIds: []*string{&instanceID},
-    })
+func (p *AWSProvider) StartEC2Instance(ctx context.Context, instanceID string) error {
+    _, err := p.EC2Service.StartInstances(ctx, []string{instanceID})
     return err
 }
 
 // StopEC2Instance stops an EC2 instance by its ID.
-func (p *AWSProvider) StopEC2Instance(instanceID string) error {
-    _, err := p.EC2Service.StopInstances(&ec2.StopInstancesInput{
-        InstanceIds: []*string{&instanceID},
-    })
+func (p *AWSProvider) StopEC2Instance(ctx context.Context, instanceID string) error {
+    _, err := p.EC2Service.StopInstances(ctx, []string{instanceID})
     return err
 }
 
 // CreateSQSQueue creates a new SQS queue.
-func (p *AWSProvider) CreateSQSQueue(queueName string) (string, error) {
-    return p.SQSService.CreateQueue(queueName)
+func (p *AWSProvider) CreateSQSQueue(ctx context.Context, queueName string) (string, error) {
+    return p.SQSService.CreateQueue(ctx, queueName)
 }
 
 // DeleteSQSQueue deletes an SQS queue by its URL.
-func (p *AWSProvider) DeleteSQSQueue(queueURL string) error {
-    return p.SQSService.DeleteQueue(queueURL)
+func (p *AWSProvider) DeleteSQSQueue(ctx context.Context, queueURL string) error {
+    return p.SQSService.DeleteQueue(ctx, queueURL)
 }
 
 // SubscribeToSNSTopic subscribes an endpoint to an SNS topic.
-func (p *AWSProvider) SubscribeToSNSTopic(topicArn, protocol, endpoint string) (string, error) {
-    return p.SNSService.Subscribe(topicArn, protocol, endpoint)
+func (p *AWSProvider) SubscribeToSNSTopic(ctx context.Context, topicArn, protocol, endpoint string) (string, error) {
+    return p.SNSService.Subscribe(ctx, topicArn, protocol, endpoint)
 }
 
 // UnsubscribeFromSNSTopic unsubscribes an endpoint from an SNS topic.
-func (p *AWSProvider) UnsubscribeFromSNSTopic(subscriptionArn string) error {
-    return p.SNSService.Unsubscribe(subscriptionArn)
+func (p *AWSProvider) UnsubscribeFromSNSTopic(ctx context.Context, subscriptionArn string) error {
+    return p.SNSService.Unsubscribe(ctx, subscriptionArn)
 }
 
 // CreateIAMAccessKey creates a new access key for an IAM user.
-func (p *AWSProvider) CreateIAMAccessKey(userName string) (*iam.AccessKey, error) {
-    return p.IAMService.CreateAccessKey(userName)
+func (p *AWSProvider) CreateIAMAccessKey(ctx context.Context, userName string) (*sdkiam.AccessKey, error) {
+    return p.IAMService.CreateAccessKey(ctx, userName)
 }
 
 // DeleteIAMAccessKey deletes an access key for an IAM user.
-func (p *AWSProvider) DeleteIAMAccessKey(userName, accessKeyID string) error {
-    _, err := p.IAMService.DeleteAccessKey(&iam.DeleteAccessKeyInput{
-        UserName:    &userName,
-        AccessKeyId: &accessKeyID,
-    })
-    return err
+func (p *AWSProvider) DeleteIAMAccessKey(ctx context.Context, userName, accessKeyID string) error {
+    return p.IAMService.DeleteAccessKey(ctx, userName, accessKeyID)
 }
 
-func (p *AWSProvider) DescribeEC2Instances() ([]*ec2.Instance, error) {
-    input := &ec2.DescribeInstancesInput{}
-    result, err := p.EC2Service.DescribeInstances(input)
-    if err != nil {
-        return nil, err
-    }
-
-    var instances []*ec2.Instance
-    for _, reservation := range result.Reservations {
-        instances = append(instances, reservation.Instances...)
-    }
-    return instances, nil
+// DescribeEC2Instances describes all EC2 instances.
+func (p *AWSProvider) DescribeEC2Instances(ctx context.Context) ([]*sdkec2.Instance, error) {
+    return p.EC2Service.DescribeInstances(ctx, nil)
 }
 
 // DeleteS3Bucket - Deletes an S3 bucket.
-func (p *AWSProvider) DeleteS3Bucket(bucketName string) error {
-    _, err := p.S3Service.DeleteBucket(&s3.DeleteBucketInput{
-        Bucket: &bucketName,
-    })
-    return err
+func (p *AWSProvider) DeleteS3Bucket(ctx context.Context, bucketName string) error {
+    return p.S3Service.DeleteBucket(ctx, bucketName)
 }
 
 // ListSQSQueues - Lists SQS queues.
-func (p *AWSProvider) ListSQSQueues() ([]string, error) {
-    result, err := p.SQSService.ListQueues(&sqs.ListQueuesInput{})
-    if err != nil {
-        return nil, err
-    }
-    return result.QueueUrls, nil
+func (p *AWSProvider) ListSQSQueues(ctx context.Context) ([]string, error) {
+    return p.SQSService.ListQueues(ctx)
 }
 
-func (p *AWSProvider) RebootEC2Instance(instanceID string) error {
-    input := &ec2.RebootInstancesInput{
-        InstanceIds: []*string{&instanceID},
-    }
-    _, err := p.EC2Service.RebootInstances(input)
-    return err
+// RebootEC2Instance reboots an EC2 instance by its ID.
+func (p *AWSProvider) RebootEC2Instance(ctx context.Context, instanceID string) error {
+    return p.EC2Service.RebootInstances(ctx, []string{instanceID})
 }
 
 // ListSNSTopics - Lists all SNS topics.
-func (p *AWSProvider) ListSNSTopics() ([]*sns.Topic, error) {
-    input := &sns.ListTopicsInput{}
-    result, err := p.SNSService.ListTopics(input)
-    if err != nil {
-        return nil, err
-    }
-
-    return result.Topics, nil
+func (p *AWSProvider) ListSNSTopics(ctx context.Context) ([]*sdksns.Topic, error) {
+    return p.SNSService.ListTopics(ctx)
 }
 
 // DeleteIAMUser - Deletes an IAM user.
-func (p *AWSProvider) DeleteIAMUser(userName string) error {
-    input := &iam.DeleteUserInput{
-        UserName: &userName,
-    }
-    _, err := p.IAMService.DeleteUser(input)
-    return err
+func (p *AWSProvider) DeleteIAMUser(ctx context.Context, userName string) error {
+    return p.IAMService.DeleteUser(ctx, userName)
 }
@@ -0,0 +1,449 @@
+package aws
+
+import (
+    "net/http"
+    "context"
+    "errors"
+    "reflect"
+    "testing"
+
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/aws/appconfig"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/aws/ec2"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/aws/iam"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/aws/sns"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/aws/sqs"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/cache"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
+    "github.com/aws/aws-sdk-go/aws/request"
+    sdkappconfig "github.com/aws/aws-sdk-go/service/appconfig"
+    "github.com/aws/aws-sdk-go/service/appconfig/appconfigiface"
+    sdkec2 "github.com/aws/aws-sdk-go/service/ec2"
+    "github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+    sdkiam "github.com/aws/aws-sdk-go/service/iam"
+    "github.com/aws/aws-sdk-go/service/iam/iamiface"
+    sdks3 "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/s3/s3iface"
+    sdksns "github.com/aws/aws-sdk-go/service/sns"
+    "github.com/aws/aws-sdk-go/service/sns/snsiface"
+    sdksqs "github.com/aws/aws-sdk-go/service/sqs"
+    "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// The stubs embed the SDK client interfaces so they only implement the calls
+// under test; any other call panics on the nil embedded interface.
+
+type stubS3 struct {
+    s3iface.S3API
+    err     error
+    buckets []string
+    created []string
+    deleted []string
+}
+
+func (s *stubS3) CreateBucketWithContext(ctx aws.Context, in *sdks3.CreateBucketInput, opts ...request.Option) (*sdks3.CreateBucketOutput, error) {
+    s.created = append(s.created, aws.StringValue(in.Bucket))
+    return &sdks3.CreateBucketOutput{}, s.err
+}
+
+func (s *stubS3) DeleteBucketWithContext(ctx aws.Context, in *sdks3.DeleteBucketInput, opts ...request.Option) (*sdks3.DeleteBucketOutput, error) {
+    s.deleted = append(s.deleted, aws.StringValue(in.Bucket))
+    return &sdks3.DeleteBucketOutput{}, s.err
+}
+
+func (s *stubS3) ListBucketsWithContext(ctx aws.Context, in *sdks3.ListBucketsInput, opts ...request.Option) (*sdks3.ListBucketsOutput, error) {
+    out := &sdks3.ListBucketsOutput{}
+    for _, name := range s.buckets {
+        out.Buckets = append(out.Buckets, &sdks3.Bucket{Name: aws.String(name)})
+    }
+    return out, s.err
+}
+
+type stubSQS struct {
+    sqsiface.SQSAPI
+    pages  [][]string
+    sent   []*sdksqs.SendMessageInput
+    list   *sdksqs.ListQueuesInput
+    served int
+}
+
+func (s *stubSQS) SendMessageWithContext(ctx aws.Context, in *sdksqs.SendMessageInput, opts ...request.Option) (*sdksqs.SendMessageOutput, error) {
+    s.sent = append(s.sent, in)
+    return &sdksqs.SendMessageOutput{MessageId: aws.String("m-1")}, nil
+}
+
+func (s *stubSQS) ListQueuesPagesWithContext(ctx aws.Context, in *sdksqs.ListQueuesInput, fn func(*sdksqs.ListQueuesOutput, bool) bool, opts ...request.Option) error {
+    s.list = in
+    for i, page := range s.pages {
+        s.served++
+        if !fn(&sdksqs.ListQueuesOutput{QueueUrls: aws.StringSlice(page)}, i == len(s.pages)-1) {
+            break
+        }
+    }
+    return nil
+}
+
+type stubSNS struct {
+    snsiface.SNSAPI
+    topics       []string
+    unsubscribed []string
+}
+
+func (s *stubSNS) CreateTopicWithContext(ctx aws.Context, in *sdksns.CreateTopicInput, opts ...request.Option) (*sdksns.CreateTopicOutput, error) {
+    return &sdksns.CreateTopicOutput{TopicArn: aws.String("arn:aws:sns:us-east-1:123456789012:" + aws.StringValue(in.Name))}, nil
+}
+
+func (s *stubSNS) ListTopicsPagesWithContext(ctx aws.Context, in *sdksns.ListTopicsInput, fn func(*sdksns.ListTopicsOutput, bool) bool, opts ...request.Option) error {
+    for i, arn := range s.topics {
+        page := &sdksns.ListTopicsOutput{Topics: []*sdksns.Topic{{TopicArn: aws.String(arn)}}}
+        if !fn(page, i == len(s.topics)-1) {
+            break
+        }
+    }
+    return nil
+}
+
+func (s *stubSNS) UnsubscribeWithContext(ctx aws.Context, in *sdksns.UnsubscribeInput, opts ...request.Option) (*sdksns.UnsubscribeOutput, error) {
+    s.unsubscribed = append(s.unsubscribed, aws.StringValue(in.SubscriptionArn))
+    return &sdksns.UnsubscribeOutput{}, nil
+}
+
+type stubIAM struct {
+    iamiface.IAMAPI
+    users       []string
+    deletedKeys []string
+    deleted     []string
+}
+
+func (s *stubIAM) ListUsersPagesWithContext(ctx aws.Context, in *sdkiam.ListUsersInput, fn func(*sdkiam.ListUsersOutput, bool) bool, opts ...request.Option) error {
+    for i, name := range s.users {
+        page := &sdkiam.ListUsersOutput{Users: []*sdkiam.User{{UserName: aws.String(name)}}}
+        if !fn(page, i == len(s.users)-1) {
+            break
+        }
+    }
+    return nil
+}
+
+func (s *stubIAM) DeleteAccessKeyWithContext(ctx aws.Context, in *sdkiam.DeleteAccessKeyInput, opts ...request.Option) (*sdkiam.DeleteAccessKeyOutput, error) {
+    s.deletedKeys = append(s.deletedKeys, aws.StringValue(in.UserName)+"/"+aws.StringValue(in.AccessKeyId))
+    return &sdkiam.DeleteAccessKeyOutput{}, nil
+}
+
+func (s *stubIAM) DeleteUserWithContext(ctx aws.Context, in *sdkiam.DeleteUserInput, opts ...request.Option) (*sdkiam.DeleteUserOutput, error) {
+    s.deleted = append(s.deleted, aws.StringValue(in.UserName))
+    return &sdkiam.DeleteUserOutput{}, nil
+}
+
+type stubEC2 struct {
+    ec2iface.EC2API
+    calls []string
+}
+
+func (s *stubEC2) record(op string, ids []*string) {
+    s.calls = append(s.calls, op+":"+aws.StringValue(ids[0]))
+}
+
+func (s *stubEC2) StartInstancesWithContext(ctx aws.Context, in *sdkec2.StartInstancesInput, opts ...request.Option) (*sdkec2.StartInstancesOutput, error) {
+    s.record("start", in.InstanceIds)
+    return &sdkec2.StartInstancesOutput{}, nil
+}
+
+func (s *stubEC2) StopInstancesWithContext(ctx aws.Context, in *sdkec2.StopInstancesInput, opts ...request.Option) (*sdkec2.StopInstancesOutput, error) {
+    s.record("stop", in.InstanceIds)
+    return &sdkec2.StopInstancesOutput{}, nil
+}
+
+func (s *stubEC2) RebootInstancesWithContext(ctx aws.Context, in *sdkec2.RebootInstancesInput, opts ...request.Option) (*sdkec2.RebootInstancesOutput, error) {
+    s.record("reboot", in.InstanceIds)
+    return &sdkec2.RebootInstancesOutput{}, nil
+}
+
+func (s *stubEC2) DescribeInstancesPagesWithContext(ctx aws.Context, in *sdkec2.DescribeInstancesInput, fn func(*sdkec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error {
+    pages := []*sdkec2.DescribeInstancesOutput{
+        {Reservations: []*sdkec2.Reservation{{Instances: []*sdkec2.Instance{{InstanceId: aws.String("i-1")}}}}},
+        {Reservations: []*sdkec2.Reservation{{Instances: []*sdkec2.Instance{{InstanceId: aws.String("i-2")}, {InstanceId: aws.String("i-3")}}}}},
+    }
+    for i, page := range pages {
+        if !fn(page, i == len(pages)-1) {
+            break
+        }
+    }
+    return nil
+}
+
+type stubAppConfig struct {
+    appconfigiface.AppConfigAPI
+    get *sdkappconfig.GetConfigurationInput
+}
+
+func (s *stubAppConfig) GetConfigurationWithContext(ctx aws.Context, in *sdkappconfig.GetConfigurationInput, opts ...request.Option) (*sdkappconfig.GetConfigurationOutput, error) {
+    s.get = in
+    return &sdkappconfig.GetConfigurationOutput{Content: []byte(`{"feature":true}`)}, nil
+}
+
+func TestS3Facade(t *testing.T) {
+    ctx := context.Background()
+    stub := &stubS3{buckets: []string{"a", "b"}}
+    p := &AWSProvider{S3Service: &s3.S3Service{Client: stub}}
+
+    if err := p.CreateS3Bucket(ctx, "new"); err != nil {
+        t.Fatalf("CreateS3Bucket() error = %v", err)
+    }
+    if err := p.DeleteS3Bucket(ctx, "old"); err != nil {
+        t.Fatalf("DeleteS3Bucket() error = %v", err)
+    }
+    buckets, err := p.ListS3Buckets(ctx)
+    if err != nil {
+        t.Fatalf("ListS3Buckets() error = %v", err)
+    }
+
+    if !reflect.DeepEqual(stub.created, []string{"new"}) {
+        t.Errorf("created = %v, want [new]", stub.created)
+    }
+    if !reflect.DeepEqual(stub.deleted, []string{"old"}) {
+        t.Errorf("deleted = %v, want [old]", stub.deleted)
+    }
+    if !reflect.DeepEqual(buckets, []string{"a", "b"}) {
+        t.Errorf("ListS3Buckets() = %v, want [a b]", buckets)
+    }
+}
+
+func TestFacadeClassifiesErrors(t *testing.T) {
+    stub := &stubS3{err: awserr.NewRequestFailure(awserr.New("NoSuchBucket", "The specified bucket does not exist", nil), 404, "req-1")}
+    p := &AWSProvider{S3Service: &s3.S3Service{Client: stub}}
+
+    err := p.DeleteS3Bucket(context.Background(), "missing")
+    if !errors.Is(err, cloud.ErrNotFound) {
+        t.Fatalf("DeleteS3Bucket() error = %v, want cloud.ErrNotFound", err)
+    }
+    var cerr *cloud.Error
+    if !errors.As(err, &cerr) || cerr.RequestID != "req-1" || cerr.Code != "NoSuchBucket" {
+        t.Errorf("DeleteS3Bucket() error = %#v, want code NoSuchBucket and request ID req-1", cerr)
+    }
+}
+
+func TestSQSFacade(t *testing.T) {
+    ctx := context.Background()
+    stub := &stubSQS{pages: [][]string{{"https://sqs/q1", "https://sqs/q2"}, {"https://sqs/q3"}}}
+    p := &AWSProvider{SQSService: &sqs.SQSService{Client: stub}}
+
+    if err := p.SendMessageToSQS(ctx, "https://sqs/q1", "hello"); err != nil {
+        t.Fatalf("SendMessageToSQS() error = %v", err)
+    }
+    if len(stub.sent) != 1 || aws.StringValue(stub.sent[0].QueueUrl) != "https://sqs/q1" || aws.StringValue(stub.sent[0].MessageBody) != "hello" {
+        t.Errorf("SendMessage input = %v, want queue https://sqs/q1 and body hello", stub.sent)
+    }
+
+    queues, err := p.ListSQSQueues(ctx)
+    if err != nil {
+        t.Fatalf("ListSQSQueues() error = %v", err)
+    }
+    want := []string{"https://sqs/q1", "https://sqs/q2", "https://sqs/q3"}
+    if !reflect.DeepEqual(queues, want) {
+        t.Errorf("ListSQSQueues() = %v, want %v", queues, want)
+    }
+}
+
+func TestSQSQueuesIterator(t *testing.T) {
+    stub := &stubSQS{pages: [][]string{{"https://sqs/q1", "https://sqs/q2"}, {"https://sqs/q3"}}}
+    service := &sqs.SQSService{Client: stub}
+
+    var queues []string
+    for queueURL, err := range service.Queues(context.Background(), &cloud.ListOptions{Prefix: "q", PageSize: 10, MaxItems: 2}) {
+        if err != nil {
+            t.Fatalf("Queues() error = %v", err)
+        }
+        queues = append(queues, queueURL)
+    }
+    if want := []string{"https://sqs/q1", "https://sqs/q2"}; !reflect.DeepEqual(queues, want) {
+        t.Errorf("Queues() = %v, want %v", queues, want)
+    }
+    if stub.served != 1 {
+        t.Errorf("Queues() fetched %d pages, want 1", stub.served)
+    }
+    if aws.StringValue(stub.list.QueueNamePrefix) != "q" || aws.Int64Value(stub.list.MaxResults) != 2 {
+        t.Errorf("ListQueues input = %v, want prefix q and 2 results per page", stub.list)
+    }
+}
+
+func TestSNSFacade(t *testing.T) {
+    ctx := context.Background()
+    stub := &stubSNS{topics: []string{"arn:t1", "arn:t2"}}
+    p := &AWSProvider{SNSService: &sns.SNSService{Client: stub}}
+
+    arn, err := p.CreateSNSTopic(ctx, "orders")
+    if err != nil {
+        t.Fatalf("CreateSNSTopic() error = %v", err)
+    }
+    if want := "arn:aws:sns:us-east-1:123456789012:orders"; arn != want {
+        t.Errorf("CreateSNSTopic() = %q, want %q", arn, want)
+    }
+
+    topics, err := p.ListSNSTopics(ctx)
+    if err != nil {
+        t.Fatalf("ListSNSTopics() error = %v", err)
+    }
+    if len(topics) != 2 || aws.StringValue(topics[1].TopicArn) != "arn:t2" {
+        t.Errorf("ListSNSTopics() = %v, want both pages", topics)
+    }
+
+    if err := p.UnsubscribeFromSNSTopic(ctx, "arn:sub"); err != nil {
+        t.Fatalf("UnsubscribeFromSNSTopic() error = %v", err)
+    }
+    if !reflect.DeepEqual(stub.unsubscribed, []string{"arn:sub"}) {
+        t.Errorf("unsubscribed = %v, want [arn:sub]", stub.unsubscribed)
+    }
+}
+
+func TestIAMFacade(t *testing.T) {
+    ctx := context.Background()
+    stub := &stubIAM{users: []string{"alice", "bob"}}
+    p := &AWSProvider{IAMService: &iam.IAMService{Client: stub}}
+
+    users, err := p.ListIAMUsers(ctx)
+    if err != nil {
+        t.Fatalf("ListIAMUsers() error = %v", err)
+    }
+    if len(users) != 2 || aws.StringValue(users[0].UserName) != "alice" {
+        t.Errorf("ListIAMUsers() = %v, want alice and bob", users)
+    }
+
+    if err := p.DeleteIAMAccessKey(ctx, "alice", "AKIA1"); err != nil {
+        t.Fatalf("DeleteIAMAccessKey() error = %v", err)
+    }
+    if err := p.DeleteIAMUser(ctx, "alice"); err != nil {
+        t.Fatalf("DeleteIAMUser() error = %v", err)
+    }
+    if !reflect.DeepEqual(stub.deletedKeys, []string{"alice/AKIA1"}) {
+        t.Errorf("deleted keys = %v, want [alice/AKIA1]", stub.deletedKeys)
+    }
+    if !reflect.DeepEqual(stub.deleted, []string{"alice"}) {
+        t.Errorf("deleted users = %v, want [alice]", stub.deleted)
+    }
+}
+
+func TestEC2Facade(t *testing.T) {
+    ctx := context.Background()
+    stub := &stubEC2{}
+    p := &AWSProvider{EC2Service: &ec2.EC2Service{Client: stub}}
+
+    if err := p.StartEC2Instance(ctx, "i-1"); err != nil {
+        t.Fatalf("StartEC2Instance() error = %v", err)
+    }
+    if err := p.StopEC2Instance(ctx, "i-1"); err != nil {
+        t.Fatalf("StopEC2Instance() error = %v", err)
+    }
+    if err := p.RebootEC2Instance(ctx, "i-1"); err != nil {
+        t.Fatalf("RebootEC2Instance() error = %v", err)
+    }
+    if want := []string{"start:i-1", "stop:i-1", "reboot:i-1"}; !reflect.DeepEqual(stub.calls, want) {
+        t.Errorf("calls = %v, want %v", stub.calls, want)
+    }
+
+    instances, err := p.DescribeEC2Instances(ctx)
+    if err != nil {
+        t.Fatalf("DescribeEC2Instances() error = %v", err)
+    }
+    if len(instances) != 3 {
+        t.Errorf("DescribeEC2Instances() returned %d instances, want 3", len(instances))
+    }
+}
+
+func TestGetAppConfig(t *testing.T) {
+    stub := &stubAppConfig{}
+    p := &AWSProvider{AppConfigService: &appconfig.AppConfigService{Client: stub}}
+
+    out, err := p.GetAppConfig(context.Background(), "app", "prod", "flags", "client-1")
+    if err != nil {
+        t.Fatalf("GetAppConfig() error = %v", err)
+    }
+    if string(out.Content) != `{"feature":true}` {
+        t.Errorf("GetAppConfig() content = %s", out.Content)
+    }
+    in := stub.get
+    if aws.StringValue(in.Application) != "app" || aws.StringValue(in.Environment) != "prod" ||
+        aws.StringValue(in.Configuration) != "flags" || aws.StringValue(in.ClientId) != "client-1" {
+        t.Errorf("GetConfiguration input = %v", in)
+    }
+}
+
+func TestGetInstanceCachesByKey(t *testing.T) {
+    config := &AWSConfig{Region: "us-east-1", AccessKey: "AKIDEXAMPLE", SecretKey: "secret"}
+    t.Cleanup(func() { EvictInstance("test-cache") })
+
+    first, err := GetInstance("test-cache", config)
+    if err != nil {
+        t.Fatalf("GetInstance() error = %v", err)
+    }
+    second, err := GetInstance("test-cache", config)
+    if err != nil {
+        t.Fatalf("GetInstance() error = %v", err)
+    }
+    if first != second {
+        t.Error("GetInstance() returned a new provider for a cached key")
+    }
+
+    rotated := &AWSConfig{Region: "us-east-1", AccessKey: "AKIDROTATED", SecretKey: "secret"}
+    if _, err := GetInstance("test-cache", rotated); !errors.Is(err, cache.ErrConfigMismatch) {
+        t.Errorf("GetInstance() with a different config error = %v, want ErrConfigMismatch", err)
+    }
+    third, err := RefreshInstance("test-cache", rotated)
+    if err != nil {
+        t.Fatalf("RefreshInstance() error = %v", err)
+    }
+    if third == first {
+        t.Error("RefreshInstance() returned the old provider")
+    }
+}
+
+// closeRecorder is an HTTP transport that records whether its idle
+// connections were closed.
+type closeRecorder struct {
+    http.RoundTripper
+    closed bool
+}
+
+func (r *closeRecorder) CloseIdleConnections() {
+    r.closed = true
+}
+
+func TestCloseLeavesCallerHTTPClient(t *testing.T) {
+    // The SDK would apply $AWS_CA_BUNDLE to the caller's transport.
+    t.Setenv("AWS_CA_BUNDLE", "")
+    transport := &closeRecorder{RoundTripper: http.DefaultTransport}
+    client := &http.Client{Transport: transport}
+    provider, err := NewAWSProvider(&AWSConfig{Region: "us-east-1", AccessKey: "AKID", SecretKey: "secret", HTTPClient: client})
+    if err != nil {
+        t.Fatalf("NewAWSProvider() error = %v", err)
+    }
+    if err := provider.Close(); err != nil {
+        t.Fatalf("Close() error = %v", err)
+    }
+    if transport.closed {
+        t.Error("Close() closed the connections of the caller's HTTP client")
+    }
+
+    for _, config := range []*AWSConfig{
+        {Region: "us-east-1", AccessKey: "AKID", SecretKey: "secret"},
+        {Region: "us-east-1", AccessKey: "AKID", SecretKey: "secret", HTTPClient: client},
+    } {
+        provider, err := NewAWSProvider(config)
+        if err != nil {
+            t.Fatalf("NewAWSProvider() error = %v", err)
+        }
+        if provider.httpClient != nil {
+            t.Errorf("provider owns HTTP client %p without TLS settings", provider.httpClient)
+        }
+    }
+    provider, err = NewAWSProvider(&AWSConfig{Region: "us-east-1", AccessKey: "AKID", SecretKey: "secret", InsecureSkipVerify: true})
+    if err != nil {
+        t.Fatalf("NewAWSProvider() error = %v", err)
+    }
+    if provider.httpClient == nil || provider.httpClient == http.DefaultClient {
+        t.Error("provider does not own the HTTP client created for InsecureSkipVerify")
+    }
+    provider.Close()
+}
@@ -0,0 +1,165 @@
+package aws
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/credentials"
+    "github.com/aws/aws-sdk-go/aws/credentials/processcreds"
+    "github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+    "github.com/aws/aws-sdk-go/aws/request"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/sts"
+)
+
+// DefaultCredentialsExpiryWindow is how long before expiry temporary
+// credentials are refreshed when AWSConfig.CredentialsExpiryWindow is zero.
+const DefaultCredentialsExpiryWindow = 5 * time.Minute
+
+// AssumeRole configures one hop of a role chain.
+type AssumeRole struct {
+    RoleARN     string
+    SessionName string
+    ExternalID  string
+    // MFASerial is the serial number or ARN of the MFA device. The token is
+    // read from AWSConfig.MFATokenProvider.
+    MFASerial string
+    // Duration of the role session. Zero uses the STS default of one hour.
+    Duration time.Duration
+}
+
+// newSession returns the session shared by all service clients, with
+// handlers. It loads the shared config and credentials files for
+// config.Profile, or $AWS_PROFILE, like the AWS CLI: profiles can take their
+// region, static keys, a credential_process, an SSO session, a web identity
+// token or a role assumed with the credentials of a source_profile from
+// either file.
+func newSession(config *AWSConfig, handlers request.Handlers) (*session.Session, error) {
+    sessionConfig, err := config.sessionConfig()
+    if err != nil {
+        return nil, err
+    }
+    window := config.expiryWindow()
+    return session.NewSessionWithOptions(session.Options{
+        Config:            *sessionConfig,
+        Handlers:          handlers,
+        SharedConfigState: session.SharedConfigEnable,
+        Profile:           config.Profile,
+        CredentialsProviderOptions: &session.CredentialsProviderOptions{
+            WebIdentityRoleProviderOptions: func(p *stscreds.WebIdentityRoleProvider) {
+                p.ExpiryWindow = window
+            },
+            ProcessProviderOptions: func(p *processcreds.ProcessProvider) {
+                p.ExpiryWindow = window
+            },
+        },
+    })
+}
+
+// newCredentials builds the credential chain for config. Base credentials are
+// resolved, in order, from:
+//
+//  1. AccessKey and SecretKey;
+//  2. the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables;
+//  3. the profile of sess, see newSession;
+//  4. a web identity token (AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN), as
+//     used by EKS IAM roles for service accounts, unless Profile is set;
+//  5. ECS container credentials (AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or
+//     AWS_CONTAINER_CREDENTIALS_FULL_URI);
+//  6. the EC2 instance role.
+//
+// Steps 3 to 6 are the default chain of the SDK, which resolves sess's
+// credentials. When UseIAMRole is set, IAMRoleARN and then every RoleChain
+// entry are assumed in turn, each with the credentials of the previous step.
+func newCredentials(sess *session.Session, config *AWSConfig) (*credentials.Credentials, error) {
+    window := config.expiryWindow()
+
+    var providers []credentials.Provider
+    if config.AccessKey != "" {
+        providers = append(providers, &credentials.StaticProvider{Value: credentials.Value{
+            AccessKeyID:     config.AccessKey,
+            SecretAccessKey: config.SecretKey,
+            ProviderName:    credentials.StaticProviderName,
+        }})
+    }
+    providers = append(providers,
+        &credentials.EnvProvider{},
+        credentialsProvider{creds: sess.Config.Credentials, window: window},
+    )
+
+    creds := credentials.NewCredentials(&credentials.ChainProvider{
+        Providers:     providers,
+        VerboseErrors: true,
+    })
+    if !config.UseIAMRole {
+        return creds, nil
+    }
+
+    roles := append([]AssumeRole{{
+        RoleARN:     config.IAMRoleARN,
+        SessionName: config.SessionName,
+        ExternalID:  config.ExternalID,
+        MFASerial:   config.MFASerial,
+        Duration:    config.RoleDuration,
+    }}, config.RoleChain...)
+    for _, role := range roles {
+        if role.MFASerial != "" && config.MFATokenProvider == nil {
+            return nil, fmt.Errorf("aws: role %s requires an MFA token but MFATokenProvider is not set", role.RoleARN)
+        }
+        stsConfig := config.endpointConfig("sts")
+        stsConfig.Credentials = creds
+        creds = stscreds.NewCredentialsWithClient(sts.New(sess, stsConfig), role.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+            p.RoleSessionName = role.SessionName
+            if role.ExternalID != "" {
+                p.ExternalID = aws.String(role.ExternalID)
+            }
+            if role.MFASerial != "" {
+                p.SerialNumber = aws.String(role.MFASerial)
+                p.TokenProvider = config.MFATokenProvider
+            }
+            if role.Duration > 0 {
+                p.Duration = role.Duration
+            }
+            p.ExpiryWindow = window
+        })
+    }
+    return creds, nil
+}
+
+// expiryWindow returns how long before expiry temporary credentials are
+// refreshed.
+func (c *AWSConfig) expiryWindow() time.Duration {
+    if c.CredentialsExpiryWindow == 0 {
+        return DefaultCredentialsExpiryWindow
+    }
+    return c.CredentialsExpiryWindow
+}
+
+// credentialsProvider exposes *credentials.Credentials as a
+// credentials.Provider so it can take part in a chain. Credentials that
+// report their expiry and expire within window are treated as expired,
+// whichever expiry window the SDK gave their provider.
+type credentialsProvider struct {
+    creds  *credentials.Credentials
+    window time.Duration
+}
+
+func (p credentialsProvider) Retrieve() (credentials.Value, error) {
+    if p.expiring() {
+        p.creds.Expire()
+    }
+    return p.creds.Get()
+}
+
+func (p credentialsProvider) IsExpired() bool {
+    return p.creds.IsExpired() || p.expiring()
+}
+
+// expiring reports whether the credentials expire within the window.
+// Credentials that never expire, or have not been retrieved, are not
+// expiring.
+func (p credentialsProvider) expiring() bool {
+    expiresAt, err := p.creds.ExpiresAt()
+    return err == nil && time.Until(expiresAt) < p.window
+}
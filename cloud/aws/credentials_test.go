@@ -0,0 +1,424 @@
+package aws
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/credentials"
+    "github.com/aws/aws-sdk-go/aws/defaults"
+)
+
+// isolateCredentials clears every source of credentials the chain consults
+// outside of the test's own servers, including the real instance metadata
+// service.
+func isolateCredentials(t *testing.T) string {
+    t.Helper()
+    dir := t.TempDir()
+    for _, key := range []string{
+        "AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN",
+        "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_SDK_LOAD_CONFIG",
+        "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME",
+        "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_AUTHORIZATION_TOKEN",
+    } {
+        t.Setenv(key, "")
+    }
+    t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+    t.Setenv("HOME", dir)
+    t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+    t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+    return dir
+}
+
+// testCredentials returns the credentials of a session created from config,
+// as NewAWSProvider does.
+func testCredentials(t *testing.T, config *AWSConfig) (*credentials.Credentials, error) {
+    t.Helper()
+    if config.Region == "" {
+        config.Region = "us-east-1"
+    }
+    sess, err := newSession(config, defaults.Handlers())
+    if err != nil {
+        t.Fatal(err)
+    }
+    return newCredentials(sess, config)
+}
+
+// fakeIMDS serves EC2 instance role credentials that expire after ttl and
+// counts how often they are fetched.
+type fakeIMDS struct {
+    mu      sync.Mutex
+    ttl     time.Duration
+    fetches int
+}
+
+func (f *fakeIMDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    switch {
+    case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+        fmt.Fprint(w, "imds-token")
+    case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+        fmt.Fprint(w, "instance-role")
+    case r.URL.Path == "/latest/meta-data/iam/security-credentials/instance-role":
+        f.fetches++
+        json.NewEncoder(w).Encode(map[string]string{
+            "Code":            "Success",
+            "AccessKeyId":     fmt.Sprintf("IMDS%d", f.fetches),
+            "SecretAccessKey": "secret",
+            "Token":           "token",
+            "Expiration":      time.Now().Add(f.ttl).UTC().Format(time.RFC3339),
+        })
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// fakeSTS answers AssumeRole and AssumeRoleWithWebIdentity, issuing the key
+// "KEY-<role name>" and recording each request's form and signing key.
+type fakeSTS struct {
+    mu       sync.Mutex
+    requests []stsRequest
+}
+
+type stsRequest struct {
+    form    map[string]string
+    signKey string
+}
+
+func (f *fakeSTS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if err := r.ParseForm(); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    req := stsRequest{form: map[string]string{}}
+    for k := range r.PostForm {
+        req.form[k] = r.PostForm.Get(k)
+    }
+    if _, cred, ok := strings.Cut(r.Header.Get("Authorization"), "Credential="); ok {
+        req.signKey, _, _ = strings.Cut(cred, "/")
+    }
+    f.mu.Lock()
+    f.requests = append(f.requests, req)
+    f.mu.Unlock()
+
+    action := req.form["Action"]
+    role := req.form["RoleArn"][strings.LastIndex(req.form["RoleArn"], "/")+1:]
+    fmt.Fprintf(w, `<%[1]sResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <%[1]sResult>
+    <Credentials>
+      <AccessKeyId>KEY-%[2]s</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%[3]s</Expiration>
+    </Credentials>
+  </%[1]sResult>
+  <ResponseMetadata><RequestId>req</RequestId></ResponseMetadata>
+</%[1]sResponse>`, action, role, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+}
+
+func TestCredentialsRefreshBeforeExpiry(t *testing.T) {
+    isolateCredentials(t)
+    t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+    imds := &fakeIMDS{ttl: 2 * time.Minute}
+    srv := httptest.NewServer(imds)
+    defer srv.Close()
+
+    config := &AWSConfig{Region: "us-east-1", Endpoints: map[string]string{"ec2metadata": srv.URL + "/latest"}}
+    creds, err := testCredentials(t, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for i := 1; i <= 2; i++ {
+        v, err := creds.Get()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if want := fmt.Sprintf("IMDS%d", i); v.AccessKeyID != want {
+            t.Errorf("Get #%d = %q, want %q", i, v.AccessKeyID, want)
+        }
+    }
+
+    // Credentials valid beyond the expiry window are cached.
+    imds.ttl = time.Hour
+    config.CredentialsExpiryWindow = time.Minute
+    creds, err = testCredentials(t, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    fetches := imds.fetches
+    creds.Get()
+    creds.Get()
+    if got := imds.fetches - fetches; got != 1 {
+        t.Errorf("fetched %d times, want 1", got)
+    }
+}
+
+func TestCredentialsEnvironmentPrecedesInstanceRole(t *testing.T) {
+    isolateCredentials(t)
+    t.Setenv("AWS_ACCESS_KEY_ID", "ENV")
+    t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        t.Errorf("unexpected IMDS request %s", r.URL.Path)
+    }))
+    defer srv.Close()
+
+    creds, err := testCredentials(t, &AWSConfig{Endpoints: map[string]string{"ec2metadata": srv.URL + "/latest"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "ENV" {
+        t.Errorf("AccessKeyID = %q, want ENV", v.AccessKeyID)
+    }
+}
+
+func TestCredentialsSharedProfile(t *testing.T) {
+    dir := isolateCredentials(t)
+    data := "[default]\naws_access_key_id = DEFAULT\naws_secret_access_key = s\n\n[dev]\naws_access_key_id = DEV\naws_secret_access_key = s\n"
+    if err := os.WriteFile(filepath.Join(dir, "credentials"), []byte(data), 0o600); err != nil {
+        t.Fatal(err)
+    }
+
+    creds, err := testCredentials(t, &AWSConfig{Profile: "dev"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "DEV" {
+        t.Errorf("AccessKeyID = %q, want DEV", v.AccessKeyID)
+    }
+}
+
+func TestCredentialsProcess(t *testing.T) {
+    dir := isolateCredentials(t)
+    script := filepath.Join(dir, "credentials.sh")
+    output := `{"Version": 1, "AccessKeyId": "PROCESS", "SecretAccessKey": "s"}`
+    if err := os.WriteFile(script, []byte("#!/bin/sh\necho '"+output+"'\n"), 0o700); err != nil {
+        t.Fatal(err)
+    }
+    data := "[profile tool]\ncredential_process = " + script + "\n"
+    if err := os.WriteFile(filepath.Join(dir, "config"), []byte(data), 0o600); err != nil {
+        t.Fatal(err)
+    }
+
+    creds, err := testCredentials(t, &AWSConfig{Profile: "tool"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "PROCESS" {
+        t.Errorf("AccessKeyID = %q, want PROCESS", v.AccessKeyID)
+    }
+}
+
+func TestCredentialsSourceProfile(t *testing.T) {
+    dir := isolateCredentials(t)
+    sts := &fakeSTS{}
+    srv := httptest.NewServer(sts)
+    defer srv.Close()
+    config := `[profile base]
+region = eu-west-1
+
+[profile deploy]
+role_arn = arn:aws:iam::123456789012:role/deployer
+source_profile = base
+role_session_name = deploy-session
+`
+    if err := os.WriteFile(filepath.Join(dir, "config"), []byte(config), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    data := "[base]\naws_access_key_id = BASE\naws_secret_access_key = s\n"
+    if err := os.WriteFile(filepath.Join(dir, "credentials"), []byte(data), 0o600); err != nil {
+        t.Fatal(err)
+    }
+
+    creds, err := testCredentials(t, &AWSConfig{Profile: "deploy", Endpoints: map[string]string{"sts": srv.URL}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "KEY-deployer" {
+        t.Errorf("AccessKeyID = %q, want KEY-deployer", v.AccessKeyID)
+    }
+    if len(sts.requests) != 1 {
+        t.Fatalf("got %d STS requests, want 1", len(sts.requests))
+    }
+    req := sts.requests[0]
+    if req.signKey != "BASE" || req.form["RoleSessionName"] != "deploy-session" {
+        t.Errorf("AssumeRole signed with %q, request = %v", req.signKey, req.form)
+    }
+}
+
+func TestSessionRegionFromProfile(t *testing.T) {
+    dir := isolateCredentials(t)
+    if err := os.WriteFile(filepath.Join(dir, "config"), []byte("[profile eu]\nregion = eu-west-1\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+
+    sess, err := newSession(&AWSConfig{Profile: "eu"}, defaults.Handlers())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := aws.StringValue(sess.Config.Region); got != "eu-west-1" {
+        t.Errorf("Region = %q, want eu-west-1 from the profile", got)
+    }
+    sess, err = newSession(&AWSConfig{Profile: "eu", Region: "us-west-2"}, defaults.Handlers())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := aws.StringValue(sess.Config.Region); got != "us-west-2" {
+        t.Errorf("Region = %q, want us-west-2 from the config", got)
+    }
+}
+
+func TestCredentialsWebIdentity(t *testing.T) {
+    dir := isolateCredentials(t)
+    tokenFile := filepath.Join(dir, "token")
+    if err := os.WriteFile(tokenFile, []byte("web-identity-token"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+    t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/pod")
+    t.Setenv("AWS_ROLE_SESSION_NAME", "pod-session")
+    sts := &fakeSTS{}
+    srv := httptest.NewServer(sts)
+    defer srv.Close()
+
+    creds, err := testCredentials(t, &AWSConfig{Endpoints: map[string]string{"sts": srv.URL}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "KEY-pod" {
+        t.Errorf("AccessKeyID = %q, want KEY-pod", v.AccessKeyID)
+    }
+    form := sts.requests[0].form
+    if form["Action"] != "AssumeRoleWithWebIdentity" || form["WebIdentityToken"] != "web-identity-token" || form["RoleSessionName"] != "pod-session" {
+        t.Errorf("request = %v", form)
+    }
+}
+
+func TestCredentialsContainer(t *testing.T) {
+    isolateCredentials(t)
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if got := r.Header.Get("Authorization"); got != "ecs-token" {
+            t.Errorf("Authorization = %q, want ecs-token", got)
+        }
+        json.NewEncoder(w).Encode(map[string]string{
+            "AccessKeyId":     "ECS",
+            "SecretAccessKey": "secret",
+            "Token":           "token",
+            "Expiration":      time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+        })
+    }))
+    defer srv.Close()
+    t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", srv.URL+"/creds")
+    t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "ecs-token")
+
+    creds, err := testCredentials(t, &AWSConfig{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "ECS" {
+        t.Errorf("AccessKeyID = %q, want ECS", v.AccessKeyID)
+    }
+}
+
+func TestCredentialsRoleChain(t *testing.T) {
+    isolateCredentials(t)
+    sts := &fakeSTS{}
+    srv := httptest.NewServer(sts)
+    defer srv.Close()
+
+    config := &AWSConfig{
+        AccessKey:    "BASE",
+        SecretKey:    "secret",
+        UseIAMRole:   true,
+        IAMRoleARN:   "arn:aws:iam::111111111111:role/first",
+        SessionName:  "first-session",
+        ExternalID:   "external",
+        MFASerial:    "arn:aws:iam::111111111111:mfa/user",
+        RoleDuration: 15 * time.Minute,
+        RoleChain: []AssumeRole{{
+            RoleARN:     "arn:aws:iam::222222222222:role/second",
+            SessionName: "second-session",
+        }},
+        MFATokenProvider: func() (string, error) { return "123456", nil },
+        Endpoints:        map[string]string{"sts": srv.URL},
+    }
+    creds, err := testCredentials(t, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    v, err := creds.Get()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v.AccessKeyID != "KEY-second" {
+        t.Errorf("AccessKeyID = %q, want KEY-second", v.AccessKeyID)
+    }
+
+    if len(sts.requests) != 2 {
+        t.Fatalf("got %d STS requests, want 2", len(sts.requests))
+    }
+    first, second := sts.requests[0], sts.requests[1]
+    if first.signKey != "BASE" || second.signKey != "KEY-first" {
+        t.Errorf("signed with %q then %q, want BASE then KEY-first", first.signKey, second.signKey)
+    }
+    want := map[string]string{
+        "RoleArn":         config.IAMRoleARN,
+        "RoleSessionName": "first-session",
+        "ExternalId":      "external",
+        "SerialNumber":    config.MFASerial,
+        "TokenCode":       "123456",
+        "DurationSeconds": "900",
+    }
+    for k, v := range want {
+        if first.form[k] != v {
+            t.Errorf("first hop %s = %q, want %q", k, first.form[k], v)
+        }
+    }
+    if second.form["RoleArn"] != config.RoleChain[0].RoleARN || second.form["ExternalId"] != "" {
+        t.Errorf("second hop = %v", second.form)
+    }
+}
+
+func TestCredentialsMFARequiresTokenProvider(t *testing.T) {
+    isolateCredentials(t)
+    _, err := testCredentials(t, &AWSConfig{
+        UseIAMRole: true,
+        IAMRoleARN: "arn:aws:iam::111111111111:role/first",
+        MFASerial:  "arn:aws:iam::111111111111:mfa/user",
+    })
+    if err == nil {
+        t.Fatal("newCredentials succeeded without MFATokenProvider")
+    }
+}
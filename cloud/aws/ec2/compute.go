@@ -0,0 +1,163 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// nameTag is the EC2 tag holding an instance's display name.
+const nameTag = "Name"
+
+// Compute implements cloud.Compute on top of an EC2Service. Instances are
+// identified by EC2 instance ID.
+type Compute struct {
+	service *EC2Service
+}
+
+var _ cloud.Compute = (*Compute)(nil)
+
+// NewCompute creates a new Compute backed by service.
+func NewCompute(service *EC2Service) *Compute {
+	return &Compute{service: service}
+}
+
+// CreateInstance launches a single EC2 instance. The spec name and labels are
+// applied as tags.
+func (c *Compute) CreateInstance(ctx context.Context, spec *cloud.InstanceSpec) (*cloud.Instance, error) {
+	input := &ec2.RunInstancesInput{
+		ImageId:      aws.String(spec.Image),
+		InstanceType: aws.String(spec.MachineType),
+		MinCount:     aws.Int64(1),
+		MaxCount:     aws.Int64(1),
+	}
+	if spec.Zone != "" {
+		input.Placement = &ec2.Placement{AvailabilityZone: aws.String(spec.Zone)}
+	}
+
+	tags := make(map[string]string, len(spec.Labels)+1)
+	for k, v := range spec.Labels {
+		tags[k] = v
+	}
+	if spec.Name != "" {
+		tags[nameTag] = spec.Name
+	}
+	if len(tags) > 0 {
+		input.TagSpecifications = []*ec2.TagSpecification{{
+			ResourceType: aws.String(ec2.ResourceTypeInstance),
+			Tags:         toTags(tags),
+		}}
+	}
+
+	result, err := c.service.Client.RunInstancesWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch instance: %w", helper.AWSError(err))
+	}
+	if len(result.Instances) == 0 {
+		return nil, fmt.Errorf("failed to launch instance: no instance returned")
+	}
+	return ToInstance(result.Instances[0]), nil
+}
+
+// ListInstances lists all EC2 instances in the region.
+func (c *Compute) ListInstances(ctx context.Context) ([]*cloud.Instance, error) {
+	return cloud.Collect(c.Instances(ctx, nil))
+}
+
+// Instances iterates over the EC2 instances in the region whose Name tag
+// starts with opts.Prefix, fetching pages as the iteration proceeds.
+func (c *Compute) Instances(ctx context.Context, opts *cloud.ListOptions) iter.Seq2[*cloud.Instance, error] {
+	return func(yield func(*cloud.Instance, error) bool) {
+		for instance, err := range c.service.Instances(ctx, opts) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(ToInstance(instance), nil) {
+				return
+			}
+		}
+	}
+}
+
+// GetInstance describes the EC2 instance with the given ID.
+func (c *Compute) GetInstance(ctx context.Context, id string) (*cloud.Instance, error) {
+	result, err := c.service.Client.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance %q: %w", id, helper.AWSError(err))
+	}
+
+	for _, reservation := range result.Reservations {
+		for _, instance := range reservation.Instances {
+			return ToInstance(instance), nil
+		}
+	}
+	return nil, fmt.Errorf("failed to describe instance %q: %w", id, cloud.ErrNotFound)
+}
+
+// StartInstance starts a stopped EC2 instance.
+func (c *Compute) StartInstance(ctx context.Context, id string) error {
+	_, err := c.service.Client.StartInstancesWithContext(ctx, &ec2.StartInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start instance %q: %w", id, helper.AWSError(err))
+	}
+	return nil
+}
+
+// StopInstance stops a running EC2 instance.
+func (c *Compute) StopInstance(ctx context.Context, id string) error {
+	_, err := c.service.Client.StopInstancesWithContext(ctx, &ec2.StopInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stop instance %q: %w", id, helper.AWSError(err))
+	}
+	return nil
+}
+
+// RebootInstance reboots a running EC2 instance.
+func (c *Compute) RebootInstance(ctx context.Context, id string) error {
+	_, err := c.service.Client.RebootInstancesWithContext(ctx, &ec2.RebootInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reboot instance %q: %w", id, helper.AWSError(err))
+	}
+	return nil
+}
+
+// DeleteInstance terminates the EC2 instance.
+func (c *Compute) DeleteInstance(ctx context.Context, id string) error {
+	_, err := c.service.Client.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to terminate instance %q: %w", id, helper.AWSError(err))
+	}
+	return nil
+}
+
+// toTags converts a map to EC2 tags in key order.
+func toTags(m map[string]string) []*ec2.Tag {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tags := make([]*ec2.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(m[k])})
+	}
+	return tags
+}
@@ -1,27 +1,33 @@
 package ec2
 
 import (
+    "context"
     "fmt"
+    "iter"
 
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/ec2"
+    "github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 // EC2Service provides operations for EC2 resources.
 type EC2Service struct {
-    Client *ec2.EC2
+    Client ec2iface.EC2API
 }
 
-// NewEC2Service creates a new EC2Service.
-func NewEC2Service(sess *session.Session) *EC2Service {
+// NewEC2Service creates a new EC2Service. cfgs are applied on top of the
+// session configuration.
+func NewEC2Service(sess *session.Session, cfgs ...*aws.Config) *EC2Service {
     return &EC2Service{
-        Client: ec2.New(sess),
+        Client: ec2.New(sess, cfgs...),
     }
 }
 
 // LaunchInstance launches a new EC2 instance.
-func (s *EC2Service) LaunchInstance(imageID, instanceType string) (*ec2.Reservation, error) {
+func (s *EC2Service) LaunchInstance(ctx context.Context, imageID, instanceType string) (*ec2.Reservation, error) {
     input := &ec2.RunInstancesInput{
         ImageId:      aws.String(imageID),
         InstanceType: aws.String(instanceType),
@@ -29,41 +35,113 @@ func (s *EC2Service) LaunchInstance(imageID, instanceType string) (*ec2.Reservat
         MaxCount:     aws.Int64(1),
     }
 
-    result, err := s.Client.RunInstances(input)
+    result, err := s.Client.RunInstancesWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to launch instance: %v", err)
+        return nil, fmt.Errorf("failed to launch instance: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
-// DescribeInstances describes EC2 instances.
-func (s *EC2Service) DescribeInstances(instanceIDs []string) ([]*ec2.Instance, error) {
-    input := &ec2.DescribeInstancesInput{
-        InstanceIds: aws.StringSlice(instanceIDs),
+// Page size limits of DescribeInstances.
+const (
+    minInstancesPageSize = 5
+    maxInstancesPageSize = 1000
+)
+
+// DescribeInstances describes the EC2 instances with the given IDs, or all
+// instances if instanceIDs is empty.
+func (s *EC2Service) DescribeInstances(ctx context.Context, instanceIDs []string) ([]*ec2.Instance, error) {
+    input := &ec2.DescribeInstancesInput{}
+    if len(instanceIDs) > 0 {
+        input.InstanceIds = aws.StringSlice(instanceIDs)
     }
+    return cloud.Collect(s.describeInstances(ctx, input, nil))
+}
 
-    result, err := s.Client.DescribeInstances(input)
-    if err != nil {
-        return nil, fmt.Errorf("failed to describe instances: %v", err)
+// Instances iterates over the EC2 instances whose Name tag starts with
+// opts.Prefix, fetching pages as the iteration proceeds.
+func (s *EC2Service) Instances(ctx context.Context, opts *cloud.ListOptions) iter.Seq2[*ec2.Instance, error] {
+    input := &ec2.DescribeInstancesInput{}
+    if prefix := helper.ListPrefix(opts); prefix != "" {
+        input.Filters = []*ec2.Filter{{
+            Name:   aws.String("tag:Name"),
+            Values: aws.StringSlice([]string{prefix + "*"}),
+        }}
+    }
+    if size := helper.PageSize(opts); size > 0 {
+        input.MaxResults = aws.Int64(int64(min(max(size, minInstancesPageSize), maxInstancesPageSize)))
     }
+    return s.describeInstances(ctx, input, opts)
+}
 
-    var instances []*ec2.Instance
-    for _, reservation := range result.Reservations {
-        instances = append(instances, reservation.Instances...)
+func (s *EC2Service) describeInstances(ctx context.Context, input *ec2.DescribeInstancesInput, opts *cloud.ListOptions) iter.Seq2[*ec2.Instance, error] {
+    return func(yield func(*ec2.Instance, error) bool) {
+        yield = helper.Limit(opts, yield)
+        err := s.Client.DescribeInstancesPagesWithContext(ctx, input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+            for _, reservation := range page.Reservations {
+                for _, instance := range reservation.Instances {
+                    if !yield(instance, nil) {
+                        return false
+                    }
+                }
+            }
+            return true
+        })
+        if err != nil {
+            yield(nil, fmt.Errorf("failed to describe instances: %w", helper.AWSError(err)))
+        }
     }
-    return instances, nil
 }
 
 // TerminateInstances terminates one or more EC2 instances.
-func (s *EC2Service) TerminateInstances(instanceIDs []string) (*ec2.TerminateInstancesOutput, error) {
+func (s *EC2Service) TerminateInstances(ctx context.Context, instanceIDs []string) (*ec2.TerminateInstancesOutput, error) {
     input := &ec2.TerminateInstancesInput{
         InstanceIds: aws.StringSlice(instanceIDs),
     }
 
-    result, err := s.Client.TerminateInstances(input)
+    result, err := s.Client.TerminateInstancesWithContext(ctx, input)
+    if err != nil {
+        return nil, fmt.Errorf("failed to terminate instances: %w", helper.AWSError(err))
+    }
+    return result, nil
+}
+
+
+// StartInstances starts one or more stopped EC2 instances.
+func (s *EC2Service) StartInstances(ctx context.Context, instanceIDs []string) (*ec2.StartInstancesOutput, error) {
+    input := &ec2.StartInstancesInput{
+        InstanceIds: aws.StringSlice(instanceIDs),
+    }
+
+    result, err := s.Client.StartInstancesWithContext(ctx, input)
     if err != nil {
-        return nil, fmt.Errorf("failed to terminate instances: %v", err)
+        return nil, fmt.Errorf("failed to start instances: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
+// StopInstances stops one or more running EC2 instances.
+func (s *EC2Service) StopInstances(ctx context.Context, instanceIDs []string) (*ec2.StopInstancesOutput, error) {
+    input := &ec2.StopInstancesInput{
+        InstanceIds: aws.StringSlice(instanceIDs),
+    }
+
+    result, err := s.Client.StopInstancesWithContext(ctx, input)
+    if err != nil {
+        return nil, fmt.Errorf("failed to stop instances: %w", helper.AWSError(err))
+    }
+    return result, nil
+}
+
+// RebootInstances reboots one or more running EC2 instances.
+func (s *EC2Service) RebootInstances(ctx context.Context, instanceIDs []string) error {
+    input := &ec2.RebootInstancesInput{
+        InstanceIds: aws.StringSlice(instanceIDs),
+    }
+
+    _, err := s.Client.RebootInstancesWithContext(ctx, input)
+    if err != nil {
+        return fmt.Errorf("failed to reboot instances: %w", helper.AWSError(err))
+    }
+    return nil
+}
@@ -0,0 +1,60 @@
+package ec2
+
+import (
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// instanceStates maps EC2 instance state names to normalized states.
+var instanceStates = map[string]model.InstanceState{
+	ec2.InstanceStateNamePending:      model.InstancePending,
+	ec2.InstanceStateNameRunning:      model.InstanceRunning,
+	ec2.InstanceStateNameStopping:     model.InstanceStopping,
+	ec2.InstanceStateNameStopped:      model.InstanceStopped,
+	ec2.InstanceStateNameShuttingDown: model.InstanceTerminating,
+	ec2.InstanceStateNameTerminated:   model.InstanceTerminated,
+}
+
+// ToInstance converts an EC2 instance to a model.Instance.
+func ToInstance(i *ec2.Instance) *model.Instance {
+	instance := &model.Instance{
+		ID:          aws.StringValue(i.InstanceId),
+		State:       model.InstanceUnknown,
+		MachineType: aws.StringValue(i.InstanceType),
+		Image:       aws.StringValue(i.ImageId),
+		LaunchTime:  aws.TimeValue(i.LaunchTime),
+		Raw:         i,
+	}
+	if i.State != nil {
+		if state, ok := instanceStates[aws.StringValue(i.State.Name)]; ok {
+			instance.State = state
+		}
+	}
+	if i.Placement != nil {
+		instance.Zone = aws.StringValue(i.Placement.AvailabilityZone)
+	}
+	if len(i.Tags) > 0 {
+		instance.Labels = make(map[string]string, len(i.Tags))
+		for _, tag := range i.Tags {
+			instance.Labels[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		instance.Name = instance.Labels[nameTag]
+	}
+
+	for _, ni := range i.NetworkInterfaces {
+		for _, addr := range ni.PrivateIpAddresses {
+			instance.PrivateIPs = append(instance.PrivateIPs, aws.StringValue(addr.PrivateIpAddress))
+			if addr.Association != nil && addr.Association.PublicIp != nil {
+				instance.PublicIPs = append(instance.PublicIPs, aws.StringValue(addr.Association.PublicIp))
+			}
+		}
+	}
+	if len(instance.PrivateIPs) == 0 && i.PrivateIpAddress != nil {
+		instance.PrivateIPs = []string{aws.StringValue(i.PrivateIpAddress)}
+	}
+	if len(instance.PublicIPs) == 0 && i.PublicIpAddress != nil {
+		instance.PublicIPs = []string{aws.StringValue(i.PublicIpAddress)}
+	}
+	return instance
+}
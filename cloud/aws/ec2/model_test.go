@@ -0,0 +1,80 @@
+package ec2_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/ec2"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestToInstance(t *testing.T) {
+	launched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   *awsec2.Instance
+		want *model.Instance
+	}{
+		{
+			name: "running",
+			in: &awsec2.Instance{
+				InstanceId:   aws.String("i-0123"),
+				InstanceType: aws.String("t3.micro"),
+				ImageId:      aws.String("ami-42"),
+				LaunchTime:   aws.Time(launched),
+				State:        &awsec2.InstanceState{Name: aws.String(awsec2.InstanceStateNameRunning)},
+				Placement:    &awsec2.Placement{AvailabilityZone: aws.String("eu-west-1a")},
+				Tags:         []*awsec2.Tag{{Key: aws.String("Name"), Value: aws.String("web")}, {Key: aws.String("team"), Value: aws.String("ops")}},
+				NetworkInterfaces: []*awsec2.InstanceNetworkInterface{{
+					PrivateIpAddresses: []*awsec2.InstancePrivateIpAddress{
+						{PrivateIpAddress: aws.String("10.0.0.1"), Association: &awsec2.InstanceNetworkInterfaceAssociation{PublicIp: aws.String("203.0.113.1")}},
+						{PrivateIpAddress: aws.String("10.0.0.2")},
+					},
+				}},
+			},
+			want: &model.Instance{
+				ID:          "i-0123",
+				Name:        "web",
+				State:       model.InstanceRunning,
+				MachineType: "t3.micro",
+				Image:       "ami-42",
+				Zone:        "eu-west-1a",
+				PrivateIPs:  []string{"10.0.0.1", "10.0.0.2"},
+				PublicIPs:   []string{"203.0.113.1"},
+				Labels:      map[string]string{"Name": "web", "team": "ops"},
+				LaunchTime:  launched,
+			},
+		},
+		{
+			name: "shutting down without interfaces",
+			in: &awsec2.Instance{
+				InstanceId:       aws.String("i-0456"),
+				State:            &awsec2.InstanceState{Name: aws.String(awsec2.InstanceStateNameShuttingDown)},
+				PrivateIpAddress: aws.String("10.0.0.3"),
+				PublicIpAddress:  aws.String("203.0.113.3"),
+			},
+			want: &model.Instance{
+				ID:         "i-0456",
+				State:      model.InstanceTerminating,
+				PrivateIPs: []string{"10.0.0.3"},
+				PublicIPs:  []string{"203.0.113.3"},
+			},
+		},
+		{
+			name: "no state",
+			in:   &awsec2.Instance{InstanceId: aws.String("i-0789")},
+			want: &model.Instance{ID: "i-0789", State: model.InstanceUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := ec2.ToInstance(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToInstance = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package aws
+
+import (
+    "net/http"
+
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/endpoints"
+    "github.com/aws/aws-sdk-go/aws/session"
+)
+
+// sessionConfig returns the SDK configuration shared by all service clients:
+// the region, S3 addressing style, endpoints and HTTP client. The endpoint
+// resolver points the STS and instance metadata clients that the SDK creates
+// to resolve shared config credentials at their overrides.
+func (c *AWSConfig) sessionConfig() (*aws.Config, error) {
+    cfg := &aws.Config{
+        S3ForcePathStyle: aws.Bool(c.S3ForcePathStyle),
+        EndpointResolver: endpoints.ResolverFunc(c.resolveEndpoint),
+    }
+    if c.Region != "" {
+        cfg.Region = aws.String(c.Region)
+    }
+
+    tlsConfig, err := helper.TLSConfig(c.CABundle, c.InsecureSkipVerify)
+    if err != nil {
+        return nil, err
+    }
+    client, err := helper.HTTPClient(c.HTTPClient, tlsConfig)
+    if err != nil {
+        return nil, err
+    }
+    if client != nil {
+        cfg.HTTPClient = client
+    }
+    return cfg, nil
+}
+
+// resolveEndpoint resolves the endpoint of service from Endpoints, falling
+// back to the SDK's defaults.
+func (c *AWSConfig) resolveEndpoint(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+    if endpoint := c.Endpoints[service]; endpoint != "" {
+        return endpoints.ResolvedEndpoint{URL: endpoint, SigningRegion: region}, nil
+    }
+    return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+}
+
+// ownHTTPClient returns the HTTP client of sess if sessionConfig created it,
+// rather than using HTTPClient or the SDK's default client as is.
+func (c *AWSConfig) ownHTTPClient(sess *session.Session) *http.Client {
+    if client := sess.Config.HTTPClient; client != c.HTTPClient && client != http.DefaultClient {
+        return client
+    }
+    return nil
+}
+
+// serviceConfigs returns the SDK configurations passed to the constructor of
+// service on top of the session configuration.
+func (c *AWSConfig) serviceConfigs(service string) []*aws.Config {
+    return []*aws.Config{c.retryConfig(service), c.endpointConfig(service)}
+}
+
+// endpointConfig returns an SDK configuration that points the named service at
+// its endpoint override, if any.
+func (c *AWSConfig) endpointConfig(service string) *aws.Config {
+    cfg := &aws.Config{}
+    if endpoint := c.Endpoints[service]; endpoint != "" {
+        cfg.Endpoint = aws.String(endpoint)
+    }
+    return cfg
+}
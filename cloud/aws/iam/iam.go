@@ -1,67 +1,116 @@
 package iam
 
 import (
+    "context"
     "fmt"
+    "iter"
 
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/iam"
+    "github.com/aws/aws-sdk-go/service/iam/iamiface"
 )
 
 // IAMService provides operations for IAM resources.
 type IAMService struct {
-    Client *iam.IAM
+    Client iamiface.IAMAPI
 }
 
-// NewIAMService creates a new IAMService.
-func NewIAMService(sess *session.Session) *IAMService {
+// NewIAMService creates a new IAMService. cfgs are applied on top of the
+// session configuration.
+func NewIAMService(sess *session.Session, cfgs ...*aws.Config) *IAMService {
     return &IAMService{
-        Client: iam.New(sess),
+        Client: iam.New(sess, cfgs...),
     }
 }
 
 // CreateUser creates a new IAM user.
-func (s *IAMService) CreateUser(userName string) (*iam.CreateUserOutput, error) {
-    result, err := s.Client.CreateUser(&iam.CreateUserInput{
+func (s *IAMService) CreateUser(ctx context.Context, userName string) (*iam.CreateUserOutput, error) {
+    result, err := s.Client.CreateUserWithContext(ctx, &iam.CreateUserInput{
         UserName: aws.String(userName),
     })
     if err != nil {
-        return nil, fmt.Errorf("failed to create user: %v", err)
+        return nil, fmt.Errorf("failed to create user: %w", helper.AWSError(err))
     }
     return result, nil
 }
 
 // AttachUserPolicy attaches a policy to an IAM user.
-func (s *IAMService) AttachUserPolicy(userName, policyArn string) error {
-    _, err := s.Client.AttachUserPolicy(&iam.AttachUserPolicyInput{
+func (s *IAMService) AttachUserPolicy(ctx context.Context, userName, policyArn string) error {
+    _, err := s.Client.AttachUserPolicyWithContext(ctx, &iam.AttachUserPolicyInput{
         UserName:  aws.String(userName),
         PolicyArn: aws.String(policyArn),
     })
     if err != nil {
-        return fmt.Errorf("failed to attach policy to user: %v", err)
+        return fmt.Errorf("failed to attach policy to user: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // CreateAccessKey creates a new access key for an IAM user.
-func (s *IAMService) CreateAccessKey(userName string) (*iam.AccessKey, error) {
-    result, err := s.Client.CreateAccessKey(&iam.CreateAccessKeyInput{
+func (s *IAMService) CreateAccessKey(ctx context.Context, userName string) (*iam.AccessKey, error) {
+    result, err := s.Client.CreateAccessKeyWithContext(ctx, &iam.CreateAccessKeyInput{
         UserName: aws.String(userName),
     })
     if err != nil {
-        return nil, fmt.Errorf("failed to create access key: %v", err)
+        return nil, fmt.Errorf("failed to create access key: %w", helper.AWSError(err))
     }
     return result.AccessKey, nil
 }
 
 // DeleteUser deletes an IAM user.
-func (s *IAMService) DeleteUser(userName string) error {
-    _, err := s.Client.DeleteUser(&iam.DeleteUserInput{
+func (s *IAMService) DeleteUser(ctx context.Context, userName string) error {
+    _, err := s.Client.DeleteUserWithContext(ctx, &iam.DeleteUserInput{
         UserName: aws.String(userName),
     })
     if err != nil {
-        return fmt.Errorf("failed to delete user: %v", err)
+        return fmt.Errorf("failed to delete user: %w", helper.AWSError(err))
     }
     return nil
 }
 
+// ListUsers lists all IAM users.
+func (s *IAMService) ListUsers(ctx context.Context) ([]*iam.User, error) {
+    return cloud.Collect(s.Users(ctx, nil))
+}
+
+// Users iterates over the IAM users, fetching pages as the iteration
+// proceeds. opts.Prefix is an IAM path prefix such as "/engineering/".
+func (s *IAMService) Users(ctx context.Context, opts *cloud.ListOptions) iter.Seq2[*iam.User, error] {
+    return func(yield func(*iam.User, error) bool) {
+        yield = helper.Limit(opts, yield)
+        input := &iam.ListUsersInput{}
+        if prefix := helper.ListPrefix(opts); prefix != "" {
+            input.PathPrefix = aws.String(prefix)
+        }
+        if size := helper.PageSize(opts); size > 0 {
+            input.MaxItems = aws.Int64(int64(size))
+        }
+
+        err := s.Client.ListUsersPagesWithContext(ctx, input, func(page *iam.ListUsersOutput, lastPage bool) bool {
+            for _, user := range page.Users {
+                if !yield(user, nil) {
+                    return false
+                }
+            }
+            return true
+        })
+        if err != nil {
+            yield(nil, fmt.Errorf("failed to list users: %w", helper.AWSError(err)))
+        }
+    }
+}
+
+// DeleteAccessKey deletes an access key of an IAM user.
+func (s *IAMService) DeleteAccessKey(ctx context.Context, userName, accessKeyID string) error {
+    _, err := s.Client.DeleteAccessKeyWithContext(ctx, &iam.DeleteAccessKeyInput{
+        UserName:    aws.String(userName),
+        AccessKeyId: aws.String(accessKeyID),
+    })
+    if err != nil {
+        return fmt.Errorf("failed to delete access key: %w", helper.AWSError(err))
+    }
+    return nil
+}
@@ -0,0 +1,27 @@
+package iam
+
+import (
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// ToUser converts an IAM user to a model.User. Tags are only present on users
+// returned by GetUser.
+func ToUser(u *iam.User) *model.User {
+	user := &model.User{
+		ID:        aws.StringValue(u.UserId),
+		Name:      aws.StringValue(u.UserName),
+		ARN:       aws.StringValue(u.Arn),
+		Path:      aws.StringValue(u.Path),
+		CreatedAt: aws.TimeValue(u.CreateDate),
+		Raw:       u,
+	}
+	if len(u.Tags) > 0 {
+		user.Labels = make(map[string]string, len(u.Tags))
+		for _, tag := range u.Tags {
+			user.Labels[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+	}
+	return user
+}
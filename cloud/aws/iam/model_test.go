@@ -0,0 +1,54 @@
+package iam_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/iam"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	awsiam "github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestToUser(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   *awsiam.User
+		want *model.User
+	}{
+		{
+			name: "tagged",
+			in: &awsiam.User{
+				UserId:     aws.String("AIDA0123"),
+				UserName:   aws.String("deploy"),
+				Arn:        aws.String("arn:aws:iam::123456789012:user/ci/deploy"),
+				Path:       aws.String("/ci/"),
+				CreateDate: aws.Time(created),
+				Tags:       []*awsiam.Tag{{Key: aws.String("team"), Value: aws.String("ops")}},
+			},
+			want: &model.User{
+				ID:        "AIDA0123",
+				Name:      "deploy",
+				ARN:       "arn:aws:iam::123456789012:user/ci/deploy",
+				Path:      "/ci/",
+				CreatedAt: created,
+				Labels:    map[string]string{"team": "ops"},
+			},
+		},
+		{
+			name: "listed",
+			in:   &awsiam.User{UserName: aws.String("reader")},
+			want: &model.User{Name: "reader"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := iam.ToUser(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToUser = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package aws
+
+import (
+    "strings"
+    "time"
+
+    "github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/request"
+)
+
+// nonIdempotentPrefixes lists operation name prefixes of AWS APIs that are not
+// safe to repeat, such as CreateAccessKey, RunInstances or SendMessage. They
+// are only retried when the request was throttled.
+var nonIdempotentPrefixes = []string{
+    "Allocate",
+    "Create",
+    "Import",
+    "Publish",
+    "Receive",
+    "Request",
+    "Run",
+    "Send",
+}
+
+// retryer adapts a retry.Policy to the aws-sdk-go request.Retryer interface.
+type retryer struct {
+    policy *retry.Policy
+}
+
+var _ request.Retryer = retryer{}
+
+// MaxRetries returns the number of retries after the first attempt.
+func (r retryer) MaxRetries() int {
+    return r.policy.Attempts() - 1
+}
+
+// RetryRules returns the delay before retrying req.
+func (r retryer) RetryRules(req *request.Request) time.Duration {
+    return r.policy.Backoff(req.RetryCount + 1)
+}
+
+// ShouldRetry classifies the request error and asks the policy.
+func (r retryer) ShouldRetry(req *request.Request) bool {
+    return r.policy.ShouldRetry(helper.AWSError(req.Error), idempotent(req.Operation.Name))
+}
+
+// idempotent reports whether the AWS operation can be safely repeated.
+func idempotent(operation string) bool {
+    for _, prefix := range nonIdempotentPrefixes {
+        if strings.HasPrefix(operation, prefix) {
+            return false
+        }
+    }
+    return true
+}
+
+// RetryPolicy returns the retry policy configured for service.
+func (c *AWSConfig) RetryPolicy(service string) *retry.Policy {
+    return retry.ForService(service, c.Retry, c.ServiceRetry)
+}
+
+// retryConfig returns the SDK configuration that applies the retry policy of
+// service. The policy is consulted for every failed request, overriding the
+// SDK's own retryable classification.
+func (c *AWSConfig) retryConfig(service string) *aws.Config {
+    cfg := &aws.Config{EnforceShouldRetryCheck: aws.Bool(true)}
+    return request.WithRetryer(cfg, retryer{policy: c.RetryPolicy(service)})
+}
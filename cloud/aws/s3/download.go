@@ -0,0 +1,417 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var (
+	// ErrChecksumMismatch is returned when a downloaded file does not match
+	// the ETag or the checksum of the object.
+	ErrChecksumMismatch = errors.New("s3: downloaded file does not match the object checksum")
+	// ErrNoChecksum is returned when an object has neither an ETag that is a
+	// digest of its content nor a checksum, so its download cannot be
+	// verified.
+	ErrNoChecksum = errors.New("s3: object has no checksum to verify the download against")
+)
+
+// DefaultDownloadConcurrency is the number of ranges fetched at once when
+// DownloadOptions.Concurrency is zero.
+const DefaultDownloadConcurrency = 4
+
+// partialSuffix is appended to the path of a file being downloaded. The
+// checkpoint of the download is next to it, with a further ".json" suffix.
+const partialSuffix = ".c2loud-part"
+
+// DownloadOptions configures S3Service.DownloadFileWithOptions.
+type DownloadOptions struct {
+	// PartSize is the size of the byte ranges fetched concurrently. Zero
+	// means DefaultPartSize.
+	PartSize int64
+	// Concurrency is the number of ranges fetched at once. Zero means
+	// DefaultDownloadConcurrency.
+	Concurrency int
+	// Retry is the policy applied to each range, in place of the retries of
+	// the SDK client. Nil means retry.DefaultPolicy().
+	Retry *retry.Policy
+	// AllowUnverified downloads objects that fail with ErrNoChecksum
+	// otherwise, checking only the size of the file.
+	AllowUnverified bool
+}
+
+// downloadCheckpoint is the persisted state of a download. Ranges are
+// numbered from 1, like the parts of multipart uploads.
+type downloadCheckpoint struct {
+	Bucket   string         `json:"bucket"`
+	Key      string         `json:"key"`
+	ETag     string         `json:"etag"`
+	Size     int64          `json:"size"`
+	PartSize int64          `json:"part_size"`
+	Done     map[int64]bool `json:"done"`
+}
+
+// DownloadFileWithOptions downloads an object from an S3 bucket to a local
+// file, fetching byte ranges concurrently. The ranges are written to filePath
+// with a ".c2loud-part" suffix, and the ranges already written are recorded
+// next to it, so a failed download resumes where it stopped if the object has
+// not changed. Once complete, the file is checked against the ETag of the
+// object, including the ETags of multipart uploads, and renamed to filePath.
+// A file that does not match is removed and ErrChecksumMismatch returned.
+//
+// The ETags of objects encrypted with SSE-KMS or SSE-C are not digests of
+// their content, so their downloads are checked against the SHA-256, SHA-1,
+// CRC32C or CRC32 checksum the object was uploaded with instead. Objects with
+// neither fail with ErrNoChecksum before anything is downloaded, unless
+// opts.AllowUnverified is set.
+func (s *S3Service) DownloadFileWithOptions(ctx context.Context, bucketName, key, filePath string, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	head, err := s.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucketName),
+		Key:          aws.String(key),
+		ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get file %q from bucket %q, %w", key, bucketName, helper.AWSError(err))
+	}
+	checksum := checksumOf(head)
+	if !etagIsDigest(head) && checksum == nil && !opts.AllowUnverified {
+		return fmt.Errorf("failed to get file %q from bucket %q, %w", key, bucketName, ErrNoChecksum)
+	}
+	partSize := opts.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+
+	d := &download{
+		service:        s,
+		partialPath:    filePath + partialSuffix,
+		checkpointPath: filePath + partialSuffix + ".json",
+		policy:         opts.Retry,
+		checksum:       checksum,
+		checkpoint: downloadCheckpoint{
+			Bucket:   bucketName,
+			Key:      key,
+			ETag:     aws.StringValue(head.ETag),
+			Size:     aws.Int64Value(head.ContentLength),
+			PartSize: partSize,
+			Done:     make(map[int64]bool),
+		},
+	}
+	if d.policy == nil {
+		d.policy = retry.DefaultPolicy()
+	}
+	if err := d.open(); err != nil {
+		return err
+	}
+	defer d.file.Close()
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
+	if err := parallel(ctx, concurrency, d.missing(), d.fetch); err != nil {
+		return fmt.Errorf("failed to get file %q from bucket %q, the download can be resumed: %w", key, bucketName, err)
+	}
+
+	if etagIsDigest(head) || checksum != nil {
+		if err := d.verify(ctx, etagIsDigest(head)); err != nil {
+			d.file.Close()
+			os.Remove(d.partialPath)
+			os.Remove(d.checkpointPath)
+			return fmt.Errorf("failed to get file %q from bucket %q, %w", key, bucketName, err)
+		}
+	}
+	if err := d.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write file %q, %w", d.partialPath, err)
+	}
+	if err := d.file.Close(); err != nil {
+		return fmt.Errorf("failed to write file %q, %w", d.partialPath, err)
+	}
+	if err := os.Rename(d.partialPath, filePath); err != nil {
+		return fmt.Errorf("failed to rename %q to %q, %w", d.partialPath, filePath, err)
+	}
+	if err := os.Remove(d.checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove download checkpoint %q, %w", d.checkpointPath, err)
+	}
+	return nil
+}
+
+// etagIsDigest reports whether the ETag of an object is derived from the MD5
+// of its content.
+func etagIsDigest(head *s3.HeadObjectOutput) bool {
+	return aws.StringValue(head.ServerSideEncryption) != s3.ServerSideEncryptionAwsKms &&
+		aws.StringValue(head.ServerSideEncryption) != s3.ServerSideEncryptionAwsKmsDsse &&
+		head.SSECustomerAlgorithm == nil
+}
+
+// download is a download of an object in progress.
+type download struct {
+	service        *S3Service
+	file           *os.File
+	partialPath    string
+	checkpointPath string
+	policy         *retry.Policy
+	// checksum is the additional checksum of the object, if it has one.
+	checksum *objectChecksum
+
+	mu         sync.Mutex
+	checkpoint downloadCheckpoint
+}
+
+// open opens the partial file. The ranges recorded by the checkpoint of an
+// earlier download of the same version of the object are kept; otherwise the
+// download starts over.
+func (d *download) open() error {
+	resumed := false
+	if data, err := os.ReadFile(d.checkpointPath); err == nil {
+		var checkpoint downloadCheckpoint
+		if json.Unmarshal(data, &checkpoint) == nil && checkpoint.Done != nil &&
+			checkpoint.Bucket == d.checkpoint.Bucket && checkpoint.Key == d.checkpoint.Key &&
+			checkpoint.ETag == d.checkpoint.ETag && checkpoint.Size == d.checkpoint.Size &&
+			checkpoint.PartSize == d.checkpoint.PartSize {
+			d.checkpoint, resumed = checkpoint, true
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read download checkpoint %q, %w", d.checkpointPath, err)
+	}
+
+	flags := os.O_RDWR | os.O_CREATE
+	if !resumed {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(d.partialPath, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q, %w", d.partialPath, err)
+	}
+	if err := file.Truncate(d.checkpoint.Size); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to create file %q, %w", d.partialPath, err)
+	}
+	d.file = file
+	if resumed {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.save(); err != nil {
+		file.Close()
+		return err
+	}
+	return nil
+}
+
+// missing returns the numbers of the ranges not downloaded yet.
+func (d *download) missing() []int64 {
+	var missing []int64
+	count := (d.checkpoint.Size + d.checkpoint.PartSize - 1) / d.checkpoint.PartSize
+	for number := int64(1); number <= count; number++ {
+		if !d.checkpoint.Done[number] {
+			missing = append(missing, number)
+		}
+	}
+	return missing
+}
+
+// fetch downloads range number into the partial file, retrying it according
+// to the policy, and records it in the checkpoint. The range is only read
+// from the version of the object whose ETag is in the checkpoint.
+func (d *download) fetch(ctx context.Context, number int64) error {
+	start := (number - 1) * d.checkpoint.PartSize
+	length := min(d.checkpoint.PartSize, d.checkpoint.Size-start)
+	err := d.policy.Do(ctx, true, func(ctx context.Context) error {
+		result, err := d.service.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket:  aws.String(d.checkpoint.Bucket),
+			Key:     aws.String(d.checkpoint.Key),
+			Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, start+length-1)),
+			IfMatch: aws.String(d.checkpoint.ETag),
+		}, withoutRetries)
+		if err != nil {
+			return helper.AWSError(err)
+		}
+		defer result.Body.Close()
+
+		n, err := io.Copy(io.NewOffsetWriter(d.file, start), io.LimitReader(result.Body, length))
+		if err == nil && n < length {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// A connection dropped while reading the body is transient.
+			return &cloud.Error{Kind: cloud.ErrUnavailable, Provider: cloud.AWSProvider, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get range %d, %w", number, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkpoint.Done[number] = true
+	return d.save()
+}
+
+// save writes the checkpoint atomically. It must be called with d.mu held.
+func (d *download) save() error {
+	data, err := json.Marshal(d.checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp := d.checkpointPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write download checkpoint %q, %w", d.checkpointPath, err)
+	}
+	if err := os.Rename(tmp, d.checkpointPath); err != nil {
+		return fmt.Errorf("failed to write download checkpoint %q, %w", d.checkpointPath, err)
+	}
+	return nil
+}
+
+// objectChecksum is an additional checksum of an object, returned by
+// HeadObject with ChecksumMode enabled.
+type objectChecksum struct {
+	algorithm string
+	// value is the base64 checksum, followed by "-" and the number of parts
+	// for the checksum of the part checksums of multipart uploads.
+	value   string
+	newHash func() hash.Hash
+}
+
+// checksumOf returns the strongest checksum in head, or nil if it has none.
+func checksumOf(head *s3.HeadObjectOutput) *objectChecksum {
+	for _, c := range []objectChecksum{
+		{s3.ChecksumAlgorithmSha256, aws.StringValue(head.ChecksumSHA256), sha256.New},
+		{s3.ChecksumAlgorithmSha1, aws.StringValue(head.ChecksumSHA1), sha1.New},
+		{s3.ChecksumAlgorithmCrc32c, aws.StringValue(head.ChecksumCRC32C), func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+		{s3.ChecksumAlgorithmCrc32, aws.StringValue(head.ChecksumCRC32), func() hash.Hash { return crc32.NewIEEE() }},
+	} {
+		if c.value != "" {
+			return &c
+		}
+	}
+	return nil
+}
+
+// verify checks the partial file against the ETag of the object when it is a
+// digest, or else against its checksum. Both are computed the same way: the
+// MD5, or the checksum, of the content for objects uploaded at once, and the
+// MD5 or checksum of those of the parts, followed by the number of parts,
+// for multipart uploads. ETags are hex encoded and checksums base64 encoded.
+func (d *download) verify(ctx context.Context, etag bool) error {
+	name, value, newHash, decode := "ETag", strings.Trim(d.checkpoint.ETag, `"`), md5.New, hex.DecodeString
+	if !etag {
+		name, value, newHash, decode = d.checksum.algorithm+" checksum", d.checksum.value, d.checksum.newHash, base64.StdEncoding.DecodeString
+	}
+	encoded, count, multipart := strings.Cut(value, "-")
+	want, err := decode(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid %s %s: %w", name, value, ErrChecksumMismatch)
+	}
+	if !multipart {
+		sum, err := hashRange(d.file, 0, d.checkpoint.Size, newHash())
+		if err != nil {
+			return err
+		}
+		return compareDigest(name, sum, want)
+	}
+
+	parts, err := strconv.Atoi(count)
+	if err != nil || parts < 1 {
+		return fmt.Errorf("invalid multipart %s %s: %w", name, value, ErrChecksumMismatch)
+	}
+	sizes, err := d.partSizes(ctx, parts)
+	if err != nil {
+		return err
+	}
+	digests := newHash()
+	offset := int64(0)
+	for _, size := range sizes {
+		sum, err := hashRange(d.file, offset, size, newHash())
+		if err != nil {
+			return err
+		}
+		digests.Write(sum)
+		offset += size
+	}
+	if offset != d.checkpoint.Size {
+		return fmt.Errorf("parts of %d bytes for an object of %d bytes: %w", offset, d.checkpoint.Size, ErrChecksumMismatch)
+	}
+	return compareDigest(name, digests.Sum(nil), want)
+}
+
+// partSizes returns the sizes of the parts the object was uploaded with.
+// Uploaders use parts of the same size but the last, so the size of the first
+// part usually determines the others; otherwise every part is looked up.
+func (d *download) partSizes(ctx context.Context, parts int) ([]int64, error) {
+	partSize := func(number int) (int64, error) {
+		head, err := d.service.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket:     aws.String(d.checkpoint.Bucket),
+			Key:        aws.String(d.checkpoint.Key),
+			PartNumber: aws.Int64(int64(number)),
+			IfMatch:    aws.String(d.checkpoint.ETag),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the size of part %d, %w", number, helper.AWSError(err))
+		}
+		return aws.Int64Value(head.ContentLength), nil
+	}
+
+	first, err := partSize(1)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int64, parts)
+	if first > 0 && (d.checkpoint.Size+first-1)/first == int64(parts) {
+		for i := range sizes {
+			sizes[i] = min(first, d.checkpoint.Size-int64(i)*first)
+		}
+		return sizes, nil
+	}
+	sizes[0] = first
+	for i := 1; i < parts; i++ {
+		if sizes[i], err = partSize(i + 1); err != nil {
+			return nil, err
+		}
+	}
+	return sizes, nil
+}
+
+// hashRange returns the hash with h of length bytes of file from offset.
+func hashRange(file *os.File, offset, length int64, h hash.Hash) ([]byte, error) {
+	if _, err := io.Copy(h, io.NewSectionReader(file, offset, length)); err != nil {
+		return nil, fmt.Errorf("failed to read file %q, %w", file.Name(), err)
+	}
+	return h.Sum(nil), nil
+}
+
+func compareDigest(name string, sum, want []byte) error {
+	if !bytes.Equal(sum, want) {
+		return fmt.Errorf("got %s %x, want %x: %w", name, sum, want, ErrChecksumMismatch)
+	}
+	return nil
+}
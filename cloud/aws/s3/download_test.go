@@ -0,0 +1,242 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	sdks3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+func TestDownloadFileMultipart(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	source, data := writeFile(t, 2*s3.MinPartSize+12345)
+	err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "artifact.bin", source, &s3.UploadOptions{
+		PartSize:       s3.MinPartSize,
+		CheckpointPath: filepath.Join(t.TempDir(), "upload.json"),
+	})
+	if err != nil {
+		t.Fatalf("UploadFileWithOptions: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "artifact.bin")
+	p.InjectError("s3", "GetObject", fake.Error("SlowDown", 503), 2)
+	err = p.S3Service.DownloadFileWithOptions(ctx, "artifacts", "artifact.bin", target, &s3.DownloadOptions{
+		PartSize:    1 << 20,
+		Concurrency: 4,
+		Retry:       &retry.Policy{MaxAttempts: 3},
+	})
+	if err != nil {
+		t.Fatalf("DownloadFileWithOptions: %v", err)
+	}
+	if got, _ := os.ReadFile(target); !bytes.Equal(got, data) {
+		t.Errorf("downloaded %d bytes, want %d", len(got), len(data))
+	}
+	if matches, _ := filepath.Glob(target + ".*"); len(matches) != 0 {
+		t.Errorf("files left after the download: %q", matches)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	source, data := writeFile(t, 1000)
+	if err := p.S3Service.UploadFile(ctx, "artifacts", "small.bin", source); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "small.bin")
+	if err := p.S3Service.DownloadFile(ctx, "artifacts", "small.bin", target); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if got, _ := os.ReadFile(target); !bytes.Equal(got, data) {
+		t.Errorf("downloaded %q, want %q", got, data)
+	}
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	source, data := writeFile(t, 3<<20)
+	if err := p.S3Service.UploadFile(ctx, "artifacts", "artifact.bin", source); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	head, err := p.S3.HeadObjectWithContext(ctx, &sdks3.HeadObjectInput{Bucket: aws.String("artifacts"), Key: aws.String("artifact.bin")})
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+
+	// Leave the state of an interrupted download whose first range was
+	// written, but corrupted.
+	target := filepath.Join(t.TempDir(), "artifact.bin")
+	partial := bytes.Clone(data)
+	partial[10] ^= 0xff
+	if err := os.WriteFile(target+".c2loud-part", partial[:1<<20], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, _ := json.Marshal(map[string]any{
+		"bucket":    "artifacts",
+		"key":       "artifact.bin",
+		"etag":      aws.StringValue(head.ETag),
+		"size":      len(data),
+		"part_size": 1 << 20,
+		"done":      map[string]bool{"1": true},
+	})
+	if err := os.WriteFile(target+".c2loud-part.json", checkpoint, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &s3.DownloadOptions{PartSize: 1 << 20}
+	err = p.S3Service.DownloadFileWithOptions(ctx, "artifacts", "artifact.bin", target, opts)
+	if !errors.Is(err, s3.ErrChecksumMismatch) {
+		t.Fatalf("resumed DownloadFileWithOptions: got %v, want ErrChecksumMismatch", err)
+	}
+	if matches, _ := filepath.Glob(target + "*"); len(matches) != 0 {
+		t.Errorf("files left after a mismatch: %q", matches)
+	}
+
+	if err := p.S3Service.DownloadFileWithOptions(ctx, "artifacts", "artifact.bin", target, opts); err != nil {
+		t.Fatalf("DownloadFileWithOptions: %v", err)
+	}
+	if got, _ := os.ReadFile(target); !bytes.Equal(got, data) {
+		t.Errorf("downloaded %d bytes, want %d", len(got), len(data))
+	}
+}
+
+// kmsClient reports objects as encrypted with SSE-KMS, so that their ETags
+// are not digests, with the given checksums when ChecksumMode is enabled.
+type kmsClient struct {
+	s3iface.S3API
+	sha256, crc32c string
+}
+
+func (c *kmsClient) HeadObjectWithContext(ctx aws.Context, input *sdks3.HeadObjectInput, opts ...request.Option) (*sdks3.HeadObjectOutput, error) {
+	head, err := c.S3API.HeadObjectWithContext(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	head.ServerSideEncryption = aws.String(sdks3.ServerSideEncryptionAwsKms)
+	if aws.StringValue(input.ChecksumMode) == sdks3.ChecksumModeEnabled && input.PartNumber == nil {
+		if c.sha256 != "" {
+			head.ChecksumSHA256 = aws.String(c.sha256)
+		}
+		if c.crc32c != "" {
+			head.ChecksumCRC32C = aws.String(c.crc32c)
+		}
+	}
+	return head, nil
+}
+
+func TestDownloadFileChecksum(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	source, data := writeFile(t, 2*s3.MinPartSize+12345)
+	err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "artifact.bin", source, &s3.UploadOptions{PartSize: s3.MinPartSize})
+	if err != nil {
+		t.Fatalf("UploadFileWithOptions: %v", err)
+	}
+
+	sha := sha256.Sum256(data)
+	castagnoli := crc32.MakeTable(crc32.Castagnoli)
+	var partSums []byte
+	for start := 0; start < len(data); start += s3.MinPartSize {
+		h := crc32.New(castagnoli)
+		h.Write(data[start:min(start+s3.MinPartSize, len(data))])
+		partSums = h.Sum(partSums)
+	}
+	composite := crc32.New(castagnoli)
+	composite.Write(partSums)
+	compositeValue := base64.StdEncoding.EncodeToString(composite.Sum(nil)) + "-3"
+
+	tests := []struct {
+		name    string
+		client  *kmsClient
+		opts    *s3.DownloadOptions
+		wantErr error
+	}{
+		{"sha256", &kmsClient{sha256: base64.StdEncoding.EncodeToString(sha[:])}, nil, nil},
+		{"composite crc32c", &kmsClient{crc32c: compositeValue}, nil, nil},
+		{"mismatch", &kmsClient{sha256: base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))}, nil, s3.ErrChecksumMismatch},
+		{"no checksum", &kmsClient{}, nil, s3.ErrNoChecksum},
+		{"unverified", &kmsClient{}, &s3.DownloadOptions{AllowUnverified: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.client.S3API = p.S3Service.Client
+			service := &s3.S3Service{Client: tt.client}
+			target := filepath.Join(t.TempDir(), "artifact.bin")
+			err := service.DownloadFileWithOptions(ctx, "artifacts", "artifact.bin", target, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DownloadFileWithOptions: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if matches, _ := filepath.Glob(target + "*"); len(matches) != 0 {
+					t.Errorf("files left after a failed download: %q", matches)
+				}
+				return
+			}
+			if got, _ := os.ReadFile(target); !bytes.Equal(got, data) {
+				t.Errorf("downloaded %d bytes, want %d", len(got), len(data))
+			}
+		})
+	}
+}
+
+// TestDownloadFileRetries checks that ranges are attempted as often as the
+// policy of the download allows, without the SDK client retrying each one.
+func TestDownloadFileRetries(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", "10")
+			w.Header().Set("ETag", `"etag"`)
+			return
+		}
+		gets.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`<Error><Code>SlowDown</Code><Message>Reduce your request rate.</Message></Error>`))
+	}))
+	defer server.Close()
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(server.URL),
+		Region:           aws.String("us-east-1"),
+		Credentials:      credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(4),
+	})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+
+	service := s3.NewS3Service(sess)
+	target := filepath.Join(t.TempDir(), "data.bin")
+	err = service.DownloadFileWithOptions(context.Background(), "artifacts", "data.bin", target, &s3.DownloadOptions{
+		Retry:           &retry.Policy{MaxAttempts: 2},
+		AllowUnverified: true,
+	})
+	if err == nil {
+		t.Fatal("DownloadFileWithOptions succeeded, want an error")
+	}
+	if n := gets.Load(); n != 2 {
+		t.Errorf("GetObject requests = %d, want 2", n)
+	}
+}
@@ -0,0 +1,72 @@
+package s3
+
+import (
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ToBucket converts an S3 bucket to a model.Bucket. ListBuckets does not
+// report location or versioning, so those fields are left empty.
+func ToBucket(b *s3.Bucket) *model.Bucket {
+	return &model.Bucket{
+		Name:      aws.StringValue(b.Name),
+		CreatedAt: aws.TimeValue(b.CreationDate),
+		Raw:       b,
+	}
+}
+
+// ToObject converts an S3 object listed in bucket to a model.Object.
+func ToObject(bucket string, o *s3.Object) *model.Object {
+	return &model.Object{
+		Bucket:       bucket,
+		Key:          aws.StringValue(o.Key),
+		Size:         aws.Int64Value(o.Size),
+		ETag:         aws.StringValue(o.ETag),
+		LastModified: aws.TimeValue(o.LastModified),
+		Raw:          o,
+	}
+}
+
+// HeadToObject converts a HeadObject response for bucket/key to a
+// model.Object.
+func HeadToObject(bucket, key string, out *s3.HeadObjectOutput) *model.Object {
+	return &model.Object{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ETag:         aws.StringValue(out.ETag),
+		ContentType:  aws.StringValue(out.ContentType),
+		LastModified: aws.TimeValue(out.LastModified),
+		Metadata:     aws.StringValueMap(out.Metadata),
+		Raw:          out,
+	}
+}
+
+// GetToObject converts a GetObject response for bucket/key to a model.Object.
+// The response body is not read.
+func GetToObject(bucket, key string, out *s3.GetObjectOutput) *model.Object {
+	return &model.Object{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ETag:         aws.StringValue(out.ETag),
+		ContentType:  aws.StringValue(out.ContentType),
+		LastModified: aws.TimeValue(out.LastModified),
+		Metadata:     aws.StringValueMap(out.Metadata),
+		Raw:          out,
+	}
+}
+
+// ToVersioningState converts an S3 bucket versioning status to a
+// model.VersioningState. Buckets that were never versioned report no status.
+func ToVersioningState(status string) model.VersioningState {
+	switch status {
+	case s3.BucketVersioningStatusEnabled:
+		return model.VersioningEnabled
+	case s3.BucketVersioningStatusSuspended:
+		return model.VersioningSuspended
+	default:
+		return model.VersioningDisabled
+	}
+}
@@ -0,0 +1,89 @@
+package s3_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+var modified = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestToBucket(t *testing.T) {
+	in := &awss3.Bucket{Name: aws.String("artifacts"), CreationDate: aws.Time(modified)}
+	want := &model.Bucket{Name: "artifacts", CreatedAt: modified, Raw: in}
+	if got := s3.ToBucket(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToBucket = %+v, want %+v", got, want)
+	}
+}
+
+func TestToObject(t *testing.T) {
+	in := &awss3.Object{Key: aws.String("a/b.txt"), Size: aws.Int64(42), ETag: aws.String(`"abc"`), LastModified: aws.Time(modified)}
+	want := &model.Object{Bucket: "artifacts", Key: "a/b.txt", Size: 42, ETag: `"abc"`, LastModified: modified, Raw: in}
+	if got := s3.ToObject("artifacts", in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToObject = %+v, want %+v", got, want)
+	}
+}
+
+func TestResponseToObject(t *testing.T) {
+	want := model.Object{
+		Bucket:       "artifacts",
+		Key:          "a/b.txt",
+		Size:         42,
+		ETag:         `"abc"`,
+		ContentType:  "text/plain",
+		LastModified: modified,
+		Metadata:     map[string]string{"Owner": "ops"},
+	}
+	head := &awss3.HeadObjectOutput{
+		ContentLength: aws.Int64(42),
+		ETag:          aws.String(`"abc"`),
+		ContentType:   aws.String("text/plain"),
+		LastModified:  aws.Time(modified),
+		Metadata:      map[string]*string{"Owner": aws.String("ops")},
+	}
+	get := &awss3.GetObjectOutput{
+		ContentLength: aws.Int64(42),
+		ETag:          aws.String(`"abc"`),
+		ContentType:   aws.String("text/plain"),
+		LastModified:  aws.Time(modified),
+		Metadata:      map[string]*string{"Owner": aws.String("ops")},
+	}
+	tests := []struct {
+		name string
+		got  *model.Object
+		raw  interface{}
+	}{
+		{"HeadToObject", s3.HeadToObject("artifacts", "a/b.txt", head), head},
+		{"GetToObject", s3.GetToObject("artifacts", "a/b.txt", get), get},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := want
+			want.Raw = tt.raw
+			if !reflect.DeepEqual(tt.got, &want) {
+				t.Errorf("%s = %+v, want %+v", tt.name, tt.got, &want)
+			}
+		})
+	}
+}
+
+func TestToVersioningState(t *testing.T) {
+	tests := []struct {
+		status string
+		want   model.VersioningState
+	}{
+		{awss3.BucketVersioningStatusEnabled, model.VersioningEnabled},
+		{awss3.BucketVersioningStatusSuspended, model.VersioningSuspended},
+		{"", model.VersioningDisabled},
+	}
+	for _, tt := range tests {
+		if got := s3.ToVersioningState(tt.status); got != tt.want {
+			t.Errorf("ToVersioningState(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
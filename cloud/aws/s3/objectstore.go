@@ -0,0 +1,133 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/url"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ObjectStore implements cloud.ObjectStore on top of an S3Service.
+type ObjectStore struct {
+	service *S3Service
+}
+
+var _ cloud.ObjectStore = (*ObjectStore)(nil)
+
+// NewObjectStore creates a new ObjectStore backed by service.
+func NewObjectStore(service *S3Service) *ObjectStore {
+	return &ObjectStore{service: service}
+}
+
+// CreateBucket creates a new S3 bucket.
+func (o *ObjectStore) CreateBucket(ctx context.Context, bucket string) error {
+	return o.service.CreateBucket(ctx, bucket)
+}
+
+// DeleteBucket deletes an S3 bucket.
+func (o *ObjectStore) DeleteBucket(ctx context.Context, bucket string) error {
+	return o.service.DeleteBucket(ctx, bucket)
+}
+
+// ListBuckets lists all S3 buckets.
+func (o *ObjectStore) ListBuckets(ctx context.Context) ([]string, error) {
+	return o.service.ListBuckets(ctx)
+}
+
+// PutObject uploads body to bucket/key. Bodies of unknown length are streamed
+// as a multipart upload; see S3Service.PutObject.
+func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io.Reader, opts *cloud.PutOptions) error {
+	uploadOpts := &UploadOptions{}
+	if opts != nil {
+		uploadOpts.ContentType = opts.ContentType
+		uploadOpts.Metadata = opts.Metadata
+	}
+	return o.service.PutObject(ctx, bucket, key, body, uploadOpts)
+}
+
+// GetObject opens bucket/key for reading.
+func (o *ObjectStore) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *cloud.Object, error) {
+	resp, err := o.service.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object %q from bucket %q, %w", key, bucket, helper.AWSError(err))
+	}
+
+	return resp.Body, GetToObject(bucket, key, resp), nil
+}
+
+// HeadObject returns the metadata of bucket/key.
+func (o *ObjectStore) HeadObject(ctx context.Context, bucket, key string) (*cloud.Object, error) {
+	resp, err := o.service.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object %q in bucket %q, %w", key, bucket, helper.AWSError(err))
+	}
+
+	return HeadToObject(bucket, key, resp), nil
+}
+
+// ListObjects lists all objects in bucket whose key starts with prefix,
+// following continuation tokens until the listing is complete.
+func (o *ObjectStore) ListObjects(ctx context.Context, bucket, prefix string) ([]*cloud.Object, error) {
+	return cloud.Collect(o.Objects(ctx, bucket, &cloud.ListOptions{Prefix: prefix}))
+}
+
+// Objects iterates over the objects in bucket whose key starts with
+// opts.Prefix, fetching pages as the iteration proceeds.
+func (o *ObjectStore) Objects(ctx context.Context, bucket string, opts *cloud.ListOptions) iter.Seq2[*cloud.Object, error] {
+	return func(yield func(*cloud.Object, error) bool) {
+		for item, err := range o.service.Objects(ctx, bucket, opts) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(ToObject(bucket, item), nil) {
+				return
+			}
+		}
+	}
+}
+
+// DeleteObject deletes bucket/key.
+func (o *ObjectStore) DeleteObject(ctx context.Context, bucket, key string) error {
+	return o.service.DeleteObject(ctx, bucket, key)
+}
+
+// CopyObject copies srcBucket/srcKey to dstBucket/dstKey on the server side.
+func (o *ObjectStore) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	_, err := o.service.Client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(dstBucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(copySource(srcBucket, srcKey)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy object %q from bucket %q to %q in bucket %q, %w", srcKey, srcBucket, dstKey, dstBucket, helper.AWSError(err))
+	}
+	return nil
+}
+
+// SignURL returns a SigV4 presigned URL for bucket/key.
+func (o *ObjectStore) SignURL(ctx context.Context, bucket, key string, opts *cloud.SignOptions) (*cloud.SignedURL, error) {
+	return o.service.PresignURL(ctx, bucket, key, opts)
+}
+
+// SignPost returns a SigV4 POST policy for bucket/key.
+func (o *ObjectStore) SignPost(ctx context.Context, bucket, key string, opts *cloud.PostOptions) (*cloud.PostPolicy, error) {
+	return o.service.PresignPost(ctx, bucket, key, opts)
+}
+
+// copySource builds the URL-encoded "bucket/key" value S3 expects in CopySource.
+func copySource(bucket, key string) string {
+	return bucket + "/" + (&url.URL{Path: key}).EscapedPath()
+}
@@ -0,0 +1,32 @@
+package s3
+
+import "testing"
+
+func TestStreamPartSize(t *testing.T) {
+	tests := []struct {
+		partSize, number, want int64
+	}{
+		{MinPartSize, 1, MinPartSize},
+		{MinPartSize, 1000, MinPartSize},
+		{MinPartSize, 1001, 2 * MinPartSize},
+		{MinPartSize, 2001, 4 * MinPartSize},
+		{MinPartSize, MaxParts, 512 * MinPartSize},
+		{DefaultPartSize, MaxParts, MaxPartSize},
+		{2 * MaxPartSize, 1, MaxPartSize},
+	}
+	for _, tt := range tests {
+		if got := streamPartSize(tt.partSize, tt.number); got != tt.want {
+			t.Errorf("streamPartSize(%d, %d) = %d, want %d", tt.partSize, tt.number, got, tt.want)
+		}
+	}
+
+	// The parts of the largest stream hold more than the 5 TiB limit of S3
+	// objects with the default part size.
+	var total int64
+	for number := int64(1); number <= MaxParts; number++ {
+		total += streamPartSize(DefaultPartSize, number)
+	}
+	if total < 5<<40 {
+		t.Errorf("MaxParts parts hold %d bytes, want at least 5 TiB", total)
+	}
+}
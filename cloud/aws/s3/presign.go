@@ -0,0 +1,185 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// PresignURL returns a SigV4 presigned URL granting temporary access to
+// bucketName/key with opts.Method. The URL is signed with the credentials of
+// the client, and is valid no longer than they are.
+func (s *S3Service) PresignURL(ctx context.Context, bucketName, key string, opts *cloud.SignOptions) (*cloud.SignedURL, error) {
+	if opts == nil {
+		opts = &cloud.SignOptions{}
+	}
+	expires, err := helper.SignExpiry(opts.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign %q in bucket %q, %w", key, bucketName, err)
+	}
+	method, err := helper.SignMethod(opts.Method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign %q in bucket %q, %w", key, bucketName, err)
+	}
+
+	var req *request.Request
+	switch method {
+	case http.MethodGet:
+		req, _ = s.Client.GetObjectRequest(&s3.GetObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+	case http.MethodPut:
+		req, _ = s.Client.PutObjectRequest(&s3.PutObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+	case http.MethodDelete:
+		req, _ = s.Client.DeleteObjectRequest(&s3.DeleteObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+	}
+	req.SetContext(ctx)
+	// Headers set on the request are signed as they are.
+	if opts.ContentType != "" {
+		req.HTTPRequest.Header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.ContentMD5 != "" {
+		req.HTTPRequest.Header.Set("Content-Md5", opts.ContentMD5)
+	}
+	for name, value := range opts.Headers {
+		req.HTTPRequest.Header.Set(name, value)
+	}
+
+	signed, headers, err := req.PresignRequest(expires)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign %q in bucket %q, %w", key, bucketName, helper.AWSError(err))
+	}
+	// The signer reports header names in lower case. Host is left out, as
+	// the HTTP client sets it from the URL.
+	canonical := make(http.Header, len(headers))
+	for name, values := range headers {
+		if !strings.EqualFold(name, "Host") {
+			canonical[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return &cloud.SignedURL{
+		URL:     signed,
+		Method:  method,
+		Headers: canonical,
+		Expires: time.Now().Add(expires),
+	}, nil
+}
+
+// PresignPost returns a SigV4 POST policy for uploading to bucketName/key
+// from an HTML form.
+func (s *S3Service) PresignPost(ctx context.Context, bucketName, key string, opts *cloud.PostOptions) (*cloud.PostPolicy, error) {
+	if opts == nil {
+		opts = &cloud.PostOptions{}
+	}
+	expires, err := helper.SignExpiry(opts.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign a POST policy for %q in bucket %q, %w", key, bucketName, err)
+	}
+	status, err := helper.SuccessStatus(opts.SuccessStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign a POST policy for %q in bucket %q, %w", key, bucketName, err)
+	}
+
+	// The form posts to the URL of the bucket, which is resolved, along with
+	// the credentials and region, by building a request to the object.
+	req, _ := s.Client.PutObjectRequest(&s3.PutObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+	req.SetContext(ctx)
+	if err := req.Build(); err != nil {
+		return nil, fmt.Errorf("failed to presign a POST policy for %q in bucket %q, %w", key, bucketName, helper.AWSError(err))
+	}
+	if req.Config.Credentials == nil {
+		return nil, fmt.Errorf("failed to presign a POST policy for %q in bucket %q, the client has no credentials: %w", key, bucketName, cloud.ErrInvalidArgument)
+	}
+	creds, err := req.Config.Credentials.GetWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign a POST policy for %q in bucket %q, %w", key, bucketName, helper.AWSError(err))
+	}
+	region := req.ClientInfo.SigningRegion
+	if region == "" {
+		region = aws.StringValue(req.Config.Region)
+	}
+	u := *req.HTTPRequest.URL
+	u.Path, u.RawPath, u.RawQuery = strings.TrimSuffix(u.Path, key), "", ""
+
+	now := time.Now().UTC()
+	date := now.Format("20060102")
+	fields := map[string]string{
+		"key":                   key,
+		"success_action_status": strconv.Itoa(status),
+		"x-amz-algorithm":       "AWS4-HMAC-SHA256",
+		"x-amz-credential":      fmt.Sprintf("%s/%s/%s/s3/aws4_request", creds.AccessKeyID, date, region),
+		"x-amz-date":            now.Format("20060102T150405Z"),
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+	if opts.ContentType != "" {
+		fields["Content-Type"] = opts.ContentType
+	}
+	for name, value := range opts.Metadata {
+		fields["x-amz-meta-"+strings.ToLower(name)] = value
+	}
+
+	conditions := []any{map[string]string{"bucket": bucketName}}
+	if opts.KeyPrefix {
+		conditions = append(conditions, []string{"starts-with", "$key", key})
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if name != "key" || !opts.KeyPrefix {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conditions = append(conditions, map[string]string{name: fields[name]})
+	}
+	if opts.MaxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", opts.MinSize, opts.MaxSize})
+	}
+
+	policy, err := json.Marshal(map[string]any{
+		"expiration": now.Add(expires).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign a POST policy for %q in bucket %q, %w", key, bucketName, err)
+	}
+	fields["policy"] = base64.StdEncoding.EncodeToString(policy)
+	signature := hmacSHA256(signingKey(creds.SecretAccessKey, date, region, "s3"), fields["policy"])
+	fields["x-amz-signature"] = hex.EncodeToString(signature)
+
+	return &cloud.PostPolicy{
+		URL:     u.String(),
+		Fields:  fields,
+		Expires: now.Add(expires),
+	}, nil
+}
+
+// signingKey derives the SigV4 signing key of secret for date, region and
+// service.
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
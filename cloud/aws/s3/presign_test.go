@@ -0,0 +1,143 @@
+package s3_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+func TestPresignURL(t *testing.T) {
+	ctx := context.Background()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("AKIDEXAMPLE", "secret", "token"),
+	}))
+	service := s3.NewS3Service(sess)
+
+	signed, err := service.PresignURL(ctx, "artifacts", "build/report.pdf", &cloud.SignOptions{
+		Method:      "put",
+		Expires:     10 * time.Minute,
+		ContentType: "application/pdf",
+		Headers:     map[string]string{"x-amz-meta-owner": "ops"},
+	})
+	if err != nil {
+		t.Fatalf("PresignURL: %v", err)
+	}
+	u, err := url.Parse(signed.URL)
+	if err != nil {
+		t.Fatalf("presigned URL %q: %v", signed.URL, err)
+	}
+	query := u.Query()
+	if got := query.Get("X-Amz-Expires"); got != "600" {
+		t.Errorf("X-Amz-Expires = %q, want 600", got)
+	}
+	if got := query.Get("X-Amz-Credential"); !strings.HasPrefix(got, "AKIDEXAMPLE/") || !strings.HasSuffix(got, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("X-Amz-Credential = %q", got)
+	}
+	if query.Get("X-Amz-Signature") == "" || query.Get("X-Amz-Security-Token") != "token" {
+		t.Errorf("presigned URL lacks a signature or session token: %s", signed.URL)
+	}
+	if got := query.Get("X-Amz-SignedHeaders"); !strings.Contains(got, "content-type") {
+		t.Errorf("X-Amz-SignedHeaders = %q, want content-type signed", got)
+	}
+	if signed.Method != "PUT" || signed.Headers.Get("Content-Type") != "application/pdf" {
+		t.Errorf("SignedURL = %s with headers %v", signed.Method, signed.Headers)
+	}
+
+	_, err = service.PresignURL(ctx, "artifacts", "report.pdf", &cloud.SignOptions{Method: "POST"})
+	if !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("PresignURL of POST: got %v, want ErrInvalidArgument", err)
+	}
+	_, err = service.PresignURL(ctx, "artifacts", "report.pdf", &cloud.SignOptions{Expires: 8 * 24 * time.Hour})
+	if !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("PresignURL for 8 days: got %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestSignURLFake(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	store := s3.NewObjectStore(p.S3Service)
+
+	for _, method := range []string{"", "PUT", "DELETE"} {
+		signed, err := store.SignURL(ctx, "artifacts", "report.pdf", &cloud.SignOptions{Method: method})
+		if err != nil {
+			t.Fatalf("SignURL(%q): %v", method, err)
+		}
+		u, _ := url.Parse(signed.URL)
+		if u.Path != "/artifacts/report.pdf" || u.Query().Get("X-Amz-Signature") == "" {
+			t.Errorf("SignURL(%q) = %s", method, signed.URL)
+		}
+	}
+}
+
+func TestPresignPost(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	store := s3.NewObjectStore(p.S3Service)
+
+	policy, err := store.SignPost(ctx, "artifacts", "uploads/", &cloud.PostOptions{
+		Expires:       time.Hour,
+		KeyPrefix:     true,
+		ContentType:   "image/png",
+		MaxSize:       1 << 20,
+		Metadata:      map[string]string{"Owner": "ops"},
+		SuccessStatus: 201,
+	})
+	if err != nil {
+		t.Fatalf("SignPost: %v", err)
+	}
+	if policy.URL != "https://s3."+fake.DefaultRegion+".amazonaws.com/artifacts/" {
+		t.Errorf("URL = %q", policy.URL)
+	}
+	fields := policy.Fields
+	if fields["key"] != "uploads/" || fields["Content-Type"] != "image/png" || fields["x-amz-meta-owner"] != "ops" || fields["success_action_status"] != "201" {
+		t.Errorf("Fields = %v", fields)
+	}
+
+	var document struct {
+		Expiration string
+		Conditions []json.RawMessage
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(fields["policy"])
+	if err := json.Unmarshal(decoded, &document); err != nil {
+		t.Fatalf("policy %q: %v", decoded, err)
+	}
+	var conditions []string
+	for _, condition := range document.Conditions {
+		conditions = append(conditions, string(condition))
+	}
+	for _, want := range []string{`{"bucket":"artifacts"}`, `["starts-with","$key","uploads/"]`, `{"Content-Type":"image/png"}`, `["content-length-range",0,1048576]`} {
+		if !strings.Contains(strings.Join(conditions, " "), want) {
+			t.Errorf("conditions %v lack %s", conditions, want)
+		}
+	}
+
+	// The signature is the HMAC of the policy with the key derived from the
+	// secret, as S3 computes it.
+	date := fields["x-amz-date"][:8]
+	key := []byte("AWS4" + fake.SecretAccessKey)
+	for _, data := range []string{date, fake.DefaultRegion, "s3", "aws4_request", fields["policy"]} {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		key = h.Sum(nil)
+	}
+	if got := fields["x-amz-signature"]; got != hex.EncodeToString(key) {
+		t.Errorf("x-amz-signature = %s, want %x", got, key)
+	}
+}
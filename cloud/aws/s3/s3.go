@@ -1,52 +1,67 @@
 package s3
 
 import (
+    "context"
     "fmt"
+    "iter"
+    "time"
 
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/s3/s3iface"
 )
 
 // S3Service provides operations for S3 resources.
 type S3Service struct {
-    Client *s3.S3
+    Client s3iface.S3API
 }
 
-// NewS3Service creates a new S3Service.
-func NewS3Service(sess *session.Session) *S3Service {
+// BucketInfo describes an S3 bucket.
+type BucketInfo struct {
+    Name         string
+    CreationDate *time.Time
+    Location     string
+    Versioning   string
+}
+
+// NewS3Service creates a new S3Service. cfgs are applied on top of the
+// session configuration.
+func NewS3Service(sess *session.Session, cfgs ...*aws.Config) *S3Service {
     return &S3Service{
-        Client: s3.New(sess),
+        Client: s3.New(sess, cfgs...),
     }
 }
 
 // CreateBucket creates a new S3 bucket.
-func (s *S3Service) CreateBucket(bucketName string) error {
-    _, err := s.Client.CreateBucket(&s3.CreateBucketInput{
+func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
+    _, err := s.Client.CreateBucketWithContext(ctx, &s3.CreateBucketInput{
         Bucket: aws.String(bucketName),
     })
     if err != nil {
-        return fmt.Errorf("failed to create bucket: %v", err)
+        return fmt.Errorf("failed to create bucket: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // DeleteBucket deletes an S3 bucket.
-func (s *S3Service) DeleteBucket(bucketName string) error {
-    _, err := s.Client.DeleteBucket(&s3.DeleteBucketInput{
+func (s *S3Service) DeleteBucket(ctx context.Context, bucketName string) error {
+    _, err := s.Client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
         Bucket: aws.String(bucketName),
     })
     if err != nil {
-        return fmt.Errorf("failed to delete bucket: %v", err)
+        return fmt.Errorf("failed to delete bucket: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // ListBuckets lists all S3 buckets.
-func (s *S3Service) ListBuckets() ([]string, error) {
-    result, err := s.Client.ListBuckets(nil)
+func (s *S3Service) ListBuckets(ctx context.Context) ([]string, error) {
+    result, err := s.Client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
     if err != nil {
-        return nil, fmt.Errorf("failed to list buckets: %v", err)
+        return nil, fmt.Errorf("failed to list buckets: %w", helper.AWSError(err))
     }
 
     var buckets []string
@@ -57,11 +72,11 @@ func (s *S3Service) ListBuckets() ([]string, error) {
 }
 
 // GetBucketInfo retrieves information about an S3 bucket.
-func (s *S3Service) GetBucketInfo(bucketName string) (*BucketInfo, error) {
+func (s *S3Service) GetBucketInfo(ctx context.Context, bucketName string) (*BucketInfo, error) {
     // Get basic bucket information (creation date)
-    result, err := s.Client.ListBuckets(nil)
+    result, err := s.Client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
     if err != nil {
-        return nil, fmt.Errorf("failed to list buckets: %v", err)
+        return nil, fmt.Errorf("failed to list buckets: %w", helper.AWSError(err))
     }
 
     var bucketInfo BucketInfo
@@ -74,16 +89,16 @@ func (s *S3Service) GetBucketInfo(bucketName string) (*BucketInfo, error) {
     }
 
     // Get bucket location
-    loc, err := s.Client.GetBucketLocation(&s3.GetBucketLocationInput{Bucket: aws.String(bucketName)})
+    loc, err := s.Client.GetBucketLocationWithContext(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(bucketName)})
     if err != nil {
-        return nil, fmt.Errorf("failed to get bucket location for %s: %v", bucketName, err)
+        return nil, fmt.Errorf("failed to get bucket location for %s: %w", bucketName, helper.AWSError(err))
     }
     bucketInfo.Location = aws.StringValue(loc.LocationConstraint)
 
     // Get versioning information
-    versioning, err := s.Client.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: aws.String(bucketName)})
+    versioning, err := s.Client.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(bucketName)})
     if err != nil {
-        return nil, fmt.Errorf("failed to get bucket versioning for %s: %v", bucketName, err)
+        return nil, fmt.Errorf("failed to get bucket versioning for %s: %w", bucketName, helper.AWSError(err))
     }
     bucketInfo.Versioning = aws.StringValue(versioning.Status)
 
@@ -92,72 +107,69 @@ func (s *S3Service) GetBucketInfo(bucketName string) (*BucketInfo, error) {
     return &bucketInfo, nil
 }
 
-func (s *S3Service) UploadFile(bucketName, key, filePath string) error {
-    file, err := os.Open(filePath)
-    if err != nil {
-        return fmt.Errorf("failed to open file %q, %v", filePath, err)
-    }
-    defer file.Close()
-
-    _, err = s.Client.PutObject(&s3.PutObjectInput{
-        Bucket: aws.String(bucketName),
-        Key:    aws.String(key),
-        Body:   file,
-    })
-    if err != nil {
-        return fmt.Errorf("failed to put file %q in bucket %q, %v", key, bucketName, err)
-    }
-    return nil
+// UploadFile uploads a local file to an S3 bucket. Files larger than
+// DefaultPartSize are uploaded in parts, which are aborted if the upload
+// fails; see UploadFileWithOptions to resume failed uploads instead.
+func (s *S3Service) UploadFile(ctx context.Context, bucketName, key, filePath string) error {
+    return s.UploadFileWithOptions(ctx, bucketName, key, filePath, nil)
 }
 
-// DownloadFile downloads a file from an S3 bucket.
-func (s *S3Service) DownloadFile(bucketName, key, filePath string) error {
-    outputFile, err := os.Create(filePath)
-    if err != nil {
-        return fmt.Errorf("failed to create file %q, %v", filePath, err)
-    }
-    defer outputFile.Close()
-
-    resp, err := s.Client.GetObject(&s3.GetObjectInput{
-        Bucket: aws.String(bucketName),
-        Key:    aws.String(key),
-    })
-    if err != nil {
-        return fmt.Errorf("failed to get file %q from bucket %q, %v", key, bucketName, err)
-    }
-    defer resp.Body.Close()
+// DownloadFile downloads a file from an S3 bucket, fetching byte ranges
+// concurrently and resuming an interrupted download; see
+// DownloadFileWithOptions.
+func (s *S3Service) DownloadFile(ctx context.Context, bucketName, key, filePath string) error {
+    return s.DownloadFileWithOptions(ctx, bucketName, key, filePath, nil)
+}
 
-    _, err = io.Copy(outputFile, resp.Body)
-    if err != nil {
-        return fmt.Errorf("failed to copy file %q, %v", filePath, err)
+// ListObjects lists the keys of all objects in an S3 bucket.
+func (s *S3Service) ListObjects(ctx context.Context, bucketName string) ([]string, error) {
+    var objects []string
+    for item, err := range s.Objects(ctx, bucketName, nil) {
+        if err != nil {
+            return nil, err
+        }
+        objects = append(objects, aws.StringValue(item.Key))
     }
-    return nil
+    return objects, nil
 }
 
-// ListObjects lists the objects in an S3 bucket.
-func (s *S3Service) ListObjects(bucketName string) ([]string, error) {
-    resp, err := s.Client.ListObjectsV2(&s3.ListObjectsV2Input{
-        Bucket: aws.String(bucketName),
-    })
-    if err != nil {
-        return nil, fmt.Errorf("failed to list objects in bucket %q, %v", bucketName, err)
-    }
+// Objects iterates over the objects in an S3 bucket whose key starts with
+// opts.Prefix, fetching pages as the iteration proceeds.
+func (s *S3Service) Objects(ctx context.Context, bucketName string, opts *cloud.ListOptions) iter.Seq2[*s3.Object, error] {
+    return func(yield func(*s3.Object, error) bool) {
+        yield = helper.Limit(opts, yield)
+        input := &s3.ListObjectsV2Input{
+            Bucket: aws.String(bucketName),
+        }
+        if prefix := helper.ListPrefix(opts); prefix != "" {
+            input.Prefix = aws.String(prefix)
+        }
+        if size := helper.PageSize(opts); size > 0 {
+            input.MaxKeys = aws.Int64(int64(size))
+        }
 
-    var objects []string
-    for _, item := range resp.Contents {
-        objects = append(objects, *item.Key)
+        err := s.Client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+            for _, item := range page.Contents {
+                if !yield(item, nil) {
+                    return false
+                }
+            }
+            return true
+        })
+        if err != nil {
+            yield(nil, fmt.Errorf("failed to list objects in bucket %q, %w", bucketName, helper.AWSError(err)))
+        }
     }
-    return objects, nil
 }
 
 // DeleteObject deletes an object from an S3 bucket.
-func (s *S3Service) DeleteObject(bucketName, key string) error {
-    _, err := s.Client.DeleteObject(&s3.DeleteObjectInput{
+func (s *S3Service) DeleteObject(ctx context.Context, bucketName, key string) error {
+    _, err := s.Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
         Bucket: aws.String(bucketName),
         Key:    aws.String(key),
     })
     if err != nil {
-        return fmt.Errorf("failed to delete object %q from bucket %q, %v", key, bucketName, err)
+        return fmt.Errorf("failed to delete object %q from bucket %q, %w", key, bucketName, helper.AWSError(err))
     }
     return nil
 }
@@ -0,0 +1,92 @@
+package s3_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// pageRecorder records the MaxKeys of a listing and the continuation token of
+// every page it fetches.
+type pageRecorder struct {
+	s3iface.S3API
+	maxKeys int64
+	tokens  []string
+}
+
+func (c *pageRecorder) ListObjectsV2PagesWithContext(ctx aws.Context, input *awss3.ListObjectsV2Input, fn func(*awss3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	c.maxKeys = aws.Int64Value(input.MaxKeys)
+	return c.S3API.ListObjectsV2PagesWithContext(ctx, input, func(page *awss3.ListObjectsV2Output, lastPage bool) bool {
+		c.tokens = append(c.tokens, aws.StringValue(page.ContinuationToken))
+		return fn(page, lastPage)
+	}, opts...)
+}
+
+func TestObjects(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	var keys []string
+	for i := range 5 {
+		keys = append(keys, fmt.Sprintf("logs/%d.txt", i))
+	}
+	for _, key := range append(keys, "other.txt") {
+		if err := p.S3Service.PutObject(ctx, "artifacts", key, strings.NewReader(key), nil); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    *cloud.ListOptions
+		want    []string
+		maxKeys int64
+		pages   int
+	}{
+		{"all", nil, append(slices.Clone(keys), "other.txt"), 0, 1},
+		{"prefix", &cloud.ListOptions{Prefix: "logs/"}, keys, 0, 1},
+		{"pages", &cloud.ListOptions{Prefix: "logs/", PageSize: 2}, keys, 2, 3},
+		{"max items across pages", &cloud.ListOptions{Prefix: "logs/", PageSize: 2, MaxItems: 3}, keys[:3], 2, 2},
+		{"max items within a page", &cloud.ListOptions{Prefix: "logs/", PageSize: 4, MaxItems: 3}, keys[:3], 3, 1},
+		{"max items at a page boundary", &cloud.ListOptions{Prefix: "logs/", PageSize: 2, MaxItems: 4}, keys[:4], 2, 2},
+		{"no match", &cloud.ListOptions{Prefix: "missing/"}, nil, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &pageRecorder{S3API: p.S3Service.Client}
+			service := &s3.S3Service{Client: recorder}
+
+			var got []string
+			for object, err := range service.Objects(ctx, "artifacts", tt.opts) {
+				if err != nil {
+					t.Fatalf("Objects: %v", err)
+				}
+				got = append(got, aws.StringValue(object.Key))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Objects = %v, want %v", got, tt.want)
+			}
+			if recorder.maxKeys != tt.maxKeys {
+				t.Errorf("MaxKeys = %d, want %d", recorder.maxKeys, tt.maxKeys)
+			}
+			if len(recorder.tokens) != tt.pages {
+				t.Fatalf("fetched %d pages, want %d", len(recorder.tokens), tt.pages)
+			}
+			// Every page after the first continues from the previous one.
+			for i, token := range recorder.tokens {
+				if (i == 0) != (token == "") || (i > 0 && token == recorder.tokens[i-1]) {
+					t.Errorf("page tokens = %q, want one new token per page after the first", recorder.tokens)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,497 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// PutObject uploads the content of body, whose length need not be known, to
+// an S3 object. Bodies no larger than one part are uploaded with a single
+// PutObject, larger ones as multipart uploads whose parts grow as the upload
+// goes on; see ObjectWriter for the largest body that fits.
+// opts.CheckpointPath is not used: streams cannot be resumed.
+func (s *S3Service) PutObject(ctx context.Context, bucketName, key string, body io.Reader, opts *UploadOptions) error {
+	w, err := s.NewWriter(ctx, bucketName, key, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// ObjectWriter is an io.WriteCloser that uploads what is written to it to an
+// S3 object. Data is buffered into parts, which are uploaded concurrently as
+// a multipart upload while writing goes on; up to UploadOptions.Concurrency
+// parts are held in memory. The object is created by Close. Content shorter
+// than one part is uploaded by Close with a single PutObject.
+//
+// As the length of the content is not known, parts start at
+// UploadOptions.PartSize bytes and double every 1000 parts, up to
+// MaxPartSize, so that the MaxParts parts of an upload hold more than the
+// 5 TiB limit of S3 objects with DefaultPartSize, and 4.8 TiB with
+// MinPartSize. Writing more fails with cloud.ErrInvalidArgument. Memory use
+// grows with the part size.
+//
+// If a part fails, the following Write or Close returns the error and the
+// multipart upload is aborted. An ObjectWriter must be closed or aborted, or
+// the multipart upload is left on the bucket.
+type ObjectWriter struct {
+	service     *S3Service
+	bucket, key string
+	opts        UploadOptions
+	// partSize is the size of the first parts; see streamPartSize.
+	partSize int64
+	policy   *retry.Policy
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// slots limits the number of parts being uploaded.
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	buf      []byte
+	uploadID string
+	next     int64
+	done     bool
+	err      error
+
+	mu    sync.Mutex
+	parts []*s3.CompletedPart
+}
+
+var _ io.WriteCloser = (*ObjectWriter)(nil)
+
+// NewWriter returns an ObjectWriter uploading to bucketName/key. The upload
+// stops when ctx is done.
+func (s *S3Service) NewWriter(ctx context.Context, bucketName, key string, opts *UploadOptions) (*ObjectWriter, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	partSize := opts.PartSize
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+	if partSize < MinPartSize {
+		return nil, fmt.Errorf("failed to upload %q to bucket %q, part size %d is below the minimum of %d: %w", key, bucketName, partSize, MinPartSize, cloud.ErrInvalidArgument)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+	policy := opts.Retry
+	if policy == nil {
+		policy = retry.DefaultPolicy()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &ObjectWriter{
+		service:  s,
+		bucket:   bucketName,
+		key:      key,
+		opts:     *opts,
+		partSize: partSize,
+		policy:   policy,
+		ctx:      ctx,
+		cancel:   cancel,
+		slots:    make(chan struct{}, concurrency),
+		next:     1,
+	}, nil
+}
+
+// Write buffers p, uploading the parts it fills.
+func (w *ObjectWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, errors.New("s3: write to closed ObjectWriter")
+	}
+	if err := w.failed(); err != nil {
+		return 0, err
+	}
+	written := 0
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, streamPartSize(w.partSize, w.next))
+		}
+		n := min(len(p), cap(w.buf)-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p, written = p[n:], written+n
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// partSizeDoubling is the number of parts after which ObjectWriter doubles
+// its part size.
+const partSizeDoubling = 1000
+
+// streamPartSize returns the size of part number of a stream whose first
+// parts have partSize bytes. The size doubles every partSizeDoubling parts,
+// up to MaxPartSize.
+func streamPartSize(partSize, number int64) int64 {
+	for n := (number - 1) / partSizeDoubling; n > 0 && partSize < MaxPartSize; n-- {
+		partSize *= 2
+	}
+	return min(partSize, MaxPartSize)
+}
+
+// failed returns the error that stopped the upload, if any.
+func (w *ObjectWriter) failed() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := context.Cause(w.ctx); err != nil {
+		w.err = fmt.Errorf("failed to upload %q to bucket %q, %w", w.key, w.bucket, err)
+	}
+	return w.err
+}
+
+// flush starts the upload of the buffered part, creating the multipart
+// upload first if needed. It blocks while Concurrency parts are uploading.
+func (w *ObjectWriter) flush() error {
+	if w.uploadID == "" {
+		result, err := w.service.Client.CreateMultipartUploadWithContext(w.ctx, &s3.CreateMultipartUploadInput{
+			Bucket:      aws.String(w.bucket),
+			Key:         aws.String(w.key),
+			ContentType: optionalString(w.opts.ContentType),
+			Metadata:    aws.StringMap(w.opts.Metadata),
+		})
+		if err != nil {
+			w.err = fmt.Errorf("failed to create multipart upload of %q in bucket %q, %w", w.key, w.bucket, helper.AWSError(err))
+			return w.err
+		}
+		w.uploadID = aws.StringValue(result.UploadId)
+	}
+	if w.next > MaxParts {
+		w.err = fmt.Errorf("failed to upload %q to bucket %q, more than %d parts: %w", w.key, w.bucket, MaxParts, cloud.ErrInvalidArgument)
+		return w.err
+	}
+
+	select {
+	case w.slots <- struct{}{}:
+	case <-w.ctx.Done():
+		return w.failed()
+	}
+	number, data := w.next, w.buf
+	w.next, w.buf = w.next+1, nil
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			<-w.slots
+			w.wg.Done()
+		}()
+		if err := w.uploadPart(number, data); err != nil {
+			w.cancel(err)
+		}
+	}()
+	return nil
+}
+
+// uploadPart uploads part number, retrying it according to the policy.
+func (w *ObjectWriter) uploadPart(number int64, data []byte) error {
+	var etag string
+	err := w.policy.Do(w.ctx, true, func(ctx context.Context) error {
+		result, err := w.service.Client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(w.bucket),
+			Key:        aws.String(w.key),
+			UploadId:   aws.String(w.uploadID),
+			PartNumber: aws.Int64(number),
+			Body:       bytes.NewReader(data),
+		}, withoutRetries)
+		if err != nil {
+			return helper.AWSError(err)
+		}
+		etag = aws.StringValue(result.ETag)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d, %w", number, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.parts = append(w.parts, &s3.CompletedPart{PartNumber: aws.Int64(number), ETag: aws.String(etag)})
+	return nil
+}
+
+// Close uploads the buffered data and creates the object. If the upload
+// failed, the multipart upload is aborted and the error returned.
+func (w *ObjectWriter) Close() error {
+	if w.done {
+		return w.err
+	}
+	w.done = true
+	defer w.cancel(nil)
+
+	if w.uploadID == "" && w.failed() == nil {
+		_, err := w.service.Client.PutObjectWithContext(w.ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(w.bucket),
+			Key:         aws.String(w.key),
+			Body:        bytes.NewReader(w.buf),
+			ContentType: optionalString(w.opts.ContentType),
+			Metadata:    aws.StringMap(w.opts.Metadata),
+		})
+		if err != nil {
+			w.err = fmt.Errorf("failed to put %q in bucket %q, %w", w.key, w.bucket, helper.AWSError(err))
+		}
+		return w.err
+	}
+
+	if len(w.buf) > 0 && w.failed() == nil {
+		w.flush()
+	}
+	w.wg.Wait()
+	if err := w.failed(); err != nil {
+		w.abort()
+		return err
+	}
+
+	sort.Slice(w.parts, func(i, j int) bool { return *w.parts[i].PartNumber < *w.parts[j].PartNumber })
+	_, err := w.service.Client.CompleteMultipartUploadWithContext(w.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(w.bucket),
+		Key:             aws.String(w.key),
+		UploadId:        aws.String(w.uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: w.parts},
+	})
+	if err != nil {
+		w.err = fmt.Errorf("failed to complete upload %s of %q to bucket %q, %w", w.uploadID, w.key, w.bucket, helper.AWSError(err))
+		w.abort()
+	}
+	return w.err
+}
+
+// Abort discards the data written and aborts the multipart upload, without
+// creating the object.
+func (w *ObjectWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	w.cancel(context.Canceled)
+	w.wg.Wait()
+	if w.err == nil {
+		w.err = fmt.Errorf("upload of %q to bucket %q aborted: %w", w.key, w.bucket, context.Canceled)
+	}
+	return w.abort()
+}
+
+// abort aborts the multipart upload, if one was created. It does not use the
+// writer context, which is done when the upload failed.
+func (w *ObjectWriter) abort() error {
+	if w.uploadID == "" {
+		return nil
+	}
+	_, err := w.service.Client.AbortMultipartUploadWithContext(context.WithoutCancel(w.ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(w.bucket),
+		Key:      aws.String(w.key),
+		UploadId: aws.String(w.uploadID),
+	})
+	if err != nil && !errors.Is(helper.AWSError(err), cloud.ErrNotFound) {
+		return fmt.Errorf("failed to abort upload %s of %q in bucket %q, %w", w.uploadID, w.key, w.bucket, helper.AWSError(err))
+	}
+	return nil
+}
+
+// ObjectReader is an io.ReadSeekCloser over an S3 object, backed by range
+// requests. Reads stream from a single request until the reader seeks; reads
+// interrupted by a dropped connection resume from the current offset. Every
+// request is conditional on the ETag the object had when it was opened, so
+// reads fail with cloud.ErrConflict if the object is replaced.
+type ObjectReader struct {
+	service     *S3Service
+	ctx         context.Context
+	bucket, key string
+	etag        string
+	size        int64
+	policy      *retry.Policy
+
+	offset int64
+	body   io.ReadCloser
+}
+
+var (
+	_ io.ReadSeekCloser = (*ObjectReader)(nil)
+	_ io.ReaderAt       = (*ObjectReader)(nil)
+)
+
+// OpenObject opens an S3 object for reading. No data is requested until the
+// first read. The reader stops when ctx is done; it must be closed.
+func (s *S3Service) OpenObject(ctx context.Context, bucketName, key string) (*ObjectReader, error) {
+	head, err := s.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q in bucket %q, %w", key, bucketName, helper.AWSError(err))
+	}
+	return &ObjectReader{
+		service: s,
+		ctx:     ctx,
+		bucket:  bucketName,
+		key:     key,
+		etag:    aws.StringValue(head.ETag),
+		size:    aws.Int64Value(head.ContentLength),
+		policy:  retry.DefaultPolicy(),
+	}, nil
+}
+
+// Size returns the size of the object.
+func (r *ObjectReader) Size() int64 {
+	return r.size
+}
+
+// ETag returns the ETag of the object.
+func (r *ObjectReader) ETag() string {
+	return r.etag
+}
+
+// get requests length bytes of the object from offset, or the rest of the
+// object if length is negative.
+func (r *ObjectReader) get(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	result, err := r.service.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(r.bucket),
+		Key:     aws.String(r.key),
+		Range:   aws.String(byteRange),
+		IfMatch: aws.String(r.etag),
+	}, withoutRetries)
+	if err != nil {
+		return nil, helper.AWSError(err)
+	}
+	return result.Body, nil
+}
+
+// Read reads from the current offset.
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var n int
+	err := r.policy.Do(r.ctx, true, func(ctx context.Context) error {
+		if r.body == nil {
+			body, err := r.get(ctx, r.offset, -1)
+			if err != nil {
+				return err
+			}
+			r.body = body
+		}
+		var err error
+		n, err = r.body.Read(p)
+		r.offset += int64(n)
+		if err != nil {
+			r.body.Close()
+			r.body = nil
+		}
+		if n > 0 || err == nil || r.offset >= r.size {
+			return nil
+		}
+		return readError(ctx, err)
+	})
+	if err != nil {
+		return n, fmt.Errorf("failed to read %q from bucket %q, %w", r.key, r.bucket, err)
+	}
+	if n == 0 && r.offset >= r.size {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// readError classifies an error reading a response body. A connection
+// dropped while reading is transient.
+func readError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return &cloud.Error{Kind: cloud.ErrUnavailable, Provider: cloud.AWSProvider, Err: err}
+}
+
+// Seek sets the offset of the next Read. Seeking past the end is allowed;
+// reads there return io.EOF.
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return r.offset, errors.New("s3: invalid whence")
+	}
+	if offset < 0 {
+		return r.offset, errors.New("s3: negative position")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// ReadAt reads len(p) bytes from offset off with a request of its own. It
+// does not change the offset of Read and may be called concurrently.
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("s3: negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	want := min(int64(len(p)), r.size-off)
+	var n int
+	err := r.policy.Do(r.ctx, true, func(ctx context.Context) error {
+		body, err := r.get(ctx, off, want)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		n, err = io.ReadFull(body, p[:want])
+		if err != nil {
+			return readError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return n, fmt.Errorf("failed to read %q from bucket %q, %w", r.key, r.bucket, err)
+	}
+	if want < int64(len(p)) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close closes the response being read, if any.
+func (r *ObjectReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,192 @@
+package s3_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+func TestPutObject(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+
+	if err := p.S3Service.PutObject(ctx, "artifacts", "small.txt", strings.NewReader("hello"), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if got, _ := p.S3.Object("artifacts", "small.txt"); string(got) != "hello" {
+		t.Errorf("object = %q, want %q", got, "hello")
+	}
+
+	// io.MultiReader hides the length of the data, as a pipe would.
+	data := bytes.Repeat([]byte("0123456789abcdef"), (2*s3.MinPartSize+100)/16)
+	body := io.MultiReader(bytes.NewReader(data))
+	err := p.S3Service.PutObject(ctx, "artifacts", "large.bin", body, &s3.UploadOptions{PartSize: s3.MinPartSize, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if got, _ := p.S3.Object("artifacts", "large.bin"); !bytes.Equal(got, data) {
+		t.Errorf("object has %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestWriterReaderGzip(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	data := bytes.Repeat([]byte("a line of logs\n"), 100000)
+
+	w, err := p.S3Service.NewWriter(ctx, "artifacts", "logs.gz", &s3.UploadOptions{ContentType: "application/gzip"})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip Close: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := p.S3Service.OpenObject(ctx, "artifacts", "logs.gz")
+	if err != nil {
+		t.Fatalf("OpenObject: %v", err)
+	}
+	defer r.Close()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("gzip NewReader: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, %v; want %d", len(got), err, len(data))
+	}
+}
+
+func TestObjectReaderSeek(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	if err := p.S3Service.PutObject(ctx, "artifacts", "digits.txt", strings.NewReader("0123456789"), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	r, err := p.S3Service.OpenObject(ctx, "artifacts", "digits.txt")
+	if err != nil {
+		t.Fatalf("OpenObject: %v", err)
+	}
+	defer r.Close()
+	if r.Size() != 10 {
+		t.Errorf("Size = %d, want 10", r.Size())
+	}
+
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "012" {
+		t.Errorf("Read = %q, %v; want 012", buf, err)
+	}
+	p.InjectError("s3", "GetObject", fake.Error("SlowDown", 503), 1)
+	if _, err := r.Seek(-4, io.SeekEnd); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if rest, err := io.ReadAll(r); err != nil || string(rest) != "6789" {
+		t.Errorf("ReadAll after Seek = %q, %v; want 6789", rest, err)
+	}
+
+	n, err := r.ReadAt(buf, 8)
+	if n != 2 || err != io.EOF || string(buf[:n]) != "89" {
+		t.Errorf("ReadAt(8) = %d, %v, %q; want 2, EOF, 89", n, err, buf[:n])
+	}
+
+	// Replacing the object invalidates the reader.
+	if err := p.S3Service.PutObject(ctx, "artifacts", "digits.txt", strings.NewReader("replaced"), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if _, err := r.ReadAt(buf, 0); !errors.Is(err, cloud.ErrConflict) {
+		t.Errorf("ReadAt of a replaced object: got %v, want ErrConflict", err)
+	}
+}
+
+// attemptRecorder records the retry attempt of every GetObject request.
+type attemptRecorder struct {
+	s3iface.S3API
+	attempts []int
+}
+
+func (c *attemptRecorder) GetObjectWithContext(ctx aws.Context, input *awss3.GetObjectInput, opts ...request.Option) (*awss3.GetObjectOutput, error) {
+	c.attempts = append(c.attempts, retry.Attempt(ctx))
+	return c.S3API.GetObjectWithContext(ctx, input, opts...)
+}
+
+func TestObjectReaderAttempts(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	if err := p.S3Service.PutObject(ctx, "artifacts", "digits.txt", strings.NewReader("0123456789"), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	recorder := &attemptRecorder{S3API: p.S3Service.Client}
+	service := &s3.S3Service{Client: recorder}
+	r, err := service.OpenObject(ctx, "artifacts", "digits.txt")
+	if err != nil {
+		t.Fatalf("OpenObject: %v", err)
+	}
+	defer r.Close()
+
+	p.InjectError("s3", "GetObject", fake.Error("SlowDown", 503), 1)
+	if data, err := io.ReadAll(r); err != nil || string(data) != "0123456789" {
+		t.Fatalf("ReadAll = %q, %v; want 0123456789", data, err)
+	}
+	if len(recorder.attempts) != 2 || recorder.attempts[0] != 1 || recorder.attempts[1] != 2 {
+		t.Errorf("GetObject attempts = %v, want [1 2]", recorder.attempts)
+	}
+}
+
+func TestObjectWriterFailure(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+
+	p.InjectError("s3", "UploadPart", fake.Error("AccessDenied", 403), 0)
+	data := make([]byte, 3*s3.MinPartSize)
+	err := p.S3Service.PutObject(ctx, "artifacts", "denied.bin", bytes.NewReader(data), &s3.UploadOptions{PartSize: s3.MinPartSize})
+	if !errors.Is(err, cloud.ErrPermissionDenied) {
+		t.Fatalf("PutObject: got %v, want ErrPermissionDenied", err)
+	}
+	p.ClearErrors()
+
+	if _, ok := p.S3.Object("artifacts", "denied.bin"); ok {
+		t.Error("object created by a failed upload")
+	}
+	uploads, err := cloud.Collect(p.S3Service.MultipartUploads(ctx, "artifacts", nil))
+	if err != nil || len(uploads) != 0 {
+		t.Errorf("MultipartUploads after a failed upload = %v, %v", uploads, err)
+	}
+
+	w, err := p.S3Service.NewWriter(ctx, "artifacts", "aborted.bin", &s3.UploadOptions{PartSize: s3.MinPartSize})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatalf("Abort: %v", err)
+	}
+	if _, ok := p.S3.Object("artifacts", "aborted.bin"); ok {
+		t.Error("object created by an aborted writer")
+	}
+	uploads, _ = cloud.Collect(p.S3Service.MultipartUploads(ctx, "artifacts", nil))
+	if len(uploads) != 0 {
+		t.Errorf("MultipartUploads after Abort = %v", uploads)
+	}
+}
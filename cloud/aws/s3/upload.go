@@ -0,0 +1,500 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Multipart upload limits of S3.
+const (
+	MinPartSize = 5 << 20
+	MaxPartSize = 5 << 30
+	MaxParts    = 10000
+
+	// DefaultPartSize is the part size used when UploadOptions.PartSize is
+	// zero.
+	DefaultPartSize = 16 << 20
+	// DefaultUploadConcurrency is the number of parts uploaded at once when
+	// UploadOptions.Concurrency is zero.
+	DefaultUploadConcurrency = 4
+)
+
+// withoutRetries sends a request once. It is passed to the parts and ranges of
+// transfers, which are retried by the policy of the transfer instead of the
+// SDK client.
+func withoutRetries(r *request.Request) {
+	r.Retryer = client.NoOpRetryer{}
+}
+
+// UploadOptions configures S3Service.UploadFileWithOptions.
+type UploadOptions struct {
+	// PartSize is the size of the parts of multipart uploads, at least
+	// MinPartSize. It is raised as needed to keep files within MaxParts
+	// parts, and grows as streams of unknown length are written; see
+	// ObjectWriter. Files no larger than one part are uploaded with a single
+	// PutObject. Zero means DefaultPartSize.
+	PartSize int64
+	// Concurrency is the number of parts uploaded at once. Zero means
+	// DefaultUploadConcurrency.
+	Concurrency int
+	// Retry is the policy applied to each part, in place of the retries of
+	// the SDK client. Nil means retry.DefaultPolicy().
+	Retry *retry.Policy
+	// CheckpointPath is the file recording the progress of the upload, so an
+	// interrupted upload resumes from the parts already uploaded. It is
+	// removed once the upload completes. When it is set, a failed upload and
+	// its checkpoint are kept so that the next call resumes it. Empty means
+	// a file in os.TempDir named after the bucket, key and file, which only
+	// outlives the call if the process dies, as failed uploads are aborted.
+	CheckpointPath string
+	// ContentType and Metadata are set on the uploaded object.
+	ContentType string
+	Metadata    map[string]string
+}
+
+// uploadCheckpoint is the persisted state of a multipart upload. The file
+// size and modification time detect files changed since the upload started.
+type uploadCheckpoint struct {
+	Bucket   string           `json:"bucket"`
+	Key      string           `json:"key"`
+	UploadID string           `json:"upload_id"`
+	Size     int64            `json:"size"`
+	ModTime  time.Time        `json:"mod_time"`
+	PartSize int64            `json:"part_size"`
+	Parts    map[int64]string `json:"parts"`
+}
+
+// UploadFileWithOptions uploads a local file to an S3 bucket, as a multipart
+// upload of concurrently uploaded parts if it is larger than one part. If an
+// earlier upload of the same file to the same key was interrupted, the parts
+// recorded in its checkpoint are not uploaded again.
+//
+// When the upload fails, the multipart upload is aborted and its checkpoint
+// removed, unless opts.CheckpointPath is set: then both are kept for the
+// next attempt. Use AbortOrphanedUploads to clean up uploads that are never
+// resumed.
+func (s *S3Service) UploadFileWithOptions(ctx context.Context, bucketName, key, filePath string, opts *UploadOptions) error {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q, %w", filePath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %q, %w", filePath, err)
+	}
+
+	partSize, err := uploadPartSize(opts.PartSize, info.Size())
+	if err != nil {
+		return fmt.Errorf("failed to upload file %q, %w", filePath, err)
+	}
+	if info.Size() <= partSize {
+		_, err = s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucketName),
+			Key:         aws.String(key),
+			Body:        file,
+			ContentType: optionalString(opts.ContentType),
+			Metadata:    aws.StringMap(opts.Metadata),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put file %q in bucket %q, %w", key, bucketName, helper.AWSError(err))
+		}
+		return nil
+	}
+
+	checkpointPath := opts.CheckpointPath
+	if checkpointPath == "" {
+		if checkpointPath, err = defaultCheckpointPath(bucketName, key, filePath); err != nil {
+			return fmt.Errorf("failed to upload file %q, %w", filePath, err)
+		}
+	}
+	u := &multipartUpload{
+		service:        s,
+		file:           file,
+		checkpointPath: checkpointPath,
+		policy:         opts.Retry,
+	}
+	if u.policy == nil {
+		u.policy = retry.DefaultPolicy()
+	}
+	if err := u.resume(ctx, bucketName, key, info, partSize); err != nil {
+		return err
+	}
+	if u.checkpoint.UploadID == "" {
+		if err := u.create(ctx, bucketName, key, info, partSize, opts); err != nil {
+			return err
+		}
+	}
+	if err := u.uploadParts(ctx, opts.Concurrency); err != nil {
+		return u.fail(ctx, opts, fmt.Errorf("failed to upload file %q to bucket %q, %w", key, bucketName, err))
+	}
+	if err := u.complete(ctx); err != nil {
+		return u.fail(ctx, opts, err)
+	}
+	return nil
+}
+
+// uploadPartSize returns the part size to upload size bytes with.
+func uploadPartSize(partSize, size int64) (int64, error) {
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+	if partSize < MinPartSize {
+		return 0, fmt.Errorf("part size %d is below the minimum of %d: %w", partSize, MinPartSize, cloud.ErrInvalidArgument)
+	}
+	if size > partSize*MaxParts {
+		partSize = (size + MaxParts - 1) / MaxParts
+	}
+	return partSize, nil
+}
+
+// defaultCheckpointPath returns the checkpoint file of the upload of
+// filePath to bucketName/key in os.TempDir.
+func defaultCheckpointPath(bucketName, key, filePath string) (string, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(bucketName + "\x00" + key + "\x00" + abs))
+	return filepath.Join(os.TempDir(), "c2loud-upload-"+hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// multipartUpload is a multipart upload of a file in progress.
+type multipartUpload struct {
+	service        *S3Service
+	file           *os.File
+	checkpointPath string
+	policy         *retry.Policy
+
+	mu         sync.Mutex
+	checkpoint uploadCheckpoint
+}
+
+// resume loads the checkpoint of an interrupted upload of the same file. The
+// parts it records are kept only if S3 still has them with the same ETag.
+// Checkpoints of other files, or of uploads that were aborted or completed,
+// are discarded.
+func (u *multipartUpload) resume(ctx context.Context, bucketName, key string, info os.FileInfo, partSize int64) error {
+	data, err := os.ReadFile(u.checkpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read upload checkpoint %q, %w", u.checkpointPath, err)
+	}
+	var checkpoint uploadCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil ||
+		checkpoint.Bucket != bucketName || checkpoint.Key != key || checkpoint.UploadID == "" ||
+		checkpoint.Size != info.Size() || !checkpoint.ModTime.Equal(info.ModTime()) || checkpoint.PartSize != partSize {
+		return u.discard(ctx, checkpoint)
+	}
+
+	uploaded := make(map[int64]string)
+	err = u.service.Client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   aws.String(bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(checkpoint.UploadID),
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		for _, part := range page.Parts {
+			uploaded[aws.Int64Value(part.PartNumber)] = aws.StringValue(part.ETag)
+		}
+		return true
+	})
+	if errors.Is(helper.AWSError(err), cloud.ErrNotFound) {
+		return u.discard(ctx, uploadCheckpoint{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list parts of upload %s, %w", checkpoint.UploadID, helper.AWSError(err))
+	}
+
+	for number, etag := range checkpoint.Parts {
+		if uploaded[number] != etag {
+			delete(checkpoint.Parts, number)
+		}
+	}
+	if checkpoint.Parts == nil {
+		checkpoint.Parts = make(map[int64]string)
+	}
+	u.checkpoint = checkpoint
+	return nil
+}
+
+// discard removes a stale checkpoint, aborting its upload if it was left on
+// the bucket.
+func (u *multipartUpload) discard(ctx context.Context, checkpoint uploadCheckpoint) error {
+	if checkpoint.UploadID != "" {
+		_, err := u.service.Client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(checkpoint.Bucket),
+			Key:      aws.String(checkpoint.Key),
+			UploadId: aws.String(checkpoint.UploadID),
+		})
+		if err != nil && !errors.Is(helper.AWSError(err), cloud.ErrNotFound) {
+			return fmt.Errorf("failed to abort stale upload %s, %w", checkpoint.UploadID, helper.AWSError(err))
+		}
+	}
+	if err := os.Remove(u.checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove upload checkpoint %q, %w", u.checkpointPath, err)
+	}
+	return nil
+}
+
+// fail ends the upload after err. It keeps the upload for the next attempt
+// when the caller chose the checkpoint, and otherwise aborts it and removes
+// its checkpoint, even if ctx was canceled.
+func (u *multipartUpload) fail(ctx context.Context, opts *UploadOptions, err error) error {
+	if opts.CheckpointPath != "" {
+		return fmt.Errorf("upload %s can be resumed: %w", u.checkpoint.UploadID, err)
+	}
+	if abortErr := u.discard(context.WithoutCancel(ctx), u.checkpoint); abortErr != nil {
+		return errors.Join(err, abortErr)
+	}
+	return err
+}
+
+// create starts a new multipart upload and records it in the checkpoint.
+func (u *multipartUpload) create(ctx context.Context, bucketName, key string, info os.FileInfo, partSize int64, opts *UploadOptions) error {
+	result, err := u.service.Client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		ContentType: optionalString(opts.ContentType),
+		Metadata:    aws.StringMap(opts.Metadata),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload of %q in bucket %q, %w", key, bucketName, helper.AWSError(err))
+	}
+	u.checkpoint = uploadCheckpoint{
+		Bucket:   bucketName,
+		Key:      key,
+		UploadID: aws.StringValue(result.UploadId),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		PartSize: partSize,
+		Parts:    make(map[int64]string),
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.save()
+}
+
+// uploadParts uploads the parts missing from the checkpoint with concurrency
+// workers. It stops at the first part that fails.
+func (u *multipartUpload) uploadParts(ctx context.Context, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+	var missing []int64
+	count := (u.checkpoint.Size + u.checkpoint.PartSize - 1) / u.checkpoint.PartSize
+	for number := int64(1); number <= count; number++ {
+		if _, ok := u.checkpoint.Parts[number]; !ok {
+			missing = append(missing, number)
+		}
+	}
+
+	return parallel(ctx, concurrency, missing, u.uploadPart)
+}
+
+// parallel calls fn for each part number with concurrency workers. It stops
+// at the first call that fails and returns its error.
+func parallel(ctx context.Context, concurrency int, numbers []int64, fn func(ctx context.Context, number int64) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	work := make(chan int64)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range work {
+				if err := fn(ctx, number); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, number := range numbers {
+		select {
+		case work <- number:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	return context.Cause(ctx)
+}
+
+// uploadPart uploads part number, retrying it according to the policy, and
+// records it in the checkpoint.
+func (u *multipartUpload) uploadPart(ctx context.Context, number int64) error {
+	offset := (number - 1) * u.checkpoint.PartSize
+	section := io.NewSectionReader(u.file, offset, min(u.checkpoint.PartSize, u.checkpoint.Size-offset))
+	hash := md5.New()
+	if _, err := io.Copy(hash, section); err != nil {
+		return fmt.Errorf("failed to read part %d, %w", number, err)
+	}
+	contentMD5 := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	var etag string
+	err := u.policy.Do(ctx, true, func(ctx context.Context) error {
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		result, err := u.service.Client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(u.checkpoint.Bucket),
+			Key:        aws.String(u.checkpoint.Key),
+			UploadId:   aws.String(u.checkpoint.UploadID),
+			PartNumber: aws.Int64(number),
+			Body:       section,
+			ContentMD5: aws.String(contentMD5),
+		}, withoutRetries)
+		if err != nil {
+			return helper.AWSError(err)
+		}
+		etag = aws.StringValue(result.ETag)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d, %w", number, err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.checkpoint.Parts[number] = etag
+	return u.save()
+}
+
+// save writes the checkpoint atomically. It must be called with u.mu held.
+func (u *multipartUpload) save() error {
+	data, err := json.Marshal(u.checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp := u.checkpointPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write upload checkpoint %q, %w", u.checkpointPath, err)
+	}
+	if err := os.Rename(tmp, u.checkpointPath); err != nil {
+		return fmt.Errorf("failed to write upload checkpoint %q, %w", u.checkpointPath, err)
+	}
+	return nil
+}
+
+// complete completes the upload from the parts in the checkpoint and removes
+// the checkpoint.
+func (u *multipartUpload) complete(ctx context.Context) error {
+	numbers := make([]int64, 0, len(u.checkpoint.Parts))
+	for number := range u.checkpoint.Parts {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	parts := make([]*s3.CompletedPart, len(numbers))
+	for i, number := range numbers {
+		parts[i] = &s3.CompletedPart{PartNumber: aws.Int64(number), ETag: aws.String(u.checkpoint.Parts[number])}
+	}
+
+	_, err := u.service.Client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.checkpoint.Bucket),
+		Key:             aws.String(u.checkpoint.Key),
+		UploadId:        aws.String(u.checkpoint.UploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete upload %s of %q to bucket %q, %w", u.checkpoint.UploadID, u.checkpoint.Key, u.checkpoint.Bucket, helper.AWSError(err))
+	}
+	if err := os.Remove(u.checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove upload checkpoint %q, %w", u.checkpointPath, err)
+	}
+	return nil
+}
+
+// MultipartUploads iterates over the multipart uploads in progress in an S3
+// bucket whose key starts with opts.Prefix, fetching pages as the iteration
+// proceeds.
+func (s *S3Service) MultipartUploads(ctx context.Context, bucketName string, opts *cloud.ListOptions) iter.Seq2[*s3.MultipartUpload, error] {
+	return func(yield func(*s3.MultipartUpload, error) bool) {
+		yield = helper.Limit(opts, yield)
+		input := &s3.ListMultipartUploadsInput{
+			Bucket: aws.String(bucketName),
+		}
+		if prefix := helper.ListPrefix(opts); prefix != "" {
+			input.Prefix = aws.String(prefix)
+		}
+		if size := helper.PageSize(opts); size > 0 {
+			input.MaxUploads = aws.Int64(int64(size))
+		}
+
+		err := s.Client.ListMultipartUploadsPagesWithContext(ctx, input, func(page *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+			for _, upload := range page.Uploads {
+				if !yield(upload, nil) {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to list multipart uploads in bucket %q, %w", bucketName, helper.AWSError(err)))
+		}
+	}
+}
+
+// AbortOrphanedUploads aborts the multipart uploads in an S3 bucket that were
+// initiated more than olderThan ago, such as uploads whose client crashed and
+// was never resumed, and returns how many it aborted. Their parts are billed
+// until they are aborted.
+func (s *S3Service) AbortOrphanedUploads(ctx context.Context, bucketName string, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	aborted := 0
+	for upload, err := range s.MultipartUploads(ctx, bucketName, nil) {
+		if err != nil {
+			return aborted, err
+		}
+		if aws.TimeValue(upload.Initiated).After(cutoff) {
+			continue
+		}
+		_, err := s.Client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucketName),
+			Key:      upload.Key,
+			UploadId: upload.UploadId,
+		})
+		if err != nil && !errors.Is(helper.AWSError(err), cloud.ErrNotFound) {
+			return aborted, fmt.Errorf("failed to abort upload %s of %q in bucket %q, %w", aws.StringValue(upload.UploadId), aws.StringValue(upload.Key), bucketName, helper.AWSError(err))
+		}
+		aborted++
+	}
+	return aborted, nil
+}
+
+// optionalString returns nil for an empty string.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
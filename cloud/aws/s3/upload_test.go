@@ -0,0 +1,160 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+)
+
+// writeFile writes size bytes of a repeating pattern to a temporary file.
+func writeFile(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+	data := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+	path := filepath.Join(t.TempDir(), "artifact.bin")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func newBucket(t *testing.T) *fake.Provider {
+	t.Helper()
+	p := fake.NewProvider(nil)
+	if err := p.S3Service.CreateBucket(context.Background(), "artifacts"); err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+	return p
+}
+
+func TestUploadFileMultipart(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	path, data := writeFile(t, 3*s3.MinPartSize+1234)
+	checkpoint := filepath.Join(t.TempDir(), "upload.json")
+
+	p.InjectError("s3", "UploadPart", fake.Error("SlowDown", 503), 2)
+	err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "build/artifact.bin", path, &s3.UploadOptions{
+		PartSize:       s3.MinPartSize,
+		Concurrency:    3,
+		Retry:          &retry.Policy{MaxAttempts: 3},
+		CheckpointPath: checkpoint,
+		ContentType:    "application/octet-stream",
+	})
+	if err != nil {
+		t.Fatalf("UploadFileWithOptions: %v", err)
+	}
+
+	got, ok := p.S3.Object("artifacts", "build/artifact.bin")
+	if !ok || !bytes.Equal(got, data) {
+		t.Errorf("uploaded object has %d bytes, want %d", len(got), len(data))
+	}
+	if _, err := os.Stat(checkpoint); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint left after the upload: %v", err)
+	}
+}
+
+func TestUploadFileResume(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	path, data := writeFile(t, 2*s3.MinPartSize+1)
+	opts := &s3.UploadOptions{
+		PartSize:       s3.MinPartSize,
+		CheckpointPath: filepath.Join(t.TempDir(), "upload.json"),
+	}
+
+	p.InjectError("s3", "CompleteMultipartUpload", fake.Error("AccessDenied", 403), 1)
+	if err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "artifact.bin", path, opts); !errors.Is(err, cloud.ErrPermissionDenied) {
+		t.Fatalf("UploadFileWithOptions: got %v, want ErrPermissionDenied", err)
+	}
+	if _, err := os.Stat(opts.CheckpointPath); err != nil {
+		t.Fatalf("checkpoint of the failed upload: %v", err)
+	}
+
+	// Every part was uploaded by the first attempt, so resuming must not
+	// upload any.
+	p.InjectError("s3", "UploadPart", fake.Error("AccessDenied", 403), 0)
+	if err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "artifact.bin", path, opts); err != nil {
+		t.Fatalf("resumed UploadFileWithOptions: %v", err)
+	}
+	if got, _ := p.S3.Object("artifacts", "artifact.bin"); !bytes.Equal(got, data) {
+		t.Errorf("uploaded object has %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestUploadFileAbortsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	path, _ := writeFile(t, 2*s3.MinPartSize+1)
+	// The default checkpoint is written to os.TempDir.
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	p.InjectError("s3", "UploadPart", fake.Error("AccessDenied", 403), 0)
+	err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "artifact.bin", path, &s3.UploadOptions{PartSize: s3.MinPartSize})
+	if !errors.Is(err, cloud.ErrPermissionDenied) {
+		t.Fatalf("UploadFileWithOptions: got %v, want ErrPermissionDenied", err)
+	}
+	for upload, err := range p.S3Service.MultipartUploads(ctx, "artifacts", nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Errorf("upload %s of %s left on the bucket", *upload.UploadId, *upload.Key)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("checkpoint %s left after the failed upload", entries[0].Name())
+	}
+	if _, ok := p.S3.Object("artifacts", "artifact.bin"); ok {
+		t.Error("object created by the failed upload")
+	}
+}
+
+func TestUploadFileSmall(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	path, data := writeFile(t, 100)
+
+	if err := p.S3Service.UploadFile(ctx, "artifacts", "small.bin", path); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if got, _ := p.S3.Object("artifacts", "small.bin"); !bytes.Equal(got, data) {
+		t.Errorf("uploaded object = %q, want %q", got, data)
+	}
+
+	err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "small.bin", path, &s3.UploadOptions{PartSize: 1 << 20})
+	if !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("UploadFileWithOptions with a small part size: got %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestAbortOrphanedUploads(t *testing.T) {
+	ctx := context.Background()
+	p := newBucket(t)
+	path, _ := writeFile(t, s3.MinPartSize+1)
+
+	p.InjectError("s3", "UploadPart", fake.Error("AccessDenied", 403), 0)
+	err := p.S3Service.UploadFileWithOptions(ctx, "artifacts", "orphan.bin", path, &s3.UploadOptions{
+		PartSize:       s3.MinPartSize,
+		CheckpointPath: filepath.Join(t.TempDir(), "upload.json"),
+	})
+	if err == nil {
+		t.Fatal("UploadFileWithOptions succeeded with failing parts")
+	}
+	p.ClearErrors()
+
+	aborted, err := p.S3Service.AbortOrphanedUploads(ctx, "artifacts", 0)
+	if err != nil || aborted != 1 {
+		t.Fatalf("AbortOrphanedUploads = %d, %v; want 1", aborted, err)
+	}
+	uploads, err := cloud.Collect(p.S3Service.MultipartUploads(ctx, "artifacts", nil))
+	if err != nil || len(uploads) != 0 {
+		t.Errorf("MultipartUploads after abort = %v, %v", uploads, err)
+	}
+}
@@ -0,0 +1,40 @@
+package sns
+
+import (
+	"strings"
+
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// pendingConfirmation is the subscription ARN SNS reports for subscriptions
+// that have not been confirmed yet.
+const pendingConfirmation = "PendingConfirmation"
+
+// ToTopic converts an SNS topic to a model.Topic. The name is the last
+// component of the topic ARN.
+func ToTopic(t *sns.Topic) *model.Topic {
+	arn := aws.StringValue(t.TopicArn)
+	return &model.Topic{
+		ID:   arn,
+		Name: arn[strings.LastIndex(arn, ":")+1:],
+		Raw:  t,
+	}
+}
+
+// ToSubscription converts an SNS subscription to a model.Subscription.
+func ToSubscription(s *sns.Subscription) *model.Subscription {
+	sub := &model.Subscription{
+		ID:       aws.StringValue(s.SubscriptionArn),
+		Topic:    aws.StringValue(s.TopicArn),
+		Protocol: aws.StringValue(s.Protocol),
+		Endpoint: aws.StringValue(s.Endpoint),
+		State:    model.SubscriptionActive,
+		Raw:      s,
+	}
+	if sub.ID == pendingConfirmation {
+		sub.State = model.SubscriptionPending
+	}
+	return sub
+}
@@ -0,0 +1,66 @@
+package sns_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/sns"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	awssns "github.com/aws/aws-sdk-go/service/sns"
+)
+
+func TestToTopic(t *testing.T) {
+	in := &awssns.Topic{TopicArn: aws.String("arn:aws:sns:eu-west-1:123456789012:events")}
+	want := &model.Topic{ID: "arn:aws:sns:eu-west-1:123456789012:events", Name: "events", Raw: in}
+	if got := sns.ToTopic(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToTopic = %+v, want %+v", got, want)
+	}
+}
+
+func TestToSubscription(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *awssns.Subscription
+		want *model.Subscription
+	}{
+		{
+			name: "confirmed",
+			in: &awssns.Subscription{
+				SubscriptionArn: aws.String("arn:aws:sns:eu-west-1:123456789012:events:1"),
+				TopicArn:        aws.String("arn:aws:sns:eu-west-1:123456789012:events"),
+				Protocol:        aws.String("https"),
+				Endpoint:        aws.String("https://example.com/hook"),
+			},
+			want: &model.Subscription{
+				ID:       "arn:aws:sns:eu-west-1:123456789012:events:1",
+				Topic:    "arn:aws:sns:eu-west-1:123456789012:events",
+				Protocol: "https",
+				Endpoint: "https://example.com/hook",
+				State:    model.SubscriptionActive,
+			},
+		},
+		{
+			name: "pending",
+			in: &awssns.Subscription{
+				SubscriptionArn: aws.String("PendingConfirmation"),
+				Protocol:        aws.String("email"),
+				Endpoint:        aws.String("ops@example.com"),
+			},
+			want: &model.Subscription{
+				ID:       "PendingConfirmation",
+				Protocol: "email",
+				Endpoint: "ops@example.com",
+				State:    model.SubscriptionPending,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := sns.ToSubscription(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToSubscription = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
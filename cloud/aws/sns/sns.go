@@ -1,69 +1,148 @@
 package sns
 
 import (
+    "context"
     "fmt"
+    "iter"
+    "strings"
 
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/sns"
+    "github.com/aws/aws-sdk-go/service/sns/snsiface"
 )
 
 // SNSService provides operations for SNS resources.
 type SNSService struct {
-    Client *sns.SNS
+    Client snsiface.SNSAPI
 }
 
-// NewSNSService creates a new SNSService.
-func NewSNSService(sess *session.Session) *SNSService {
+// NewSNSService creates a new SNSService. cfgs are applied on top of the
+// session configuration.
+func NewSNSService(sess *session.Session, cfgs ...*aws.Config) *SNSService {
     return &SNSService{
-        Client: sns.New(sess),
+        Client: sns.New(sess, cfgs...),
     }
 }
 
 // CreateTopic creates a new SNS topic.
-func (s *SNSService) CreateTopic(topicName string) (string, error) {
-    result, err := s.Client.CreateTopic(&sns.CreateTopicInput{
+func (s *SNSService) CreateTopic(ctx context.Context, topicName string) (string, error) {
+    result, err := s.Client.CreateTopicWithContext(ctx, &sns.CreateTopicInput{
         Name: aws.String(topicName),
     })
     if err != nil {
-        return "", fmt.Errorf("failed to create topic: %v", err)
+        return "", fmt.Errorf("failed to create topic: %w", helper.AWSError(err))
     }
     return aws.StringValue(result.TopicArn), nil
 }
 
 // Subscribe subscribes an endpoint to an SNS topic.
-func (s *SNSService) Subscribe(topicArn, protocol, endpoint string) (string, error) {
-    result, err := s.Client.Subscribe(&sns.SubscribeInput{
-        TopicArn: aws.String(topicArn),
-        Protocol: aws.String(protocol),
-        Endpoint: aws.String(endpoint),
-    })
+func (s *SNSService) Subscribe(ctx context.Context, topicArn, protocol, endpoint string) (string, error) {
+    return s.SubscribeWithAttributes(ctx, topicArn, protocol, endpoint, nil)
+}
+
+// SubscribeWithAttributes subscribes an endpoint to an SNS topic, setting
+// the given subscription attributes, such as RawMessageDelivery. It returns
+// the subscription ARN, even while the subscription awaits confirmation.
+func (s *SNSService) SubscribeWithAttributes(ctx context.Context, topicArn, protocol, endpoint string, attributes map[string]string) (string, error) {
+    input := &sns.SubscribeInput{
+        TopicArn:              aws.String(topicArn),
+        Protocol:              aws.String(protocol),
+        Endpoint:              aws.String(endpoint),
+        ReturnSubscriptionArn: aws.Bool(true),
+    }
+    if len(attributes) > 0 {
+        input.Attributes = aws.StringMap(attributes)
+    }
+
+    result, err := s.Client.SubscribeWithContext(ctx, input)
     if err != nil {
-        return "", fmt.Errorf("failed to subscribe to topic: %v", err)
+        return "", fmt.Errorf("failed to subscribe to topic: %w", helper.AWSError(err))
     }
     return aws.StringValue(result.SubscriptionArn), nil
 }
 
 // Publish sends a message to an SNS topic.
-func (s *SNSService) Publish(topicArn, message string) (string, error) {
-    result, err := s.Client.Publish(&sns.PublishInput{
+func (s *SNSService) Publish(ctx context.Context, topicArn, message string) (string, error) {
+    return s.PublishWithAttributes(ctx, topicArn, message, nil)
+}
+
+// PublishWithAttributes sends a message to an SNS topic with the given
+// message attributes, sent as String attributes.
+func (s *SNSService) PublishWithAttributes(ctx context.Context, topicArn, message string, attributes map[string]string) (string, error) {
+    input := &sns.PublishInput{
         TopicArn: aws.String(topicArn),
         Message:  aws.String(message),
-    })
+    }
+    if len(attributes) > 0 {
+        input.MessageAttributes = make(map[string]*sns.MessageAttributeValue, len(attributes))
+        for k, v := range attributes {
+            input.MessageAttributes[k] = &sns.MessageAttributeValue{
+                DataType:    aws.String("String"),
+                StringValue: aws.String(v),
+            }
+        }
+    }
+
+    result, err := s.Client.PublishWithContext(ctx, input)
     if err != nil {
-        return "", fmt.Errorf("failed to publish message: %v", err)
+        return "", fmt.Errorf("failed to publish message: %w", helper.AWSError(err))
     }
     return aws.StringValue(result.MessageId), nil
 }
 
 // DeleteTopic deletes an SNS topic.
-func (s *SNSService) DeleteTopic(topicArn string) error {
-    _, err := s.Client.DeleteTopic(&sns.DeleteTopicInput{
+func (s *SNSService) DeleteTopic(ctx context.Context, topicArn string) error {
+    _, err := s.Client.DeleteTopicWithContext(ctx, &sns.DeleteTopicInput{
         TopicArn: aws.String(topicArn),
     })
     if err != nil {
-        return fmt.Errorf("failed to delete topic: %v", err)
+        return fmt.Errorf("failed to delete topic: %w", helper.AWSError(err))
+    }
+    return nil
+}
+
+// Unsubscribe deletes an SNS subscription by its ARN.
+func (s *SNSService) Unsubscribe(ctx context.Context, subscriptionArn string) error {
+    _, err := s.Client.UnsubscribeWithContext(ctx, &sns.UnsubscribeInput{
+        SubscriptionArn: aws.String(subscriptionArn),
+    })
+    if err != nil {
+        return fmt.Errorf("failed to unsubscribe: %w", helper.AWSError(err))
     }
     return nil
 }
 
+// ListTopics lists all SNS topics.
+func (s *SNSService) ListTopics(ctx context.Context) ([]*sns.Topic, error) {
+    return cloud.Collect(s.Topics(ctx, nil))
+}
+
+// Topics iterates over the SNS topics whose name starts with opts.Prefix,
+// fetching pages as the iteration proceeds. SNS has no server-side prefix
+// filter or page size, so the prefix is matched locally and opts.PageSize is
+// ignored.
+func (s *SNSService) Topics(ctx context.Context, opts *cloud.ListOptions) iter.Seq2[*sns.Topic, error] {
+    return func(yield func(*sns.Topic, error) bool) {
+        yield = helper.Limit(opts, yield)
+        prefix := helper.ListPrefix(opts)
+
+        err := s.Client.ListTopicsPagesWithContext(ctx, &sns.ListTopicsInput{}, func(page *sns.ListTopicsOutput, lastPage bool) bool {
+            for _, topic := range page.Topics {
+                arn := aws.StringValue(topic.TopicArn)
+                if !strings.HasPrefix(arn[strings.LastIndex(arn, ":")+1:], prefix) {
+                    continue
+                }
+                if !yield(topic, nil) {
+                    return false
+                }
+            }
+            return true
+        })
+        if err != nil {
+            yield(nil, fmt.Errorf("failed to list topics: %w", helper.AWSError(err)))
+        }
+    }
+}
@@ -0,0 +1,51 @@
+package sns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+)
+
+// Topic implements cloud.Topic on top of an SNSService. Topics and
+// subscriptions are identified by ARN.
+type Topic struct {
+	service *SNSService
+}
+
+var _ cloud.Topic = (*Topic)(nil)
+
+// NewTopic creates a new Topic backed by service.
+func NewTopic(service *SNSService) *Topic {
+	return &Topic{service: service}
+}
+
+// CreateTopic creates an SNS topic and returns its ARN.
+func (t *Topic) CreateTopic(ctx context.Context, name string) (string, error) {
+	return t.service.CreateTopic(ctx, name)
+}
+
+// DeleteTopic deletes the SNS topic with the given ARN.
+func (t *Topic) DeleteTopic(ctx context.Context, topicArn string) error {
+	return t.service.DeleteTopic(ctx, topicArn)
+}
+
+// Publish publishes body to the topic. Attributes are sent as SNS String
+// message attributes.
+func (t *Topic) Publish(ctx context.Context, topicArn string, body []byte, attributes map[string]string) (string, error) {
+	return t.service.PublishWithAttributes(ctx, topicArn, string(body), attributes)
+}
+
+// Subscribe subscribes opts.Endpoint to the topic using opts.Protocol and
+// returns the subscription ARN.
+func (t *Topic) Subscribe(ctx context.Context, topicArn string, opts *cloud.SubscribeOptions) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("failed to subscribe to topic: protocol and endpoint are required: %w", cloud.ErrInvalidArgument)
+	}
+	return t.service.SubscribeWithAttributes(ctx, topicArn, opts.Protocol, opts.Endpoint, opts.Attributes)
+}
+
+// Unsubscribe deletes the subscription with the given ARN.
+func (t *Topic) Unsubscribe(ctx context.Context, subscriptionArn string) error {
+	return t.service.Unsubscribe(ctx, subscriptionArn)
+}
@@ -0,0 +1,49 @@
+package sqs
+
+import (
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// ToQueue converts an SQS queue URL and its attributes, as returned by
+// GetQueueAttributes, to a model.Queue. attributes may be nil.
+func ToQueue(queueURL string, attributes map[string]string) *model.Queue {
+	queue := &model.Queue{
+		ID:         queueURL,
+		Name:       path.Base(queueURL),
+		Attributes: attributes,
+		Raw:        queueURL,
+	}
+	if n, err := strconv.ParseInt(attributes[sqs.QueueAttributeNameApproximateNumberOfMessages], 10, 64); err == nil {
+		queue.ApproximateMessages = n
+	}
+	return queue
+}
+
+// ToMessage converts a received SQS message to a model.Message.
+func ToMessage(m *sqs.Message) *model.Message {
+	msg := &model.Message{
+		ID:        aws.StringValue(m.MessageId),
+		Body:      []byte(aws.StringValue(m.Body)),
+		AckHandle: aws.StringValue(m.ReceiptHandle),
+		Raw:       m,
+	}
+	if len(m.MessageAttributes) > 0 {
+		msg.Attributes = make(map[string]string, len(m.MessageAttributes))
+		for k, v := range m.MessageAttributes {
+			msg.Attributes[k] = aws.StringValue(v.StringValue)
+		}
+	}
+	if n, err := strconv.Atoi(aws.StringValue(m.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount])); err == nil {
+		msg.ReceiveCount = n
+	}
+	if ms, err := strconv.ParseInt(aws.StringValue(m.Attributes[sqs.MessageSystemAttributeNameSentTimestamp]), 10, 64); err == nil {
+		msg.SentAt = time.UnixMilli(ms)
+	}
+	return msg
+}
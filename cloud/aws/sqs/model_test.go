@@ -0,0 +1,88 @@
+package sqs_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/sqs"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	awssqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func TestToQueue(t *testing.T) {
+	const url = "https://sqs.eu-west-1.amazonaws.com/123456789012/jobs"
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		want       *model.Queue
+	}{
+		{
+			name:       "with attributes",
+			attributes: map[string]string{"ApproximateNumberOfMessages": "42", "VisibilityTimeout": "30"},
+			want: &model.Queue{
+				ID:                  url,
+				Name:                "jobs",
+				ApproximateMessages: 42,
+				Attributes:          map[string]string{"ApproximateNumberOfMessages": "42", "VisibilityTimeout": "30"},
+				Raw:                 url,
+			},
+		},
+		{
+			name: "without attributes",
+			want: &model.Queue{ID: url, Name: "jobs", Raw: url},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqs.ToQueue(url, tt.attributes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToQueue = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMessage(t *testing.T) {
+	sent := time.UnixMilli(1772366400123)
+	tests := []struct {
+		name string
+		in   *awssqs.Message
+		want *model.Message
+	}{
+		{
+			name: "with attributes",
+			in: &awssqs.Message{
+				MessageId:         aws.String("m-1"),
+				Body:              aws.String("job"),
+				ReceiptHandle:     aws.String("handle"),
+				MessageAttributes: map[string]*awssqs.MessageAttributeValue{"kind": {DataType: aws.String("String"), StringValue: aws.String("build")}},
+				Attributes: map[string]*string{
+					"ApproximateReceiveCount": aws.String("3"),
+					"SentTimestamp":           aws.String("1772366400123"),
+				},
+			},
+			want: &model.Message{
+				ID:           "m-1",
+				Body:         []byte("job"),
+				Attributes:   map[string]string{"kind": "build"},
+				AckHandle:    "handle",
+				ReceiveCount: 3,
+				SentAt:       sent,
+			},
+		},
+		{
+			name: "bare",
+			in:   &awssqs.Message{MessageId: aws.String("m-2")},
+			want: &model.Message{ID: "m-2", Body: []byte{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := sqs.ToMessage(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToMessage = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const (
+	// maxReceiveMessages is the most messages SQS returns per receive.
+	maxReceiveMessages = 10
+	// maxWaitTime is the longest SQS waits for messages in a receive.
+	maxWaitTime = 20 * time.Second
+)
+
+// Queue implements cloud.Queue on top of an SQSService. Queue names are SQS
+// queue URLs and ack handles are receipt handles.
+type Queue struct {
+	service *SQSService
+}
+
+var _ cloud.Queue = (*Queue)(nil)
+
+// NewQueue creates a new Queue backed by service.
+func NewQueue(service *SQSService) *Queue {
+	return &Queue{service: service}
+}
+
+// Send sends msg to the queue at queueURL. Message attributes are sent as
+// SQS String attributes.
+func (q *Queue) Send(ctx context.Context, queueURL string, msg *cloud.Message) (string, error) {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String(string(msg.Body)),
+	}
+	if len(msg.Attributes) > 0 {
+		input.MessageAttributes = make(map[string]*sqs.MessageAttributeValue, len(msg.Attributes))
+		for k, v := range msg.Attributes {
+			input.MessageAttributes[k] = &sqs.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
+			}
+		}
+	}
+
+	result, err := q.service.Client.SendMessageWithContext(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", helper.AWSError(err))
+	}
+	return aws.StringValue(result.MessageId), nil
+}
+
+// Receive receives messages from the queue at queueURL using long polling
+// when opts.WaitTime is set. SQS returns at most 10 messages per call, so
+// larger opts.MaxMessages are lowered to 10, and waits at most 20 seconds, so
+// longer waits are lowered to 20 seconds. Durations are rounded up to whole
+// seconds.
+func (q *Queue) Receive(ctx context.Context, queueURL string, opts *cloud.ReceiveOptions) ([]*cloud.Message, error) {
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(queueURL),
+		AttributeNames:        aws.StringSlice([]string{sqs.MessageSystemAttributeNameAll}),
+		MessageAttributeNames: aws.StringSlice([]string{"All"}),
+	}
+	if opts != nil {
+		if opts.MaxMessages > 0 {
+			input.MaxNumberOfMessages = aws.Int64(int64(min(opts.MaxMessages, maxReceiveMessages)))
+		}
+		if opts.WaitTime > 0 {
+			input.WaitTimeSeconds = aws.Int64(seconds(min(opts.WaitTime, maxWaitTime)))
+		}
+		if opts.VisibilityTimeout > 0 {
+			input.VisibilityTimeout = aws.Int64(seconds(opts.VisibilityTimeout))
+		}
+	}
+
+	result, err := q.service.Client.ReceiveMessageWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive messages: %w", helper.AWSError(err))
+	}
+
+	messages := make([]*cloud.Message, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		messages = append(messages, ToMessage(m))
+	}
+	return messages, nil
+}
+
+// Ack deletes a received message from the queue.
+func (q *Queue) Ack(ctx context.Context, queueURL, receiptHandle string) error {
+	_, err := q.service.Client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: aws.String(receiptHandle),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", helper.AWSError(err))
+	}
+	return nil
+}
+
+// Nack makes a received message visible again by setting its visibility
+// timeout to zero.
+func (q *Queue) Nack(ctx context.Context, queueURL, receiptHandle string) error {
+	return q.ExtendVisibility(ctx, queueURL, receiptHandle, 0)
+}
+
+// ExtendVisibility changes the visibility timeout of a received message,
+// rounded up to whole seconds.
+func (q *Queue) ExtendVisibility(ctx context.Context, queueURL, receiptHandle string, timeout time.Duration) error {
+	_, err := q.service.Client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: aws.Int64(seconds(timeout)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change message visibility: %w", helper.AWSError(err))
+	}
+	return nil
+}
+
+// Attributes returns all attributes of the queue at queueURL.
+func (q *Queue) Attributes(ctx context.Context, queueURL string) (map[string]string, error) {
+	result, err := q.service.Client.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: aws.StringSlice([]string{sqs.QueueAttributeNameAll}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue attributes: %w", helper.AWSError(err))
+	}
+	return aws.StringValueMap(result.Attributes), nil
+}
+
+// seconds returns d in whole seconds, rounded up so that a sub-second
+// duration is not taken as zero.
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,78 @@
+package sqs_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/sqs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// receiveServer answers every ReceiveMessage call with no messages and
+// records its input.
+func receiveServer(t *testing.T) (*sqs.Queue, map[string]any) {
+	t.Helper()
+	input := make(map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&input)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write([]byte(`{"Messages": []}`))
+	}))
+	t.Cleanup(server.Close)
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	return sqs.NewQueue(sqs.NewSQSService(sess)), input
+}
+
+func TestReceiveMaxMessages(t *testing.T) {
+	queue, input := receiveServer(t)
+
+	// SQS rejects more than 10 messages per receive.
+	if _, err := queue.Receive(context.Background(), "https://sqs.example.com/jobs", &cloud.ReceiveOptions{MaxMessages: 25}); err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if got := input["MaxNumberOfMessages"]; got != 10.0 {
+		t.Errorf("MaxNumberOfMessages = %v, want 10", got)
+	}
+}
+
+func TestReceiveDurations(t *testing.T) {
+	tests := []struct {
+		name                  string
+		opts                  cloud.ReceiveOptions
+		wantWait, wantTimeout any
+	}{
+		{"unset", cloud.ReceiveOptions{}, nil, nil},
+		{"whole seconds", cloud.ReceiveOptions{WaitTime: 5 * time.Second, VisibilityTimeout: time.Minute}, 5.0, 60.0},
+		{"sub-second", cloud.ReceiveOptions{WaitTime: 500 * time.Millisecond, VisibilityTimeout: 200 * time.Millisecond}, 1.0, 1.0},
+		{"rounded up", cloud.ReceiveOptions{WaitTime: 1500 * time.Millisecond, VisibilityTimeout: 30*time.Second + time.Millisecond}, 2.0, 31.0},
+		{"long wait", cloud.ReceiveOptions{WaitTime: time.Minute}, 20.0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue, input := receiveServer(t)
+			if _, err := queue.Receive(context.Background(), "https://sqs.example.com/jobs", &tt.opts); err != nil {
+				t.Fatalf("Receive: %v", err)
+			}
+			if got := input["WaitTimeSeconds"]; got != tt.wantWait {
+				t.Errorf("WaitTimeSeconds = %v, want %v", got, tt.wantWait)
+			}
+			if got := input["VisibilityTimeout"]; got != tt.wantTimeout {
+				t.Errorf("VisibilityTimeout = %v, want %v", got, tt.wantTimeout)
+			}
+		})
+	}
+}
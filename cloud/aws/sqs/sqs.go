@@ -1,115 +1,159 @@
 package sqs
 
 import (
+    "context"
     "fmt"
+    "iter"
 
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/sqs"
+    "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
 
 // SQSService provides operations for SQS resources.
 type SQSService struct {
-    Client *sqs.SQS
+    Client sqsiface.SQSAPI
 }
 
-// NewSQSService creates a new SQSService.
-func NewSQSService(sess *session.Session) *SQSService {
+// NewSQSService creates a new SQSService. cfgs are applied on top of the
+// session configuration.
+func NewSQSService(sess *session.Session, cfgs ...*aws.Config) *SQSService {
     return &SQSService{
-        Client: sqs.New(sess),
+        Client: sqs.New(sess, cfgs...),
     }
 }
 
 // CreateQueue creates a new SQS queue.
-func (s *SQSService) CreateQueue(queueName string) (string, error) {
-    result, err := s.Client.CreateQueue(&sqs.CreateQueueInput{
+func (s *SQSService) CreateQueue(ctx context.Context, queueName string) (string, error) {
+    result, err := s.Client.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{
         QueueName: aws.String(queueName),
     })
     if err != nil {
-        return "", fmt.Errorf("failed to create queue: %v", err)
+        return "", fmt.Errorf("failed to create queue: %w", helper.AWSError(err))
     }
     return aws.StringValue(result.QueueUrl), nil
 }
 
 // SendMessage sends a message to an SQS queue.
-func (s *SQSService) SendMessage(queueURL, messageBody string) error {
-    _, err := s.Client.SendMessage(&sqs.SendMessageInput{
+func (s *SQSService) SendMessage(ctx context.Context, queueURL, messageBody string) error {
+    _, err := s.Client.SendMessageWithContext(ctx, &sqs.SendMessageInput{
         QueueUrl:    aws.String(queueURL),
         MessageBody: aws.String(messageBody),
     })
     if err != nil {
-        return fmt.Errorf("failed to send message: %v", err)
+        return fmt.Errorf("failed to send message: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // ReceiveMessage receives messages from an SQS queue.
-func (s *SQSService) ReceiveMessage(queueURL string) ([]*sqs.Message, error) {
-    result, err := s.Client.ReceiveMessage(&sqs.ReceiveMessageInput{
+func (s *SQSService) ReceiveMessage(ctx context.Context, queueURL string) ([]*sqs.Message, error) {
+    result, err := s.Client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
         QueueUrl: aws.String(queueURL),
     })
     if err != nil {
-        return nil, fmt.Errorf("failed to receive messages: %v", err)
+        return nil, fmt.Errorf("failed to receive messages: %w", helper.AWSError(err))
     }
     return result.Messages, nil
 }
 
 // DeleteQueue deletes an SQS queue.
-func (s *SQSService) DeleteQueue(queueURL string) error {
-    _, err := s.Client.DeleteQueue(&sqs.DeleteQueueInput{
+func (s *SQSService) DeleteQueue(ctx context.Context, queueURL string) error {
+    _, err := s.Client.DeleteQueueWithContext(ctx, &sqs.DeleteQueueInput{
         QueueUrl: aws.String(queueURL),
     })
     if err != nil {
-        return fmt.Errorf("failed to delete queue: %v", err)
+        return fmt.Errorf("failed to delete queue: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // DeleteMessage deletes a message from the SQS queue.
-func (s *SQSService) DeleteMessage(queueURL, receiptHandle string) error {
-    _, err := s.Client.DeleteMessage(&sqs.DeleteMessageInput{
+func (s *SQSService) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+    _, err := s.Client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
         QueueUrl:      aws.String(queueURL),
         ReceiptHandle: aws.String(receiptHandle),
     })
     if err != nil {
-        return fmt.Errorf("failed to delete message: %v", err)
+        return fmt.Errorf("failed to delete message: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // ChangeMessageVisibility sets the visibility timeout for a specific message.
-func (s *SQSService) ChangeMessageVisibility(queueURL, receiptHandle string, visibilityTimeout int64) error {
-    _, err := s.Client.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+func (s *SQSService) ChangeMessageVisibility(ctx context.Context, queueURL, receiptHandle string, visibilityTimeout int64) error {
+    _, err := s.Client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
         QueueUrl:          aws.String(queueURL),
         ReceiptHandle:     aws.String(receiptHandle),
         VisibilityTimeout: aws.Int64(visibilityTimeout),
     })
     if err != nil {
-        return fmt.Errorf("failed to change message visibility: %v", err)
+        return fmt.Errorf("failed to change message visibility: %w", helper.AWSError(err))
     }
     return nil
 }
 
 // GetQueueAttributes retrieves attributes for an SQS queue.
-func (s *SQSService) GetQueueAttributes(queueURL string) (map[string]string, error) {
-    result, err := s.Client.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+func (s *SQSService) GetQueueAttributes(ctx context.Context, queueURL string) (map[string]string, error) {
+    result, err := s.Client.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
         QueueUrl:       aws.String(queueURL),
         AttributeNames: aws.StringSlice([]string{"All"}),
     })
     if err != nil {
-        return nil, fmt.Errorf("failed to get queue attributes: %v", err)
+        return nil, fmt.Errorf("failed to get queue attributes: %w", helper.AWSError(err))
     }
     return aws.StringValueMap(result.Attributes), nil
 }
 
 // SetQueueAttributes sets attributes for an SQS queue.
-func (s *SQSService) SetQueueAttributes(queueURL string, attributes map[string]string) error {
-    _, err := s.Client.SetQueueAttributes(&sqs.SetQueueAttributesInput{
+func (s *SQSService) SetQueueAttributes(ctx context.Context, queueURL string, attributes map[string]string) error {
+    _, err := s.Client.SetQueueAttributesWithContext(ctx, &sqs.SetQueueAttributesInput{
         QueueUrl:   aws.String(queueURL),
         Attributes: aws.StringMap(attributes),
     })
     if err != nil {
-        return fmt.Errorf("failed to set queue attributes: %v", err)
+        return fmt.Errorf("failed to set queue attributes: %w", helper.AWSError(err))
     }
     return nil
 }
+
+// maxQueuesPageSize is the largest page ListQueues returns. SQS only pages
+// its results when a page size is given.
+const maxQueuesPageSize = 1000
+
+// ListQueues lists the URLs of all SQS queues.
+func (s *SQSService) ListQueues(ctx context.Context) ([]string, error) {
+    return cloud.Collect(s.Queues(ctx, nil))
+}
+
+// Queues iterates over the URLs of the SQS queues whose name starts with
+// opts.Prefix, fetching pages as the iteration proceeds.
+func (s *SQSService) Queues(ctx context.Context, opts *cloud.ListOptions) iter.Seq2[string, error] {
+    return func(yield func(string, error) bool) {
+        yield = helper.Limit(opts, yield)
+        input := &sqs.ListQueuesInput{
+            MaxResults: aws.Int64(maxQueuesPageSize),
+        }
+        if prefix := helper.ListPrefix(opts); prefix != "" {
+            input.QueueNamePrefix = aws.String(prefix)
+        }
+        if size := helper.PageSize(opts); size > 0 && size < maxQueuesPageSize {
+            input.MaxResults = aws.Int64(int64(size))
+        }
+
+        err := s.Client.ListQueuesPagesWithContext(ctx, input, func(page *sqs.ListQueuesOutput, lastPage bool) bool {
+            for _, queueURL := range page.QueueUrls {
+                if !yield(aws.StringValue(queueURL), nil) {
+                    return false
+                }
+            }
+            return true
+        })
+        if err != nil {
+            yield("", fmt.Errorf("failed to list queues: %w", helper.AWSError(err)))
+        }
+    }
+}
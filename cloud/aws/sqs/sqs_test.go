@@ -0,0 +1,67 @@
+package sqs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/sqs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+func TestReceiveMessageCanceled(t *testing.T) {
+	// The server holds every request, like a long poll, until the client
+	// goes away.
+	arrived := make(chan struct{}, 1)
+	released := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reading the whole body lets the server notice the client closing
+		// the connection.
+		io.Copy(io.Discard, r.Body)
+		arrived <- struct{}{}
+		select {
+		case <-r.Context().Done():
+			released <- struct{}{}
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	service := sqs.NewSQSService(sess)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-arrived
+		cancel()
+	}()
+	start := time.Now()
+	_, err = service.ReceiveMessage(ctx, server.URL+"/123456789012/jobs")
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != request.CanceledErrorCode {
+		t.Fatalf("ReceiveMessage: got %v, want %s", err, request.CanceledErrorCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ReceiveMessage returned %v after the context was canceled", elapsed)
+	}
+	select {
+	case <-released:
+	case <-time.After(5 * time.Second):
+		t.Error("the request was not canceled on the server")
+	}
+}
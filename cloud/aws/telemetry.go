@@ -0,0 +1,188 @@
+package aws
+
+import (
+    "context"
+    "io"
+    "log/slog"
+    "reflect"
+    "strings"
+
+    "github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+    "github.com/Akshay-Verma-CS/c2loud/internal/helper"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/request"
+    "go.opentelemetry.io/otel/attribute"
+)
+
+// resourceFields maps the input fields of AWS operations that identify the
+// resource acted on to span attributes.
+var resourceFields = []struct {
+    field string
+    key   attribute.Key
+}{
+    {"Bucket", telemetry.BucketKey},
+    {"QueueUrl", telemetry.QueueKey},
+    {"TopicArn", telemetry.TopicKey},
+    {"InstanceId", telemetry.InstanceKey},
+    {"InstanceIds", telemetry.InstanceKey},
+}
+
+// callKey is the request context key of the requestCall of a request.
+type callKey struct{}
+
+// requestCall is the call recording a request, and the error of its last
+// failed attempt.
+type requestCall struct {
+    call    *telemetry.Call
+    lastErr error
+}
+
+// instrument adds request handlers that record every request sent by the
+// clients created from handlers with in. The call starts with the first
+// attempt, so that presigned requests, which are never sent, are not
+// recorded, and ends once the request has completed, retries included.
+// Being installed on the session, the handlers cover every service client.
+func instrument(handlers *request.Handlers, in *telemetry.Instrumentation) {
+    if in == nil {
+        return
+    }
+
+    handlers.Send.PushFrontNamed(request.NamedHandler{
+        Name: "c2loud.telemetry.Start",
+        Fn: func(r *request.Request) {
+            if rc := callOf(r); rc != nil {
+                // The request is sent again only when it is retried.
+                rc.call.Retry(rc.lastErr)
+                return
+            }
+            ctx, call := in.Start(r.Context(), r.ClientInfo.ServiceName, r.Operation.Name, resourceAttributes(r.Params)...)
+            r.SetContext(context.WithValue(ctx, callKey{}, &requestCall{call: call}))
+            if in.LogsBodies(ctx, r.ClientInfo.ServiceName) {
+                call.LogBody("request", r.HTTPRequest.URL.String(), r.HTTPRequest.Header.Get("Content-Type"), requestBody(r))
+            }
+        },
+    })
+    handlers.Send.PushBackNamed(request.NamedHandler{
+        Name: "c2loud.telemetry.ResponseBody",
+        Fn: func(r *request.Request) {
+            rc := callOf(r)
+            if rc == nil || r.HTTPResponse == nil || r.HTTPResponse.Body == nil || !in.LogsBodies(r.Context(), r.ClientInfo.ServiceName) {
+                return
+            }
+            body, rest, err := telemetry.PeekBody(r.HTTPResponse.Body, telemetry.MaxLoggedBody)
+            r.HTTPResponse.Body = rest
+            if err == nil {
+                rc.call.LogBody("response", r.HTTPRequest.URL.String(), r.HTTPResponse.Header.Get("Content-Type"), body)
+            }
+        },
+    })
+    handlers.Retry.PushBackNamed(request.NamedHandler{
+        Name: "c2loud.telemetry.Failed",
+        Fn: func(r *request.Request) {
+            if rc := callOf(r); rc != nil {
+                rc.lastErr = helper.AWSError(r.Error)
+            }
+        },
+    })
+    handlers.Complete.PushBackNamed(request.NamedHandler{
+        Name: "c2loud.telemetry.End",
+        Fn: func(r *request.Request) {
+            if rc := callOf(r); rc != nil {
+                rc.call.End(r.RequestID, helper.AWSError(r.Error))
+            }
+        },
+    })
+}
+
+// requestBody returns the start of the body of r, leaving the body to be sent
+// unread.
+func requestBody(r *request.Request) []byte {
+    body := r.GetBody()
+    if body == nil {
+        return nil
+    }
+    start, err := body.Seek(0, io.SeekCurrent)
+    if err != nil {
+        return nil
+    }
+    buf := make([]byte, telemetry.MaxLoggedBody)
+    n, _ := io.ReadFull(body, buf)
+    if _, err := body.Seek(start, io.SeekStart); err != nil {
+        r.Error = err
+    }
+    return buf[:n]
+}
+
+// callOf returns the call recording r, or nil if there is none.
+func callOf(r *request.Request) *requestCall {
+    rc, _ := r.Context().Value(callKey{}).(*requestCall)
+    return rc
+}
+
+// resourceAttributes returns the span attributes of the resource identifiers
+// in the input params of an operation.
+func resourceAttributes(params interface{}) []attribute.KeyValue {
+    v := reflect.Indirect(reflect.ValueOf(params))
+    if v.Kind() != reflect.Struct {
+        return nil
+    }
+
+    var attrs []attribute.KeyValue
+    for _, rf := range resourceFields {
+        f := v.FieldByName(rf.field)
+        if !f.IsValid() {
+            continue
+        }
+        switch value := f.Interface().(type) {
+        case *string:
+            if value != nil {
+                attrs = append(attrs, rf.key.String(*value))
+            }
+        case []*string:
+            if len(value) > 0 {
+                attrs = append(attrs, rf.key.String(strings.Join(aws.StringValueSlice(value), ",")))
+            }
+        }
+    }
+    return attrs
+}
+
+// telemetryOptions returns the instrumentation options of the provider.
+func (c *AWSConfig) telemetryOptions() telemetry.Options {
+    return telemetry.Options{
+        TracerProvider:   c.TracerProvider,
+        Registerer:       c.MetricsRegisterer,
+        Logger:           c.Logger,
+        ServiceLogLevels: c.ServiceLogLevels,
+        LogBodies:        c.LogBodies,
+    }
+}
+
+// LogValue implements slog.LogValuer, so that logging the configuration never
+// reveals its credentials.
+func (c *AWSConfig) LogValue() slog.Value {
+    attrs := []slog.Attr{
+        slog.String("region", c.Region),
+        slog.Bool("use_iam_role", c.UseIAMRole),
+    }
+    if c.Profile != "" {
+        attrs = append(attrs, slog.String("profile", c.Profile))
+    }
+    if c.IAMRoleARN != "" {
+        attrs = append(attrs, slog.String("iam_role_arn", c.IAMRoleARN))
+    }
+    secrets := []struct{ key, value string }{
+        {"access_key", c.AccessKey},
+        {"secret_key", c.SecretKey},
+        {"external_id", c.ExternalID},
+    }
+    for _, secret := range secrets {
+        if secret.value != "" {
+            attrs = append(attrs, slog.String(secret.key, telemetry.Redacted))
+        }
+    }
+    if len(c.Endpoints) > 0 {
+        attrs = append(attrs, slog.Any("endpoints", c.Endpoints))
+    }
+    return slog.GroupValue(attrs...)
+}
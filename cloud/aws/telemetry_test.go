@@ -0,0 +1,270 @@
+package aws
+
+import (
+    "bytes"
+    "context"
+    "crypto/md5"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/Akshay-Verma-CS/c2loud/cloud"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+    "github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/defaults"
+    sdkec2 "github.com/aws/aws-sdk-go/service/ec2"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/testutil"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeS3 answers DeleteBucket: the bucket "missing" does not exist, and the
+// first attempt on any other bucket fails with 503.
+type fakeS3 struct {
+    mu       sync.Mutex
+    requests int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    f.mu.Lock()
+    f.requests++
+    n := f.requests
+    f.mu.Unlock()
+
+    w.Header().Set("x-amz-request-id", fmt.Sprintf("REQ-%d", n))
+    switch {
+    case r.URL.Path == "/missing":
+        w.WriteHeader(http.StatusNotFound)
+        fmt.Fprintf(w, "<Error><Code>NoSuchBucket</Code><Message>not found</Message><RequestId>REQ-%d</RequestId></Error>", n)
+    case n == 1:
+        w.WriteHeader(http.StatusServiceUnavailable)
+        fmt.Fprintf(w, "<Error><Code>ServiceUnavailable</Code><Message>try again</Message><RequestId>REQ-%d</RequestId></Error>", n)
+    default:
+        w.WriteHeader(http.StatusNoContent)
+    }
+}
+
+func instrumentedProvider(t *testing.T, handler http.Handler) (*AWSProvider, *tracetest.InMemoryExporter, *prometheus.Registry) {
+    t.Helper()
+    isolateCredentials(t)
+    server := httptest.NewServer(handler)
+    t.Cleanup(server.Close)
+
+    exporter := tracetest.NewInMemoryExporter()
+    reg := prometheus.NewRegistry()
+    provider, err := NewAWSProvider(&AWSConfig{
+        Region:            "us-east-1",
+        AccessKey:         "AKID",
+        SecretKey:         "SECRET",
+        Endpoints:         map[string]string{"s3": server.URL},
+        S3ForcePathStyle:  true,
+        Retry:             &retry.Policy{MaxAttempts: 3},
+        TracerProvider:    sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+        MetricsRegisterer: reg,
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return provider, exporter, reg
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+    attrs := make(map[attribute.Key]attribute.Value)
+    for _, kv := range span.Attributes {
+        attrs[kv.Key] = kv.Value
+    }
+    return attrs
+}
+
+func TestTracingRecordsRetries(t *testing.T) {
+    provider, exporter, _ := instrumentedProvider(t, &fakeS3{})
+
+    if err := provider.S3Service.DeleteBucket(context.Background(), "my-bucket"); err != nil {
+        t.Fatalf("DeleteBucket() error = %v", err)
+    }
+
+    spans := exporter.GetSpans()
+    if len(spans) != 1 {
+        t.Fatalf("recorded %d spans, want 1 for the call and its retry", len(spans))
+    }
+    span := spans[0]
+    if span.Name != "s3.DeleteBucket" {
+        t.Errorf("span name = %q, want s3.DeleteBucket", span.Name)
+    }
+    attrs := spanAttributes(span)
+    want := map[attribute.Key]attribute.Value{
+        telemetry.ProviderKey:  attribute.StringValue("aws"),
+        telemetry.ServiceKey:   attribute.StringValue("s3"),
+        telemetry.OperationKey: attribute.StringValue("DeleteBucket"),
+        telemetry.BucketKey:    attribute.StringValue("my-bucket"),
+        telemetry.RetriesKey:   attribute.IntValue(1),
+        telemetry.RequestIDKey: attribute.StringValue("REQ-2"),
+    }
+    for key, value := range want {
+        if attrs[key] != value {
+            t.Errorf("attribute %s = %v, want %v", key, attrs[key].Emit(), value.Emit())
+        }
+    }
+    if _, ok := attrs[telemetry.ErrorClassKey]; ok || span.Status.Code == codes.Error {
+        t.Errorf("successful call recorded as failed: %v", span.Status)
+    }
+}
+
+func TestTracingRecordsErrorClass(t *testing.T) {
+    provider, exporter, _ := instrumentedProvider(t, &fakeS3{})
+
+    if err := provider.S3Service.DeleteBucket(context.Background(), "missing"); err == nil {
+        t.Fatal("DeleteBucket() succeeded, want not found")
+    }
+
+    spans := exporter.GetSpans()
+    if len(spans) != 1 {
+        t.Fatalf("recorded %d spans, want 1", len(spans))
+    }
+    attrs := spanAttributes(spans[0])
+    if got := attrs[telemetry.ErrorClassKey].AsString(); got != "not_found" {
+        t.Errorf("error class = %q, want not_found", got)
+    }
+    if got := attrs[telemetry.RequestIDKey].AsString(); got != "REQ-1" {
+        t.Errorf("request ID = %q, want REQ-1", got)
+    }
+    if spans[0].Status.Code != codes.Error {
+        t.Errorf("span status = %v, want error", spans[0].Status)
+    }
+}
+
+func TestMetricsFromSessionHandlers(t *testing.T) {
+    provider, _, reg := instrumentedProvider(t, &fakeS3{})
+    ctx := context.Background()
+
+    if err := provider.S3Service.DeleteBucket(ctx, "my-bucket"); err != nil {
+        t.Fatalf("DeleteBucket() error = %v", err)
+    }
+    if err := provider.S3Service.DeleteBucket(ctx, "missing"); err == nil {
+        t.Fatal("DeleteBucket() succeeded, want not found")
+    }
+
+    want := `
+# HELP c2loud_operations_total Calls to cloud services by outcome, either success or the class of the error.
+# TYPE c2loud_operations_total counter
+c2loud_operations_total{operation="DeleteBucket",outcome="not_found",provider="aws",service="s3"} 1
+c2loud_operations_total{operation="DeleteBucket",outcome="success",provider="aws",service="s3"} 1
+# HELP c2loud_retries_total Retried attempts of calls to cloud services.
+# TYPE c2loud_retries_total counter
+c2loud_retries_total{operation="DeleteBucket",provider="aws",service="s3"} 1
+`
+    if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "c2loud_operations_total", "c2loud_retries_total"); err != nil {
+        t.Error(err)
+    }
+}
+
+// fakeSQS answers SendMessage over the JSON protocol.
+func fakeSQS(w http.ResponseWriter, r *http.Request) {
+    var in struct{ MessageBody string }
+    json.NewDecoder(r.Body).Decode(&in)
+    sum := md5.Sum([]byte(in.MessageBody))
+    w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+    w.Header().Set("x-amzn-RequestId", "REQ-SQS")
+    fmt.Fprintf(w, `{"MessageId":"m-1","MD5OfMessageBody":%q}`, hex.EncodeToString(sum[:]))
+}
+
+func loggedProvider(t *testing.T, levels map[string]slog.Level) (*AWSProvider, *AWSConfig, *bytes.Buffer) {
+    t.Helper()
+    isolateCredentials(t)
+    server := httptest.NewServer(http.HandlerFunc(fakeSQS))
+    t.Cleanup(server.Close)
+
+    var logs bytes.Buffer
+    config := &AWSConfig{
+        Region:           "us-east-1",
+        AccessKey:        "AKID",
+        SecretKey:        "SECRET",
+        Endpoints:        map[string]string{"sqs": server.URL},
+        Logger:           slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+        ServiceLogLevels: levels,
+        LogBodies:        true,
+    }
+    provider, err := NewAWSProvider(config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return provider, config, &logs
+}
+
+func TestLoggingRedactsSecrets(t *testing.T) {
+    provider, config, logs := loggedProvider(t, nil)
+    queueURL := "https://sqs.us-east-1.amazonaws.com/123456789012/orders"
+
+    if err := provider.SQSService.SendMessage(context.Background(), queueURL, "card=4111111111111111"); err != nil {
+        t.Fatalf("SendMessage() error = %v", err)
+    }
+    config.Logger.Info("provider created", "config", config)
+
+    records := map[string]map[string]any{}
+    for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+        var record map[string]any
+        if err := json.Unmarshal(line, &record); err != nil {
+            t.Fatalf("invalid log record %s: %v", line, err)
+        }
+        records[record["msg"].(string)] = record
+    }
+
+    call := records["cloud call"]
+    if call["operation"] != "SendMessage" || call["queue"] != queueURL || call["request_id"] != "REQ-SQS" {
+        t.Errorf("call record = %v, want SendMessage to %s with its request ID", call, queueURL)
+    }
+    if body, _ := records["cloud request"]["body"].(string); !strings.Contains(body, `"MessageBody":"REDACTED"`) {
+        t.Errorf("request body = %q, want the message body redacted", body)
+    }
+    if _, ok := records["cloud response"]; !ok {
+        t.Error("response body not logged")
+    }
+    for _, secret := range []string{"4111111111111111", "SECRET", "AKID"} {
+        if strings.Contains(logs.String(), secret) {
+            t.Errorf("logs contain %q:\n%s", secret, logs)
+        }
+    }
+}
+
+func TestLoggingServiceLevels(t *testing.T) {
+    provider, _, logs := loggedProvider(t, map[string]slog.Level{"sqs": slog.LevelWarn})
+
+    if err := provider.SQSService.SendMessage(context.Background(), "https://sqs/q", "hello"); err != nil {
+        t.Fatalf("SendMessage() error = %v", err)
+    }
+    if logs.Len() > 0 {
+        t.Errorf("successful SQS call logged below the SQS level:\n%s", logs)
+    }
+}
+
+func TestTracingDisabledByDefault(t *testing.T) {
+    handlers := defaults.Handlers()
+    before := handlers.Send.Len()
+    in, err := telemetry.New(cloud.AWSProvider, telemetry.Options{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    instrument(&handlers, in)
+    if handlers.Send.Len() != before {
+        t.Error("instrument() added handlers without a TracerProvider")
+    }
+}
+
+func TestResourceAttributes(t *testing.T) {
+    got := resourceAttributes(&sdkec2.StartInstancesInput{InstanceIds: aws.StringSlice([]string{"i-1", "i-2"})})
+    want := []attribute.KeyValue{telemetry.InstanceKey.String("i-1,i-2")}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("resourceAttributes() = %v, want %v", got, want)
+    }
+}
@@ -0,0 +1,210 @@
+// Package cache manages long-lived provider instances keyed by name.
+//
+// A Cache remembers the configuration each instance was built from, so that
+// asking for an existing key with a different configuration is reported
+// rather than silently answered with the old instance. Instances are closed
+// when they are evicted, replaced by Refresh, or when the cache is closed, if
+// they implement io.Closer.
+//
+// The provider packages keep a package-level Cache behind their GetInstance
+// functions; code that needs isolation, such as tests, creates its own.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrConfigMismatch is returned by Get when key is cached with a different
+// configuration.
+var ErrConfigMismatch = errors.New("cache: key is cached with a different config")
+
+// Cache holds instances of type P built from configs of type C.
+type Cache[C, P any] struct {
+	newFunc func(context.Context, C) (P, error)
+	// Equal reports whether two configs are the same. Nil compares their
+	// fingerprints, which follow pointers, slices and maps and compare
+	// functions, channels, interface values such as tracer providers,
+	// loggers and HTTP clients by identity: configs only share an instance
+	// if they share those. Set it before first use.
+	Equal func(a, b C) bool
+
+	mu      sync.Mutex
+	entries map[string]*entry[C, P]
+	// builds ensures a single build per key is in flight in Get.
+	builds singleflight.Group
+}
+
+type entry[C, P any] struct {
+	config      C
+	fingerprint string
+	instance    P
+}
+
+// New returns an empty cache that builds instances with newFunc.
+func New[C, P any](newFunc func(context.Context, C) (P, error)) *Cache[C, P] {
+	return &Cache[C, P]{
+		newFunc: newFunc,
+		entries: make(map[string]*entry[C, P]),
+	}
+}
+
+// Get returns the instance cached under key, building it from config if
+// there is none. It fails with ErrConfigMismatch if key was built from a
+// different config; use Refresh to replace it.
+//
+// Instances are built without holding the cache's lock, so that other keys
+// are served meanwhile, and concurrent calls for a key wait for the same
+// build, sharing its result. The build keeps the values of ctx but not its
+// cancellation, so that a caller that stops waiting does not fail the build
+// of the others.
+//
+// Configs must not be modified after they are passed to Get.
+func (c *Cache[C, P]) Get(ctx context.Context, key string, config C) (P, error) {
+	var zero P
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		result := c.builds.DoChan(key, func() (interface{}, error) {
+			return c.build(context.WithoutCancel(ctx), key, config)
+		})
+		select {
+		case r := <-result:
+			if r.Err != nil {
+				return zero, r.Err
+			}
+			e = r.Val.(*entry[C, P])
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+	if !c.equal(e, config) {
+		return zero, fmt.Errorf("%w: %q", ErrConfigMismatch, key)
+	}
+	return e.instance, nil
+}
+
+// Refresh builds a new instance for key from config, for example after its
+// credentials were rotated, and closes the instance it replaces. The old
+// instance is kept if the new one cannot be built.
+func (c *Cache[C, P]) Refresh(ctx context.Context, key string, config C) (P, error) {
+	instance, err := c.newFunc(ctx, config)
+	if err != nil {
+		return instance, err
+	}
+
+	c.mu.Lock()
+	old, ok := c.entries[key]
+	c.entries[key] = c.newEntry(config, instance)
+	c.mu.Unlock()
+
+	if ok {
+		if err := closeInstance(old.instance); err != nil {
+			return instance, fmt.Errorf("cache: closing replaced instance %q: %w", key, err)
+		}
+	}
+	return instance, nil
+}
+
+// Evict removes key from the cache and closes its instance. Evicting a key
+// that is not cached does nothing.
+func (c *Cache[C, P]) Evict(key string) error {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	delete(c.entries, key)
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return closeInstance(e.instance)
+}
+
+// Keys returns the cached keys in no particular order.
+func (c *Cache[C, P]) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Close evicts every key and returns the errors of closing their instances,
+// joined. The cache remains usable.
+func (c *Cache[C, P]) Close() error {
+	c.mu.Lock()
+	entries := c.entries
+	c.entries = make(map[string]*entry[C, P])
+	c.mu.Unlock()
+
+	var errs []error
+	for key, e := range entries {
+		if err := closeInstance(e.instance); err != nil {
+			errs = append(errs, fmt.Errorf("cache: closing %q: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// build returns the entry of key, creating and storing it unless another
+// call stored one first.
+func (c *Cache[C, P]) build(ctx context.Context, key string, config C) (*entry[C, P], error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return e, nil
+	}
+
+	instance, err := c.newFunc(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	e, ok = c.entries[key]
+	if !ok {
+		e = c.newEntry(config, instance)
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	if ok {
+		// A Refresh stored an instance while this one was built.
+		closeInstance(instance)
+	}
+	return e, nil
+}
+
+func (c *Cache[C, P]) newEntry(config C, instance P) *entry[C, P] {
+	e := &entry[C, P]{config: config, instance: instance}
+	if c.Equal == nil {
+		e.fingerprint = fingerprint(config)
+	}
+	return e
+}
+
+// equal reports whether config is the config e was built from.
+func (c *Cache[C, P]) equal(e *entry[C, P], config C) bool {
+	if c.Equal != nil {
+		return c.Equal(e.config, config)
+	}
+	return e.fingerprint == fingerprint(config)
+}
+
+func closeInstance(instance any) error {
+	if closer, ok := instance.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
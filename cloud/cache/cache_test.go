@@ -0,0 +1,362 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type config struct {
+	Name string
+}
+
+type instance struct {
+	config config
+	closed bool
+	err    error
+}
+
+func (i *instance) Close() error {
+	i.closed = true
+	return i.err
+}
+
+func newTestCache() (*Cache[config, *instance], *int) {
+	builds := 0
+	return New(func(ctx context.Context, c config) (*instance, error) {
+		if c.Name == "" {
+			return nil, errors.New("name is required")
+		}
+		builds++
+		return &instance{config: c}, nil
+	}), &builds
+}
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	c, builds := newTestCache()
+
+	first, err := c.Get(ctx, "a", config{Name: "one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Get(ctx, "a", config{Name: "one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second || *builds != 1 {
+		t.Errorf("Get built %d instances for an equal config, want 1", *builds)
+	}
+
+	if _, err := c.Get(ctx, "a", config{Name: "two"}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("Get with a different config error = %v, want ErrConfigMismatch", err)
+	}
+	if _, err := c.Get(ctx, "b", config{}); err == nil {
+		t.Error("Get succeeded although the instance could not be built")
+	}
+	if keys := c.Keys(); !slices.Equal(keys, []string{"a"}) {
+		t.Errorf("Keys() = %v, want [a]", keys)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCache()
+	c.Equal = func(a, b config) bool { return true }
+
+	if _, err := c.Get(ctx, "a", config{Name: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Get(ctx, "a", config{Name: "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.config.Name != "one" {
+		t.Errorf("Get returned the instance of %q, want one", got.config.Name)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCache()
+
+	old, _ := c.Get(ctx, "a", config{Name: "one"})
+	if _, err := c.Refresh(ctx, "a", config{}); err == nil {
+		t.Fatal("Refresh succeeded although the instance could not be built")
+	}
+	if old.closed {
+		t.Error("Refresh closed the old instance although it was kept")
+	}
+
+	fresh, err := c.Refresh(ctx, "a", config{Name: "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !old.closed {
+		t.Error("Refresh did not close the replaced instance")
+	}
+	if got, _ := c.Get(ctx, "a", config{Name: "two"}); got != fresh {
+		t.Error("Get did not return the refreshed instance")
+	}
+}
+
+func TestEvictAndClose(t *testing.T) {
+	ctx := context.Background()
+	c, builds := newTestCache()
+
+	a, _ := c.Get(ctx, "a", config{Name: "one"})
+	b, _ := c.Get(ctx, "b", config{Name: "one"})
+	b.err = errors.New("close failed")
+
+	if err := c.Evict("a"); err != nil || !a.closed {
+		t.Errorf("Evict() = %v, closed = %v", err, a.closed)
+	}
+	if err := c.Evict("missing"); err != nil {
+		t.Errorf("Evict of a missing key = %v", err)
+	}
+	if err := c.Close(); !errors.Is(err, b.err) || !b.closed {
+		t.Errorf("Close() = %v, closed = %v", err, b.closed)
+	}
+	if len(c.Keys()) != 0 {
+		t.Errorf("Keys() after Close = %v", c.Keys())
+	}
+
+	if _, err := c.Get(ctx, "a", config{Name: "one"}); err != nil || *builds != 3 {
+		t.Errorf("Get after Close = %v with %d builds, want a rebuild", err, *builds)
+	}
+}
+
+func TestGetBuildsOnce(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	builds := map[string]int{}
+	c := New(func(ctx context.Context, c config) (*instance, error) {
+		mu.Lock()
+		builds[c.Name]++
+		mu.Unlock()
+		if c.Name == "slow" {
+			<-release
+		}
+		return &instance{config: c}, nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]*instance, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.Get(ctx, "a", config{Name: "slow"})
+		}()
+	}
+
+	// Other keys are served while the slow build runs.
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "b", config{Name: "fast"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get of another key waited for a build in progress")
+	}
+
+	// A caller whose context ends stops waiting for the build.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := c.Get(canceled, "a", config{Name: "slow"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get with a canceled context = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	wg.Wait()
+	for _, got := range results {
+		if got == nil || got != results[0] {
+			t.Fatalf("concurrent Get returned %v, want one shared instance", results)
+		}
+	}
+	if builds["slow"] != 1 {
+		t.Errorf("built %d instances for concurrent Gets, want 1", builds["slow"])
+	}
+	if _, err := c.Get(ctx, "a", config{Name: "other"}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("Get with a different config after a shared build = %v, want ErrConfigMismatch", err)
+	}
+}
+
+// providerConfig resembles the configs of the provider packages.
+type providerConfig struct {
+	Region     string
+	Endpoints  map[string]string
+	Scopes     []string
+	Timeout    time.Duration
+	Retry      *retryPolicy
+	Logger     *slog.Logger
+	HTTPClient *http.Client
+	Tracer     any
+	Token      func() (string, error)
+}
+
+type retryPolicy struct {
+	MaxAttempts int
+	Retryable   func(error) bool
+}
+
+func TestFingerprint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := &http.Client{Timeout: time.Second}
+	token := func() (string, error) { return "", nil }
+	base := func() *providerConfig {
+		return &providerConfig{
+			Region:     "us-east-1",
+			Endpoints:  map[string]string{"s3": "http://localhost:9000", "sqs": "http://localhost:4566"},
+			Scopes:     []string{"read", "write"},
+			Timeout:    time.Minute,
+			Retry:      &retryPolicy{MaxAttempts: 3},
+			Logger:     logger,
+			HTTPClient: client,
+			Tracer:     client,
+			Token:      token,
+		}
+	}
+	want := fingerprint(base())
+
+	same := base()
+	same.Endpoints = map[string]string{"sqs": "http://localhost:4566", "s3": "http://localhost:9000"}
+	if got := fingerprint(same); got != want {
+		t.Error("configs differing in map order have different fingerprints")
+	}
+
+	// closures returns closures of the same code that capture different
+	// variables.
+	closures := func() (func(error) bool, func(error) bool) {
+		var a, b bool
+		return func(error) bool { return a }, func(error) bool { return b }
+	}
+	retryableA, _ := closures()
+	retryableB, _ := closures()
+
+	for name, change := range map[string]func(*providerConfig){
+		"region":         func(c *providerConfig) { c.Region = "eu-west-1" },
+		"endpoint":       func(c *providerConfig) { c.Endpoints["s3"] = "http://minio:9000" },
+		"endpoint key":   func(c *providerConfig) { c.Endpoints["sns"] = c.Endpoints["s3"]; delete(c.Endpoints, "s3") },
+		"scope order":    func(c *providerConfig) { c.Scopes = []string{"write", "read"} },
+		"nil scopes":     func(c *providerConfig) { c.Scopes = nil },
+		"timeout":        func(c *providerConfig) { c.Timeout = time.Second },
+		"retry":          func(c *providerConfig) { c.Retry.MaxAttempts = 5 },
+		"no retry":       func(c *providerConfig) { c.Retry = nil },
+		"string joining": func(c *providerConfig) { c.Region, c.Scopes = "us-east-1read", []string{"", "write"} },
+		"logger":         func(c *providerConfig) { c.Logger = slog.New(slog.NewTextHandler(io.Discard, nil)) },
+		"no logger":      func(c *providerConfig) { c.Logger = nil },
+		"http client":    func(c *providerConfig) { c.HTTPClient = &http.Client{Timeout: time.Second} },
+		"tracer":         func(c *providerConfig) { c.Tracer = &http.Client{Timeout: time.Second} },
+		"tracer type":    func(c *providerConfig) { c.Tracer = logger },
+		"token":          func(c *providerConfig) { c.Token = func() (string, error) { return "", nil } },
+		"retryable":      func(c *providerConfig) { c.Retry.Retryable = func(error) bool { return true } },
+	} {
+		changed := base()
+		change(changed)
+		if fingerprint(changed) == want {
+			t.Errorf("changing the %s leaves the fingerprint unchanged", name)
+		}
+	}
+
+	withA, withB := base(), base()
+	withA.Retry.Retryable, withB.Retry.Retryable = retryableA, retryableB
+	if fingerprint(withA) == fingerprint(withB) {
+		t.Error("closures of the same code have the same fingerprint")
+	}
+	withB.Retry.Retryable = retryableA
+	if fingerprint(withA) != fingerprint(withB) {
+		t.Error("configs sharing a closure have different fingerprints")
+	}
+	inMap := func(f func(error) bool) string {
+		return fingerprint(map[string]func(error) bool{"retryable": f})
+	}
+	if inMap(retryableA) == inMap(retryableB) || inMap(retryableA) != inMap(retryableA) {
+		t.Error("closures in maps are not fingerprinted by identity")
+	}
+
+	type node struct {
+		Name string
+		Next *node
+	}
+	cycle := &node{Name: "a"}
+	cycle.Next = cycle
+	if fingerprint(cycle) == fingerprint(&node{Name: "a"}) {
+		t.Error("a cyclic config has the fingerprint of an acyclic one")
+	}
+}
+
+func TestGetComparesLoggers(t *testing.T) {
+	ctx := context.Background()
+	c := New(func(ctx context.Context, c *providerConfig) (*providerConfig, error) {
+		return c, nil
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	first, err := c.Get(ctx, "a", &providerConfig{Region: "us-east-1", Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Get(ctx, "a", &providerConfig{Region: "us-east-1", Logger: logger})
+	if err != nil || second != first {
+		t.Errorf("Get with the same logger = %p, %v; want the cached instance", second, err)
+	}
+	if _, err := c.Get(ctx, "a", &providerConfig{Region: "us-east-1", Logger: slog.Default()}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("Get with another logger = %v, want ErrConfigMismatch", err)
+	}
+	if _, err := c.Get(ctx, "a", &providerConfig{Region: "eu-west-1", Logger: logger}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("Get with another region = %v, want ErrConfigMismatch", err)
+	}
+}
+
+func TestGetBuildOutlivesCaller(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	c := New(func(ctx context.Context, c config) (*instance, error) {
+		close(started)
+		select {
+		case <-release:
+			return &instance{config: c}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	first, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := c.Get(first, "a", config{Name: "one"})
+		firstDone <- err
+	}()
+	<-started
+
+	secondDone := make(chan error, 1)
+	go func() {
+		got, err := c.Get(context.Background(), "a", config{Name: "one"})
+		if err == nil && got == nil {
+			err = errors.New("no instance")
+		}
+		secondDone <- err
+	}()
+
+	// The caller that started the build stops waiting; the build goes on
+	// for the other one.
+	cancel()
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("Get of the canceled caller = %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-secondDone; err != nil {
+		t.Errorf("Get of the waiting caller = %v, want the built instance", err)
+	}
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// identityTypes are fingerprinted by the address they point to, like
+// functions, channels and interface values, rather than by their data: they
+// hold behaviour or connections rather than configuration, and configs only
+// match if they share them.
+var identityTypes = map[reflect.Type]bool{
+	reflect.TypeFor[*slog.Logger](): true,
+	reflect.TypeFor[*http.Client](): true,
+}
+
+// fingerprint returns a digest of the data in config, following pointers,
+// slices and maps, so that configs built separately with the same values
+// have the same fingerprint. Functions, channels, interface values, loggers
+// and HTTP clients are compared by identity.
+func fingerprint(config any) string {
+	h := sha256.New()
+	writeValue(h, addressable(reflect.ValueOf(config)), make(map[uintptr]bool))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addressable returns v, or a copy of it that is addressable when v is not,
+// so that the words of the functions and interface values it holds can be
+// read.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// closure returns the address of the closure of the func value v, when v is
+// addressable.
+func closure(v reflect.Value) (uintptr, bool) {
+	if !v.CanAddr() {
+		return 0, false
+	}
+	return *(*uintptr)(v.Addr().UnsafePointer()), true
+}
+
+// dataWord returns the data word of the interface value v, when v is
+// addressable.
+func dataWord(v reflect.Value) (uintptr, bool) {
+	if !v.CanAddr() {
+		return 0, false
+	}
+	return (*[2]uintptr)(v.Addr().UnsafePointer())[1], true
+}
+
+// writeValue writes v to h. seen holds the pointers being written, which
+// are written once to guard against cycles.
+func writeValue(h hash.Hash, v reflect.Value, seen map[uintptr]bool) {
+	if !v.IsValid() {
+		fmt.Fprint(h, "invalid;")
+		return
+	}
+	if identityTypes[v.Type()] {
+		fmt.Fprintf(h, "%s@%x;", v.Type(), v.Pointer())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Func:
+		if v.IsNil() {
+			fmt.Fprint(h, "nil;")
+			return
+		}
+		// A func value points to its closure, which tells apart closures
+		// of the same code; v.Pointer only returns the code.
+		if p, ok := closure(v); ok {
+			fmt.Fprintf(h, "func@%x;", p)
+			return
+		}
+		fmt.Fprintf(h, "func@%x;", v.Pointer())
+	case reflect.Chan, reflect.UnsafePointer:
+		fmt.Fprintf(h, "%s@%x;", v.Type(), v.Pointer())
+	case reflect.Interface:
+		if v.IsNil() {
+			fmt.Fprint(h, "nil;")
+			return
+		}
+		// The data word of an interface value is the pointer it holds, or
+		// the address of its boxed copy of the value.
+		if p, ok := dataWord(v); ok {
+			fmt.Fprintf(h, "%s@%x;", v.Elem().Type(), p)
+			return
+		}
+		writeValue(h, v.Elem(), seen)
+	case reflect.Pointer:
+		if v.IsNil() {
+			fmt.Fprint(h, "nil;")
+			return
+		}
+		if seen[v.Pointer()] {
+			fmt.Fprint(h, "cycle;")
+			return
+		}
+		seen[v.Pointer()] = true
+		defer delete(seen, v.Pointer())
+		fmt.Fprint(h, "&")
+		writeValue(h, v.Elem(), seen)
+	case reflect.Struct:
+		fmt.Fprintf(h, "%s{", v.Type())
+		for i := range v.NumField() {
+			fmt.Fprintf(h, "%s:", v.Type().Field(i).Name)
+			writeValue(h, v.Field(i), seen)
+		}
+		fmt.Fprint(h, "}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			fmt.Fprint(h, "nil;")
+			return
+		}
+		fmt.Fprintf(h, "[%d:", v.Len())
+		for i := range v.Len() {
+			writeValue(h, v.Index(i), seen)
+		}
+		fmt.Fprint(h, "]")
+	case reflect.Map:
+		if v.IsNil() {
+			fmt.Fprint(h, "nil;")
+			return
+		}
+		// Entries are written in the order of their keys' fingerprints.
+		entries := make([][2]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key, value := sha256.New(), sha256.New()
+			writeValue(key, addressable(iter.Key()), seen)
+			writeValue(value, addressable(iter.Value()), seen)
+			entries = append(entries, [2]string{string(key.Sum(nil)), string(value.Sum(nil))})
+		}
+		slices.SortFunc(entries, func(a, b [2]string) int {
+			return strings.Compare(a[0], b[0])
+		})
+		fmt.Fprintf(h, "map[%d:", len(entries))
+		for _, entry := range entries {
+			fmt.Fprintf(h, "%x=%x;", entry[0], entry[1])
+		}
+		fmt.Fprint(h, "]")
+	case reflect.String:
+		fmt.Fprintf(h, "%q;", v.String())
+	case reflect.Bool:
+		fmt.Fprintf(h, "%t;", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(h, "%d;", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(h, "%d;", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(h, "%v;", v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		fmt.Fprintf(h, "%v;", v.Complex())
+	}
+}
@@ -0,0 +1,42 @@
+package cloud
+
+import (
+	"context"
+	"iter"
+)
+
+// InstanceSpec describes an instance to create with Compute.CreateInstance.
+type InstanceSpec struct {
+	Name string
+	// Image is an AMI ID for EC2 or a source image for Compute Engine, such
+	// as "projects/debian-cloud/global/images/family/debian-12".
+	Image string
+	// MachineType is an EC2 instance type or a Compute Engine machine type.
+	MachineType string
+	// Zone is an availability zone or Compute Engine zone. Empty uses the
+	// provider default.
+	Zone   string
+	Labels map[string]string
+}
+
+// Compute is a provider-neutral interface over VM instances such as Amazon
+// EC2 and Google Compute Engine.
+type Compute interface {
+	// CreateInstance launches a new instance from spec.
+	CreateInstance(ctx context.Context, spec *InstanceSpec) (*Instance, error)
+	// ListInstances lists all instances.
+	ListInstances(ctx context.Context) ([]*Instance, error)
+	// Instances iterates over the instances whose name starts with
+	// opts.Prefix, fetching pages lazily.
+	Instances(ctx context.Context, opts *ListOptions) iter.Seq2[*Instance, error]
+	// GetInstance returns the instance with the given ID.
+	GetInstance(ctx context.Context, id string) (*Instance, error)
+	// StartInstance starts a stopped instance.
+	StartInstance(ctx context.Context, id string) error
+	// StopInstance stops a running instance.
+	StopInstance(ctx context.Context, id string) error
+	// RebootInstance restarts a running instance.
+	RebootInstance(ctx context.Context, id string) error
+	// DeleteInstance terminates and deletes an instance.
+	DeleteInstance(ctx context.Context, id string) error
+}
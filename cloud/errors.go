@@ -0,0 +1,45 @@
+package cloud
+
+import "errors"
+
+// Sentinel errors classifying provider failures. Errors returned by c2loud
+// services match at most one of them with errors.Is, whatever the provider.
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrAlreadyExists    = errors.New("already exists")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrThrottled        = errors.New("throttled")
+	ErrConflict         = errors.New("conflict")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrUnavailable      = errors.New("unavailable")
+)
+
+// Error is a provider error annotated with its classification. It wraps the
+// original SDK error, so errors.As still reaches awserr.Error, googleapi.Error
+// and friends.
+type Error struct {
+	// Kind is one of the sentinel errors above, or nil when the error could
+	// not be classified.
+	Kind     error
+	Provider ProviderType
+	// Code is the provider error code, such as "NoSuchBucket" or "notFound".
+	Code       string
+	StatusCode int
+	RequestID  string
+	// Err is the original SDK error.
+	Err error
+}
+
+// Error returns the message of the original error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the classification and the original error, so errors.Is and
+// errors.As match either.
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
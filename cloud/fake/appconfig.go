@@ -0,0 +1,362 @@
+package fake
+
+import (
+	"crypto/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/appconfig"
+	"github.com/aws/aws-sdk-go/service/appconfig/appconfigiface"
+)
+
+// hostedLocation is the location URI of profiles whose configuration is
+// stored by AppConfig.
+const hostedLocation = "hosted"
+
+// AppConfig is an in-memory appconfigiface.AppConfigAPI for applications,
+// environments and hosted configuration profiles. Deployments are not
+// modelled: GetConfiguration serves the latest hosted version of a profile in
+// every environment, and validators are not run.
+type AppConfig struct {
+	appconfigiface.AppConfigAPI
+	env *env
+
+	mu           sync.Mutex
+	applications map[string]*application
+}
+
+type application struct {
+	id, name     string
+	description  string
+	environments map[string]*appconfig.CreateEnvironmentOutput
+	profiles     map[string]*profile
+}
+
+type profile struct {
+	*appconfig.CreateConfigurationProfileOutput
+	versions []*appconfig.CreateHostedConfigurationVersionOutput
+}
+
+func resourceNotFound(format string, args ...any) error {
+	return newError(appconfig.ErrCodeResourceNotFoundException, http.StatusNotFound, format, args...)
+}
+
+func badRequest(format string, args ...any) error {
+	return invalidParameter(appconfig.ErrCodeBadRequestException, format, args...)
+}
+
+// resourceID returns a new ID of an AppConfig resource.
+func resourceID() string {
+	return strings.ToLower(rand.Text()[:7])
+}
+
+// application returns the application with the given ID, or name if
+// byName is set.
+func (c *AppConfig) application(id *string, byName bool) (*application, error) {
+	if app, ok := c.applications[aws.StringValue(id)]; ok {
+		return app, nil
+	}
+	if byName {
+		for _, app := range c.applications {
+			if app.name == aws.StringValue(id) {
+				return app, nil
+			}
+		}
+	}
+	return nil, resourceNotFound("application %s not found", aws.StringValue(id))
+}
+
+// profile returns the profile with the given ID, or name if byName is set.
+func (a *application) profile(id *string, byName bool) (*profile, error) {
+	if p, ok := a.profiles[aws.StringValue(id)]; ok {
+		return p, nil
+	}
+	if byName {
+		for _, p := range a.profiles {
+			if aws.StringValue(p.Name) == aws.StringValue(id) {
+				return p, nil
+			}
+		}
+	}
+	return nil, resourceNotFound("configuration profile %s not found", aws.StringValue(id))
+}
+
+// CreateApplicationWithContext creates an application.
+func (c *AppConfig) CreateApplicationWithContext(ctx aws.Context, input *appconfig.CreateApplicationInput, opts ...request.Option) (*appconfig.CreateApplicationOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "CreateApplication"); err != nil {
+		return nil, err
+	}
+	if aws.StringValue(input.Name) == "" {
+		return nil, badRequest("Name is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app := &application{
+		id:           resourceID(),
+		name:         aws.StringValue(input.Name),
+		description:  aws.StringValue(input.Description),
+		environments: make(map[string]*appconfig.CreateEnvironmentOutput),
+		profiles:     make(map[string]*profile),
+	}
+	if c.applications == nil {
+		c.applications = make(map[string]*application)
+	}
+	c.applications[app.id] = app
+	return &appconfig.CreateApplicationOutput{
+		Id:          aws.String(app.id),
+		Name:        input.Name,
+		Description: input.Description,
+	}, nil
+}
+
+// CreateEnvironmentWithContext creates an environment of an application.
+func (c *AppConfig) CreateEnvironmentWithContext(ctx aws.Context, input *appconfig.CreateEnvironmentInput, opts ...request.Option) (*appconfig.CreateEnvironmentOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "CreateEnvironment"); err != nil {
+		return nil, err
+	}
+	if aws.StringValue(input.Name) == "" {
+		return nil, badRequest("Name is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.ApplicationId, false)
+	if err != nil {
+		return nil, err
+	}
+	env := &appconfig.CreateEnvironmentOutput{
+		ApplicationId: aws.String(app.id),
+		Id:            aws.String(resourceID()),
+		Name:          input.Name,
+		Description:   input.Description,
+		Monitors:      input.Monitors,
+		State:         aws.String(appconfig.EnvironmentStateReadyForDeployment),
+	}
+	app.environments[aws.StringValue(env.Id)] = env
+	out := *env
+	return &out, nil
+}
+
+// CreateConfigurationProfileWithContext creates a configuration profile of
+// an application.
+func (c *AppConfig) CreateConfigurationProfileWithContext(ctx aws.Context, input *appconfig.CreateConfigurationProfileInput, opts ...request.Option) (*appconfig.CreateConfigurationProfileOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "CreateConfigurationProfile"); err != nil {
+		return nil, err
+	}
+	switch {
+	case aws.StringValue(input.Name) == "":
+		return nil, badRequest("Name is required")
+	case aws.StringValue(input.LocationUri) == "":
+		return nil, badRequest("LocationUri is required")
+	}
+	profileType := aws.StringValue(input.Type)
+	if profileType == "" {
+		profileType = "AWS.Freeform"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.ApplicationId, false)
+	if err != nil {
+		return nil, err
+	}
+	p := &profile{CreateConfigurationProfileOutput: &appconfig.CreateConfigurationProfileOutput{
+		ApplicationId:    aws.String(app.id),
+		Id:               aws.String(resourceID()),
+		Name:             input.Name,
+		Description:      input.Description,
+		LocationUri:      input.LocationUri,
+		RetrievalRoleArn: input.RetrievalRoleArn,
+		Type:             aws.String(profileType),
+		Validators:       input.Validators,
+	}}
+	app.profiles[aws.StringValue(p.Id)] = p
+	out := *p.CreateConfigurationProfileOutput
+	return &out, nil
+}
+
+// GetConfigurationProfileWithContext returns a configuration profile of an
+// application.
+func (c *AppConfig) GetConfigurationProfileWithContext(ctx aws.Context, input *appconfig.GetConfigurationProfileInput, opts ...request.Option) (*appconfig.GetConfigurationProfileOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "GetConfigurationProfile"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.ApplicationId, false)
+	if err != nil {
+		return nil, err
+	}
+	p, err := app.profile(input.ConfigurationProfileId, false)
+	if err != nil {
+		return nil, err
+	}
+	return &appconfig.GetConfigurationProfileOutput{
+		ApplicationId:    p.ApplicationId,
+		Id:               p.Id,
+		Name:             p.Name,
+		Description:      p.Description,
+		LocationUri:      p.LocationUri,
+		RetrievalRoleArn: p.RetrievalRoleArn,
+		Type:             p.Type,
+		Validators:       p.Validators,
+	}, nil
+}
+
+// UpdateConfigurationProfileWithContext updates the name, description,
+// retrieval role or validators of a configuration profile.
+func (c *AppConfig) UpdateConfigurationProfileWithContext(ctx aws.Context, input *appconfig.UpdateConfigurationProfileInput, opts ...request.Option) (*appconfig.UpdateConfigurationProfileOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "UpdateConfigurationProfile"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.ApplicationId, false)
+	if err != nil {
+		return nil, err
+	}
+	p, err := app.profile(input.ConfigurationProfileId, false)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		p.Name = input.Name
+	}
+	if input.Description != nil {
+		p.Description = input.Description
+	}
+	if input.RetrievalRoleArn != nil {
+		p.RetrievalRoleArn = input.RetrievalRoleArn
+	}
+	if input.Validators != nil {
+		p.Validators = input.Validators
+	}
+	return &appconfig.UpdateConfigurationProfileOutput{
+		ApplicationId:    p.ApplicationId,
+		Id:               p.Id,
+		Name:             p.Name,
+		Description:      p.Description,
+		LocationUri:      p.LocationUri,
+		RetrievalRoleArn: p.RetrievalRoleArn,
+		Type:             p.Type,
+		Validators:       p.Validators,
+	}, nil
+}
+
+// CreateHostedConfigurationVersionWithContext stores a new version of the
+// configuration of a hosted profile. Versions are numbered from 1.
+func (c *AppConfig) CreateHostedConfigurationVersionWithContext(ctx aws.Context, input *appconfig.CreateHostedConfigurationVersionInput, opts ...request.Option) (*appconfig.CreateHostedConfigurationVersionOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "CreateHostedConfigurationVersion"); err != nil {
+		return nil, err
+	}
+	if aws.StringValue(input.ContentType) == "" {
+		return nil, badRequest("ContentType is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.ApplicationId, false)
+	if err != nil {
+		return nil, err
+	}
+	p, err := app.profile(input.ConfigurationProfileId, false)
+	if err != nil {
+		return nil, err
+	}
+	if aws.StringValue(p.LocationUri) != hostedLocation {
+		return nil, badRequest("configuration profile %s is not hosted", aws.StringValue(p.Id))
+	}
+	if input.LatestVersionNumber != nil && aws.Int64Value(input.LatestVersionNumber) != int64(len(p.versions)) {
+		return nil, newError(appconfig.ErrCodeConflictException, http.StatusConflict, "the latest version number is %d", len(p.versions))
+	}
+	version := &appconfig.CreateHostedConfigurationVersionOutput{
+		ApplicationId:          aws.String(app.id),
+		ConfigurationProfileId: p.Id,
+		Content:                append([]byte(nil), input.Content...),
+		ContentType:            input.ContentType,
+		Description:            input.Description,
+		VersionLabel:           input.VersionLabel,
+		VersionNumber:          aws.Int64(int64(len(p.versions) + 1)),
+	}
+	p.versions = append(p.versions, version)
+	out := *version
+	return &out, nil
+}
+
+// GetConfigurationWithContext returns the latest hosted version of a
+// configuration. Application, Environment and Configuration are IDs or names.
+// The content is empty when ClientConfigurationVersion is the latest version.
+func (c *AppConfig) GetConfigurationWithContext(ctx aws.Context, input *appconfig.GetConfigurationInput, opts ...request.Option) (*appconfig.GetConfigurationOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "GetConfiguration"); err != nil {
+		return nil, err
+	}
+	if aws.StringValue(input.ClientId) == "" {
+		return nil, badRequest("ClientId is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.Application, true)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for id, env := range app.environments {
+		if id == aws.StringValue(input.Environment) || aws.StringValue(env.Name) == aws.StringValue(input.Environment) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, resourceNotFound("environment %s not found", aws.StringValue(input.Environment))
+	}
+	p, err := app.profile(input.Configuration, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.versions) == 0 {
+		return nil, resourceNotFound("no configuration deployed for %s", aws.StringValue(input.Configuration))
+	}
+
+	latest := p.versions[len(p.versions)-1]
+	version := strconv.FormatInt(aws.Int64Value(latest.VersionNumber), 10)
+	out := &appconfig.GetConfigurationOutput{
+		ConfigurationVersion: aws.String(version),
+		ContentType:          latest.ContentType,
+	}
+	if aws.StringValue(input.ClientConfigurationVersion) != version {
+		out.Content = append([]byte(nil), latest.Content...)
+	}
+	return out, nil
+}
+
+// ValidateConfigurationWithContext checks that a version of a configuration
+// exists. Validators are not run.
+func (c *AppConfig) ValidateConfigurationWithContext(ctx aws.Context, input *appconfig.ValidateConfigurationInput, opts ...request.Option) (*appconfig.ValidateConfigurationOutput, error) {
+	if err := c.env.call(ctx, "appconfig", "ValidateConfiguration"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	app, err := c.application(input.ApplicationId, false)
+	if err != nil {
+		return nil, err
+	}
+	p, err := app.profile(input.ConfigurationProfileId, false)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(aws.StringValue(input.ConfigurationVersion))
+	if err != nil || n < 1 || n > len(p.versions) {
+		return nil, badRequest("configuration version %s not found", aws.StringValue(input.ConfigurationVersion))
+	}
+	return &appconfig.ValidateConfigurationOutput{}, nil
+}
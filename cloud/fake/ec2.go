@@ -0,0 +1,474 @@
+package fake
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// Page size limits of DescribeInstances.
+const (
+	minDescribeInstancesResults = 5
+	maxDescribeInstancesResults = 1000
+)
+
+// instanceStateCodes are the codes of the instance states.
+var instanceStateCodes = map[string]int64{
+	ec2.InstanceStateNamePending:      0,
+	ec2.InstanceStateNameRunning:      16,
+	ec2.InstanceStateNameShuttingDown: 32,
+	ec2.InstanceStateNameTerminated:   48,
+	ec2.InstanceStateNameStopping:     64,
+	ec2.InstanceStateNameStopped:      80,
+}
+
+// instanceID matches well-formed instance IDs.
+var instanceID = regexp.MustCompile(`^i-[0-9a-f]{8}([0-9a-f]{9})?$`)
+
+// EC2 is an in-memory ec2iface.EC2API for instances. Instances go through the
+// pending, stopping and shutting-down states for Config.InstanceTransition
+// before reaching their next state; transitions are applied as the fake clock
+// advances.
+type EC2 struct {
+	ec2iface.EC2API
+	env *env
+
+	mu        sync.Mutex
+	instances map[string]*instance
+	order     []string
+}
+
+type instance struct {
+	*ec2.Instance
+	reservation string
+	// next is the state the instance reaches at due, if it is in transition.
+	next string
+	due  time.Time
+}
+
+// Instance returns a copy of the instance with the given ID, with its current
+// state, and whether it exists.
+func (c *EC2) Instance(id string) (*ec2.Instance, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settle(c.env.now())
+	i, ok := c.instances[id]
+	if !ok {
+		return nil, false
+	}
+	return awsutil.CopyOf(i.Instance).(*ec2.Instance), true
+}
+
+// settle completes the transitions due at now. It must be called with c.mu
+// held.
+func (c *EC2) settle(now time.Time) {
+	for _, i := range c.instances {
+		if i.next != "" && !now.Before(i.due) {
+			i.setState(i.next)
+			i.next = ""
+		}
+	}
+}
+
+func (i *instance) setState(name string) {
+	i.State = &ec2.InstanceState{Name: aws.String(name), Code: aws.Int64(instanceStateCodes[name])}
+}
+
+// transition moves the instance to state, and to next after the transition
+// time.
+func (c *EC2) transition(i *instance, state, next string, now time.Time) *ec2.InstanceStateChange {
+	previous := i.State
+	i.setState(state)
+	i.next, i.due = next, now.Add(c.env.transition)
+	return &ec2.InstanceStateChange{
+		InstanceId:    i.InstanceId,
+		PreviousState: previous,
+		CurrentState:  i.State,
+	}
+}
+
+// lookup returns the instances with the given IDs, after settling their
+// transitions. It must be called with c.mu held.
+func (c *EC2) lookup(ids []*string) ([]*instance, error) {
+	if len(ids) == 0 {
+		return nil, missingParameter("InstanceId")
+	}
+	c.settle(c.env.now())
+	instances := make([]*instance, 0, len(ids))
+	var missing []string
+	for _, id := range aws.StringValueSlice(ids) {
+		if !instanceID.MatchString(id) {
+			return nil, invalidParameter("InvalidInstanceID.Malformed", "invalid id: %q", id)
+		}
+		i, ok := c.instances[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		instances = append(instances, i)
+	}
+	if len(missing) > 0 {
+		return nil, invalidParameter("InvalidInstanceID.NotFound", "the instance IDs '%s' do not exist", strings.Join(missing, ", "))
+	}
+	return instances, nil
+}
+
+func incorrectState(i *instance, operation string) error {
+	return invalidParameter("IncorrectInstanceState", "the instance '%s' is not in a state from which it can be %s", aws.StringValue(i.InstanceId), operation)
+}
+
+// RunInstancesWithContext launches MaxCount instances in a new reservation.
+// They start pending.
+func (c *EC2) RunInstancesWithContext(ctx aws.Context, input *ec2.RunInstancesInput, opts ...request.Option) (*ec2.Reservation, error) {
+	if err := c.env.call(ctx, "ec2", "RunInstances"); err != nil {
+		return nil, err
+	}
+	switch {
+	case input.ImageId == nil:
+		return nil, missingParameter("ImageId")
+	case input.MinCount == nil:
+		return nil, missingParameter("MinCount")
+	case input.MaxCount == nil:
+		return nil, missingParameter("MaxCount")
+	}
+	if !strings.HasPrefix(aws.StringValue(input.ImageId), "ami-") {
+		return nil, invalidParameter("InvalidAMIID.Malformed", "invalid id: %q", aws.StringValue(input.ImageId))
+	}
+	minCount, maxCount := aws.Int64Value(input.MinCount), aws.Int64Value(input.MaxCount)
+	if minCount < 1 || maxCount < minCount {
+		return nil, invalidParameter("InvalidParameterValue", "invalid MinCount %d or MaxCount %d", minCount, maxCount)
+	}
+	instanceType := aws.StringValue(input.InstanceType)
+	if instanceType == "" {
+		instanceType = ec2.InstanceTypeM1Small
+	}
+	zone := c.env.region + "a"
+	if input.Placement != nil && input.Placement.AvailabilityZone != nil {
+		zone = aws.StringValue(input.Placement.AvailabilityZone)
+		if !strings.HasPrefix(zone, c.env.region) {
+			return nil, invalidParameter("InvalidParameterValue", "invalid availability zone: [%s]", zone)
+		}
+	}
+	var tags []*ec2.Tag
+	for _, spec := range input.TagSpecifications {
+		if aws.StringValue(spec.ResourceType) == ec2.ResourceTypeInstance {
+			tags = append(tags, spec.Tags...)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.env.now()
+	reservation := &ec2.Reservation{
+		ReservationId: aws.String(fmt.Sprintf("r-%017x", c.env.next())),
+		OwnerId:       aws.String(c.env.account),
+	}
+	if c.instances == nil {
+		c.instances = make(map[string]*instance)
+	}
+	for range maxCount {
+		n := c.env.next()
+		ip := fmt.Sprintf("10.0.%d.%d", n>>8&0xff, n&0xff)
+		i := &instance{
+			Instance: &ec2.Instance{
+				InstanceId:       aws.String(fmt.Sprintf("i-%017x", n)),
+				ImageId:          input.ImageId,
+				InstanceType:     aws.String(instanceType),
+				KeyName:          input.KeyName,
+				LaunchTime:       aws.Time(now.UTC().Truncate(time.Second)),
+				Placement:        &ec2.Placement{AvailabilityZone: aws.String(zone), Tenancy: aws.String(ec2.TenancyDefault)},
+				PrivateIpAddress: aws.String(ip),
+				PrivateDnsName:   aws.String("ip-" + strings.ReplaceAll(ip, ".", "-") + ".ec2.internal"),
+			},
+			reservation: aws.StringValue(reservation.ReservationId),
+		}
+		for _, tag := range tags {
+			i.Tags = append(i.Tags, &ec2.Tag{Key: tag.Key, Value: tag.Value})
+		}
+		c.transition(i, ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, now)
+		c.instances[aws.StringValue(i.InstanceId)] = i
+		c.order = append(c.order, aws.StringValue(i.InstanceId))
+		reservation.Instances = append(reservation.Instances, awsutil.CopyOf(i.Instance).(*ec2.Instance))
+	}
+	return reservation, nil
+}
+
+// DescribeInstancesWithContext describes instances in launch order, grouped
+// by reservation. The fake supports the tag:<key>, tag-key,
+// instance-state-name, instance-id, image-id, instance-type and
+// availability-zone filters.
+func (c *EC2) DescribeInstancesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, opts ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+	if err := c.env.call(ctx, "ec2", "DescribeInstances"); err != nil {
+		return nil, err
+	}
+	if input.MaxResults != nil {
+		if len(input.InstanceIds) > 0 {
+			return nil, invalidParameter("InvalidParameterCombination", "the parameter instancesSet cannot be used with the parameter maxResults")
+		}
+		if n := aws.Int64Value(input.MaxResults); n < minDescribeInstancesResults || n > maxDescribeInstancesResults {
+			return nil, invalidParameter("InvalidParameterValue", "MaxResults must be between %d and %d", minDescribeInstancesResults, maxDescribeInstancesResults)
+		}
+	}
+	filters, err := parseFilters(input.Filters)
+	if err != nil {
+		return nil, err
+	}
+	marker, err := decodeToken(input.NextToken, "InvalidNextToken")
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var candidates []*instance
+	if len(input.InstanceIds) > 0 {
+		if candidates, err = c.lookup(input.InstanceIds); err != nil {
+			return nil, err
+		}
+	} else {
+		c.settle(c.env.now())
+		for _, id := range c.order {
+			candidates = append(candidates, c.instances[id])
+		}
+	}
+
+	out := &ec2.DescribeInstancesOutput{}
+	var last *ec2.Reservation
+	count := 0
+	skipping := marker != ""
+	for _, i := range candidates {
+		if skipping {
+			skipping = aws.StringValue(i.InstanceId) != marker
+			continue
+		}
+		if !filters.matches(i) {
+			continue
+		}
+		if input.MaxResults != nil && int64(count) == aws.Int64Value(input.MaxResults) {
+			out.NextToken = encodeToken(aws.StringValue(last.Instances[len(last.Instances)-1].InstanceId))
+			break
+		}
+		if last == nil || aws.StringValue(last.ReservationId) != i.reservation {
+			last = &ec2.Reservation{ReservationId: aws.String(i.reservation), OwnerId: aws.String(c.env.account)}
+			out.Reservations = append(out.Reservations, last)
+		}
+		last.Instances = append(last.Instances, awsutil.CopyOf(i.Instance).(*ec2.Instance))
+		count++
+	}
+	return out, nil
+}
+
+// DescribeInstancesPagesWithContext calls fn with each page of the listing.
+func (c *EC2) DescribeInstancesPagesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error {
+	page := *input
+	for {
+		out, err := c.DescribeInstancesWithContext(ctx, &page, opts...)
+		if err != nil {
+			return err
+		}
+		lastPage := out.NextToken == nil
+		if !fn(out, lastPage) || lastPage {
+			return nil
+		}
+		page.NextToken = out.NextToken
+	}
+}
+
+// StartInstancesWithContext starts stopped instances. Starting a running or
+// pending instance does nothing.
+func (c *EC2) StartInstancesWithContext(ctx aws.Context, input *ec2.StartInstancesInput, opts ...request.Option) (*ec2.StartInstancesOutput, error) {
+	if err := c.env.call(ctx, "ec2", "StartInstances"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	instances, err := c.lookup(input.InstanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		if state := aws.StringValue(i.State.Name); state != ec2.InstanceStateNameStopped && state != ec2.InstanceStateNameRunning && state != ec2.InstanceStateNamePending {
+			return nil, incorrectState(i, "started")
+		}
+	}
+	now, out := c.env.now(), &ec2.StartInstancesOutput{}
+	for _, i := range instances {
+		out.StartingInstances = append(out.StartingInstances, c.change(i, ec2.InstanceStateNameStopped, ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, now))
+	}
+	return out, nil
+}
+
+// StopInstancesWithContext stops running instances. Stopping a stopped or
+// stopping instance does nothing.
+func (c *EC2) StopInstancesWithContext(ctx aws.Context, input *ec2.StopInstancesInput, opts ...request.Option) (*ec2.StopInstancesOutput, error) {
+	if err := c.env.call(ctx, "ec2", "StopInstances"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	instances, err := c.lookup(input.InstanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		if state := aws.StringValue(i.State.Name); state != ec2.InstanceStateNameRunning && state != ec2.InstanceStateNameStopped && state != ec2.InstanceStateNameStopping {
+			return nil, incorrectState(i, "stopped")
+		}
+	}
+	now, out := c.env.now(), &ec2.StopInstancesOutput{}
+	for _, i := range instances {
+		out.StoppingInstances = append(out.StoppingInstances, c.change(i, ec2.InstanceStateNameRunning, ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped, now))
+	}
+	return out, nil
+}
+
+// RebootInstancesWithContext reboots running instances. Reboots are
+// instantaneous.
+func (c *EC2) RebootInstancesWithContext(ctx aws.Context, input *ec2.RebootInstancesInput, opts ...request.Option) (*ec2.RebootInstancesOutput, error) {
+	if err := c.env.call(ctx, "ec2", "RebootInstances"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	instances, err := c.lookup(input.InstanceIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		if aws.StringValue(i.State.Name) != ec2.InstanceStateNameRunning {
+			return nil, incorrectState(i, "rebooted")
+		}
+	}
+	return &ec2.RebootInstancesOutput{}, nil
+}
+
+// TerminateInstancesWithContext terminates instances. Terminated instances
+// remain visible to DescribeInstances.
+func (c *EC2) TerminateInstancesWithContext(ctx aws.Context, input *ec2.TerminateInstancesInput, opts ...request.Option) (*ec2.TerminateInstancesOutput, error) {
+	if err := c.env.call(ctx, "ec2", "TerminateInstances"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	instances, err := c.lookup(input.InstanceIds)
+	if err != nil {
+		return nil, err
+	}
+	now, out := c.env.now(), &ec2.TerminateInstancesOutput{}
+	for _, i := range instances {
+		change := unchanged(i)
+		if state := aws.StringValue(i.State.Name); state != ec2.InstanceStateNameShuttingDown && state != ec2.InstanceStateNameTerminated {
+			change = c.transition(i, ec2.InstanceStateNameShuttingDown, ec2.InstanceStateNameTerminated, now)
+		}
+		out.TerminatingInstances = append(out.TerminatingInstances, change)
+	}
+	return out, nil
+}
+
+// change moves an instance in state from to state, then next, and returns the
+// state change. Instances in any other state are left as they are.
+func (c *EC2) change(i *instance, from, state, next string, now time.Time) *ec2.InstanceStateChange {
+	if aws.StringValue(i.State.Name) != from {
+		return unchanged(i)
+	}
+	return c.transition(i, state, next, now)
+}
+
+// unchanged returns the state change of an instance left in its state.
+func unchanged(i *instance) *ec2.InstanceStateChange {
+	return &ec2.InstanceStateChange{InstanceId: i.InstanceId, PreviousState: i.State, CurrentState: i.State}
+}
+
+// instanceFilters are parsed DescribeInstances filters: an instance matches
+// when, for each filter, one of its values matches.
+type instanceFilters []instanceFilter
+
+type instanceFilter struct {
+	name   string
+	values []*regexp.Regexp
+}
+
+func parseFilters(filters []*ec2.Filter) (instanceFilters, error) {
+	parsed := make(instanceFilters, 0, len(filters))
+	for _, f := range filters {
+		name := aws.StringValue(f.Name)
+		switch {
+		case strings.HasPrefix(name, "tag:"):
+		case name == "tag-key", name == "instance-state-name", name == "instance-id",
+			name == "image-id", name == "instance-type", name == "availability-zone":
+		default:
+			return nil, invalidParameter("InvalidParameterValue", "the filter '%s' is invalid", name)
+		}
+		filter := instanceFilter{name: name}
+		for _, v := range aws.StringValueSlice(f.Values) {
+			filter.values = append(filter.values, wildcard(v))
+		}
+		parsed = append(parsed, filter)
+	}
+	return parsed, nil
+}
+
+// wildcard compiles a filter value, in which * matches any sequence of
+// characters and ? any single character.
+func wildcard(value string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(value)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+func (fs instanceFilters) matches(i *instance) bool {
+	for _, f := range fs {
+		if !f.matches(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f instanceFilter) matches(i *instance) bool {
+	var candidates []string
+	switch f.name {
+	case "tag-key":
+		for _, tag := range i.Tags {
+			candidates = append(candidates, aws.StringValue(tag.Key))
+		}
+	case "instance-state-name":
+		candidates = []string{aws.StringValue(i.State.Name)}
+	case "instance-id":
+		candidates = []string{aws.StringValue(i.InstanceId)}
+	case "image-id":
+		candidates = []string{aws.StringValue(i.ImageId)}
+	case "instance-type":
+		candidates = []string{aws.StringValue(i.InstanceType)}
+	case "availability-zone":
+		candidates = []string{aws.StringValue(i.Placement.AvailabilityZone)}
+	default:
+		key := strings.TrimPrefix(f.name, "tag:")
+		for _, tag := range i.Tags {
+			if aws.StringValue(tag.Key) == key {
+				candidates = append(candidates, aws.StringValue(tag.Value))
+			}
+		}
+	}
+	for _, c := range candidates {
+		for _, v := range f.values {
+			if v.MatchString(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// requestIDs numbers the request IDs of errors.
+var requestIDs atomic.Int64
+
+// Error returns an AWS error with the given code and HTTP status code, as the
+// SDK returns for failed requests, for use with Provider.InjectError. Error
+// codes such as "SlowDown" or "ServiceUnavailable" are classified like those
+// of the real services:
+//
+//	p.InjectError("s3", "PutObject", fake.Error("SlowDown", 503), 2)
+func Error(code string, statusCode int) error {
+	return newError(code, statusCode, "injected error")
+}
+
+// newError returns an AWS error with the given code, HTTP status code and
+// message.
+func newError(code string, statusCode int, format string, args ...any) error {
+	requestID := fmt.Sprintf("00000000-0000-4000-8000-%012x", requestIDs.Add(1))
+	return awserr.NewRequestFailure(awserr.New(code, fmt.Sprintf(format, args...), nil), statusCode, requestID)
+}
+
+// canceled returns the error of the SDK for a request whose context is done.
+func canceled(err error) error {
+	return awserr.New(request.CanceledErrorCode, "request context canceled", err)
+}
+
+// Errors shared by several fakes.
+
+func invalidParameter(code, format string, args ...any) error {
+	return newError(code, http.StatusBadRequest, format, args...)
+}
+
+func missingParameter(name string) error {
+	return newError("MissingParameter", http.StatusBadRequest, "the request must contain the parameter %s", name)
+}
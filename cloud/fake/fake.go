@@ -0,0 +1,325 @@
+// Package fake provides an in-memory cloud provider for unit tests of code
+// built on c2loud.
+//
+// A Provider mirrors the services of the AWS provider, S3Service, SQSService,
+// SNSService, EC2Service, IAMService and AppConfigService, on top of fake SDK
+// clients that keep their state in memory, so tests need neither network
+// access nor credentials:
+//
+//	p := fake.NewProvider(nil)
+//	err := p.S3Service.CreateBucket(ctx, "artifacts")
+//
+// The fakes model what tests usually depend on: SQS visibility timeouts,
+// delays and redrive, SNS fan-out to SQS queues with filter policies, EC2
+// instance state transitions and S3 multipart uploads. They fail with the
+// error codes of AWS, which package cloud classifies as for the real
+// services, and further failures can be injected with InjectError.
+//
+// The fake clients implement the operations used by c2loud, in their
+// WithContext form, and a few closely related ones. Calling any other
+// operation panics.
+//
+// Importing the package registers cloud.FakeProvider with
+// cloud.NewCloudProvider; its configuration is a *Config.
+package fake
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/appconfig"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/ec2"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/iam"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/s3"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/sns"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws/sqs"
+)
+
+func init() {
+	cloud.Register(cloud.FakeProvider, newCloudProvider)
+}
+
+// Default configuration values.
+const (
+	DefaultRegion    = "us-east-1"
+	DefaultAccountID = "123456789012"
+)
+
+// Config defines the configuration of a fake Provider. The zero value is
+// ready to use.
+type Config struct {
+	// Region is the region of the fake resources. It defaults to
+	// DefaultRegion.
+	Region string
+	// AccountID is the account owning the fake resources, as it appears in
+	// ARNs and queue URLs. It defaults to DefaultAccountID.
+	AccountID string
+	// Now returns the current time. It defaults to time.Now. Provider.Advance
+	// moves the fake clock forward from it.
+	Now func() time.Time
+	// InstanceTransition is how long EC2 instances stay pending, stopping or
+	// shutting down before reaching their next state. Zero completes
+	// transitions on the next describe call.
+	InstanceTransition time.Duration
+}
+
+// Provider holds fake clients for the AWS services of c2loud, and the
+// services built on them.
+type Provider struct {
+	S3Service        *s3.S3Service
+	SQSService       *sqs.SQSService
+	SNSService       *sns.SNSService
+	IAMService       *iam.IAMService
+	EC2Service       *ec2.EC2Service
+	AppConfigService *appconfig.AppConfigService
+
+	// The fake clients of the services, for tests that seed or inspect
+	// their state.
+	S3        *S3
+	SQS       *SQS
+	SNS       *SNS
+	IAM       *IAM
+	EC2       *EC2
+	AppConfig *AppConfig
+
+	env *env
+}
+
+// NewProvider creates a Provider with no resources. A nil config uses the
+// defaults.
+func NewProvider(config *Config) *Provider {
+	if config == nil {
+		config = &Config{}
+	}
+	e := newEnv(config)
+
+	p := &Provider{
+		S3:        &S3{env: e},
+		SQS:       &SQS{env: e},
+		IAM:       &IAM{env: e},
+		EC2:       &EC2{env: e},
+		AppConfig: &AppConfig{env: e},
+		env:       e,
+	}
+	p.SNS = &SNS{env: e, sqs: p.SQS}
+
+	p.S3Service = &s3.S3Service{Client: p.S3}
+	p.SQSService = &sqs.SQSService{Client: p.SQS}
+	p.SNSService = &sns.SNSService{Client: p.SNS}
+	p.IAMService = &iam.IAMService{Client: p.IAM}
+	p.EC2Service = &ec2.EC2Service{Client: p.EC2}
+	p.AppConfigService = &appconfig.AppConfigService{Client: p.AppConfig}
+	return p
+}
+
+// AWS returns an AWSProvider sharing the services of p, for code written
+// against the AWS provider.
+func (p *Provider) AWS() *aws.AWSProvider {
+	return &aws.AWSProvider{
+		S3Service:        p.S3Service,
+		SQSService:       p.SQSService,
+		SNSService:       p.SNSService,
+		IAMService:       p.IAMService,
+		EC2Service:       p.EC2Service,
+		AppConfigService: p.AppConfigService,
+	}
+}
+
+// Now returns the current time of the fake clock.
+func (p *Provider) Now() time.Time {
+	return p.env.now()
+}
+
+// Advance moves the fake clock forward by d, expiring visibility timeouts and
+// completing instance transitions that are due.
+func (p *Provider) Advance(d time.Duration) {
+	p.env.advance(d)
+}
+
+// InjectError makes the next times calls to operation of service fail with
+// err, or every call when times is zero or negative. service is a key of
+// aws.AWSConfig.ServiceRetry, such as "s3", and operation the name of an API
+// operation, such as "PutObject"; an empty operation matches every operation
+// of service. Injected errors are returned as is: use Error to build AWS
+// errors. Errors are matched in the order they were injected.
+func (p *Provider) InjectError(service, operation string, err error, times int) {
+	p.env.inject(&fault{service: service, operation: operation, err: err, times: times})
+}
+
+// ClearErrors removes the errors injected with InjectError.
+func (p *Provider) ClearErrors() {
+	p.env.clearFaults()
+}
+
+// Close does nothing: the fake clients hold no resources.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// newCloudProvider is the cloud.Factory for the fake provider. It accepts a
+// *Config or nil.
+func newCloudProvider(ctx context.Context, config interface{}) (*cloud.CloudProvider, error) {
+	var cfg *Config
+	switch c := config.(type) {
+	case nil:
+	case *Config:
+		cfg = c
+	default:
+		return nil, fmt.Errorf("fake: expected *Config, got %T", config)
+	}
+
+	provider := NewProvider(cfg)
+	return &cloud.CloudProvider{
+		ObjectStore: s3.NewObjectStore(provider.S3Service),
+		Queue:       sqs.NewQueue(provider.SQSService),
+		Topic:       sns.NewTopic(provider.SNSService),
+		Compute:     ec2.NewCompute(provider.EC2Service),
+		Native:      provider,
+		Closer:      provider,
+	}, nil
+}
+
+// env is the state shared by the fake clients of a Provider: its identity,
+// clock, injected errors and ID sequence.
+type env struct {
+	region     string
+	account    string
+	clock      func() time.Time
+	transition time.Duration
+
+	mu      sync.Mutex
+	offset  time.Duration
+	faults  []*fault
+	seq     int64
+	changed chan struct{}
+}
+
+func newEnv(config *Config) *env {
+	e := &env{
+		region:     config.Region,
+		account:    config.AccountID,
+		clock:      config.Now,
+		transition: config.InstanceTransition,
+		changed:    make(chan struct{}),
+	}
+	if e.region == "" {
+		e.region = DefaultRegion
+	}
+	if e.account == "" {
+		e.account = DefaultAccountID
+	}
+	if e.clock == nil {
+		e.clock = time.Now
+	}
+	return e
+}
+
+func (e *env) now() time.Time {
+	e.mu.Lock()
+	offset := e.offset
+	e.mu.Unlock()
+	return e.clock().Add(offset)
+}
+
+func (e *env) advance(d time.Duration) {
+	e.mu.Lock()
+	e.offset += d
+	e.mu.Unlock()
+	e.notify()
+}
+
+// notify wakes the calls waiting for a change of state, such as SQS long
+// polls.
+func (e *env) notify() {
+	e.mu.Lock()
+	close(e.changed)
+	e.changed = make(chan struct{})
+	e.mu.Unlock()
+}
+
+// wait returns a channel closed on the next call to notify.
+func (e *env) wait() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.changed
+}
+
+// next returns the next number of the sequence used to build IDs.
+func (e *env) next() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.seq++
+	return e.seq
+}
+
+// arn returns the ARN of a resource of service in the fake region and account.
+func (e *env) arn(service, resource string) string {
+	return "arn:aws:" + service + ":" + e.region + ":" + e.account + ":" + resource
+}
+
+// fault is an error injected with Provider.InjectError.
+type fault struct {
+	service   string
+	operation string
+	err       error
+	times     int
+}
+
+func (e *env) inject(f *fault) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.faults = append(e.faults, f)
+}
+
+func (e *env) clearFaults() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.faults = nil
+}
+
+// call starts a call to operation of service. It fails if ctx is done or an
+// error was injected for the call.
+func (e *env) call(ctx context.Context, service, operation string) error {
+	if err := ctx.Err(); err != nil {
+		return canceled(err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, f := range e.faults {
+		if f.service != service || (f.operation != "" && f.operation != operation) {
+			continue
+		}
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				e.faults = append(e.faults[:i:i], e.faults[i+1:]...)
+			}
+		}
+		return f.err
+	}
+	return nil
+}
+
+// encodeToken and decodeToken convert the markers of listings to and from
+// opaque pagination tokens. decodeToken fails with code for invalid tokens.
+func encodeToken(marker string) *string {
+	token := base64.StdEncoding.EncodeToString([]byte(marker))
+	return &token
+}
+
+func decodeToken(token *string, code string) (string, error) {
+	if token == nil {
+		return "", nil
+	}
+	marker, err := base64.StdEncoding.DecodeString(*token)
+	if err != nil {
+		return "", invalidParameter(code, "invalid NextToken")
+	}
+	return string(marker), nil
+}
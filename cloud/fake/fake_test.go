@@ -0,0 +1,253 @@
+package fake_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newProvider(t *testing.T) (*cloud.CloudProvider, *fake.Provider) {
+	t.Helper()
+	provider, err := cloud.NewCloudProvider(context.Background(), cloud.FakeProvider, &fake.Config{
+		Now:                func() time.Time { return epoch },
+		InstanceTransition: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudProvider: %v", err)
+	}
+	return provider, provider.Native.(*fake.Provider)
+}
+
+func TestObjectStore(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newProvider(t)
+	store := provider.ObjectStore
+
+	if err := store.CreateBucket(ctx, "artifacts"); err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+	if err := store.CreateBucket(ctx, "artifacts"); !errors.Is(err, cloud.ErrAlreadyExists) {
+		t.Errorf("CreateBucket twice: got %v, want ErrAlreadyExists", err)
+	}
+	opts := &cloud.PutOptions{ContentType: "text/plain", Metadata: map[string]string{"build": "42"}}
+	if err := store.PutObject(ctx, "artifacts", "logs/build.txt", bytes.NewReader([]byte("ok")), opts); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	body, object, err := store.GetObject(ctx, "artifacts", "logs/build.txt")
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "ok" || object.ContentType != "text/plain" || object.Size != 2 {
+		t.Errorf("GetObject = %q, %+v", data, object)
+	}
+	if head, err := store.HeadObject(ctx, "artifacts", "logs/build.txt"); err != nil || len(head.Metadata) != 1 {
+		t.Errorf("HeadObject = %+v, %v; want the metadata of PutObject", head, err)
+	}
+
+	objects, err := store.ListObjects(ctx, "artifacts", "logs/")
+	if err != nil || len(objects) != 1 || objects[0].Key != "logs/build.txt" {
+		t.Errorf("ListObjects = %v, %v", objects, err)
+	}
+	if err := store.DeleteBucket(ctx, "artifacts"); !errors.Is(err, cloud.ErrConflict) {
+		t.Errorf("DeleteBucket of a non-empty bucket: got %v, want ErrConflict", err)
+	}
+	if _, _, err := store.GetObject(ctx, "artifacts", "missing"); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("GetObject of a missing key: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueueVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	provider, p := newProvider(t)
+	queue := provider.Queue
+
+	url, err := p.SQSService.CreateQueue(ctx, "jobs")
+	if err != nil {
+		t.Fatalf("CreateQueue: %v", err)
+	}
+	if _, err := queue.Send(ctx, url, &cloud.Message{Body: []byte("job-1")}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	opts := &cloud.ReceiveOptions{VisibilityTimeout: 30 * time.Second}
+	messages, err := queue.Receive(ctx, url, opts)
+	if err != nil || len(messages) != 1 || string(messages[0].Body) != "job-1" {
+		t.Fatalf("Receive = %v, %v", messages, err)
+	}
+	if again, _ := queue.Receive(ctx, url, opts); len(again) != 0 {
+		t.Errorf("Receive during the visibility timeout returned %d messages", len(again))
+	}
+
+	p.Advance(31 * time.Second)
+	again, err := queue.Receive(ctx, url, opts)
+	if err != nil || len(again) != 1 || again[0].ReceiveCount != 2 {
+		t.Fatalf("Receive after the visibility timeout = %v, %v", again, err)
+	}
+	if err := queue.Ack(ctx, url, messages[0].AckHandle); err == nil {
+		t.Error("Ack with a stale handle succeeded")
+	}
+	if err := queue.Ack(ctx, url, again[0].AckHandle); err != nil {
+		t.Errorf("Ack: %v", err)
+	}
+	if remaining := p.SQS.Messages(url); len(remaining) != 0 {
+		t.Errorf("messages left after Ack: %q", remaining)
+	}
+}
+
+func TestTopicFanOut(t *testing.T) {
+	ctx := context.Background()
+	provider, p := newProvider(t)
+	topic := provider.Topic
+
+	topicArn, err := topic.CreateTopic(ctx, "events")
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	subscribe := func(name string, attributes map[string]string) string {
+		url, err := p.SQSService.CreateQueue(ctx, name)
+		if err != nil {
+			t.Fatalf("CreateQueue: %v", err)
+		}
+		queueAttributes, err := p.SQSService.GetQueueAttributes(ctx, url)
+		if err != nil {
+			t.Fatalf("GetQueueAttributes: %v", err)
+		}
+		_, err = topic.Subscribe(ctx, topicArn, &cloud.SubscribeOptions{Protocol: "sqs", Endpoint: queueAttributes["QueueArn"], Attributes: attributes})
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		return url
+	}
+	all := subscribe("all", nil)
+	raw := subscribe("raw", map[string]string{"RawMessageDelivery": "true"})
+	orders := subscribe("orders", map[string]string{"FilterPolicy": `{"kind":["order"]}`})
+
+	if _, err := topic.Publish(ctx, topicArn, []byte("signup"), map[string]string{"kind": "user"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	delivered := p.SQS.Messages(all)
+	if len(delivered) != 1 {
+		t.Fatalf("queue all holds %d messages, want 1", len(delivered))
+	}
+	var notification struct{ Type, Message, TopicArn string }
+	if err := json.Unmarshal([]byte(delivered[0]), &notification); err != nil {
+		t.Fatalf("notification: %v", err)
+	}
+	if notification.Type != "Notification" || notification.Message != "signup" || notification.TopicArn != topicArn {
+		t.Errorf("notification = %+v", notification)
+	}
+	if got := p.SQS.Messages(raw); len(got) != 1 || got[0] != "signup" {
+		t.Errorf("raw delivery = %q, want [signup]", got)
+	}
+	if got := p.SQS.Messages(orders); len(got) != 0 {
+		t.Errorf("filtered queue holds %q, want nothing", got)
+	}
+	if got := p.SNS.Published(topicArn); len(got) != 1 {
+		t.Errorf("Published = %d messages, want 1", len(got))
+	}
+}
+
+func TestInstanceTransitions(t *testing.T) {
+	ctx := context.Background()
+	provider, p := newProvider(t)
+	compute := provider.Compute
+
+	instance, err := compute.CreateInstance(ctx, &cloud.InstanceSpec{Name: "web", Image: "ami-12345678", MachineType: "t3.micro"})
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	if instance.State != cloud.InstancePending || instance.Name != "web" {
+		t.Errorf("CreateInstance = %+v", instance)
+	}
+	if err := compute.StopInstance(ctx, instance.ID); !errors.Is(err, cloud.ErrConflict) {
+		t.Errorf("StopInstance of a pending instance: got %v, want ErrConflict", err)
+	}
+
+	state := func() cloud.InstanceState {
+		t.Helper()
+		got, err := compute.GetInstance(ctx, instance.ID)
+		if err != nil {
+			t.Fatalf("GetInstance: %v", err)
+		}
+		return got.State
+	}
+	p.Advance(time.Minute)
+	if got := state(); got != cloud.InstanceRunning {
+		t.Errorf("state after launch = %v, want running", got)
+	}
+	if err := compute.StopInstance(ctx, instance.ID); err != nil {
+		t.Fatalf("StopInstance: %v", err)
+	}
+	if got := state(); got != cloud.InstanceStopping {
+		t.Errorf("state after StopInstance = %v, want stopping", got)
+	}
+	p.Advance(time.Minute)
+	if got := state(); got != cloud.InstanceStopped {
+		t.Errorf("state after the transition = %v, want stopped", got)
+	}
+	if _, err := compute.GetInstance(ctx, "i-0123456789abcdef0"); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("GetInstance of a missing instance: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestInjectError(t *testing.T) {
+	ctx := context.Background()
+	_, p := newProvider(t)
+	p.InjectError("s3", "CreateBucket", fake.Error("SlowDown", 503), 1)
+
+	if err := p.S3Service.CreateBucket(ctx, "artifacts"); !errors.Is(err, cloud.ErrThrottled) {
+		t.Errorf("CreateBucket with an injected error: got %v, want ErrThrottled", err)
+	}
+	if err := p.S3Service.CreateBucket(ctx, "artifacts"); err != nil {
+		t.Errorf("CreateBucket after the injected error: %v", err)
+	}
+
+	p.InjectError("sqs", "", fake.Error("ServiceUnavailable", 503), 0)
+	for range 2 {
+		if _, err := p.SQSService.CreateQueue(ctx, "jobs"); err == nil {
+			t.Error("CreateQueue succeeded with an injected error")
+		}
+	}
+	p.ClearErrors()
+	if _, err := p.SQSService.CreateQueue(ctx, "jobs"); err != nil {
+		t.Errorf("CreateQueue after ClearErrors: %v", err)
+	}
+}
+
+func TestIAMDeleteConflict(t *testing.T) {
+	ctx := context.Background()
+	_, p := newProvider(t)
+
+	if _, err := p.IAMService.CreateUser(ctx, "deploy"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	key, err := p.IAMService.CreateAccessKey(ctx, "deploy")
+	if err != nil {
+		t.Fatalf("CreateAccessKey: %v", err)
+	}
+	if err := p.IAMService.DeleteUser(ctx, "deploy"); !errors.Is(err, cloud.ErrConflict) {
+		t.Errorf("DeleteUser with an access key: got %v, want ErrConflict", err)
+	}
+	if err := p.IAMService.DeleteAccessKey(ctx, "deploy", *key.AccessKeyId); err != nil {
+		t.Fatalf("DeleteAccessKey: %v", err)
+	}
+	if err := p.IAMService.DeleteUser(ctx, "deploy"); err != nil {
+		t.Errorf("DeleteUser: %v", err)
+	}
+	if err := p.IAMService.DeleteUser(ctx, "deploy"); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("DeleteUser of a deleted user: got %v, want ErrNotFound", err)
+	}
+}
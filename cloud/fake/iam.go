@@ -0,0 +1,341 @@
+package fake
+
+import (
+	"crypto/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+const (
+	// maxAccessKeys is the number of access keys a user may have.
+	maxAccessKeys = 2
+	// defaultMaxItems is the page size of IAM listings.
+	defaultMaxItems = 100
+)
+
+var (
+	// userName matches valid user names.
+	userName = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+	// iamPath matches valid paths.
+	iamPath = regexp.MustCompile(`^/([\x21-\x7e]*/)?$`)
+)
+
+// IAM is an in-memory iamiface.IAMAPI for users, their managed policies and
+// their access keys. Policies are not evaluated.
+type IAM struct {
+	iamiface.IAMAPI
+	env *env
+
+	mu    sync.Mutex
+	users map[string]*user
+}
+
+type user struct {
+	*iam.User
+	policies []string
+	keys     []*iam.AccessKey
+}
+
+func noSuchEntity(format string, args ...any) error {
+	return newError(iam.ErrCodeNoSuchEntityException, http.StatusNotFound, format, args...)
+}
+
+func (c *IAM) user(name *string) (*user, error) {
+	u, ok := c.users[aws.StringValue(name)]
+	if !ok {
+		return nil, noSuchEntity("the user with name %s cannot be found", aws.StringValue(name))
+	}
+	return u, nil
+}
+
+// CreateUserWithContext creates a user.
+func (c *IAM) CreateUserWithContext(ctx aws.Context, input *iam.CreateUserInput, opts ...request.Option) (*iam.CreateUserOutput, error) {
+	if err := c.env.call(ctx, "iam", "CreateUser"); err != nil {
+		return nil, err
+	}
+	name := aws.StringValue(input.UserName)
+	if !userName.MatchString(name) {
+		return nil, invalidParameter("ValidationError", "invalid user name: %q", name)
+	}
+	path := aws.StringValue(input.Path)
+	if path == "" {
+		path = "/"
+	}
+	if !iamPath.MatchString(path) {
+		return nil, invalidParameter("ValidationError", "invalid path: %q", path)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.users[name]; ok {
+		return nil, newError(iam.ErrCodeEntityAlreadyExistsException, http.StatusConflict, "user with name %s already exists", name)
+	}
+	u := &user{User: &iam.User{
+		UserName:   aws.String(name),
+		UserId:     aws.String("AIDA" + randomID(17)),
+		Path:       aws.String(path),
+		Arn:        aws.String("arn:aws:iam::" + c.env.account + ":user" + path + name),
+		CreateDate: aws.Time(c.env.now().UTC().Truncate(time.Second)),
+		Tags:       input.Tags,
+	}}
+	if c.users == nil {
+		c.users = make(map[string]*user)
+	}
+	c.users[name] = u
+	return &iam.CreateUserOutput{User: awsutil.CopyOf(u.User).(*iam.User)}, nil
+}
+
+// GetUserWithContext returns a user.
+func (c *IAM) GetUserWithContext(ctx aws.Context, input *iam.GetUserInput, opts ...request.Option) (*iam.GetUserOutput, error) {
+	if err := c.env.call(ctx, "iam", "GetUser"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	return &iam.GetUserOutput{User: awsutil.CopyOf(u.User).(*iam.User)}, nil
+}
+
+// DeleteUserWithContext deletes a user. As with IAM, it fails while the user
+// has access keys or attached policies.
+func (c *IAM) DeleteUserWithContext(ctx aws.Context, input *iam.DeleteUserInput, opts ...request.Option) (*iam.DeleteUserOutput, error) {
+	if err := c.env.call(ctx, "iam", "DeleteUser"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.keys) > 0 || len(u.policies) > 0 {
+		return nil, newError(iam.ErrCodeDeleteConflictException, http.StatusConflict, "cannot delete entity, must remove access keys and detach policies first")
+	}
+	delete(c.users, aws.StringValue(input.UserName))
+	return &iam.DeleteUserOutput{}, nil
+}
+
+// ListUsersWithContext lists the users under input.PathPrefix in name order.
+func (c *IAM) ListUsersWithContext(ctx aws.Context, input *iam.ListUsersInput, opts ...request.Option) (*iam.ListUsersOutput, error) {
+	if err := c.env.call(ctx, "iam", "ListUsers"); err != nil {
+		return nil, err
+	}
+	maxItems, err := iamMaxItems(input.MaxItems)
+	if err != nil {
+		return nil, err
+	}
+	marker, err := decodeToken(input.Marker, "ValidationError")
+	if err != nil {
+		return nil, err
+	}
+	prefix := aws.StringValue(input.PathPrefix)
+	if prefix == "" {
+		prefix = "/"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := &iam.ListUsersOutput{IsTruncated: aws.Bool(false)}
+	for _, name := range sortedKeys(c.users) {
+		u := c.users[name]
+		if name <= marker || !strings.HasPrefix(aws.StringValue(u.Path), prefix) {
+			continue
+		}
+		if len(out.Users) == maxItems {
+			out.IsTruncated = aws.Bool(true)
+			out.Marker = encodeToken(aws.StringValue(out.Users[maxItems-1].UserName))
+			break
+		}
+		out.Users = append(out.Users, awsutil.CopyOf(u.User).(*iam.User))
+	}
+	return out, nil
+}
+
+// ListUsersPagesWithContext calls fn with each page of the listing.
+func (c *IAM) ListUsersPagesWithContext(ctx aws.Context, input *iam.ListUsersInput, fn func(*iam.ListUsersOutput, bool) bool, opts ...request.Option) error {
+	page := *input
+	for {
+		out, err := c.ListUsersWithContext(ctx, &page, opts...)
+		if err != nil {
+			return err
+		}
+		lastPage := !aws.BoolValue(out.IsTruncated)
+		if !fn(out, lastPage) || lastPage {
+			return nil
+		}
+		page.Marker = out.Marker
+	}
+}
+
+// AttachUserPolicyWithContext attaches a managed policy to a user. Attaching
+// an attached policy does nothing.
+func (c *IAM) AttachUserPolicyWithContext(ctx aws.Context, input *iam.AttachUserPolicyInput, opts ...request.Option) (*iam.AttachUserPolicyOutput, error) {
+	if err := c.env.call(ctx, "iam", "AttachUserPolicy"); err != nil {
+		return nil, err
+	}
+	arn := aws.StringValue(input.PolicyArn)
+	if !strings.HasPrefix(arn, "arn:aws:iam::") || !strings.Contains(arn, ":policy/") {
+		return nil, invalidParameter(iam.ErrCodeInvalidInputException, "ARN %s is not valid", arn)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range u.policies {
+		if p == arn {
+			return &iam.AttachUserPolicyOutput{}, nil
+		}
+	}
+	u.policies = append(u.policies, arn)
+	return &iam.AttachUserPolicyOutput{}, nil
+}
+
+// DetachUserPolicyWithContext detaches a managed policy from a user.
+func (c *IAM) DetachUserPolicyWithContext(ctx aws.Context, input *iam.DetachUserPolicyInput, opts ...request.Option) (*iam.DetachUserPolicyOutput, error) {
+	if err := c.env.call(ctx, "iam", "DetachUserPolicy"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range u.policies {
+		if p == aws.StringValue(input.PolicyArn) {
+			u.policies = append(u.policies[:i], u.policies[i+1:]...)
+			return &iam.DetachUserPolicyOutput{}, nil
+		}
+	}
+	return nil, noSuchEntity("policy %s was not found", aws.StringValue(input.PolicyArn))
+}
+
+// ListAttachedUserPoliciesWithContext lists the managed policies attached to
+// a user, in attachment order. Results are not paged.
+func (c *IAM) ListAttachedUserPoliciesWithContext(ctx aws.Context, input *iam.ListAttachedUserPoliciesInput, opts ...request.Option) (*iam.ListAttachedUserPoliciesOutput, error) {
+	if err := c.env.call(ctx, "iam", "ListAttachedUserPolicies"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	out := &iam.ListAttachedUserPoliciesOutput{IsTruncated: aws.Bool(false)}
+	for _, arn := range u.policies {
+		out.AttachedPolicies = append(out.AttachedPolicies, &iam.AttachedPolicy{
+			PolicyArn:  aws.String(arn),
+			PolicyName: aws.String(arn[strings.LastIndex(arn, "/")+1:]),
+		})
+	}
+	return out, nil
+}
+
+// CreateAccessKeyWithContext creates an access key for a user. As with IAM,
+// a user has at most two access keys.
+func (c *IAM) CreateAccessKeyWithContext(ctx aws.Context, input *iam.CreateAccessKeyInput, opts ...request.Option) (*iam.CreateAccessKeyOutput, error) {
+	if err := c.env.call(ctx, "iam", "CreateAccessKey"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.keys) == maxAccessKeys {
+		return nil, newError(iam.ErrCodeLimitExceededException, http.StatusConflict, "cannot exceed quota for AccessKeysPerUser: %d", maxAccessKeys)
+	}
+	key := &iam.AccessKey{
+		UserName:        u.UserName,
+		AccessKeyId:     aws.String("AKIA" + randomID(16)),
+		SecretAccessKey: aws.String(rand.Text() + rand.Text()[:14]),
+		Status:          aws.String(iam.StatusTypeActive),
+		CreateDate:      aws.Time(c.env.now().UTC().Truncate(time.Second)),
+	}
+	u.keys = append(u.keys, key)
+	return &iam.CreateAccessKeyOutput{AccessKey: awsutil.CopyOf(key).(*iam.AccessKey)}, nil
+}
+
+// DeleteAccessKeyWithContext deletes an access key of a user.
+func (c *IAM) DeleteAccessKeyWithContext(ctx aws.Context, input *iam.DeleteAccessKeyInput, opts ...request.Option) (*iam.DeleteAccessKeyOutput, error) {
+	if err := c.env.call(ctx, "iam", "DeleteAccessKey"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range u.keys {
+		if aws.StringValue(key.AccessKeyId) == aws.StringValue(input.AccessKeyId) {
+			u.keys = append(u.keys[:i], u.keys[i+1:]...)
+			return &iam.DeleteAccessKeyOutput{}, nil
+		}
+	}
+	return nil, noSuchEntity("the access key with id %s cannot be found", aws.StringValue(input.AccessKeyId))
+}
+
+// ListAccessKeysWithContext lists the access keys of a user, without their
+// secrets. Results are not paged.
+func (c *IAM) ListAccessKeysWithContext(ctx aws.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	if err := c.env.call(ctx, "iam", "ListAccessKeys"); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.user(input.UserName)
+	if err != nil {
+		return nil, err
+	}
+	out := &iam.ListAccessKeysOutput{IsTruncated: aws.Bool(false)}
+	for _, key := range u.keys {
+		out.AccessKeyMetadata = append(out.AccessKeyMetadata, &iam.AccessKeyMetadata{
+			UserName:    key.UserName,
+			AccessKeyId: key.AccessKeyId,
+			Status:      key.Status,
+			CreateDate:  key.CreateDate,
+		})
+	}
+	return out, nil
+}
+
+func iamMaxItems(maxItems *int64) (int, error) {
+	if maxItems == nil {
+		return defaultMaxItems, nil
+	}
+	n := aws.Int64Value(maxItems)
+	if n < 1 || n > 1000 {
+		return 0, invalidParameter("ValidationError", "MaxItems must be between 1 and 1000")
+	}
+	return int(n), nil
+}
+
+// randomID returns n random characters of the alphabet of IAM unique IDs.
+func randomID(n int) string {
+	return rand.Text()[:n]
+}
@@ -0,0 +1,595 @@
+package fake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// pendingConfirmation is the subscription ARN returned for subscriptions that
+// must be confirmed by their endpoint.
+const pendingConfirmation = "pending confirmation"
+
+const listTopicsPageSize = 100
+
+// topicName matches valid standard topic names.
+var topicName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// subscriptionProtocols are the protocols accepted by Subscribe, and whether
+// their subscriptions are confirmed on creation.
+var subscriptionProtocols = map[string]bool{
+	"sqs": true, "lambda": true, "firehose": true, "application": true, "sms": true,
+	"http": false, "https": false, "email": false, "email-json": false,
+}
+
+// SNS is an in-memory snsiface.SNSAPI for standard topics. Messages published
+// to a topic are delivered to its SQS subscriptions, subject to their filter
+// policies, and recorded for the other protocols; see Published.
+type SNS struct {
+	snsiface.SNSAPI
+	env *env
+	sqs *SQS
+
+	mu            sync.Mutex
+	topics        map[string]*topic
+	subscriptions map[string]*subscription
+}
+
+type topic struct {
+	attributes map[string]string
+	published  []*sns.PublishInput
+}
+
+type subscription struct {
+	topic      string
+	protocol   string
+	endpoint   string
+	attributes map[string]string
+	filter     filterPolicy
+	confirmed  bool
+}
+
+// Published returns the messages published to the topic with the given ARN,
+// in order.
+func (s *SNS) Published(topicArn string) []*sns.PublishInput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.topics[topicArn]
+	if !ok {
+		return nil
+	}
+	return append([]*sns.PublishInput(nil), t.published...)
+}
+
+func notFound(format string, args ...any) error {
+	return newError(sns.ErrCodeNotFoundException, http.StatusNotFound, format, args...)
+}
+
+// CreateTopicWithContext creates a topic. Creating an existing topic returns
+// its ARN.
+func (s *SNS) CreateTopicWithContext(ctx aws.Context, input *sns.CreateTopicInput, opts ...request.Option) (*sns.CreateTopicOutput, error) {
+	if err := s.env.call(ctx, "sns", "CreateTopic"); err != nil {
+		return nil, err
+	}
+	name := aws.StringValue(input.Name)
+	if strings.HasSuffix(name, ".fifo") {
+		return nil, invalidParameter(sns.ErrCodeInvalidParameterException, "FIFO topics are not supported by the fake")
+	}
+	if !topicName.MatchString(name) {
+		return nil, invalidParameter(sns.ErrCodeInvalidParameterException, "invalid parameter: Topic Name")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arn := s.env.arn("sns", name)
+	if _, ok := s.topics[arn]; ok {
+		return &sns.CreateTopicOutput{TopicArn: aws.String(arn)}, nil
+	}
+	if s.topics == nil {
+		s.topics = make(map[string]*topic)
+	}
+	t := &topic{attributes: map[string]string{"DisplayName": ""}}
+	for k, v := range aws.StringValueMap(input.Attributes) {
+		t.attributes[k] = v
+	}
+	s.topics[arn] = t
+	return &sns.CreateTopicOutput{TopicArn: aws.String(arn)}, nil
+}
+
+// DeleteTopicWithContext deletes a topic and its subscriptions. Deleting a
+// missing topic succeeds.
+func (s *SNS) DeleteTopicWithContext(ctx aws.Context, input *sns.DeleteTopicInput, opts ...request.Option) (*sns.DeleteTopicOutput, error) {
+	if err := s.env.call(ctx, "sns", "DeleteTopic"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arn := aws.StringValue(input.TopicArn)
+	delete(s.topics, arn)
+	for subArn, sub := range s.subscriptions {
+		if sub.topic == arn {
+			delete(s.subscriptions, subArn)
+		}
+	}
+	return &sns.DeleteTopicOutput{}, nil
+}
+
+// GetTopicAttributesWithContext returns the attributes of a topic, including
+// its subscription counts.
+func (s *SNS) GetTopicAttributesWithContext(ctx aws.Context, input *sns.GetTopicAttributesInput, opts ...request.Option) (*sns.GetTopicAttributesOutput, error) {
+	if err := s.env.call(ctx, "sns", "GetTopicAttributes"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arn := aws.StringValue(input.TopicArn)
+	t, ok := s.topics[arn]
+	if !ok {
+		return nil, notFound("topic does not exist")
+	}
+	var confirmed, pending int
+	for _, sub := range s.subscriptions {
+		if sub.topic != arn {
+			continue
+		}
+		if sub.confirmed {
+			confirmed++
+		} else {
+			pending++
+		}
+	}
+
+	attributes := map[string]*string{
+		"TopicArn":               aws.String(arn),
+		"Owner":                  aws.String(s.env.account),
+		"SubscriptionsConfirmed": aws.String(strconv.Itoa(confirmed)),
+		"SubscriptionsPending":   aws.String(strconv.Itoa(pending)),
+		"SubscriptionsDeleted":   aws.String("0"),
+	}
+	for k, v := range t.attributes {
+		attributes[k] = aws.String(v)
+	}
+	return &sns.GetTopicAttributesOutput{Attributes: attributes}, nil
+}
+
+// ListTopicsWithContext lists topics in ARN order, 100 per page.
+func (s *SNS) ListTopicsWithContext(ctx aws.Context, input *sns.ListTopicsInput, opts ...request.Option) (*sns.ListTopicsOutput, error) {
+	if err := s.env.call(ctx, "sns", "ListTopics"); err != nil {
+		return nil, err
+	}
+	marker, err := decodeToken(input.NextToken, sns.ErrCodeInvalidParameterException)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := &sns.ListTopicsOutput{}
+	for _, arn := range sortedKeys(s.topics) {
+		if arn <= marker {
+			continue
+		}
+		if len(out.Topics) == listTopicsPageSize {
+			out.NextToken = encodeToken(aws.StringValue(out.Topics[len(out.Topics)-1].TopicArn))
+			break
+		}
+		out.Topics = append(out.Topics, &sns.Topic{TopicArn: aws.String(arn)})
+	}
+	return out, nil
+}
+
+// ListTopicsPagesWithContext calls fn with each page of the listing.
+func (s *SNS) ListTopicsPagesWithContext(ctx aws.Context, input *sns.ListTopicsInput, fn func(*sns.ListTopicsOutput, bool) bool, opts ...request.Option) error {
+	page := *input
+	for {
+		out, err := s.ListTopicsWithContext(ctx, &page, opts...)
+		if err != nil {
+			return err
+		}
+		lastPage := out.NextToken == nil
+		if !fn(out, lastPage) || lastPage {
+			return nil
+		}
+		page.NextToken = out.NextToken
+	}
+}
+
+// SubscribeWithContext subscribes an endpoint to a topic. Subscriptions of
+// SQS queues and other AWS endpoints are confirmed at once; HTTP and email
+// subscriptions stay pending. Subscribing the same endpoint twice returns the
+// existing subscription.
+func (s *SNS) SubscribeWithContext(ctx aws.Context, input *sns.SubscribeInput, opts ...request.Option) (*sns.SubscribeOutput, error) {
+	if err := s.env.call(ctx, "sns", "Subscribe"); err != nil {
+		return nil, err
+	}
+	protocol := aws.StringValue(input.Protocol)
+	confirmed, ok := subscriptionProtocols[protocol]
+	if !ok {
+		return nil, invalidParameter(sns.ErrCodeInvalidParameterException, "invalid parameter: Amazon SNS does not support this protocol string: %s", protocol)
+	}
+	if input.Endpoint == nil {
+		return nil, invalidParameter(sns.ErrCodeInvalidParameterException, "invalid parameter: Endpoint")
+	}
+	attributes := aws.StringValueMap(input.Attributes)
+	filter, err := parseFilterPolicy(attributes["FilterPolicy"])
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	topicArn := aws.StringValue(input.TopicArn)
+	if _, ok := s.topics[topicArn]; !ok {
+		return nil, notFound("topic does not exist")
+	}
+	for arn, sub := range s.subscriptions {
+		if sub.topic == topicArn && sub.protocol == protocol && sub.endpoint == aws.StringValue(input.Endpoint) {
+			return &sns.SubscribeOutput{SubscriptionArn: aws.String(subscriptionArn(arn, sub, input.ReturnSubscriptionArn))}, nil
+		}
+	}
+
+	arn := fmt.Sprintf("%s:%08x-0000-4000-8000-000000000000", topicArn, s.env.next())
+	sub := &subscription{
+		topic:      topicArn,
+		protocol:   protocol,
+		endpoint:   aws.StringValue(input.Endpoint),
+		attributes: attributes,
+		filter:     filter,
+		confirmed:  confirmed,
+	}
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[string]*subscription)
+	}
+	s.subscriptions[arn] = sub
+	return &sns.SubscribeOutput{SubscriptionArn: aws.String(subscriptionArn(arn, sub, input.ReturnSubscriptionArn))}, nil
+}
+
+// subscriptionArn returns the ARN Subscribe returns for sub: "pending
+// confirmation" until it is confirmed, unless returnArn is set.
+func subscriptionArn(arn string, sub *subscription, returnArn *bool) string {
+	if !sub.confirmed && !aws.BoolValue(returnArn) {
+		return pendingConfirmation
+	}
+	return arn
+}
+
+// Confirm confirms the pending subscription of endpoint to the
+// topic, as the endpoint would by visiting the confirmation URL.
+func (s *SNS) Confirm(topicArn, endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subscriptions {
+		if sub.topic == topicArn && sub.endpoint == endpoint {
+			sub.confirmed = true
+			return nil
+		}
+	}
+	return notFound("subscription does not exist")
+}
+
+// UnsubscribeWithContext deletes a subscription.
+func (s *SNS) UnsubscribeWithContext(ctx aws.Context, input *sns.UnsubscribeInput, opts ...request.Option) (*sns.UnsubscribeOutput, error) {
+	if err := s.env.call(ctx, "sns", "Unsubscribe"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arn := aws.StringValue(input.SubscriptionArn)
+	if _, ok := s.subscriptions[arn]; !ok {
+		return nil, notFound("subscription does not exist")
+	}
+	delete(s.subscriptions, arn)
+	return &sns.UnsubscribeOutput{}, nil
+}
+
+// ListSubscriptionsByTopicWithContext lists the subscriptions of a topic.
+func (s *SNS) ListSubscriptionsByTopicWithContext(ctx aws.Context, input *sns.ListSubscriptionsByTopicInput, opts ...request.Option) (*sns.ListSubscriptionsByTopicOutput, error) {
+	if err := s.env.call(ctx, "sns", "ListSubscriptionsByTopic"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	topicArn := aws.StringValue(input.TopicArn)
+	if _, ok := s.topics[topicArn]; !ok {
+		return nil, notFound("topic does not exist")
+	}
+	out := &sns.ListSubscriptionsByTopicOutput{}
+	for _, arn := range sortedKeys(s.subscriptions) {
+		sub := s.subscriptions[arn]
+		if sub.topic != topicArn {
+			continue
+		}
+		if !sub.confirmed {
+			arn = "PendingConfirmation"
+		}
+		out.Subscriptions = append(out.Subscriptions, &sns.Subscription{
+			SubscriptionArn: aws.String(arn),
+			TopicArn:        aws.String(sub.topic),
+			Protocol:        aws.String(sub.protocol),
+			Endpoint:        aws.String(sub.endpoint),
+			Owner:           aws.String(s.env.account),
+		})
+	}
+	return out, nil
+}
+
+// GetSubscriptionAttributesWithContext returns the attributes of a
+// subscription.
+func (s *SNS) GetSubscriptionAttributesWithContext(ctx aws.Context, input *sns.GetSubscriptionAttributesInput, opts ...request.Option) (*sns.GetSubscriptionAttributesOutput, error) {
+	if err := s.env.call(ctx, "sns", "GetSubscriptionAttributes"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arn := aws.StringValue(input.SubscriptionArn)
+	sub, ok := s.subscriptions[arn]
+	if !ok {
+		return nil, notFound("subscription does not exist")
+	}
+	attributes := map[string]*string{
+		"SubscriptionArn":     aws.String(arn),
+		"TopicArn":            aws.String(sub.topic),
+		"Protocol":            aws.String(sub.protocol),
+		"Endpoint":            aws.String(sub.endpoint),
+		"Owner":               aws.String(s.env.account),
+		"PendingConfirmation": aws.String(strconv.FormatBool(!sub.confirmed)),
+	}
+	for k, v := range sub.attributes {
+		attributes[k] = aws.String(v)
+	}
+	return &sns.GetSubscriptionAttributesOutput{Attributes: attributes}, nil
+}
+
+// SetSubscriptionAttributesWithContext sets an attribute of a subscription,
+// such as FilterPolicy or RawMessageDelivery.
+func (s *SNS) SetSubscriptionAttributesWithContext(ctx aws.Context, input *sns.SetSubscriptionAttributesInput, opts ...request.Option) (*sns.SetSubscriptionAttributesOutput, error) {
+	if err := s.env.call(ctx, "sns", "SetSubscriptionAttributes"); err != nil {
+		return nil, err
+	}
+	name, value := aws.StringValue(input.AttributeName), aws.StringValue(input.AttributeValue)
+	var filter filterPolicy
+	if name == "FilterPolicy" {
+		var err error
+		if filter, err = parseFilterPolicy(value); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[aws.StringValue(input.SubscriptionArn)]
+	if !ok {
+		return nil, notFound("subscription does not exist")
+	}
+	if sub.attributes == nil {
+		sub.attributes = make(map[string]string)
+	}
+	sub.attributes[name] = value
+	if name == "FilterPolicy" {
+		sub.filter = filter
+	}
+	return &sns.SetSubscriptionAttributesOutput{}, nil
+}
+
+// PublishWithContext publishes a message to a topic and delivers it to the
+// topic's confirmed SQS subscriptions whose filter policy matches its
+// attributes: as an SNS notification, or as is with RawMessageDelivery.
+// Deliveries to missing queues are dropped, as SNS does.
+func (s *SNS) PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	if err := s.env.call(ctx, "sns", "Publish"); err != nil {
+		return nil, err
+	}
+	if input.Message == nil || *input.Message == "" {
+		return nil, invalidParameter(sns.ErrCodeInvalidParameterException, "invalid parameter: Empty message")
+	}
+	topicArn := aws.StringValue(input.TopicArn)
+	if topicArn == "" {
+		topicArn = aws.StringValue(input.TargetArn)
+	}
+
+	s.mu.Lock()
+	t, ok := s.topics[topicArn]
+	if !ok {
+		s.mu.Unlock()
+		return nil, notFound("topic does not exist")
+	}
+	t.published = append(t.published, input)
+	id := fmt.Sprintf("00000000-0000-4000-8000-%012x", s.env.next())
+
+	type delivery struct {
+		queueArn string
+		raw      bool
+	}
+	var deliveries []delivery
+	for _, arn := range sortedKeys(s.subscriptions) {
+		sub := s.subscriptions[arn]
+		if sub.topic != topicArn || sub.protocol != "sqs" || !sub.confirmed || !sub.filter.matches(input.MessageAttributes) {
+			continue
+		}
+		deliveries = append(deliveries, delivery{queueArn: sub.endpoint, raw: sub.attributes["RawMessageDelivery"] == "true"})
+	}
+	s.mu.Unlock()
+
+	notification, attributes := s.notification(id, topicArn, input), toSQSAttributes(input.MessageAttributes)
+	s.sqs.mu.Lock()
+	for _, d := range deliveries {
+		q := s.sqs.queueByARN(d.queueArn)
+		if q == nil {
+			continue
+		}
+		if d.raw {
+			s.sqs.enqueue(q, aws.StringValue(input.Message), attributes, 0)
+		} else {
+			s.sqs.enqueue(q, notification, nil, 0)
+		}
+	}
+	s.sqs.mu.Unlock()
+	s.env.notify()
+
+	return &sns.PublishOutput{MessageId: aws.String(id)}, nil
+}
+
+// notification returns the JSON document SNS delivers for a published
+// message. Its signature is not valid.
+func (s *SNS) notification(id, topicArn string, input *sns.PublishInput) string {
+	type attribute struct {
+		Type  string
+		Value string
+	}
+	n := struct {
+		Type              string
+		MessageId         string
+		TopicArn          string
+		Subject           string `json:",omitempty"`
+		Message           string
+		Timestamp         string
+		SignatureVersion  string
+		Signature         string
+		SigningCertURL    string
+		UnsubscribeURL    string
+		MessageAttributes map[string]attribute `json:",omitempty"`
+	}{
+		Type:             "Notification",
+		MessageId:        id,
+		TopicArn:         topicArn,
+		Subject:          aws.StringValue(input.Subject),
+		Message:          aws.StringValue(input.Message),
+		Timestamp:        s.env.now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		SignatureVersion: "1",
+		Signature:        base64.StdEncoding.EncodeToString([]byte("fake")),
+		SigningCertURL:   fmt.Sprintf("https://sns.%s.amazonaws.com/SimpleNotificationService-fake.pem", s.env.region),
+		UnsubscribeURL:   fmt.Sprintf("https://sns.%s.amazonaws.com/?Action=Unsubscribe&TopicArn=%s", s.env.region, topicArn),
+	}
+	for k, v := range input.MessageAttributes {
+		if n.MessageAttributes == nil {
+			n.MessageAttributes = make(map[string]attribute)
+		}
+		value := aws.StringValue(v.StringValue)
+		if v.BinaryValue != nil {
+			value = base64.StdEncoding.EncodeToString(v.BinaryValue)
+		}
+		n.MessageAttributes[k] = attribute{Type: aws.StringValue(v.DataType), Value: value}
+	}
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+// toSQSAttributes converts SNS message attributes to the SQS message
+// attributes of a raw delivery.
+func toSQSAttributes(attributes map[string]*sns.MessageAttributeValue) map[string]*sqs.MessageAttributeValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+	out := make(map[string]*sqs.MessageAttributeValue, len(attributes))
+	for k, v := range attributes {
+		out[k] = &sqs.MessageAttributeValue{DataType: v.DataType, StringValue: v.StringValue, BinaryValue: v.BinaryValue}
+	}
+	return out
+}
+
+// filterPolicy is a parsed subscription filter policy: for each message
+// attribute, the conditions of which one must match. The fake supports exact
+// string and numeric values and the "prefix", "exists" and "anything-but"
+// operators.
+type filterPolicy map[string][]any
+
+func parseFilterPolicy(policy string) (filterPolicy, error) {
+	if policy == "" {
+		return nil, nil
+	}
+	invalid := invalidParameter(sns.ErrCodeInvalidParameterException, "invalid parameter: FilterPolicy")
+	var parsed map[string][]any
+	if err := json.Unmarshal([]byte(policy), &parsed); err != nil {
+		return nil, invalid
+	}
+	for _, conditions := range parsed {
+		for _, c := range conditions {
+			switch c := c.(type) {
+			case string, float64:
+			case map[string]any:
+				if len(c) != 1 {
+					return nil, invalid
+				}
+				for op := range c {
+					if op != "prefix" && op != "exists" && op != "anything-but" {
+						return nil, invalid
+					}
+				}
+			default:
+				return nil, invalid
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// matches reports whether attributes satisfy the policy. A nil policy matches
+// every message.
+func (p filterPolicy) matches(attributes map[string]*sns.MessageAttributeValue) bool {
+	for name, conditions := range p {
+		attr, ok := attributes[name]
+		matched := false
+		for _, c := range conditions {
+			if matchCondition(c, attr, ok) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func matchCondition(condition any, attr *sns.MessageAttributeValue, exists bool) bool {
+	value := ""
+	if exists {
+		value = aws.StringValue(attr.StringValue)
+	}
+	switch c := condition.(type) {
+	case string:
+		return exists && value == c
+	case float64:
+		n, err := strconv.ParseFloat(value, 64)
+		return exists && err == nil && n == c
+	case map[string]any:
+		for op, arg := range c {
+			switch op {
+			case "exists":
+				return exists == (arg == true)
+			case "prefix":
+				prefix, _ := arg.(string)
+				return exists && strings.HasPrefix(value, prefix)
+			case "anything-but":
+				excluded, ok := arg.([]any)
+				if !ok {
+					excluded = []any{arg}
+				}
+				for _, e := range excluded {
+					if matchCondition(e, attr, exists) {
+						return false
+					}
+				}
+				return exists
+			}
+		}
+	}
+	return false
+}
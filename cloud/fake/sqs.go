@@ -0,0 +1,665 @@
+package fake
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// SQS limits and defaults.
+const (
+	maxReceiveMessages    = 10
+	maxWaitTimeSeconds    = 20
+	maxVisibilityTimeout  = 12 * 60 * 60
+	maxListQueuesPageSize = 1000
+)
+
+// queueName matches valid standard queue names.
+var queueName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,80}$`)
+
+// queueDefaults are the attributes of new queues.
+var queueDefaults = map[string]string{
+	sqs.QueueAttributeNameDelaySeconds:                  "0",
+	sqs.QueueAttributeNameMaximumMessageSize:            "262144",
+	sqs.QueueAttributeNameMessageRetentionPeriod:        "345600",
+	sqs.QueueAttributeNameReceiveMessageWaitTimeSeconds: "0",
+	sqs.QueueAttributeNameVisibilityTimeout:             "30",
+}
+
+// queueSettable are the attributes that can be set on a queue, beyond those
+// of queueDefaults.
+var queueSettable = map[string]bool{
+	sqs.QueueAttributeNamePolicy:         true,
+	sqs.QueueAttributeNameRedrivePolicy:  true,
+	sqs.QueueAttributeNameKmsMasterKeyId: true,
+}
+
+// SQS is an in-memory sqsiface.SQSAPI for standard queues. Messages are
+// delivered in the order they were sent.
+type SQS struct {
+	sqsiface.SQSAPI
+	env *env
+
+	mu     sync.Mutex
+	queues map[string]*queue
+}
+
+type queue struct {
+	name       string
+	url        string
+	arn        string
+	attributes map[string]string
+	created    time.Time
+	modified   time.Time
+	messages   []*message
+}
+
+type message struct {
+	id           string
+	body         string
+	attributes   map[string]*sqs.MessageAttributeValue
+	sent         time.Time
+	visibleAt    time.Time
+	receiveCount int
+	firstReceive time.Time
+	// receipt is the receipt handle of the last receive, empty until the
+	// message is received.
+	receipt string
+}
+
+// inFlight reports whether m was received and is still invisible at now.
+func (m *message) inFlight(now time.Time) bool {
+	return m.receipt != "" && now.Before(m.visibleAt)
+}
+
+// Messages returns the bodies of the messages of the queue at queueURL, in
+// flight or not, for tests that check what was sent.
+func (s *SQS) Messages(queueURL string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.queues[queueURL]
+	if !ok {
+		return nil
+	}
+	bodies := make([]string, 0, len(q.messages))
+	for _, m := range q.messages {
+		bodies = append(bodies, m.body)
+	}
+	return bodies
+}
+
+// queue returns the queue at queueURL. s.mu must be held.
+func (s *SQS) queue(queueURL *string) (*queue, error) {
+	q, ok := s.queues[aws.StringValue(queueURL)]
+	if !ok {
+		return nil, newError(sqs.ErrCodeQueueDoesNotExist, http.StatusBadRequest, "the specified queue does not exist")
+	}
+	return q, nil
+}
+
+// queueByARN returns the queue with the given ARN. s.mu must be held.
+func (s *SQS) queueByARN(arn string) *queue {
+	for _, q := range s.queues {
+		if q.arn == arn {
+			return q
+		}
+	}
+	return nil
+}
+
+// CreateQueueWithContext creates a queue. Creating an existing queue with the
+// same attributes returns its URL.
+func (s *SQS) CreateQueueWithContext(ctx aws.Context, input *sqs.CreateQueueInput, opts ...request.Option) (*sqs.CreateQueueOutput, error) {
+	if err := s.env.call(ctx, "sqs", "CreateQueue"); err != nil {
+		return nil, err
+	}
+	name := aws.StringValue(input.QueueName)
+	if strings.HasSuffix(name, ".fifo") {
+		return nil, invalidParameter("InvalidParameterValue", "FIFO queues are not supported by the fake")
+	}
+	if !queueName.MatchString(name) {
+		return nil, invalidParameter("InvalidParameterValue", "can only include alphanumeric characters, hyphens, or underscores, 1 to 80 in length")
+	}
+	attributes := make(map[string]string, len(queueDefaults))
+	for k, v := range queueDefaults {
+		attributes[k] = v
+	}
+	if err := setQueueAttributes(attributes, aws.StringValueMap(input.Attributes)); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url := fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", s.env.region, s.env.account, name)
+	if q, ok := s.queues[url]; ok {
+		for k, v := range aws.StringValueMap(input.Attributes) {
+			if q.attributes[k] != v {
+				return nil, newError(sqs.ErrCodeQueueNameExists, http.StatusBadRequest, "a queue already exists with the same name and a different value for attribute %s", k)
+			}
+		}
+		return &sqs.CreateQueueOutput{QueueUrl: aws.String(url)}, nil
+	}
+
+	now := s.env.now()
+	if s.queues == nil {
+		s.queues = make(map[string]*queue)
+	}
+	s.queues[url] = &queue{
+		name:       name,
+		url:        url,
+		arn:        s.env.arn("sqs", name),
+		attributes: attributes,
+		created:    now,
+		modified:   now,
+	}
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String(url)}, nil
+}
+
+// DeleteQueueWithContext deletes a queue and its messages.
+func (s *SQS) DeleteQueueWithContext(ctx aws.Context, input *sqs.DeleteQueueInput, opts ...request.Option) (*sqs.DeleteQueueOutput, error) {
+	if err := s.env.call(ctx, "sqs", "DeleteQueue"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.queue(input.QueueUrl); err != nil {
+		return nil, err
+	}
+	delete(s.queues, aws.StringValue(input.QueueUrl))
+	return &sqs.DeleteQueueOutput{}, nil
+}
+
+// GetQueueUrlWithContext returns the URL of a queue from its name.
+func (s *SQS) GetQueueUrlWithContext(ctx aws.Context, input *sqs.GetQueueUrlInput, opts ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+	if err := s.env.call(ctx, "sqs", "GetQueueUrl"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for url, q := range s.queues {
+		if q.name == aws.StringValue(input.QueueName) {
+			return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(url)}, nil
+		}
+	}
+	return nil, newError(sqs.ErrCodeQueueDoesNotExist, http.StatusBadRequest, "the specified queue does not exist")
+}
+
+// ListQueuesWithContext lists queue URLs in name order. As with SQS, results
+// are only paged when input.MaxResults is set.
+func (s *SQS) ListQueuesWithContext(ctx aws.Context, input *sqs.ListQueuesInput, opts ...request.Option) (*sqs.ListQueuesOutput, error) {
+	if err := s.env.call(ctx, "sqs", "ListQueues"); err != nil {
+		return nil, err
+	}
+	pageSize := int64(maxListQueuesPageSize)
+	if input.MaxResults != nil {
+		pageSize = aws.Int64Value(input.MaxResults)
+		if pageSize < 1 || pageSize > maxListQueuesPageSize {
+			return nil, invalidParameter("InvalidParameterValue", "MaxResults must be between 1 and %d", maxListQueuesPageSize)
+		}
+	}
+	marker, err := decodeToken(input.NextToken, "InvalidParameterValue")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make(map[string]string, len(s.queues))
+	for url, q := range s.queues {
+		if strings.HasPrefix(q.name, aws.StringValue(input.QueueNamePrefix)) && q.name > marker {
+			names[q.name] = url
+		}
+	}
+
+	out := &sqs.ListQueuesOutput{}
+	for _, name := range sortedKeys(names) {
+		if int64(len(out.QueueUrls)) == pageSize {
+			if input.MaxResults != nil {
+				out.NextToken = encodeToken(queueNameOf(aws.StringValue(out.QueueUrls[pageSize-1])))
+			}
+			break
+		}
+		out.QueueUrls = append(out.QueueUrls, aws.String(names[name]))
+	}
+	return out, nil
+}
+
+// ListQueuesPagesWithContext calls fn with each page of the listing.
+func (s *SQS) ListQueuesPagesWithContext(ctx aws.Context, input *sqs.ListQueuesInput, fn func(*sqs.ListQueuesOutput, bool) bool, opts ...request.Option) error {
+	page := *input
+	for {
+		out, err := s.ListQueuesWithContext(ctx, &page, opts...)
+		if err != nil {
+			return err
+		}
+		lastPage := out.NextToken == nil
+		if !fn(out, lastPage) || lastPage {
+			return nil
+		}
+		page.NextToken = out.NextToken
+	}
+}
+
+// SendMessageWithContext enqueues a message, delayed by input.DelaySeconds or
+// the queue's DelaySeconds.
+func (s *SQS) SendMessageWithContext(ctx aws.Context, input *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	if err := s.env.call(ctx, "sqs", "SendMessage"); err != nil {
+		return nil, err
+	}
+	if input.MessageBody == nil || *input.MessageBody == "" {
+		return nil, missingParameter("MessageBody")
+	}
+
+	s.mu.Lock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if len(*input.MessageBody) > atoi(q.attributes[sqs.QueueAttributeNameMaximumMessageSize]) {
+		s.mu.Unlock()
+		return nil, invalidParameter("InvalidParameterValue", "message must be shorter than %s bytes", q.attributes[sqs.QueueAttributeNameMaximumMessageSize])
+	}
+	delay := atoi(q.attributes[sqs.QueueAttributeNameDelaySeconds])
+	if input.DelaySeconds != nil {
+		delay = int(aws.Int64Value(input.DelaySeconds))
+	}
+	m := s.enqueue(q, aws.StringValue(input.MessageBody), input.MessageAttributes, time.Duration(delay)*time.Second)
+	s.mu.Unlock()
+	s.env.notify()
+
+	sum := md5.Sum([]byte(m.body))
+	return &sqs.SendMessageOutput{
+		MessageId:        aws.String(m.id),
+		MD5OfMessageBody: aws.String(fmt.Sprintf("%x", sum)),
+	}, nil
+}
+
+// enqueue adds a message to q. s.mu must be held, and s.env.notify called once
+// it is released.
+func (s *SQS) enqueue(q *queue, body string, attributes map[string]*sqs.MessageAttributeValue, delay time.Duration) *message {
+	now := s.env.now()
+	m := &message{
+		id:         fmt.Sprintf("00000000-0000-4000-8000-%012x", s.env.next()),
+		body:       body,
+		attributes: attributes,
+		sent:       now,
+		visibleAt:  now.Add(delay),
+	}
+	q.messages = append(q.messages, m)
+	return m
+}
+
+// ReceiveMessageWithContext receives up to input.MaxNumberOfMessages visible
+// messages, waiting up to the wait time, in real time, for one to arrive.
+// Messages received more often than the maxReceiveCount of the queue's
+// RedrivePolicy are moved to its dead-letter queue instead.
+func (s *SQS) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	if err := s.env.call(ctx, "sqs", "ReceiveMessage"); err != nil {
+		return nil, err
+	}
+	maxMessages := 1
+	if input.MaxNumberOfMessages != nil {
+		maxMessages = int(aws.Int64Value(input.MaxNumberOfMessages))
+		if maxMessages < 1 || maxMessages > maxReceiveMessages {
+			return nil, invalidParameter("InvalidParameterValue", "MaxNumberOfMessages must be between 1 and %d", maxReceiveMessages)
+		}
+	}
+	if t := aws.Int64Value(input.VisibilityTimeout); t < 0 || t > maxVisibilityTimeout {
+		return nil, invalidParameter("InvalidParameterValue", "VisibilityTimeout must be between 0 and %d", maxVisibilityTimeout)
+	}
+	if t := aws.Int64Value(input.WaitTimeSeconds); t < 0 || t > maxWaitTimeSeconds {
+		return nil, invalidParameter("InvalidParameterValue", "WaitTimeSeconds must be between 0 and %d", maxWaitTimeSeconds)
+	}
+
+	s.mu.Lock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	wait := time.Duration(atoi(q.attributes[sqs.QueueAttributeNameReceiveMessageWaitTimeSeconds])) * time.Second
+	s.mu.Unlock()
+	if input.WaitTimeSeconds != nil {
+		wait = time.Duration(aws.Int64Value(input.WaitTimeSeconds)) * time.Second
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		changed := s.env.wait()
+		messages, err := s.receive(input, maxMessages)
+		if err != nil || len(messages) > 0 {
+			return &sqs.ReceiveMessageOutput{Messages: messages}, err
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return &sqs.ReceiveMessageOutput{}, nil
+		case <-ctx.Done():
+			return nil, canceled(ctx.Err())
+		}
+	}
+}
+
+// receive receives the visible messages of a queue once.
+func (s *SQS) receive(input *sqs.ReceiveMessageInput, maxMessages int) ([]*sqs.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.env.now()
+	s.expire(q, now)
+	timeout := time.Duration(atoi(q.attributes[sqs.QueueAttributeNameVisibilityTimeout])) * time.Second
+	if input.VisibilityTimeout != nil {
+		timeout = time.Duration(aws.Int64Value(input.VisibilityTimeout)) * time.Second
+	}
+	deadLetters, maxReceives := redrivePolicy(q.attributes[sqs.QueueAttributeNameRedrivePolicy])
+
+	var messages []*sqs.Message
+	kept := q.messages[:0]
+	for _, m := range q.messages {
+		if len(messages) == maxMessages || now.Before(m.visibleAt) {
+			kept = append(kept, m)
+			continue
+		}
+		if dlq := s.queueByARN(deadLetters); dlq != nil && maxReceives > 0 && m.receiveCount >= maxReceives {
+			m.receipt, m.visibleAt = "", now
+			dlq.messages = append(dlq.messages, m)
+			continue
+		}
+		kept = append(kept, m)
+
+		m.receiveCount++
+		if m.receiveCount == 1 {
+			m.firstReceive = now
+		}
+		m.visibleAt = now.Add(timeout)
+		m.receipt = base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "%s#%d", m.id, s.env.next()))
+		messages = append(messages, toSQSMessage(m, input.AttributeNames, input.MessageAttributeNames))
+	}
+	clear(q.messages[len(kept):])
+	q.messages = kept
+	return messages, nil
+}
+
+// expire drops the messages of q older than its retention period. s.mu must
+// be held.
+func (s *SQS) expire(q *queue, now time.Time) {
+	retention := time.Duration(atoi(q.attributes[sqs.QueueAttributeNameMessageRetentionPeriod])) * time.Second
+	kept := q.messages[:0]
+	for _, m := range q.messages {
+		if now.Sub(m.sent) < retention {
+			kept = append(kept, m)
+		}
+	}
+	clear(q.messages[len(kept):])
+	q.messages = kept
+}
+
+// toSQSMessage returns a received message with the requested system and
+// message attributes.
+func toSQSMessage(m *message, attributeNames, messageAttributeNames []*string) *sqs.Message {
+	sum := md5.Sum([]byte(m.body))
+	out := &sqs.Message{
+		MessageId:     aws.String(m.id),
+		ReceiptHandle: aws.String(m.receipt),
+		Body:          aws.String(m.body),
+		MD5OfBody:     aws.String(fmt.Sprintf("%x", sum)),
+	}
+
+	system := map[string]string{
+		sqs.MessageSystemAttributeNameApproximateReceiveCount:          strconv.Itoa(m.receiveCount),
+		sqs.MessageSystemAttributeNameApproximateFirstReceiveTimestamp: strconv.FormatInt(m.firstReceive.UnixMilli(), 10),
+		sqs.MessageSystemAttributeNameSentTimestamp:                    strconv.FormatInt(m.sent.UnixMilli(), 10),
+	}
+	for name, value := range system {
+		if requested(attributeNames, name) {
+			if out.Attributes == nil {
+				out.Attributes = make(map[string]*string)
+			}
+			out.Attributes[name] = aws.String(value)
+		}
+	}
+	for name, value := range m.attributes {
+		if requested(messageAttributeNames, name) {
+			if out.MessageAttributes == nil {
+				out.MessageAttributes = make(map[string]*sqs.MessageAttributeValue)
+			}
+			out.MessageAttributes[name] = value
+		}
+	}
+	return out
+}
+
+// requested reports whether the attribute name is in names, which may hold
+// "All" or, for message attributes, ".*".
+func requested(names []*string, name string) bool {
+	for _, n := range aws.StringValueSlice(names) {
+		if n == name || n == sqs.QueueAttributeNameAll || n == ".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteMessageWithContext deletes a received message. Deleting a message
+// that was already deleted succeeds.
+func (s *SQS) DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	if err := s.env.call(ctx, "sqs", "DeleteMessage"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	i, err := q.find(aws.StringValue(input.ReceiptHandle))
+	if err != nil {
+		return nil, err
+	}
+	if i >= 0 {
+		q.messages = append(q.messages[:i], q.messages[i+1:]...)
+	}
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// ChangeMessageVisibilityWithContext changes the visibility timeout of a
+// message in flight. A timeout of zero makes it visible again at once.
+func (s *SQS) ChangeMessageVisibilityWithContext(ctx aws.Context, input *sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	if err := s.env.call(ctx, "sqs", "ChangeMessageVisibility"); err != nil {
+		return nil, err
+	}
+	timeout := aws.Int64Value(input.VisibilityTimeout)
+	if timeout < 0 || timeout > maxVisibilityTimeout {
+		return nil, invalidParameter("InvalidParameterValue", "VisibilityTimeout must be between 0 and %d", maxVisibilityTimeout)
+	}
+
+	s.mu.Lock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	i, err := q.find(aws.StringValue(input.ReceiptHandle))
+	now := s.env.now()
+	if err == nil && (i < 0 || !q.messages[i].inFlight(now)) {
+		err = invalidParameter(sqs.ErrCodeMessageNotInflight, "the message referred to is not in flight")
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	q.messages[i].visibleAt = now.Add(time.Duration(timeout) * time.Second)
+	s.mu.Unlock()
+	s.env.notify()
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// find returns the index of the message with the given receipt handle, or -1
+// if the message is no longer in the queue. It fails if the handle is not
+// that of the last receive of the message.
+func (q *queue) find(receipt string) (int, error) {
+	invalid := invalidParameter(sqs.ErrCodeReceiptHandleIsInvalid, "the input receipt handle %q is not valid", receipt)
+	decoded, err := base64.StdEncoding.DecodeString(receipt)
+	id, _, ok := strings.Cut(string(decoded), "#")
+	if err != nil || !ok {
+		return 0, invalid
+	}
+	for i, m := range q.messages {
+		if m.id != id {
+			continue
+		}
+		if m.receipt != receipt {
+			return 0, invalid
+		}
+		return i, nil
+	}
+	return -1, nil
+}
+
+// PurgeQueueWithContext deletes all the messages of a queue.
+func (s *SQS) PurgeQueueWithContext(ctx aws.Context, input *sqs.PurgeQueueInput, opts ...request.Option) (*sqs.PurgeQueueOutput, error) {
+	if err := s.env.call(ctx, "sqs", "PurgeQueue"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	q.messages = nil
+	return &sqs.PurgeQueueOutput{}, nil
+}
+
+// GetQueueAttributesWithContext returns the requested attributes of a queue,
+// including the approximate message counts.
+func (s *SQS) GetQueueAttributesWithContext(ctx aws.Context, input *sqs.GetQueueAttributesInput, opts ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	if err := s.env.call(ctx, "sqs", "GetQueueAttributes"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.env.now()
+	s.expire(q, now)
+	var visible, inFlight, delayed int
+	for _, m := range q.messages {
+		switch {
+		case m.inFlight(now):
+			inFlight++
+		case now.Before(m.visibleAt):
+			delayed++
+		default:
+			visible++
+		}
+	}
+	attributes := map[string]string{
+		sqs.QueueAttributeNameApproximateNumberOfMessages:           strconv.Itoa(visible),
+		sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible: strconv.Itoa(inFlight),
+		sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed:    strconv.Itoa(delayed),
+		sqs.QueueAttributeNameCreatedTimestamp:                      strconv.FormatInt(q.created.Unix(), 10),
+		sqs.QueueAttributeNameLastModifiedTimestamp:                 strconv.FormatInt(q.modified.Unix(), 10),
+		sqs.QueueAttributeNameQueueArn:                              q.arn,
+	}
+	for k, v := range q.attributes {
+		attributes[k] = v
+	}
+
+	out := &sqs.GetQueueAttributesOutput{Attributes: make(map[string]*string)}
+	for name, value := range attributes {
+		if requested(input.AttributeNames, name) {
+			out.Attributes[name] = aws.String(value)
+		}
+	}
+	return out, nil
+}
+
+// SetQueueAttributesWithContext sets attributes of a queue.
+func (s *SQS) SetQueueAttributesWithContext(ctx aws.Context, input *sqs.SetQueueAttributesInput, opts ...request.Option) (*sqs.SetQueueAttributesOutput, error) {
+	if err := s.env.call(ctx, "sqs", "SetQueueAttributes"); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, err := s.queue(input.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := setQueueAttributes(q.attributes, aws.StringValueMap(input.Attributes)); err != nil {
+		return nil, err
+	}
+	q.modified = s.env.now()
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+// setQueueAttributes validates attributes and sets them in dst.
+func setQueueAttributes(dst, attributes map[string]string) error {
+	for name, value := range attributes {
+		if _, ok := queueDefaults[name]; ok {
+			if _, err := strconv.Atoi(value); err != nil {
+				return invalidParameter("InvalidAttributeValue", "invalid value for the parameter %s", name)
+			}
+		} else if !queueSettable[name] {
+			return invalidParameter("InvalidAttributeName", "unknown attribute %s", name)
+		}
+		if name == sqs.QueueAttributeNameRedrivePolicy {
+			if arn, _ := redrivePolicy(value); arn == "" {
+				return invalidParameter("InvalidAttributeValue", "invalid value for the parameter RedrivePolicy")
+			}
+		}
+		dst[name] = value
+	}
+	return nil
+}
+
+// redrivePolicy returns the dead-letter queue ARN and maxReceiveCount of a
+// RedrivePolicy attribute.
+func redrivePolicy(value string) (string, int) {
+	var policy struct {
+		DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+		// maxReceiveCount is a string or a number.
+		MaxReceiveCount json.Number `json:"maxReceiveCount"`
+	}
+	if value == "" || json.Unmarshal([]byte(value), &policy) != nil {
+		return "", 0
+	}
+	return policy.DeadLetterTargetArn, atoi(policy.MaxReceiveCount.String())
+}
+
+// queueNameOf returns the name of the queue at queueURL.
+func queueNameOf(queueURL string) string {
+	return queueURL[strings.LastIndex(queueURL, "/")+1:]
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
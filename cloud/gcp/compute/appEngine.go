@@ -2,32 +2,34 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/appengine/v1"
 )
 
 // AppEngineService provides operations for interacting with GCP App Engine.
 type AppEngineService struct {
-	service *appengine.Service
-	ctx     context.Context
+	service *appengine.APIService
+	retry   *retry.Policy
 }
 
-// NewAppEngineService creates a new instance of AppEngineService.
-func NewAppEngineService(ctx context.Context) (*AppEngineService, error) {
-	service, err := appengine.NewService(ctx)
-	if err != nil {
-		return nil, err
-	}
-
+// NewAppEngineService creates a new AppEngineService from an authenticated
+// App Engine Admin client, such as GCPProvider.AppEngineService. policy is
+// applied to every API call.
+func NewAppEngineService(service *appengine.APIService, policy *retry.Policy) *AppEngineService {
 	return &AppEngineService{
 		service: service,
-		ctx:     ctx,
-	}, nil
+		retry:   policy,
+	}
 }
 
 // CreateApplication creates a new App Engine application in a given location.
-func (ae *AppEngineService) CreateApplication(locationID, projectID string) (*appengine.Operation, error) {
+func (ae *AppEngineService) CreateApplication(ctx context.Context, locationID, projectID string) (*appengine.Operation, error) {
 	// Define the application to be created
 	application := &appengine.Application{
 		Id:         projectID,  // Set the Project ID
@@ -35,37 +37,56 @@ func (ae *AppEngineService) CreateApplication(locationID, projectID string) (*ap
 	}
 
 	// Call App Engine's create application method
-	op, err := ae.service.Apps.Create(application).Context(ae.ctx).Do()
+	op, err := call(ctx, ae.retry, false, func(ctx context.Context) (*appengine.Operation, error) {
+		return ae.service.Apps.Create(application).Context(ctx).Do()
+	})
 	if err != nil {
-		return nil, fmt.Errorf("App Engine Application creation failed: %v", err)
+		return nil, fmt.Errorf("App Engine Application creation failed: %w", err)
 	}
 	return op, nil
 }
 
-// ListApplications lists all App Engine applications in a given project.
-func (ae *AppEngineService) ListApplications() ([]*appengine.Application, error) {
-	appsListCall := ae.service.Apps.List()
-	response, err := appsListCall.Context(ae.ctx).Do()
+// ListApplications lists the App Engine applications of the project of the
+// Application Default Credentials: the project's application, or none.
+//
+// Deprecated: A project has at most one App Engine application, whose ID is
+// the project ID. Use GetApplication.
+func (ae *AppEngineService) ListApplications(ctx context.Context) ([]*appengine.Application, error) {
+	creds, err := google.FindDefaultCredentials(ctx, appengine.CloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("App Engine Application listing failed: %w", err)
+	}
+	if creds.ProjectID == "" {
+		return nil, fmt.Errorf("App Engine Application listing failed: the default credentials have no project: %w", cloud.ErrInvalidArgument)
+	}
+	app, err := ae.GetApplication(ctx, creds.ProjectID)
+	if errors.Is(err, cloud.ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("App Engine Application listing failed: %v", err)
+		return nil, fmt.Errorf("App Engine Application listing failed: %w", err)
 	}
-	return response.Apps, nil
+	return []*appengine.Application{app}, nil
 }
 
 // GetApplication retrieves details of a specific App Engine application.
-func (ae *AppEngineService) GetApplication(appID string) (*appengine.Application, error) {
-	app, err := ae.service.Apps.Get(appID).Context(ae.ctx).Do()
+func (ae *AppEngineService) GetApplication(ctx context.Context, appID string) (*appengine.Application, error) {
+	app, err := call(ctx, ae.retry, true, func(ctx context.Context) (*appengine.Application, error) {
+		return ae.service.Apps.Get(appID).Context(ctx).Do()
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving App Engine application: %v", err)
+		return nil, fmt.Errorf("error retrieving App Engine application: %w", err)
 	}
 	return app, nil
 }
 
 // UpdateApplication updates specific fields of an App Engine application.
-func (ae *AppEngineService) UpdateApplication(appID string, updates *appengine.Application) (*appengine.Operation, error) {
-	op, err := ae.service.Apps.Patch(appID, updates).Context(ae.ctx).Do()
+func (ae *AppEngineService) UpdateApplication(ctx context.Context, appID string, updates *appengine.Application) (*appengine.Operation, error) {
+	op, err := call(ctx, ae.retry, true, func(ctx context.Context) (*appengine.Operation, error) {
+		return ae.service.Apps.Patch(appID, updates).Context(ctx).Do()
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error updating App Engine application: %v", err)
+		return nil, fmt.Errorf("error updating App Engine application: %w", err)
 	}
 	return op, nil
 }
@@ -0,0 +1,62 @@
+package compute_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gcpcompute "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/compute"
+
+	"google.golang.org/api/appengine/v1"
+	"google.golang.org/api/option"
+)
+
+// useDefaultCredentials makes Application Default Credentials of projectID
+// the default for the test.
+func useDefaultCredentials(t *testing.T, projectID string) {
+	t.Helper()
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   projectID,
+		"client_email": "test@" + projectID + ".iam.gserviceaccount.com",
+		"private_key":  "unused",
+	})
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, credentials, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+}
+
+func TestListApplications(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/apps/with-app" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"not found","errors":[{"reason":"notFound"}]}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(&appengine.Application{Id: "with-app", LocationId: "us-central"})
+	}))
+	defer server.Close()
+	service, err := appengine.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	ae := gcpcompute.NewAppEngineService(service, nil)
+
+	useDefaultCredentials(t, "with-app")
+	apps, err := ae.ListApplications(context.Background())
+	if err != nil || len(apps) != 1 || apps[0].Id != "with-app" {
+		t.Errorf("ListApplications = %v, %v; want the project's application", apps, err)
+	}
+
+	useDefaultCredentials(t, "without-app")
+	apps, err = ae.ListApplications(context.Background())
+	if err != nil || len(apps) != 0 {
+		t.Errorf("ListApplications = %v, %v; want none", apps, err)
+	}
+}
@@ -1,36 +1,66 @@
 package compute
 
 import (
+	"context"
+	"iter"
+	"strings"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
 	"google.golang.org/api/cloudfunctions/v1"
 )
 
 type CloudFunctionsService struct {
 	service *cloudfunctions.Service
+	retry   *retry.Policy
 }
 
-func NewCloudFunctionsService() *CloudFunctionsService {
-	// Initialize Cloud Functions Service
-	cloudFunctionsService, err := cloudfunctions.New(client)
-	if err != nil {
-		// handle error
-	}
-
+// NewCloudFunctionsService creates a new CloudFunctionsService from an
+// authenticated Cloud Functions client, such as
+// GCPProvider.CloudFunctionsService. policy is applied to every API call.
+func NewCloudFunctionsService(service *cloudfunctions.Service, policy *retry.Policy) *CloudFunctionsService {
 	return &CloudFunctionsService{
-		service: cloudFunctionsService,
+		service: service,
+		retry:   policy,
 	}
 }
 
 // CreateFunction creates a new cloud function.
-func (cf *CloudFunctionsService) CreateFunction(projectLocation string, function *cloudfunctions.CloudFunction) error {
-	_, err := cf.service.Projects.Locations.Functions.Create(projectLocation, function).Do()
+func (cf *CloudFunctionsService) CreateFunction(ctx context.Context, projectLocation string, function *cloudfunctions.CloudFunction) error {
+	_, err := call(ctx, cf.retry, false, func(ctx context.Context) (*cloudfunctions.Operation, error) {
+		return cf.service.Projects.Locations.Functions.Create(projectLocation, function).Context(ctx).Do()
+	})
 	return err
 }
 
 // ListFunctions lists all cloud functions in a given location.
-func (cf *CloudFunctionsService) ListFunctions(projectLocation string) ([]*cloudfunctions.CloudFunction, error) {
-	response, err := cf.service.Projects.Locations.Functions.List(projectLocation).Do()
-	if err != nil {
-		return nil, err
-	}
-	return response.Functions, nil
+func (cf *CloudFunctionsService) ListFunctions(ctx context.Context, projectLocation string) ([]*cloudfunctions.CloudFunction, error) {
+	return cloud.Collect(cf.Functions(ctx, projectLocation, nil))
+}
+
+// Functions iterates over the cloud functions in a given location whose short
+// name starts with opts.Prefix, fetching pages as the iteration proceeds. The
+// API has no name filter, so the prefix is matched locally.
+func (cf *CloudFunctionsService) Functions(ctx context.Context, projectLocation string, opts *cloud.ListOptions) iter.Seq2[*cloudfunctions.CloudFunction, error] {
+	prefix := helper.ListPrefix(opts)
+	return pages(ctx, cf.retry, opts, "functions", func(ctx context.Context, pageToken string) ([]*cloudfunctions.CloudFunction, string, error) {
+		call := cf.service.Projects.Locations.Functions.List(projectLocation).PageToken(pageToken)
+		if size := helper.PageSize(opts); size > 0 && prefix == "" {
+			call = call.PageSize(int64(size))
+		}
+		response, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, "", err
+		}
+
+		var functions []*cloudfunctions.CloudFunction
+		for _, function := range response.Functions {
+			if strings.HasPrefix(function.Name[strings.LastIndex(function.Name, "/")+1:], prefix) {
+				functions = append(functions, function)
+			}
+		}
+		return functions, response.NextPageToken, nil
+	})
 }
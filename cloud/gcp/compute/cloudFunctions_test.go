@@ -0,0 +1,89 @@
+package compute_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	gcpcompute "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/compute"
+
+	"google.golang.org/api/cloudfunctions/v1"
+	"google.golang.org/api/option"
+)
+
+const functionsLocation = "projects/api-project/locations/us-central1"
+
+// functionPages serves the functions of functionsLocation two to a page, and
+// records the page size of every request.
+type functionPages struct {
+	mu        sync.Mutex
+	pageSizes []string
+}
+
+func (f *functionPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.pageSizes = append(f.pageSizes, r.URL.Query().Get("pageSize"))
+	f.mu.Unlock()
+
+	names := []string{"api-users", "web", "api-orders", "worker", "api-billing"}
+	// The page token is the index of the first function of the page.
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	response := &cloudfunctions.ListFunctionsResponse{}
+	for _, name := range names[start:min(start+2, len(names))] {
+		response.Functions = append(response.Functions, &cloudfunctions.CloudFunction{Name: functionsLocation + "/functions/" + name})
+	}
+	if start+2 < len(names) {
+		response.NextPageToken = strconv.Itoa(start + 2)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func TestFunctionsPrefix(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *cloud.ListOptions
+		want      []string
+		pageSizes []string
+	}{
+		{"all", nil, []string{"api-users", "web", "api-orders", "worker", "api-billing"}, []string{"", "", ""}},
+		{"prefix", &cloud.ListOptions{Prefix: "api-"}, []string{"api-users", "api-orders", "api-billing"}, []string{"", "", ""}},
+		// The location contains the prefix, the short names do not.
+		{"no match", &cloud.ListOptions{Prefix: "projects/"}, nil, []string{"", "", ""}},
+		{"prefix and max items", &cloud.ListOptions{Prefix: "api-", MaxItems: 2}, []string{"api-users", "api-orders"}, []string{"", ""}},
+		// Without a prefix the page size is sent, as every item counts.
+		{"max items", &cloud.ListOptions{MaxItems: 1}, []string{"api-users"}, []string{"1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := &functionPages{}
+			server := httptest.NewServer(pages)
+			defer server.Close()
+			service, err := cloudfunctions.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+			if err != nil {
+				t.Fatalf("NewService: %v", err)
+			}
+			cf := gcpcompute.NewCloudFunctionsService(service, nil)
+
+			var got []string
+			for function, err := range cf.Functions(context.Background(), functionsLocation, tt.opts) {
+				if err != nil {
+					t.Fatalf("Functions: %v", err)
+				}
+				got = append(got, function.Name[strings.LastIndex(function.Name, "/")+1:])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Functions = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(pages.pageSizes, tt.pageSizes) {
+				t.Errorf("page sizes requested = %q, want %q", pages.pageSizes, tt.pageSizes)
+			}
+		})
+	}
+}
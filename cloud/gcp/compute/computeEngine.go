@@ -1,36 +1,110 @@
 package compute
 
 import (
+	"context"
+	"fmt"
+	"iter"
+	"regexp"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
 	"google.golang.org/api/compute/v1"
 )
 
+// ComputeEngineService provides operations for Compute Engine VM instances.
 type ComputeEngineService struct {
 	service *compute.Service
+	retry   *retry.Policy
 }
 
-func NewComputeEngineService() *ComputeEngineService {
-	// Assume 'client' is the authenticated client for GCP
-	computeService, err := compute.New(client)
-	if err != nil {
-		// handle error
-	}
-
+// NewComputeEngineService creates a new ComputeEngineService from an
+// authenticated Compute Engine client, such as GCPProvider.ComputeService.
+// policy is applied to every API call.
+func NewComputeEngineService(service *compute.Service, policy *retry.Policy) *ComputeEngineService {
 	return &ComputeEngineService{
-		service: computeService,
+		service: service,
+		retry:   policy,
 	}
 }
 
 // AddVMInstance creates a new VM instance.
-func (ce *ComputeEngineService) AddVMInstance(projectID, zone string, instance *compute.Instance) error {
-	_, err := ce.service.Instances.Insert(projectID, zone, instance).Do()
+func (ce *ComputeEngineService) AddVMInstance(ctx context.Context, projectID, zone string, instance *compute.Instance) error {
+	_, err := call(ctx, ce.retry, false, func(ctx context.Context) (*compute.Operation, error) {
+		return ce.service.Instances.Insert(projectID, zone, instance).Context(ctx).Do()
+	})
 	return err
 }
 
 // ListVMInstances lists all VM instances in a given zone.
-func (ce *ComputeEngineService) ListVMInstances(projectID, zone string) ([]*compute.Instance, error) {
-	response, err := ce.service.Instances.List(projectID, zone).Do()
-	if err != nil {
-		return nil, err
+func (ce *ComputeEngineService) ListVMInstances(ctx context.Context, projectID, zone string) ([]*compute.Instance, error) {
+	return cloud.Collect(ce.VMInstances(ctx, projectID, zone, nil))
+}
+
+// VMInstances iterates over the VM instances in a given zone whose name starts
+// with opts.Prefix, fetching pages as the iteration proceeds.
+func (ce *ComputeEngineService) VMInstances(ctx context.Context, projectID, zone string, opts *cloud.ListOptions) iter.Seq2[*compute.Instance, error] {
+	return pages(ctx, ce.retry, opts, "instances", func(ctx context.Context, pageToken string) ([]*compute.Instance, string, error) {
+		call := ce.service.Instances.List(projectID, zone).PageToken(pageToken)
+		if prefix := helper.ListPrefix(opts); prefix != "" {
+			call = call.Filter(nameFilter(prefix))
+		}
+		if size := helper.PageSize(opts); size > 0 {
+			call = call.MaxResults(int64(size))
+		}
+		list, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Items, list.NextPageToken, nil
+	})
+}
+
+// nameFilter returns a Compute Engine list filter matching names that start
+// with prefix.
+func nameFilter(prefix string) string {
+	return fmt.Sprintf("name eq %q", regexp.QuoteMeta(prefix)+".*")
+}
+
+// pages iterates over the items of a paged Google API listing of what. fetch
+// returns the items of the page at pageToken and the token of the next page,
+// or "" after the last one. Each page is fetched under policy.
+func pages[T any](ctx context.Context, policy *retry.Policy, opts *cloud.ListOptions, what string, fetch func(ctx context.Context, pageToken string) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		yield = helper.Limit(opts, yield)
+		pageToken := ""
+		for {
+			var items []T
+			var next string
+			err := policy.Do(ctx, true, func(ctx context.Context) error {
+				var err error
+				items, next, err = fetch(ctx, pageToken)
+				return helper.GoogleError(err)
+			})
+			if err != nil {
+				var zero T
+				yield(zero, fmt.Errorf("failed to list %s: %w", what, err))
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			pageToken = next
+		}
 	}
-	return response.Items, nil
+}
+
+// call runs a Google API call under policy. The call's error is classified
+// before the policy sees it, so retries follow the googleapi status code.
+func call[T any](ctx context.Context, policy *retry.Policy, idempotent bool, fn func(ctx context.Context) (T, error)) (T, error) {
+	return retry.DoValue(ctx, policy, idempotent, func(ctx context.Context) (T, error) {
+		v, err := fn(ctx)
+		return v, helper.GoogleError(err)
+	})
 }
@@ -0,0 +1,79 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+)
+
+// page is a page of a listing and the token of the next one.
+type page struct {
+	items []string
+	next  string
+}
+
+func TestPages(t *testing.T) {
+	listing := map[string]page{
+		"":   {[]string{"a", "b"}, "p2"},
+		"p2": {[]string{"c", "d"}, "p3"},
+		"p3": {[]string{"e"}, ""},
+	}
+	tests := []struct {
+		name    string
+		pages   map[string]page
+		opts    *cloud.ListOptions
+		failAt  string // the page token whose fetch fails
+		stop    int    // the consumer stops after this many items; 0 never
+		want    []string
+		fetched []string
+		wantErr bool
+	}{
+		{"all pages", listing, nil, "", 0, []string{"a", "b", "c", "d", "e"}, []string{"", "p2", "p3"}, false},
+		{"empty", map[string]page{"": {}}, nil, "", 0, nil, []string{""}, false},
+		{"empty page in between", map[string]page{"": {nil, "p2"}, "p2": {[]string{"a"}, ""}}, nil, "", 0, []string{"a"}, []string{"", "p2"}, false},
+		{"max items within a page", listing, &cloud.ListOptions{MaxItems: 1}, "", 0, []string{"a"}, []string{""}, false},
+		{"max items across pages", listing, &cloud.ListOptions{MaxItems: 3}, "", 0, []string{"a", "b", "c"}, []string{"", "p2"}, false},
+		{"max items at a page boundary", listing, &cloud.ListOptions{MaxItems: 2}, "", 0, []string{"a", "b"}, []string{""}, false},
+		{"consumer stops", listing, nil, "", 3, []string{"a", "b", "c"}, []string{"", "p2"}, false},
+		{"failing page", listing, nil, "p2", 0, []string{"a", "b"}, []string{"", "p2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched []string
+			seq := pages(context.Background(), &retry.Policy{MaxAttempts: 1}, tt.opts, "things", func(ctx context.Context, pageToken string) ([]string, string, error) {
+				fetched = append(fetched, pageToken)
+				if pageToken == tt.failAt && tt.failAt != "" {
+					return nil, "", errors.New("boom")
+				}
+				p := tt.pages[pageToken]
+				return p.items, p.next, nil
+			})
+
+			var got []string
+			var err error
+			for item, e := range seq {
+				if e != nil {
+					err = e
+					break
+				}
+				got = append(got, item)
+				if len(got) == tt.stop {
+					break
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(fetched, tt.fetched) {
+				t.Errorf("fetched pages %q, want %q", fetched, tt.fetched)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
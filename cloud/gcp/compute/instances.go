@@ -0,0 +1,211 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// Compute implements cloud.Compute on top of a ComputeEngineService.
+// Instances are identified as "zone/name"; a bare name refers to an instance
+// in the default zone.
+type Compute struct {
+	service   *ComputeEngineService
+	projectID string
+	zone      string
+}
+
+var _ cloud.Compute = (*Compute)(nil)
+
+// NewCompute creates a new Compute for projectID. zone is the default zone
+// for instances created or addressed without one.
+func NewCompute(service *ComputeEngineService, projectID, zone string) *Compute {
+	return &Compute{
+		service:   service,
+		projectID: projectID,
+		zone:      zone,
+	}
+}
+
+// CreateInstance creates a VM with a boot disk from spec.Image on the default
+// network, waits for the insert operation and returns the new instance.
+func (c *Compute) CreateInstance(ctx context.Context, spec *cloud.InstanceSpec) (*cloud.Instance, error) {
+	zone := spec.Zone
+	if zone == "" {
+		zone = c.zone
+	}
+
+	instance := &compute.Instance{
+		Name:        spec.Name,
+		MachineType: fmt.Sprintf("zones/%s/machineTypes/%s", zone, spec.MachineType),
+		Labels:      spec.Labels,
+		Disks: []*compute.AttachedDisk{{
+			Boot:       true,
+			AutoDelete: true,
+			InitializeParams: &compute.AttachedDiskInitializeParams{
+				SourceImage: spec.Image,
+			},
+		}},
+		NetworkInterfaces: []*compute.NetworkInterface{{
+			Network: "global/networks/default",
+			AccessConfigs: []*compute.AccessConfig{{
+				Name: "External NAT",
+				Type: "ONE_TO_ONE_NAT",
+			}},
+		}},
+	}
+
+	op, err := call(ctx, c.service.retry, false, func(ctx context.Context) (*compute.Operation, error) {
+		return c.service.service.Instances.Insert(c.projectID, zone, instance).Context(ctx).Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", err)
+	}
+	if err := c.wait(ctx, zone, op); err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", helper.GoogleError(err))
+	}
+	return c.GetInstance(ctx, zone+"/"+spec.Name)
+}
+
+// ListInstances lists the VM instances in all zones of the project.
+func (c *Compute) ListInstances(ctx context.Context) ([]*cloud.Instance, error) {
+	return cloud.Collect(c.Instances(ctx, nil))
+}
+
+// Instances iterates over the VM instances in all zones of the project whose
+// name starts with opts.Prefix, fetching pages as the iteration proceeds.
+func (c *Compute) Instances(ctx context.Context, opts *cloud.ListOptions) iter.Seq2[*cloud.Instance, error] {
+	return pages(ctx, c.service.retry, opts, "instances", func(ctx context.Context, pageToken string) ([]*cloud.Instance, string, error) {
+		call := c.service.service.Instances.AggregatedList(c.projectID).PageToken(pageToken)
+		if prefix := helper.ListPrefix(opts); prefix != "" {
+			call = call.Filter(nameFilter(prefix))
+		}
+		if size := helper.PageSize(opts); size > 0 {
+			call = call.MaxResults(int64(size))
+		}
+		page, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Items is keyed by scope, such as "zones/us-central1-a".
+		var instances []*cloud.Instance
+		for _, scope := range slices.Sorted(maps.Keys(page.Items)) {
+			for _, instance := range page.Items[scope].Instances {
+				instances = append(instances, ToInstance(instance))
+			}
+		}
+		return instances, page.NextPageToken, nil
+	})
+}
+
+// GetInstance returns the VM instance with the given ID.
+func (c *Compute) GetInstance(ctx context.Context, id string) (*cloud.Instance, error) {
+	zone, name := c.parseID(id)
+	instance, err := call(ctx, c.service.retry, true, func(ctx context.Context) (*compute.Instance, error) {
+		return c.service.service.Instances.Get(c.projectID, zone, name).Context(ctx).Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance %q: %w", id, err)
+	}
+	return ToInstance(instance), nil
+}
+
+// StartInstance starts a stopped VM instance and waits for the start
+// operation.
+func (c *Compute) StartInstance(ctx context.Context, id string) error {
+	zone, name := c.parseID(id)
+	op, err := call(ctx, c.service.retry, true, func(ctx context.Context) (*compute.Operation, error) {
+		return c.service.service.Instances.Start(c.projectID, zone, name).Context(ctx).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start instance %q: %w", id, err)
+	}
+	if err := c.wait(ctx, zone, op); err != nil {
+		return fmt.Errorf("failed to start instance %q: %w", id, err)
+	}
+	return nil
+}
+
+// StopInstance stops a running VM instance and waits for the stop operation.
+func (c *Compute) StopInstance(ctx context.Context, id string) error {
+	zone, name := c.parseID(id)
+	op, err := call(ctx, c.service.retry, true, func(ctx context.Context) (*compute.Operation, error) {
+		return c.service.service.Instances.Stop(c.projectID, zone, name).Context(ctx).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stop instance %q: %w", id, err)
+	}
+	if err := c.wait(ctx, zone, op); err != nil {
+		return fmt.Errorf("failed to stop instance %q: %w", id, err)
+	}
+	return nil
+}
+
+// RebootInstance resets a running VM instance and waits for the reset
+// operation.
+func (c *Compute) RebootInstance(ctx context.Context, id string) error {
+	zone, name := c.parseID(id)
+	op, err := call(ctx, c.service.retry, false, func(ctx context.Context) (*compute.Operation, error) {
+		return c.service.service.Instances.Reset(c.projectID, zone, name).Context(ctx).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset instance %q: %w", id, err)
+	}
+	if err := c.wait(ctx, zone, op); err != nil {
+		return fmt.Errorf("failed to reset instance %q: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteInstance deletes a VM instance and waits for the delete operation.
+func (c *Compute) DeleteInstance(ctx context.Context, id string) error {
+	zone, name := c.parseID(id)
+	op, err := call(ctx, c.service.retry, true, func(ctx context.Context) (*compute.Operation, error) {
+		return c.service.service.Instances.Delete(c.projectID, zone, name).Context(ctx).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete instance %q: %w", id, err)
+	}
+	if err := c.wait(ctx, zone, op); err != nil {
+		return fmt.Errorf("failed to delete instance %q: %w", id, err)
+	}
+	return nil
+}
+
+// parseID splits an instance ID into its zone and name.
+func (c *Compute) parseID(id string) (zone, name string) {
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		return id[:i], id[i+1:]
+	}
+	return c.zone, id
+}
+
+// wait blocks until the zonal operation op is done, and returns its first
+// error, classified, if it failed.
+func (c *Compute) wait(ctx context.Context, zone string, op *compute.Operation) error {
+	for op.Status != "DONE" {
+		var err error
+		// Wait returns when the operation is done or after about two minutes.
+		name := op.Name
+		op, err = call(ctx, c.service.retry, true, func(ctx context.Context) (*compute.Operation, error) {
+			return c.service.service.ZoneOperations.Wait(c.projectID, zone, name).Context(ctx).Do()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		first := op.Error.Errors[0]
+		return fmt.Errorf("operation %s failed: %w", op.Name, helper.GoogleOperationError(first.Code, first.Message, int(op.HttpErrorStatusCode)))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package compute_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	gcpcompute "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/compute"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+// operations serves instance actions of the Compute Engine API, returning
+// pending zone operations which complete, with result, when waited for.
+type operations struct {
+	mu     sync.Mutex
+	result map[string]*compute.Operation
+	waited []string
+}
+
+func (o *operations) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// projects/{project}/zones/{zone}/instances/{name}[/{action}] or
+	// projects/{project}/zones/{zone}/operations/{name}/wait
+	if len(parts) < 6 || parts[0] != "projects" || parts[2] != "zones" {
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotImplemented)
+		return
+	}
+	switch parts[4] {
+	case "instances":
+		action := "delete"
+		if len(parts) == 7 {
+			action = parts[6]
+		}
+		json.NewEncoder(w).Encode(&compute.Operation{Name: action + "-" + parts[5], Status: "RUNNING"})
+	case "operations":
+		o.waited = append(o.waited, parts[5])
+		op := &compute.Operation{Name: parts[5], Status: "DONE"}
+		if result, ok := o.result[parts[5]]; ok {
+			op = result
+		}
+		json.NewEncoder(w).Encode(op)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotImplemented)
+	}
+}
+
+func newCompute(t *testing.T, ops *operations) *gcpcompute.Compute {
+	t.Helper()
+	server := httptest.NewServer(ops)
+	t.Cleanup(server.Close)
+	service, err := compute.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return gcpcompute.NewCompute(gcpcompute.NewComputeEngineService(service, nil), "c2loud-test", "us-central1-a")
+}
+
+func TestInstanceActionsWait(t *testing.T) {
+	ctx := context.Background()
+	ops := &operations{}
+	c := newCompute(t, ops)
+
+	if err := c.StartInstance(ctx, "web"); err != nil {
+		t.Errorf("StartInstance: %v", err)
+	}
+	if err := c.StopInstance(ctx, "europe-west1-b/web"); err != nil {
+		t.Errorf("StopInstance: %v", err)
+	}
+	if err := c.RebootInstance(ctx, "web"); err != nil {
+		t.Errorf("RebootInstance: %v", err)
+	}
+	if err := c.DeleteInstance(ctx, "web"); err != nil {
+		t.Errorf("DeleteInstance: %v", err)
+	}
+	if got := strings.Join(ops.waited, ","); got != "start-web,stop-web,reset-web,delete-web" {
+		t.Errorf("waited for %s", got)
+	}
+}
+
+func TestInstanceOperationError(t *testing.T) {
+	failed := func(code string, status int64) *compute.Operation {
+		return &compute.Operation{
+			Status:              "DONE",
+			HttpErrorStatusCode: status,
+			Error: &compute.OperationError{Errors: []*compute.OperationErrorErrors{{
+				Code:    code,
+				Message: "operation failed with " + code,
+			}}},
+		}
+	}
+	tests := []struct {
+		code   string
+		status int64
+		want   error
+	}{
+		{"ZONE_RESOURCE_POOL_EXHAUSTED", 503, cloud.ErrUnavailable},
+		{"QUOTA_EXCEEDED", 403, cloud.ErrThrottled},
+		{"RESOURCE_NOT_FOUND", 404, cloud.ErrNotFound},
+		{"RESOURCE_IN_USE_BY_ANOTHER_RESOURCE", 400, cloud.ErrConflict},
+		{"UNDOCUMENTED", 403, cloud.ErrPermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			c := newCompute(t, &operations{result: map[string]*compute.Operation{"start-web": failed(tt.code, tt.status)}})
+			err := c.StartInstance(context.Background(), "web")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("StartInstance: got %v, want %v", err, tt.want)
+			}
+			var cerr *cloud.Error
+			if !errors.As(err, &cerr) || cerr.Code != tt.code || cerr.StatusCode != int(tt.status) {
+				t.Errorf("error = %#v", cerr)
+			}
+		})
+	}
+}
@@ -1,34 +1,41 @@
 package compute
 
 import (
+	"context"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+
 	"google.golang.org/api/container/v1"
 )
 
 type KubernetesEngineService struct {
 	service *container.Service
+	retry   *retry.Policy
 }
 
-func NewKubernetesEngineService() *KubernetesEngineService {
-	// Initialize Kubernetes Engine Service
-	containerService, err := container.New(client)
-	if err != nil {
-		// handle error
-	}
-
+// NewKubernetesEngineService creates a new KubernetesEngineService from an
+// authenticated Kubernetes Engine client, such as
+// GCPProvider.KubernetesEngineService. policy is applied to every API call.
+func NewKubernetesEngineService(service *container.Service, policy *retry.Policy) *KubernetesEngineService {
 	return &KubernetesEngineService{
-		service: containerService,
+		service: service,
+		retry:   policy,
 	}
 }
 
 // CreateCluster creates a new Kubernetes cluster.
-func (ke *KubernetesEngineService) CreateCluster(projectID, zone string, cluster *container.Cluster) error {
-	_, err := ke.service.Projects.Zones.Clusters.Create(projectID, zone, cluster).Do()
+func (ke *KubernetesEngineService) CreateCluster(ctx context.Context, projectID, zone string, cluster *container.Cluster) error {
+	_, err := call(ctx, ke.retry, false, func(ctx context.Context) (*container.Operation, error) {
+		return ke.service.Projects.Zones.Clusters.Create(projectID, zone, &container.CreateClusterRequest{Cluster: cluster}).Context(ctx).Do()
+	})
 	return err
 }
 
 // ListClusters lists all clusters in a given zone.
-func (ke *KubernetesEngineService) ListClusters(projectID, zone string) ([]*container.Cluster, error) {
-	response, err := ke.service.Projects.Zones.Clusters.List(projectID, zone).Do()
+func (ke *KubernetesEngineService) ListClusters(ctx context.Context, projectID, zone string) ([]*container.Cluster, error) {
+	response, err := call(ctx, ke.retry, true, func(ctx context.Context) (*container.ListClustersResponse, error) {
+		return ke.service.Projects.Zones.Clusters.List(projectID, zone).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
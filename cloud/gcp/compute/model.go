@@ -0,0 +1,145 @@
+package compute
+
+import (
+	"path"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+	"google.golang.org/api/appengine/v1"
+	"google.golang.org/api/cloudfunctions/v1"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/container/v1"
+)
+
+// instanceStates maps Compute Engine instance statuses to normalized states.
+// Compute Engine reports stopped instances as TERMINATED.
+var instanceStates = map[string]model.InstanceState{
+	"PROVISIONING": model.InstancePending,
+	"STAGING":      model.InstancePending,
+	"RUNNING":      model.InstanceRunning,
+	"STOPPING":     model.InstanceStopping,
+	"SUSPENDING":   model.InstanceStopping,
+	"SUSPENDED":    model.InstanceStopped,
+	"TERMINATED":   model.InstanceStopped,
+}
+
+// ToInstance converts a Compute Engine instance to a model.Instance.
+func ToInstance(i *compute.Instance) *model.Instance {
+	zone := path.Base(i.Zone)
+	instance := &model.Instance{
+		ID:     zone + "/" + i.Name,
+		Name:   i.Name,
+		State:  model.InstanceUnknown,
+		Zone:   zone,
+		Labels: i.Labels,
+		Raw:    i,
+	}
+	if i.MachineType != "" {
+		instance.MachineType = path.Base(i.MachineType)
+	}
+	if state, ok := instanceStates[i.Status]; ok {
+		instance.State = state
+	}
+	if t, err := time.Parse(time.RFC3339, i.CreationTimestamp); err == nil {
+		instance.LaunchTime = t
+	}
+	for _, ni := range i.NetworkInterfaces {
+		if ni.NetworkIP != "" {
+			instance.PrivateIPs = append(instance.PrivateIPs, ni.NetworkIP)
+		}
+		for _, ac := range ni.AccessConfigs {
+			if ac.NatIP != "" {
+				instance.PublicIPs = append(instance.PublicIPs, ac.NatIP)
+			}
+		}
+	}
+	return instance
+}
+
+// clusterStates maps GKE cluster statuses to normalized states.
+var clusterStates = map[string]model.ClusterState{
+	"PROVISIONING": model.ClusterProvisioning,
+	"RUNNING":      model.ClusterRunning,
+	"RECONCILING":  model.ClusterReconciling,
+	"STOPPING":     model.ClusterStopping,
+	"ERROR":        model.ClusterError,
+	"DEGRADED":     model.ClusterDegraded,
+}
+
+// ToCluster converts a GKE cluster to a model.Cluster.
+func ToCluster(c *container.Cluster) *model.Cluster {
+	cluster := &model.Cluster{
+		Name:      c.Name,
+		Location:  c.Location,
+		State:     model.ClusterUnknown,
+		Version:   c.CurrentMasterVersion,
+		Endpoint:  c.Endpoint,
+		NodeCount: c.CurrentNodeCount,
+		Labels:    c.ResourceLabels,
+		Raw:       c,
+	}
+	if state, ok := clusterStates[c.Status]; ok {
+		cluster.State = state
+	}
+	if t, err := time.Parse(time.RFC3339, c.CreateTime); err == nil {
+		cluster.CreatedAt = t
+	}
+	return cluster
+}
+
+// applicationStates maps App Engine serving statuses to normalized states.
+var applicationStates = map[string]model.ApplicationState{
+	"SERVING":         model.ApplicationServing,
+	"USER_DISABLED":   model.ApplicationDisabled,
+	"SYSTEM_DISABLED": model.ApplicationDisabled,
+}
+
+// ToApplication converts an App Engine application to a model.Application.
+func ToApplication(a *appengine.Application) *model.Application {
+	app := &model.Application{
+		ID:       a.Id,
+		Name:     a.Name,
+		Location: a.LocationId,
+		Hostname: a.DefaultHostname,
+		State:    model.ApplicationUnknown,
+		Raw:      a,
+	}
+	if state, ok := applicationStates[a.ServingStatus]; ok {
+		app.State = state
+	}
+	return app
+}
+
+// functionStates maps Cloud Functions statuses to normalized states. OFFLINE
+// functions failed to deploy.
+var functionStates = map[string]model.FunctionState{
+	"DEPLOY_IN_PROGRESS": model.FunctionDeploying,
+	"ACTIVE":             model.FunctionActive,
+	"DELETE_IN_PROGRESS": model.FunctionDeleting,
+	"OFFLINE":            model.FunctionError,
+}
+
+// ToFunction converts a Cloud Function to a model.Function. The name is the
+// last component of the resource name.
+func ToFunction(f *cloudfunctions.CloudFunction) *model.Function {
+	function := &model.Function{
+		ID:         f.Name,
+		Name:       path.Base(f.Name),
+		Runtime:    f.Runtime,
+		EntryPoint: f.EntryPoint,
+		State:      model.FunctionUnknown,
+		Labels:     f.Labels,
+		Raw:        f,
+	}
+	if state, ok := functionStates[f.Status]; ok {
+		function.State = state
+	}
+	if f.HttpsTrigger != nil {
+		function.URL = f.HttpsTrigger.Url
+	}
+	if t, err := time.Parse(time.RFC3339, f.UpdateTime); err == nil {
+		function.UpdatedAt = t
+	}
+	return function
+}
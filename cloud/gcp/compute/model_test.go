@@ -0,0 +1,208 @@
+package compute_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	gcpcompute "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/compute"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+	"google.golang.org/api/appengine/v1"
+	"google.golang.org/api/cloudfunctions/v1"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/container/v1"
+)
+
+var created = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestToInstance(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *compute.Instance
+		want *model.Instance
+	}{
+		{
+			name: "running",
+			in: &compute.Instance{
+				Name:              "web",
+				Zone:              "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a",
+				MachineType:       "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a/machineTypes/e2-small",
+				Status:            "RUNNING",
+				CreationTimestamp: "2026-03-01T12:00:00Z",
+				Labels:            map[string]string{"team": "ops"},
+				NetworkInterfaces: []*compute.NetworkInterface{{
+					NetworkIP:     "10.0.0.1",
+					AccessConfigs: []*compute.AccessConfig{{NatIP: "203.0.113.1"}, {}},
+				}},
+			},
+			want: &model.Instance{
+				ID:          "us-central1-a/web",
+				Name:        "web",
+				State:       model.InstanceRunning,
+				MachineType: "e2-small",
+				Zone:        "us-central1-a",
+				PrivateIPs:  []string{"10.0.0.1"},
+				PublicIPs:   []string{"203.0.113.1"},
+				Labels:      map[string]string{"team": "ops"},
+				LaunchTime:  created,
+			},
+		},
+		{
+			name: "stopped",
+			in:   &compute.Instance{Name: "batch", Zone: "zones/europe-west1-b", Status: "TERMINATED"},
+			want: &model.Instance{ID: "europe-west1-b/batch", Name: "batch", State: model.InstanceStopped, Zone: "europe-west1-b"},
+		},
+		{
+			name: "repairing",
+			in:   &compute.Instance{Name: "db", Zone: "zones/europe-west1-b", Status: "REPAIRING"},
+			want: &model.Instance{ID: "europe-west1-b/db", Name: "db", State: model.InstanceUnknown, Zone: "europe-west1-b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := gcpcompute.ToInstance(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToInstance = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToCluster(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *container.Cluster
+		want *model.Cluster
+	}{
+		{
+			name: "running",
+			in: &container.Cluster{
+				Name:                 "prod",
+				Location:             "us-central1",
+				Status:               "RUNNING",
+				CurrentMasterVersion: "1.33.1-gke.100",
+				Endpoint:             "203.0.113.10",
+				CurrentNodeCount:     3,
+				ResourceLabels:       map[string]string{"team": "ops"},
+				CreateTime:           "2026-03-01T12:00:00Z",
+			},
+			want: &model.Cluster{
+				Name:      "prod",
+				Location:  "us-central1",
+				State:     model.ClusterRunning,
+				Version:   "1.33.1-gke.100",
+				Endpoint:  "203.0.113.10",
+				NodeCount: 3,
+				Labels:    map[string]string{"team": "ops"},
+				CreatedAt: created,
+			},
+		},
+		{
+			name: "degraded",
+			in:   &container.Cluster{Name: "dev", Status: "DEGRADED"},
+			want: &model.Cluster{Name: "dev", State: model.ClusterDegraded},
+		},
+		{
+			name: "unspecified",
+			in:   &container.Cluster{Name: "new"},
+			want: &model.Cluster{Name: "new", State: model.ClusterUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := gcpcompute.ToCluster(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToCluster = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToApplication(t *testing.T) {
+	tests := []struct {
+		status string
+		want   model.ApplicationState
+	}{
+		{"SERVING", model.ApplicationServing},
+		{"USER_DISABLED", model.ApplicationDisabled},
+		{"SYSTEM_DISABLED", model.ApplicationDisabled},
+		{"UNSPECIFIED", model.ApplicationUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			in := &appengine.Application{
+				Id:              "c2loud-test",
+				Name:            "apps/c2loud-test",
+				LocationId:      "us-central",
+				DefaultHostname: "c2loud-test.appspot.com",
+				ServingStatus:   tt.status,
+			}
+			want := &model.Application{
+				ID:       "c2loud-test",
+				Name:     "apps/c2loud-test",
+				Location: "us-central",
+				Hostname: "c2loud-test.appspot.com",
+				State:    tt.want,
+				Raw:      in,
+			}
+			if got := gcpcompute.ToApplication(in); !reflect.DeepEqual(got, want) {
+				t.Errorf("ToApplication = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestToFunction(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *cloudfunctions.CloudFunction
+		want *model.Function
+	}{
+		{
+			name: "http",
+			in: &cloudfunctions.CloudFunction{
+				Name:         "projects/p/locations/us-central1/functions/resize",
+				Runtime:      "go125",
+				EntryPoint:   "Resize",
+				Status:       "ACTIVE",
+				HttpsTrigger: &cloudfunctions.HttpsTrigger{Url: "https://us-central1-p.cloudfunctions.net/resize"},
+				Labels:       map[string]string{"team": "ops"},
+				UpdateTime:   "2026-03-01T12:00:00Z",
+			},
+			want: &model.Function{
+				ID:         "projects/p/locations/us-central1/functions/resize",
+				Name:       "resize",
+				Runtime:    "go125",
+				EntryPoint: "Resize",
+				State:      model.FunctionActive,
+				URL:        "https://us-central1-p.cloudfunctions.net/resize",
+				Labels:     map[string]string{"team": "ops"},
+				UpdatedAt:  created,
+			},
+		},
+		{
+			name: "failed",
+			in:   &cloudfunctions.CloudFunction{Name: "projects/p/locations/us-central1/functions/ingest", Status: "OFFLINE"},
+			want: &model.Function{ID: "projects/p/locations/us-central1/functions/ingest", Name: "ingest", State: model.FunctionError},
+		},
+		{
+			name: "deploying",
+			in:   &cloudfunctions.CloudFunction{Name: "projects/p/locations/us-central1/functions/ingest", Status: "DEPLOY_IN_PROGRESS"},
+			want: &model.Function{ID: "projects/p/locations/us-central1/functions/ingest", Name: "ingest", State: model.FunctionDeploying},
+		},
+		{
+			name: "unspecified",
+			in:   &cloudfunctions.CloudFunction{Name: "projects/p/locations/us-central1/functions/ingest"},
+			want: &model.Function{ID: "projects/p/locations/us-central1/functions/ingest", Name: "ingest", State: model.FunctionUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := gcpcompute.ToFunction(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToFunction = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
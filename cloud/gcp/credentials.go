@@ -0,0 +1,157 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+	pubsubapi "cloud.google.com/go/pubsub/apiv1"
+	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2/google"
+	appengine "google.golang.org/api/appengine/v1"
+	cloudfunctions "google.golang.org/api/cloudfunctions/v1"
+	compute "google.golang.org/api/compute/v1"
+	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+// cloudPlatformScope grants access to all Google Cloud APIs. It is requested
+// for the source credentials of an impersonation chain.
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// defaultScopes are the OAuth scopes requested for each service unless
+// overridden by GCPConfig.ServiceScopes.
+var defaultScopes = map[string][]string{
+	"compute":        {compute.ComputeScope},
+	"appengine":      {appengine.CloudPlatformScope},
+	"container":      {container.CloudPlatformScope},
+	"cloudfunctions": {cloudfunctions.CloudPlatformScope},
+	"storage":        {storage.ScopeFullControl},
+	"pubsub":         pubsubapi.DefaultAuthScopes(),
+}
+
+// credentialTypes are the credential file types accepted in
+// GCPConfig.Credentials.
+var credentialTypes = []google.CredentialsType{
+	google.ServiceAccount,
+	google.AuthorizedUser,
+	google.ExternalAccount,
+	google.ExternalAccountAuthorizedUser,
+	google.ImpersonatedServiceAccount,
+}
+
+// Scopes returns the OAuth scopes requested for service.
+func (c *GCPConfig) Scopes(service string) []string {
+	if scopes, ok := c.ServiceScopes[service]; ok {
+		return scopes
+	}
+	return defaultScopes[service]
+}
+
+// authenticator creates the credentials of each service from one source, so
+// that every service is granted only its own scopes.
+type authenticator struct {
+	config *GCPConfig
+	// json is the content of the credentials file, or nil when using the
+	// metadata server or Application Default Credentials.
+	json     []byte
+	credType google.CredentialsType
+}
+
+// newAuthenticator resolves the credentials source of config:
+//
+//   - the metadata server of the instance, if UseIAMRole is set;
+//   - Credentials, either inline JSON or the path of a JSON file, holding a
+//     service account key, user credentials or an external account
+//     configuration for workload identity federation from AWS or an OIDC
+//     provider;
+//   - otherwise Application Default Credentials.
+//
+// Credentials are trusted as is; do not pass configurations from untrusted
+// sources, as external accounts may point at arbitrary URLs.
+func newAuthenticator(config *GCPConfig) (*authenticator, error) {
+	a := &authenticator{config: config}
+	switch {
+	case config.UseIAMRole:
+		if !metadata.OnGCE() {
+			return nil, fmt.Errorf("UseIAMRole is true, but not running on GCE or App Engine")
+		}
+	case config.Credentials != "":
+		a.json = []byte(config.Credentials)
+		if !strings.HasPrefix(strings.TrimSpace(config.Credentials), "{") {
+			data, err := os.ReadFile(config.Credentials)
+			if err != nil {
+				return nil, fmt.Errorf("gcp: reading credentials: %w", err)
+			}
+			a.json = data
+		}
+		var f struct {
+			Type google.CredentialsType `json:"type"`
+		}
+		if err := json.Unmarshal(a.json, &f); err != nil {
+			return nil, fmt.Errorf("gcp: parsing credentials: %w", err)
+		}
+		if !validCredentialType(f.Type) {
+			return nil, fmt.Errorf("gcp: unsupported credentials type %q", f.Type)
+		}
+		a.credType = f.Type
+	}
+	return a, nil
+}
+
+func validCredentialType(t google.CredentialsType) bool {
+	for _, valid := range credentialTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// clientOption returns the authentication option for service. When
+// ImpersonateServiceAccount is set, the source credentials are exchanged for
+// tokens of that service account, through Delegates if any.
+func (a *authenticator) clientOption(ctx context.Context, service string) (option.ClientOption, error) {
+	scopes := a.config.Scopes(service)
+	if a.config.ImpersonateServiceAccount == "" {
+		return a.source(ctx, scopes)
+	}
+
+	source, err := a.source(ctx, []string{cloudPlatformScope})
+	if err != nil {
+		return nil, err
+	}
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: a.config.ImpersonateServiceAccount,
+		Delegates:       a.config.Delegates,
+		Scopes:          scopes,
+		Lifetime:        a.config.ImpersonationLifetime,
+	}, source)
+	if err != nil {
+		return nil, fmt.Errorf("gcp: impersonating %s: %w", a.config.ImpersonateServiceAccount, err)
+	}
+	return option.WithTokenSource(ts), nil
+}
+
+// source returns the source credentials for scopes.
+func (a *authenticator) source(ctx context.Context, scopes []string) (option.ClientOption, error) {
+	if a.config.UseIAMRole {
+		return option.WithTokenSource(google.ComputeTokenSource("", scopes...)), nil
+	}
+
+	var creds *google.Credentials
+	var err error
+	if a.json != nil {
+		creds, err = google.CredentialsFromJSONWithType(ctx, a.json, a.credType, scopes...)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, scopes...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gcp: loading credentials: %w", err)
+	}
+	return option.WithCredentials(creds), nil
+}
@@ -0,0 +1,266 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+const (
+	sourceAccount = "runner@c2loud-test.iam.gserviceaccount.com"
+	targetAccount = "deployer@c2loud-test.iam.gserviceaccount.com"
+)
+
+// fakeGoogleAuth issues OAuth tokens and impersonated tokens which name the
+// scopes they were requested for, and echoes the token of other requests.
+type fakeGoogleAuth struct {
+	mu            sync.Mutex
+	impersonation []impersonationRequest
+}
+
+type impersonationRequest struct {
+	path, authorization string
+	body                struct {
+		Delegates []string `json:"delegates"`
+		Scope     []string `json:"scope"`
+		Lifetime  string   `json:"lifetime"`
+	}
+}
+
+func (f *fakeGoogleAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/token":
+		// A service account exchanges a JWT assertion naming the scopes.
+		_, payload, _ := strings.Cut(r.FormValue("assertion"), ".")
+		payload, _, _ = strings.Cut(payload, ".")
+		data, _ := base64.RawURLEncoding.DecodeString(payload)
+		var claims struct {
+			Scope string `json:"scope"`
+		}
+		json.Unmarshal(data, &claims)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "sa:" + claims.Scope,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	case strings.HasSuffix(r.URL.Path, ":generateAccessToken"):
+		req := impersonationRequest{path: r.URL.Path, authorization: r.Header.Get("Authorization")}
+		json.NewDecoder(r.Body).Decode(&req.body)
+		f.mu.Lock()
+		f.impersonation = append(f.impersonation, req)
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{
+			"accessToken": "impersonated:" + strings.Join(req.body.Scope, " "),
+			"expireTime":  time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	default:
+		io.WriteString(w, r.Header.Get("Authorization"))
+	}
+}
+
+// fakeGoogle serves auth, and routes every connection of the default
+// transport to it, whatever the host, until the test ends.
+func fakeGoogle(t *testing.T) *fakeGoogleAuth {
+	t.Helper()
+	auth := &fakeGoogleAuth{}
+	server := httptest.NewTLSServer(auth)
+	t.Cleanup(server.Close)
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	original := http.DefaultTransport
+	http.DefaultTransport = transport
+	t.Cleanup(func() {
+		http.DefaultTransport = original
+		transport.CloseIdleConnections()
+	})
+	return auth
+}
+
+// serviceAccountKey returns the JSON key of sourceAccount.
+func serviceAccountKey(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "c2loud-test",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   sourceAccount,
+		"client_id":      "1",
+		"token_uri":      "https://oauth2.googleapis.com/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// writeKey writes key to a file and returns its path.
+func writeKey(t *testing.T, key string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, []byte(key), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// authorization returns the Authorization header sent by a client
+// authenticated with opt.
+func authorization(t *testing.T, opt option.ClientOption) string {
+	t.Helper()
+	client, _, err := htransport.NewClient(context.Background(), opt)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := client.Get("https://probe.googleapis.com/")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return string(data)
+}
+
+func TestAuthenticatorCredentials(t *testing.T) {
+	fakeGoogle(t)
+	key := serviceAccountKey(t)
+	tests := []struct {
+		name   string
+		config *GCPConfig
+		adc    string
+	}{
+		{"path", &GCPConfig{Credentials: writeKey(t, key)}, ""},
+		{"inline", &GCPConfig{Credentials: "\n  " + key}, ""},
+		{"application default", &GCPConfig{}, writeKey(t, key)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", tt.adc)
+			a, err := newAuthenticator(tt.config)
+			if err != nil {
+				t.Fatalf("newAuthenticator: %v", err)
+			}
+			opt, err := a.clientOption(context.Background(), "storage")
+			if err != nil {
+				t.Fatalf("clientOption: %v", err)
+			}
+			if got, want := authorization(t, opt), "Bearer sa:https://www.googleapis.com/auth/devstorage.full_control"; got != want {
+				t.Errorf("Authorization = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorErrors(t *testing.T) {
+	tests := []struct {
+		name, credentials, want string
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "missing.json"), "reading credentials"},
+		{"invalid JSON", "{not json", "parsing credentials"},
+		{"unsupported type", `{"type": "gdch_service_account"}`, `unsupported credentials type "gdch_service_account"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAuthenticator(&GCPConfig{Credentials: tt.credentials})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("newAuthenticator: got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorScopes(t *testing.T) {
+	fakeGoogle(t)
+	config := &GCPConfig{
+		Credentials:   serviceAccountKey(t),
+		ServiceScopes: map[string][]string{"storage": {"https://www.googleapis.com/auth/devstorage.read_only"}},
+	}
+	a, err := newAuthenticator(config)
+	if err != nil {
+		t.Fatalf("newAuthenticator: %v", err)
+	}
+	for service, want := range map[string]string{
+		"storage": "https://www.googleapis.com/auth/devstorage.read_only",
+		"compute": "https://www.googleapis.com/auth/compute",
+		"pubsub":  "https://www.googleapis.com/auth/cloud-platform https://www.googleapis.com/auth/pubsub",
+	} {
+		opt, err := a.clientOption(context.Background(), service)
+		if err != nil {
+			t.Fatalf("clientOption(%s): %v", service, err)
+		}
+		if got := authorization(t, opt); got != "Bearer sa:"+want {
+			t.Errorf("%s Authorization = %q, want scopes %q", service, got, want)
+		}
+	}
+}
+
+func TestAuthenticatorImpersonation(t *testing.T) {
+	auth := fakeGoogle(t)
+	config := &GCPConfig{
+		Credentials:               serviceAccountKey(t),
+		ImpersonateServiceAccount: targetAccount,
+		Delegates:                 []string{"relay@c2loud-test.iam.gserviceaccount.com"},
+		ImpersonationLifetime:     15 * time.Minute,
+	}
+	a, err := newAuthenticator(config)
+	if err != nil {
+		t.Fatalf("newAuthenticator: %v", err)
+	}
+	opt, err := a.clientOption(context.Background(), "compute")
+	if err != nil {
+		t.Fatalf("clientOption: %v", err)
+	}
+	if got, want := authorization(t, opt), "Bearer impersonated:https://www.googleapis.com/auth/compute"; got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+
+	if len(auth.impersonation) != 1 {
+		t.Fatalf("got %d impersonation requests, want 1", len(auth.impersonation))
+	}
+	req := auth.impersonation[0]
+	if want := "/v1/projects/-/serviceAccounts/" + targetAccount + ":generateAccessToken"; req.path != want {
+		t.Errorf("path = %q, want %q", req.path, want)
+	}
+	// The source credentials are scoped to the whole platform, the
+	// impersonated token to the service.
+	if want := "Bearer sa:" + cloudPlatformScope; req.authorization != want {
+		t.Errorf("source Authorization = %q, want %q", req.authorization, want)
+	}
+	if got := req.body.Delegates; len(got) != 1 || got[0] != "projects/-/serviceAccounts/relay@c2loud-test.iam.gserviceaccount.com" {
+		t.Errorf("delegates = %v", got)
+	}
+	if req.body.Lifetime != "900s" {
+		t.Errorf("lifetime = %q, want 900s", req.body.Lifetime)
+	}
+}
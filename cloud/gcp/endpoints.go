@@ -0,0 +1,93 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gcppubsub "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/pubsub"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// grpcServices are the services whose clients use gRPC rather than HTTP.
+var grpcServices = map[string]bool{
+	"pubsub": true,
+}
+
+// clientOptions returns the options of the client of service: its
+// authentication, endpoint and transport, instrumented with in.
+//
+// An endpoint with the http scheme, such as that of fake-gcs-server or the
+// Pub/Sub emulator, is treated as a local emulator: it is reached in plain
+// text and without authentication.
+func (c *GCPConfig) clientOptions(ctx context.Context, auth *authenticator, in *telemetry.Instrumentation, service string) ([]option.ClientOption, error) {
+	tlsConfig, err := helper.TLSConfig(c.CABundle, c.InsecureSkipVerify)
+	if err != nil {
+		return nil, fmt.Errorf("gcp: %w", err)
+	}
+	client, err := helper.HTTPClient(c.HTTPClient, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("gcp: %w", err)
+	}
+	if in != nil && client == nil {
+		client = &http.Client{}
+	}
+
+	endpoint := c.Endpoints[service]
+	if strings.HasPrefix(endpoint, "http://") {
+		if grpcServices[service] {
+			opts := gcppubsub.EmulatorOptions(strings.TrimPrefix(endpoint, "http://"))
+			return append(opts, instrumentOptions(in, service)...), nil
+		}
+		opts := []option.ClientOption{option.WithEndpoint(endpoint), option.WithoutAuthentication()}
+		if client != nil {
+			instrumented := *client
+			instrumented.Transport = instrumentTransport(in, service, transportOf(client))
+			opts = append(opts, option.WithHTTPClient(&instrumented))
+		}
+		return opts, nil
+	}
+
+	authOption, err := auth.clientOption(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	opts := []option.ClientOption{authOption}
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+
+	switch {
+	case grpcServices[service]:
+		if tlsConfig != nil {
+			opts = append(opts, option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))))
+		}
+		opts = append(opts, instrumentOptions(in, service)...)
+	case client != nil:
+		// option.WithHTTPClient bypasses authentication, so the client's
+		// transport is wrapped in an authenticated one first.
+		rt, err := htransport.NewTransport(ctx, transportOf(client), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("gcp: creating %s transport: %w", service, err)
+		}
+		authed := *client
+		authed.Transport = instrumentTransport(in, service, rt)
+		opts = append(opts, option.WithHTTPClient(&authed))
+	}
+	return opts, nil
+}
+
+// transportOf returns the transport of client, or the default transport.
+func transportOf(client *http.Client) http.RoundTripper {
+	if client.Transport == nil {
+		return http.DefaultTransport
+	}
+	return client.Transport
+}
@@ -2,102 +2,244 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sync"
-
-	"cloud.google.com/go/compute/metadata"
-	"golang.org/x/oauth2/google"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/cache"
+	gcpcompute "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/compute"
+	gcppubsub "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/pubsub"
+	gcpstorage "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/storage"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+
+	pubsubapi "cloud.google.com/go/pubsub/apiv1"
+	"cloud.google.com/go/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	appengine "google.golang.org/api/appengine/v1"
 	cloudfunctions "google.golang.org/api/cloudfunctions/v1"
 	compute "google.golang.org/api/compute/v1"
 	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/option"
 )
 
 // GCPConfig defines the configuration for GCPProvider.
 type GCPConfig struct {
-	UseIAMRole  bool
-	Credentials string // Path to JSON key file
+	UseIAMRole bool // Use the metadata server of the GCE instance
+	// Credentials is the path of a credentials JSON file, or its content.
+	// When empty and UseIAMRole is false, Application Default Credentials
+	// are used.
+	Credentials string
 	ProjectID   string
 	Region      string
+	Zone        string // Default zone for VM instances
+
+	// ImpersonateServiceAccount is the email of a service account whose
+	// tokens are used in place of the credentials above.
+	ImpersonateServiceAccount string
+	// Delegates are the service accounts between the credentials and
+	// ImpersonateServiceAccount in an impersonation chain.
+	Delegates []string
+	// ImpersonationLifetime is the lifetime of impersonated tokens. Zero
+	// means one hour.
+	ImpersonationLifetime time.Duration
+	// ServiceScopes overrides the OAuth scopes requested per service, keyed
+	// like ServiceRetry.
+	ServiceScopes map[string][]string
+
+	// Endpoints overrides service endpoints, keyed like ServiceRetry. An
+	// http:// endpoint, such as "http://localhost:4443/storage/v1/" for
+	// fake-gcs-server or "http://localhost:8085" for the Pub/Sub emulator,
+	// is used without TLS or authentication.
+	Endpoints map[string]string
+	// CABundle is the path of a PEM file of certificate authorities trusted
+	// in addition to the system roots.
+	CABundle string
+	// InsecureSkipVerify disables TLS certificate verification. It is meant
+	// for local development only.
+	InsecureSkipVerify bool
+	// HTTPClient replaces the default HTTP client of the HTTP-based
+	// services. It is wrapped to add authentication.
+	HTTPClient *http.Client
+
+	// Retry is the retry policy for all services. Nil means
+	// retry.DefaultPolicy().
+	Retry *retry.Policy
+	// ServiceRetry overrides Retry per service. Keys are "compute",
+	// "appengine", "container", "cloudfunctions", "storage" and "pubsub".
+	ServiceRetry map[string]*retry.Policy
+
+	// TracerProvider, when set, traces every request to Google Cloud. See
+	// package telemetry.
+	TracerProvider trace.TracerProvider
+	// MetricsRegisterer, when set, is where the metrics of requests to Google
+	// Cloud are registered. See package telemetry.
+	MetricsRegisterer prometheus.Registerer
+	// Logger, when set, logs every request to Google Cloud. ServiceLogLevels
+	// raises the minimum level per service, keyed like ServiceRetry, and
+	// LogBodies adds request and response bodies at debug level. Secrets and
+	// message data are redacted. See package telemetry.
+	Logger           *slog.Logger
+	ServiceLogLevels map[string]slog.Level
+	LogBodies        bool
+}
+
+// RetryPolicy returns the retry policy configured for service, for use with
+// the service constructors in package compute.
+func (c *GCPConfig) RetryPolicy(service string) *retry.Policy {
+	return retry.ForService(service, c.Retry, c.ServiceRetry)
 }
 
 // GCPProvider holds the clients for various GCP services.
 type GCPProvider struct {
 	ComputeService          *compute.Service
-	AppEngineService        *appengine.Service
+	AppEngineService        *appengine.APIService
 	KubernetesEngineService *container.Service
 	CloudFunctionsService   *cloudfunctions.Service
+	StorageClient           *storage.Client
+	PubSubPublisher         *pubsubapi.PublisherClient
+	PubSubSubscriber        *pubsubapi.SubscriberClient
 	// ... add other service clients as needed ...
 }
 
-var (
-	instances = make(map[string]*GCPProvider) // Holds the map of GCPProvider instances
-	mutex     sync.Mutex                      // Ensures thread-safe access to the instances map
-)
+// instances caches the GCPProvider instances returned by GetInstance.
+var instances = NewCache()
+
+func init() {
+	cloud.Register(cloud.GCPProvider, newCloudProvider)
+}
 
 // NewGCPProvider creates a new GCPProvider with all the necessary service clients.
 func NewGCPProvider(ctx context.Context, config *GCPConfig) (*GCPProvider, error) {
-	var clientOption google.ClientOption
-
-	if config.UseIAMRole {
-		if metadata.OnGCE() {
-			// Use the App Engine default service account
-			clientOption = google.ComputeTokenSource("")
-		} else {
-			return nil, fmt.Errorf("UseIAMRole is true, but not running on GCE or App Engine")
-		}
-	} else {
-		creds, err := google.CredentialsFromJSON(ctx, []byte(config.Credentials), compute.ComputeScope)
-		if err != nil {
+	auth, err := newAuthenticator(config)
+	if err != nil {
+		return nil, err
+	}
+	in, err := telemetry.New(cloud.GCPProvider, config.telemetryOptions())
+	if err != nil {
+		return nil, err
+	}
+	// Each service is authorized with its own scopes.
+	opts := make(map[string][]option.ClientOption)
+	for service := range defaultScopes {
+		if opts[service], err = config.clientOptions(ctx, auth, in, service); err != nil {
 			return nil, err
 		}
-		clientOption = google.WithCredentials(creds)
 	}
 
-	computeService, err := compute.NewService(ctx, clientOption)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create Compute service: %v", err)
+	// The provider is filled in as clients are created, so that those created
+	// before a failure are closed.
+	p := &GCPProvider{}
+	if p.ComputeService, err = compute.NewService(ctx, opts["compute"]...); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("Failed to create Compute service: %w", err)
 	}
 
-	appengineService, err := appengine.NewService(ctx, clientOption)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create App Engine service: %v", err)
+	if p.AppEngineService, err = appengine.NewService(ctx, opts["appengine"]...); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("Failed to create App Engine service: %w", err)
 	}
 
-	kubernetesService, err := container.NewService(ctx, clientOption)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create Kubernetes Engine service: %v", err)
+	if p.KubernetesEngineService, err = container.NewService(ctx, opts["container"]...); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("Failed to create Kubernetes Engine service: %w", err)
 	}
 
-	cloudfunctionsService, err := cloudfunctions.NewService(ctx, clientOption)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create Cloud Functions service: %v", err)
+	if p.CloudFunctionsService, err = cloudfunctions.NewService(ctx, opts["cloudfunctions"]...); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("Failed to create Cloud Functions service: %w", err)
 	}
 
-	return &GCPProvider{
-		ComputeService:          computeService,
-		AppEngineService:        appengineService,
-		KubernetesEngineService: kubernetesService,
-		CloudFunctionsService:   cloudfunctionsService,
-		// ... initialize other services ...
-	}, nil
-}
+	if p.StorageClient, err = storage.NewClient(ctx, opts["storage"]...); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("Failed to create Cloud Storage client: %w", err)
+	}
+	p.StorageClient.SetRetry(gcpstorage.RetryOptions(config.RetryPolicy("storage"))...)
 
-func GetInstance(ctx context.Context, key string, config *GCPConfig) (*GCPProvider, error) {
-	mutex.Lock()
-	defer mutex.Unlock()
+	if p.PubSubPublisher, p.PubSubSubscriber, err = gcppubsub.NewClients(ctx, opts["pubsub"]...); err != nil {
+		p.Close()
+		return nil, err
+	}
+	// ... initialize other services, closing p on failure ...
+
+	return p, nil
+}
 
-	if instance, exists := instances[key]; exists {
-		// Return existing instance
-		return instance, nil
+// newCloudProvider is the cloud.Factory for GCP. It accepts a *GCPConfig.
+func newCloudProvider(ctx context.Context, config interface{}) (*cloud.CloudProvider, error) {
+	cfg, ok := config.(*GCPConfig)
+	if !ok || cfg == nil {
+		return nil, fmt.Errorf("gcp: expected *GCPConfig, got %T", config)
 	}
 
-	// Create a new instance if it does not exist
-	instance, err := NewGCPProvider(ctx, config)
+	provider, err := NewGCPProvider(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
+	return &cloud.CloudProvider{
+		ObjectStore: gcpstorage.NewObjectStore(provider.StorageClient, cfg.ProjectID),
+		Queue:       gcppubsub.NewQueue(provider.PubSubPublisher, provider.PubSubSubscriber, cfg.ProjectID, cfg.RetryPolicy("pubsub")),
+		Topic:       gcppubsub.NewTopic(provider.PubSubPublisher, provider.PubSubSubscriber, cfg.ProjectID, cfg.RetryPolicy("pubsub")),
+		Compute:     gcpcompute.NewCompute(gcpcompute.NewComputeEngineService(provider.ComputeService, cfg.RetryPolicy("compute")), cfg.ProjectID, cfg.Zone),
+		Native:      provider,
+		Closer:      provider,
+	}, nil
+}
+
+// Close closes the Cloud Storage and Pub/Sub clients. The other services hold
+// no resources that need releasing.
+func (p *GCPProvider) Close() error {
+	var errs []error
+	if p.StorageClient != nil {
+		errs = append(errs, p.StorageClient.Close())
+	}
+	if p.PubSubPublisher != nil {
+		errs = append(errs, p.PubSubPublisher.Close())
+	}
+	if p.PubSubSubscriber != nil {
+		errs = append(errs, p.PubSubSubscriber.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ProviderCache caches GCPProvider instances by key.
+type ProviderCache = cache.Cache[*GCPConfig, *GCPProvider]
+
+// NewCache returns an empty ProviderCache, for callers that manage provider
+// lifetimes themselves rather than through GetInstance.
+func NewCache() *ProviderCache {
+	return cache.New(NewGCPProvider)
+}
+
+// GetInstance returns the GCPProvider cached under key, creating it from
+// config on first use. It fails with cache.ErrConfigMismatch if key was
+// created from a different config.
+//
+// Configs are compared by value, except for HTTPClient, TracerProvider,
+// MetricsRegisterer, Logger and the Retryable functions of the retry
+// policies, which are compared by identity: pass the same ones to get the
+// cached provider. Nothing in the config is left out of the comparison.
+func GetInstance(ctx context.Context, key string, config *GCPConfig) (*GCPProvider, error) {
+	return instances.Get(ctx, key, config)
+}
+
+// RefreshInstance replaces the GCPProvider cached under key with one created
+// from config, such as after rotating its service account key, and closes the
+// old one.
+func RefreshInstance(ctx context.Context, key string, config *GCPConfig) (*GCPProvider, error) {
+	return instances.Refresh(ctx, key, config)
+}
+
+// EvictInstance removes the GCPProvider cached under key and closes it.
+func EvictInstance(key string) error {
+	return instances.Evict(key)
+}
 
-	instances[key] = instance
-	return instance, nil
+// CloseInstances closes and removes every cached GCPProvider.
+func CloseInstances() error {
+	return instances.Close()
 }
@@ -0,0 +1,84 @@
+package pubsub
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+)
+
+// ToMessage converts a received Pub/Sub message to a model.Message.
+func ToMessage(m *pubsubpb.ReceivedMessage) *model.Message {
+	msg := &model.Message{
+		AckHandle:    m.AckId,
+		ReceiveCount: int(m.DeliveryAttempt),
+		Raw:          m,
+	}
+	if pm := m.Message; pm != nil {
+		msg.ID = pm.MessageId
+		msg.Body = pm.Data
+		msg.Attributes = pm.Attributes
+		if pm.PublishTime != nil {
+			msg.SentAt = pm.PublishTime.AsTime()
+		}
+	}
+	return msg
+}
+
+// ToTopic converts a Pub/Sub topic to a model.Topic.
+func ToTopic(t *pubsubpb.Topic) *model.Topic {
+	return &model.Topic{
+		ID:   t.Name,
+		Name: path.Base(t.Name),
+		Raw:  t,
+	}
+}
+
+// ToSubscription converts a Pub/Sub subscription to a model.Subscription.
+// Push subscriptions report the scheme of their push endpoint, "http" or
+// "https", as accepted by Topic.Subscribe; pull subscriptions have the protocol
+// "pull".
+func ToSubscription(s *pubsubpb.Subscription) *model.Subscription {
+	sub := &model.Subscription{
+		ID:       s.Name,
+		Topic:    s.Topic,
+		Protocol: "pull",
+		State:    model.SubscriptionUnknown,
+		Raw:      s,
+	}
+	if s.PushConfig != nil && s.PushConfig.PushEndpoint != "" {
+		sub.Protocol = "https"
+		if u, err := url.Parse(s.PushConfig.PushEndpoint); err == nil && u.Scheme == "http" {
+			sub.Protocol = "http"
+		}
+		sub.Endpoint = s.PushConfig.PushEndpoint
+	}
+	switch s.State {
+	case pubsubpb.Subscription_ACTIVE:
+		sub.State = model.SubscriptionActive
+	case pubsubpb.Subscription_RESOURCE_ERROR:
+		sub.State = model.SubscriptionError
+	}
+	return sub
+}
+
+// ToQueue converts a Pub/Sub subscription, which backs a cloud.Queue, to a
+// model.Queue. Pub/Sub does not report a message count.
+func ToQueue(s *pubsubpb.Subscription) *model.Queue {
+	attrs := map[string]string{
+		"Topic":              s.Topic,
+		"AckDeadlineSeconds": strconv.Itoa(int(s.AckDeadlineSeconds)),
+	}
+	if s.Filter != "" {
+		attrs["Filter"] = s.Filter
+	}
+	return &model.Queue{
+		ID:         s.Name,
+		Name:       path.Base(s.Name),
+		Attributes: attrs,
+		Raw:        s,
+	}
+}
@@ -0,0 +1,129 @@
+package pubsub_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	gcppubsub "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/pubsub"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestToMessage(t *testing.T) {
+	published := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   *pubsubpb.ReceivedMessage
+		want *model.Message
+	}{
+		{
+			name: "received",
+			in: &pubsubpb.ReceivedMessage{
+				AckId:           "ack-1",
+				DeliveryAttempt: 2,
+				Message: &pubsubpb.PubsubMessage{
+					MessageId:   "m-1",
+					Data:        []byte("job"),
+					Attributes:  map[string]string{"kind": "build"},
+					PublishTime: timestamppb.New(published),
+				},
+			},
+			want: &model.Message{
+				ID:           "m-1",
+				Body:         []byte("job"),
+				Attributes:   map[string]string{"kind": "build"},
+				AckHandle:    "ack-1",
+				ReceiveCount: 2,
+				SentAt:       published,
+			},
+		},
+		{
+			name: "without message",
+			in:   &pubsubpb.ReceivedMessage{AckId: "ack-2"},
+			want: &model.Message{AckHandle: "ack-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := gcppubsub.ToMessage(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToMessage = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToTopic(t *testing.T) {
+	in := &pubsubpb.Topic{Name: "projects/c2loud-test/topics/events"}
+	want := &model.Topic{ID: "projects/c2loud-test/topics/events", Name: "events", Raw: in}
+	if got := gcppubsub.ToTopic(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToTopic = %+v, want %+v", got, want)
+	}
+}
+
+func TestToSubscription(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *pubsubpb.Subscription
+		want *model.Subscription
+	}{
+		{
+			name: "pull",
+			in:   &pubsubpb.Subscription{Name: "s", Topic: "t", State: pubsubpb.Subscription_ACTIVE},
+			want: &model.Subscription{ID: "s", Topic: "t", Protocol: "pull", State: model.SubscriptionActive},
+		},
+		{
+			name: "http",
+			in:   &pubsubpb.Subscription{PushConfig: &pubsubpb.PushConfig{PushEndpoint: "http://example.com/hook"}},
+			want: &model.Subscription{Protocol: "http", Endpoint: "http://example.com/hook", State: model.SubscriptionUnknown},
+		},
+		{
+			name: "https",
+			in:   &pubsubpb.Subscription{PushConfig: &pubsubpb.PushConfig{PushEndpoint: "https://example.com/hook"}},
+			want: &model.Subscription{Protocol: "https", Endpoint: "https://example.com/hook", State: model.SubscriptionUnknown},
+		},
+		{
+			name: "error",
+			in:   &pubsubpb.Subscription{State: pubsubpb.Subscription_RESOURCE_ERROR},
+			want: &model.Subscription{Protocol: "pull", State: model.SubscriptionError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := gcppubsub.ToSubscription(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToSubscription = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *pubsubpb.Subscription
+		want map[string]string
+	}{
+		{
+			name: "filtered",
+			in:   &pubsubpb.Subscription{Name: "projects/c2loud-test/subscriptions/jobs", Topic: "projects/c2loud-test/topics/jobs", AckDeadlineSeconds: 30, Filter: `attributes.kind = "build"`},
+			want: map[string]string{"Topic": "projects/c2loud-test/topics/jobs", "AckDeadlineSeconds": "30", "Filter": `attributes.kind = "build"`},
+		},
+		{
+			name: "unfiltered",
+			in:   &pubsubpb.Subscription{Name: "projects/c2loud-test/subscriptions/jobs", Topic: "projects/c2loud-test/topics/jobs", AckDeadlineSeconds: 10},
+			want: map[string]string{"Topic": "projects/c2loud-test/topics/jobs", "AckDeadlineSeconds": "10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &model.Queue{ID: "projects/c2loud-test/subscriptions/jobs", Name: "jobs", Attributes: tt.want, Raw: tt.in}
+			if got := gcppubsub.ToQueue(tt.in); !reflect.DeepEqual(got, want) {
+				t.Errorf("ToQueue = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	pubsubapi "cloud.google.com/go/pubsub/apiv1"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// emulatorHostEnv is the environment variable set by `gcloud beta emulators
+// pubsub env-init` to point clients at a local Pub/Sub emulator.
+const emulatorHostEnv = "PUBSUB_EMULATOR_HOST"
+
+// NewClients creates the Pub/Sub publisher and subscriber clients. When
+// PUBSUB_EMULATOR_HOST is set, opts are ignored and the clients connect to the
+// emulator without authentication.
+func NewClients(ctx context.Context, opts ...option.ClientOption) (*pubsubapi.PublisherClient, *pubsubapi.SubscriberClient, error) {
+	if addr := os.Getenv(emulatorHostEnv); addr != "" {
+		opts = EmulatorOptions(addr)
+	}
+
+	publisher, err := pubsubapi.NewPublisherClient(ctx, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create Pub/Sub publisher client: %w", err)
+	}
+
+	subscriber, err := pubsubapi.NewSubscriberClient(ctx, opts...)
+	if err != nil {
+		publisher.Close()
+		return nil, nil, fmt.Errorf("Failed to create Pub/Sub subscriber client: %w", err)
+	}
+	return publisher, subscriber, nil
+}
+
+// EmulatorOptions returns the client options that connect to the Pub/Sub
+// emulator at addr, given as host:port, over plaintext gRPC without
+// authentication.
+func EmulatorOptions(addr string) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(addr),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithoutAuthentication(),
+	}
+}
+
+// resourceName expands a short Pub/Sub resource ID into its full
+// "projects/<project>/<collection>/<id>" name. Full names are returned as is.
+func resourceName(projectID, collection, id string) string {
+	if strings.HasPrefix(id, "projects/") {
+		return id
+	}
+	return fmt.Sprintf("projects/%s/%s/%s", projectID, collection, id)
+}
+
+// callOptions applies policy to a single Pub/Sub RPC in place of the client
+// library's default retry settings. A nil policy keeps the defaults.
+func callOptions(policy *retry.Policy, idempotent bool) []gax.CallOption {
+	if policy == nil {
+		return nil
+	}
+	return []gax.CallOption{gax.WithRetry(func() gax.Retryer {
+		return &retryer{policy: policy, idempotent: idempotent}
+	})}
+}
+
+// retryer adapts a retry.Policy to gax.Retryer. gax creates one per call.
+type retryer struct {
+	policy     *retry.Policy
+	idempotent bool
+	retries    int
+}
+
+func (r *retryer) Retry(err error) (time.Duration, bool) {
+	r.retries++
+	if r.retries >= r.policy.Attempts() || !r.policy.ShouldRetry(helper.GoogleError(err), r.idempotent) {
+		return 0, false
+	}
+	return r.policy.Backoff(r.retries), true
+}
@@ -0,0 +1,193 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	gcppubsub "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/pubsub"
+
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/pstest"
+)
+
+// newServices returns a Queue and a Topic backed by an in-memory Pub/Sub
+// server.
+func newServices(t *testing.T) (*gcppubsub.Queue, *gcppubsub.Topic, *pstest.Server) {
+	t.Helper()
+	server := pstest.NewServer()
+	t.Cleanup(func() { server.Close() })
+	t.Setenv("PUBSUB_EMULATOR_HOST", server.Addr)
+
+	publisher, subscriber, err := gcppubsub.NewClients(context.Background())
+	if err != nil {
+		t.Fatalf("NewClients: %v", err)
+	}
+	t.Cleanup(func() {
+		publisher.Close()
+		subscriber.Close()
+	})
+	return gcppubsub.NewQueue(publisher, subscriber, "c2loud-test", nil),
+		gcppubsub.NewTopic(publisher, subscriber, "c2loud-test", nil),
+		server
+}
+
+// receive receives from subscription until a message arrives, as the server
+// delivers published messages in the background.
+func receive(t *testing.T, queue *gcppubsub.Queue, subscription string) *cloud.Message {
+	t.Helper()
+	ctx := context.Background()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		messages, err := queue.Receive(ctx, subscription, &cloud.ReceiveOptions{WaitTime: time.Second})
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		if len(messages) > 0 {
+			return messages[0]
+		}
+	}
+	t.Fatal("Receive: no message")
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	queue, topic, _ := newServices(t)
+	if _, err := topic.CreateTopic(ctx, "jobs"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if _, err := topic.Subscribe(ctx, "jobs", &cloud.SubscribeOptions{Name: "jobs-queue"}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if _, err := queue.Send(ctx, "jobs-queue", &cloud.Message{Body: []byte("job-1"), Attributes: map[string]string{"kind": "build"}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	message := receive(t, queue, "jobs-queue")
+	if string(message.Body) != "job-1" || message.Attributes["kind"] != "build" {
+		t.Errorf("received %q with attributes %v", message.Body, message.Attributes)
+	}
+	if err := queue.Ack(ctx, "jobs-queue", message.AckHandle); err != nil {
+		t.Errorf("Ack: %v", err)
+	}
+	if messages, err := queue.Receive(ctx, "jobs-queue", nil); err != nil || len(messages) != 0 {
+		t.Errorf("Receive after Ack = %v, %v; want none", messages, err)
+	}
+
+	attrs, err := queue.Attributes(ctx, "jobs-queue")
+	if err != nil || attrs["Topic"] != "projects/c2loud-test/topics/jobs" {
+		t.Errorf("Attributes = %v, %v", attrs, err)
+	}
+}
+
+func TestQueueNack(t *testing.T) {
+	ctx := context.Background()
+	queue, topic, _ := newServices(t)
+	topic.CreateTopic(ctx, "jobs")
+	topic.Subscribe(ctx, "jobs", &cloud.SubscribeOptions{Name: "jobs-queue"})
+
+	if _, err := queue.Send(ctx, "jobs-queue", &cloud.Message{Body: []byte("job-1")}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	message := receive(t, queue, "jobs-queue")
+	if err := queue.Nack(ctx, "jobs-queue", message.AckHandle); err != nil {
+		t.Fatalf("Nack: %v", err)
+	}
+	if again := receive(t, queue, "jobs-queue"); string(again.Body) != "job-1" {
+		t.Errorf("redelivered %q, want job-1", again.Body)
+	}
+}
+
+func TestQueueSharedTopic(t *testing.T) {
+	ctx := context.Background()
+	queue, topic, server := newServices(t)
+	topic.CreateTopic(ctx, "events")
+	for _, name := range []string{"audit", "billing"} {
+		if _, err := topic.Subscribe(ctx, "events", &cloud.SubscribeOptions{Name: name}); err != nil {
+			t.Fatalf("Subscribe(%s): %v", name, err)
+		}
+	}
+
+	// Sending to one would deliver to both, so it is refused.
+	if _, err := queue.Send(ctx, "audit", &cloud.Message{Body: []byte("event")}); !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("Send to a shared topic: got %v, want ErrInvalidArgument", err)
+	}
+	if published := server.Messages(); len(published) != 0 {
+		t.Errorf("%d messages published to a shared topic", len(published))
+	}
+}
+
+func TestTopic(t *testing.T) {
+	ctx := context.Background()
+	queue, topic, _ := newServices(t)
+
+	name, err := topic.CreateTopic(ctx, "events")
+	if err != nil || name != "projects/c2loud-test/topics/events" {
+		t.Fatalf("CreateTopic = %q, %v", name, err)
+	}
+	if _, err := topic.CreateTopic(ctx, "events"); !errors.Is(err, cloud.ErrAlreadyExists) {
+		t.Errorf("CreateTopic of an existing topic: got %v, want ErrAlreadyExists", err)
+	}
+	if _, err := topic.Subscribe(ctx, "events", nil); !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("Subscribe without a name: got %v, want ErrInvalidArgument", err)
+	}
+	if _, err := topic.Subscribe(ctx, "events", &cloud.SubscribeOptions{Name: "mail", Protocol: "email"}); !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("Subscribe with email: got %v, want ErrInvalidArgument", err)
+	}
+
+	sub, err := topic.Subscribe(ctx, "events", &cloud.SubscribeOptions{
+		Name:       "audit",
+		Attributes: map[string]string{"AckDeadlineSeconds": "30"},
+	})
+	if err != nil || sub != "projects/c2loud-test/subscriptions/audit" {
+		t.Fatalf("Subscribe = %q, %v", sub, err)
+	}
+	if _, err := topic.Publish(ctx, "events", []byte("created"), map[string]string{"type": "user"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if message := receive(t, queue, "audit"); string(message.Body) != "created" || message.Attributes["type"] != "user" {
+		t.Errorf("received %q with attributes %v", message.Body, message.Attributes)
+	}
+
+	if err := topic.Unsubscribe(ctx, "audit"); err != nil {
+		t.Errorf("Unsubscribe: %v", err)
+	}
+	if _, err := queue.Attributes(ctx, "audit"); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("Attributes of a deleted subscription: got %v, want ErrNotFound", err)
+	}
+	if err := topic.DeleteTopic(ctx, "events"); err != nil {
+		t.Errorf("DeleteTopic: %v", err)
+	}
+	if _, err := topic.Publish(ctx, "events", []byte("lost"), nil); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("Publish to a deleted topic: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestTopicPushSubscription(t *testing.T) {
+	ctx := context.Background()
+	_, topic, server := newServices(t)
+	topic.CreateTopic(ctx, "events")
+
+	sub, err := topic.Subscribe(ctx, "events", &cloud.SubscribeOptions{Name: "hook", Protocol: "https", Endpoint: "https://example.com/hook"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	got, err := server.GServer.GetSubscription(ctx, &pubsubpb.GetSubscriptionRequest{Subscription: sub})
+	if err != nil || got.PushConfig.GetPushEndpoint() != "https://example.com/hook" {
+		t.Fatalf("subscription = %v, %v; want a push subscription", got, err)
+	}
+	if protocol := gcppubsub.ToSubscription(got).Protocol; protocol != "https" {
+		t.Errorf("Protocol = %q, want https", protocol)
+	}
+
+	for _, opts := range []*cloud.SubscribeOptions{
+		{Name: "plain", Protocol: "https", Endpoint: "http://example.com/hook"},
+		{Name: "missing", Protocol: "http"},
+	} {
+		if _, err := topic.Subscribe(ctx, "events", opts); !errors.Is(err, cloud.ErrInvalidArgument) {
+			t.Errorf("Subscribe(%s %q): got %v, want ErrInvalidArgument", opts.Protocol, opts.Endpoint, err)
+		}
+	}
+}
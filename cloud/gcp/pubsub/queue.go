@@ -0,0 +1,234 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	pubsubapi "cloud.google.com/go/pubsub/apiv1"
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"google.golang.org/api/iterator"
+)
+
+// Queue implements cloud.Queue on top of Pub/Sub pull subscriptions. Queue
+// names are subscription IDs (or full subscription names), and ack handles
+// are Pub/Sub ack IDs.
+//
+// Send publishes to the subscription's topic, which every subscription of
+// the topic receives. Each queue therefore needs a topic of its own: Send
+// fails with cloud.ErrInvalidArgument when the topic has other subscriptions.
+type Queue struct {
+	publisher  *pubsubapi.PublisherClient
+	subscriber *pubsubapi.SubscriberClient
+	projectID  string
+	retry      *retry.Policy
+
+	mu     sync.Mutex
+	topics map[string]string // subscription name -> topic name
+}
+
+var _ cloud.Queue = (*Queue)(nil)
+
+// NewQueue creates a new Queue. projectID is used to expand short
+// subscription IDs, and policy is applied to every RPC. The subscriptions
+// used as queues must be the only subscriptions of their topics.
+func NewQueue(publisher *pubsubapi.PublisherClient, subscriber *pubsubapi.SubscriberClient, projectID string, policy *retry.Policy) *Queue {
+	return &Queue{
+		publisher:  publisher,
+		subscriber: subscriber,
+		projectID:  projectID,
+		retry:      policy,
+		topics:     make(map[string]string),
+	}
+}
+
+// Send publishes msg to the topic the subscription is attached to, which
+// must have no other subscription.
+func (q *Queue) Send(ctx context.Context, subscription string, msg *cloud.Message) (string, error) {
+	topic, err := q.topic(ctx, q.subscription(subscription))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := q.publisher.Publish(ctx, &pubsubpb.PublishRequest{
+		Topic: topic,
+		Messages: []*pubsubpb.PubsubMessage{{
+			Data:       msg.Body,
+			Attributes: msg.Attributes,
+		}},
+	}, callOptions(q.retry, false)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", helper.GoogleError(err))
+	}
+	return resp.MessageIds[0], nil
+}
+
+// Receive pulls messages from the subscription. With a zero WaitTime the pull
+// gives up as soon as the server reports no messages.
+func (q *Queue) Receive(ctx context.Context, subscription string, opts *cloud.ReceiveOptions) ([]*cloud.Message, error) {
+	name := q.subscription(subscription)
+	req := &pubsubpb.PullRequest{
+		Subscription: name,
+		MaxMessages:  1,
+	}
+	pullCtx := ctx
+	if opts != nil {
+		if opts.MaxMessages > 0 {
+			req.MaxMessages = int32(opts.MaxMessages)
+		}
+		if opts.WaitTime > 0 {
+			var cancel context.CancelFunc
+			pullCtx, cancel = context.WithTimeout(ctx, opts.WaitTime)
+			defer cancel()
+		}
+	}
+	if opts == nil || opts.WaitTime <= 0 {
+		// Deprecated upstream, but the only way to make a unary pull not block.
+		req.ReturnImmediately = true
+	}
+
+	resp, err := q.subscriber.Pull(pullCtx, req, callOptions(q.retry, true)...)
+	if err != nil {
+		// An expired wait is an empty receive, not a failure.
+		if ctx.Err() == nil && errors.Is(pullCtx.Err(), context.DeadlineExceeded) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to receive messages: %w", helper.GoogleError(err))
+	}
+
+	messages := make([]*cloud.Message, 0, len(resp.ReceivedMessages))
+	ackIDs := make([]string, 0, len(resp.ReceivedMessages))
+	for _, m := range resp.ReceivedMessages {
+		messages = append(messages, ToMessage(m))
+		ackIDs = append(ackIDs, m.AckId)
+	}
+
+	if opts != nil && opts.VisibilityTimeout > 0 && len(ackIDs) > 0 {
+		if err := q.modifyAckDeadline(ctx, name, ackIDs, opts.VisibilityTimeout); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// Ack acknowledges a received message so it is not redelivered.
+func (q *Queue) Ack(ctx context.Context, subscription, ackID string) error {
+	err := q.subscriber.Acknowledge(ctx, &pubsubpb.AcknowledgeRequest{
+		Subscription: q.subscription(subscription),
+		AckIds:       []string{ackID},
+	}, callOptions(q.retry, true)...)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge message: %w", helper.GoogleError(err))
+	}
+	return nil
+}
+
+// Nack makes a received message available for redelivery by setting its ack
+// deadline to zero.
+func (q *Queue) Nack(ctx context.Context, subscription, ackID string) error {
+	return q.modifyAckDeadline(ctx, q.subscription(subscription), []string{ackID}, 0)
+}
+
+// ExtendVisibility sets a received message's ack deadline to timeout from now.
+func (q *Queue) ExtendVisibility(ctx context.Context, subscription, ackID string, timeout time.Duration) error {
+	return q.modifyAckDeadline(ctx, q.subscription(subscription), []string{ackID}, timeout)
+}
+
+// Attributes returns the configuration of the subscription as string
+// attributes.
+func (q *Queue) Attributes(ctx context.Context, subscription string) (map[string]string, error) {
+	sub, err := q.subscriber.GetSubscription(ctx, &pubsubpb.GetSubscriptionRequest{
+		Subscription: q.subscription(subscription),
+	}, callOptions(q.retry, true)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription attributes: %w", helper.GoogleError(err))
+	}
+
+	attrs := map[string]string{
+		"Name":                      sub.Name,
+		"Topic":                     sub.Topic,
+		"AckDeadlineSeconds":        strconv.Itoa(int(sub.AckDeadlineSeconds)),
+		"RetainAckedMessages":       strconv.FormatBool(sub.RetainAckedMessages),
+		"EnableMessageOrdering":     strconv.FormatBool(sub.EnableMessageOrdering),
+		"EnableExactlyOnceDelivery": strconv.FormatBool(sub.EnableExactlyOnceDelivery),
+		"Filter":                    sub.Filter,
+	}
+	if sub.MessageRetentionDuration != nil {
+		attrs["MessageRetentionDuration"] = sub.MessageRetentionDuration.AsDuration().String()
+	}
+	if sub.DeadLetterPolicy != nil {
+		attrs["DeadLetterTopic"] = sub.DeadLetterPolicy.DeadLetterTopic
+		attrs["MaxDeliveryAttempts"] = strconv.Itoa(int(sub.DeadLetterPolicy.MaxDeliveryAttempts))
+	}
+	for k, v := range sub.Labels {
+		attrs["Label."+k] = v
+	}
+	return attrs, nil
+}
+
+// modifyAckDeadline sets the ack deadline of ackIDs to deadline from now.
+func (q *Queue) modifyAckDeadline(ctx context.Context, subscription string, ackIDs []string, deadline time.Duration) error {
+	// The deadline is rounded up, so that a sub-second one is not taken as a
+	// nack.
+	err := q.subscriber.ModifyAckDeadline(ctx, &pubsubpb.ModifyAckDeadlineRequest{
+		Subscription:       subscription,
+		AckIds:             ackIDs,
+		AckDeadlineSeconds: int32((deadline + time.Second - 1) / time.Second),
+	}, callOptions(q.retry, true)...)
+	if err != nil {
+		return fmt.Errorf("failed to modify ack deadline: %w", helper.GoogleError(err))
+	}
+	return nil
+}
+
+// subscription expands a subscription ID into its full name.
+func (q *Queue) subscription(id string) string {
+	return resourceName(q.projectID, "subscriptions", id)
+}
+
+// topic returns the topic the subscription is attached to, checking that
+// the subscription is its only one. The answer is cached since a
+// subscription's topic never changes; subscriptions added to the topic later
+// are not detected.
+func (q *Queue) topic(ctx context.Context, subscription string) (string, error) {
+	q.mu.Lock()
+	topic, ok := q.topics[subscription]
+	q.mu.Unlock()
+	if ok {
+		return topic, nil
+	}
+
+	sub, err := q.subscriber.GetSubscription(ctx, &pubsubpb.GetSubscriptionRequest{
+		Subscription: subscription,
+	}, callOptions(q.retry, true)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get subscription %q: %w", subscription, helper.GoogleError(err))
+	}
+	it := q.publisher.ListTopicSubscriptions(ctx, &pubsubpb.ListTopicSubscriptionsRequest{
+		Topic: sub.Topic,
+	}, callOptions(q.retry, true)...)
+	for {
+		name, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to list subscriptions of topic %q: %w", sub.Topic, helper.GoogleError(err))
+		}
+		if name != subscription {
+			return "", fmt.Errorf("failed to send to %q: its topic %q also delivers to subscription %q: %w", subscription, sub.Topic, name, cloud.ErrInvalidArgument)
+		}
+	}
+
+	q.mu.Lock()
+	q.topics[subscription] = sub.Topic
+	q.mu.Unlock()
+	return sub.Topic, nil
+}
@@ -0,0 +1,127 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	pubsubapi "cloud.google.com/go/pubsub/apiv1"
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+)
+
+// Topic implements cloud.Topic on top of Pub/Sub topics. Topics and
+// subscriptions are identified by ID or full resource name.
+type Topic struct {
+	publisher  *pubsubapi.PublisherClient
+	subscriber *pubsubapi.SubscriberClient
+	projectID  string
+	retry      *retry.Policy
+}
+
+var _ cloud.Topic = (*Topic)(nil)
+
+// NewTopic creates a new Topic. projectID is used to expand short topic and
+// subscription IDs, and policy is applied to every RPC.
+func NewTopic(publisher *pubsubapi.PublisherClient, subscriber *pubsubapi.SubscriberClient, projectID string, policy *retry.Policy) *Topic {
+	return &Topic{
+		publisher:  publisher,
+		subscriber: subscriber,
+		projectID:  projectID,
+		retry:      policy,
+	}
+}
+
+// CreateTopic creates a Pub/Sub topic and returns its full name.
+func (t *Topic) CreateTopic(ctx context.Context, name string) (string, error) {
+	topic, err := t.publisher.CreateTopic(ctx, &pubsubpb.Topic{
+		Name: resourceName(t.projectID, "topics", name),
+	}, callOptions(t.retry, false)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create topic: %w", helper.GoogleError(err))
+	}
+	return topic.Name, nil
+}
+
+// DeleteTopic deletes a Pub/Sub topic. Its subscriptions are detached, not
+// deleted.
+func (t *Topic) DeleteTopic(ctx context.Context, topic string) error {
+	err := t.publisher.DeleteTopic(ctx, &pubsubpb.DeleteTopicRequest{
+		Topic: resourceName(t.projectID, "topics", topic),
+	}, callOptions(t.retry, true)...)
+	if err != nil {
+		return fmt.Errorf("failed to delete topic: %w", helper.GoogleError(err))
+	}
+	return nil
+}
+
+// Publish publishes body with attributes to the topic.
+func (t *Topic) Publish(ctx context.Context, topic string, body []byte, attributes map[string]string) (string, error) {
+	resp, err := t.publisher.Publish(ctx, &pubsubpb.PublishRequest{
+		Topic: resourceName(t.projectID, "topics", topic),
+		Messages: []*pubsubpb.PubsubMessage{{
+			Data:       body,
+			Attributes: attributes,
+		}},
+	}, callOptions(t.retry, false)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to publish message: %w", helper.GoogleError(err))
+	}
+	return resp.MessageIds[0], nil
+}
+
+// Subscribe creates the subscription opts.Name on the topic. Protocol "pull"
+// (or empty) creates a pull subscription; "http" and "https" create a push
+// subscription delivering to opts.Endpoint, whose scheme must match the
+// protocol.
+func (t *Topic) Subscribe(ctx context.Context, topic string, opts *cloud.SubscribeOptions) (string, error) {
+	if opts == nil || opts.Name == "" {
+		return "", fmt.Errorf("failed to subscribe to topic: subscription name is required: %w", cloud.ErrInvalidArgument)
+	}
+
+	sub := &pubsubpb.Subscription{
+		Name:  resourceName(t.projectID, "subscriptions", opts.Name),
+		Topic: resourceName(t.projectID, "topics", topic),
+	}
+	switch opts.Protocol {
+	case "", "pull":
+	case "http", "https":
+		if u, err := url.Parse(opts.Endpoint); err != nil || u.Scheme != opts.Protocol || u.Host == "" {
+			return "", fmt.Errorf("failed to subscribe to topic: endpoint %q is not an %s URL: %w", opts.Endpoint, opts.Protocol, cloud.ErrInvalidArgument)
+		}
+		sub.PushConfig = &pubsubpb.PushConfig{PushEndpoint: opts.Endpoint}
+	default:
+		return "", fmt.Errorf("failed to subscribe to topic: unsupported protocol %q: %w", opts.Protocol, cloud.ErrInvalidArgument)
+	}
+	if filter, ok := opts.Attributes["Filter"]; ok {
+		sub.Filter = filter
+	}
+	if v, ok := opts.Attributes["AckDeadlineSeconds"]; ok {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to subscribe to topic: invalid AckDeadlineSeconds %q: %w", v, cloud.ErrInvalidArgument)
+		}
+		sub.AckDeadlineSeconds = int32(seconds)
+	}
+
+	created, err := t.subscriber.CreateSubscription(ctx, sub, callOptions(t.retry, false)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to subscribe to topic: %w", helper.GoogleError(err))
+	}
+	return created.Name, nil
+}
+
+// Unsubscribe deletes the subscription.
+func (t *Topic) Unsubscribe(ctx context.Context, subscription string) error {
+	err := t.subscriber.DeleteSubscription(ctx, &pubsubpb.DeleteSubscriptionRequest{
+		Subscription: resourceName(t.projectID, "subscriptions", subscription),
+	}, callOptions(t.retry, true)...)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe from topic: %w", helper.GoogleError(err))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+	"cloud.google.com/go/storage"
+)
+
+// ToBucket converts GCS bucket attributes to a model.Bucket.
+func ToBucket(attrs *storage.BucketAttrs) *model.Bucket {
+	bucket := &model.Bucket{
+		Name:       attrs.Name,
+		Location:   attrs.Location,
+		Versioning: model.VersioningDisabled,
+		CreatedAt:  attrs.Created,
+		Labels:     attrs.Labels,
+		Raw:        attrs,
+	}
+	if attrs.VersioningEnabled {
+		bucket.Versioning = model.VersioningEnabled
+	}
+	return bucket
+}
+
+// ToObject converts GCS object attributes to a model.Object.
+func ToObject(attrs *storage.ObjectAttrs) *model.Object {
+	return &model.Object{
+		Bucket:       attrs.Bucket,
+		Key:          attrs.Name,
+		Size:         attrs.Size,
+		ETag:         attrs.Etag,
+		ContentType:  attrs.ContentType,
+		LastModified: attrs.Updated,
+		Metadata:     attrs.Metadata,
+		Raw:          attrs,
+	}
+}
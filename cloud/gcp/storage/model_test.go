@@ -0,0 +1,66 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	gcpstorage "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/storage"
+	"github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+	"cloud.google.com/go/storage"
+)
+
+func TestToBucket(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   *storage.BucketAttrs
+		want *model.Bucket
+	}{
+		{
+			name: "versioned",
+			in:   &storage.BucketAttrs{Name: "artifacts", Location: "EU", VersioningEnabled: true, Created: created, Labels: map[string]string{"team": "ops"}},
+			want: &model.Bucket{Name: "artifacts", Location: "EU", Versioning: model.VersioningEnabled, CreatedAt: created, Labels: map[string]string{"team": "ops"}},
+		},
+		{
+			name: "unversioned",
+			in:   &storage.BucketAttrs{Name: "logs"},
+			want: &model.Bucket{Name: "logs", Versioning: model.VersioningDisabled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.in
+			if got := gcpstorage.ToBucket(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToBucket = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToObject(t *testing.T) {
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	in := &storage.ObjectAttrs{
+		Bucket:      "artifacts",
+		Name:        "a/b.txt",
+		Size:        42,
+		Etag:        "CJ2",
+		ContentType: "text/plain",
+		Updated:     updated,
+		Metadata:    map[string]string{"owner": "ops"},
+	}
+	want := &model.Object{
+		Bucket:       "artifacts",
+		Key:          "a/b.txt",
+		Size:         42,
+		ETag:         "CJ2",
+		ContentType:  "text/plain",
+		LastModified: updated,
+		Metadata:     map[string]string{"owner": "ops"},
+		Raw:          in,
+	}
+	if got := gcpstorage.ToObject(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToObject = %+v, want %+v", got, want)
+	}
+}
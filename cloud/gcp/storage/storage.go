@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	"cloud.google.com/go/storage"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/iterator"
+)
+
+// ObjectStore implements cloud.ObjectStore on top of Google Cloud Storage.
+type ObjectStore struct {
+	client    *storage.Client
+	projectID string
+}
+
+var _ cloud.ObjectStore = (*ObjectStore)(nil)
+
+// NewObjectStore creates a new ObjectStore. projectID is used when creating
+// and listing buckets.
+func NewObjectStore(client *storage.Client, projectID string) *ObjectStore {
+	return &ObjectStore{
+		client:    client,
+		projectID: projectID,
+	}
+}
+
+// CreateBucket creates a new GCS bucket in the configured project.
+func (o *ObjectStore) CreateBucket(ctx context.Context, bucket string) error {
+	if err := o.client.Bucket(bucket).Create(ctx, o.projectID, nil); err != nil {
+		return fmt.Errorf("failed to create bucket: %w", storageError(err))
+	}
+	return nil
+}
+
+// DeleteBucket deletes a GCS bucket.
+func (o *ObjectStore) DeleteBucket(ctx context.Context, bucket string) error {
+	if err := o.client.Bucket(bucket).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete bucket: %w", storageError(err))
+	}
+	return nil
+}
+
+// ListBuckets lists all GCS buckets in the configured project.
+func (o *ObjectStore) ListBuckets(ctx context.Context) ([]string, error) {
+	var buckets []string
+	it := o.client.Buckets(ctx, o.projectID)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", storageError(err))
+		}
+		buckets = append(buckets, attrs.Name)
+	}
+	return buckets, nil
+}
+
+// PutObject streams body to bucket/key. If body fails, the upload is
+// discarded and any existing object is left as it was.
+func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io.Reader, opts *cloud.PutOptions) error {
+	// Closing a writer commits what was written, so a failed upload is
+	// abandoned by canceling its context first.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := o.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	if opts != nil {
+		w.ContentType = opts.ContentType
+		w.Metadata = opts.Metadata
+	}
+
+	if _, err := io.Copy(w, body); err != nil {
+		cancel()
+		w.Close()
+		return fmt.Errorf("failed to put object %q in bucket %q, %w", key, bucket, storageError(err))
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to put object %q in bucket %q, %w", key, bucket, storageError(err))
+	}
+	return nil
+}
+
+// GetObject opens bucket/key for reading.
+func (o *ObjectStore) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *cloud.Object, error) {
+	obj := o.client.Bucket(bucket).Object(key)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object %q from bucket %q, %w", key, bucket, storageError(err))
+	}
+
+	// Pin the generation so the content matches the returned attributes.
+	r, err := obj.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object %q from bucket %q, %w", key, bucket, storageError(err))
+	}
+	return r, ToObject(attrs), nil
+}
+
+// HeadObject returns the metadata of bucket/key.
+func (o *ObjectStore) HeadObject(ctx context.Context, bucket, key string) (*cloud.Object, error) {
+	attrs, err := o.client.Bucket(bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object %q in bucket %q, %w", key, bucket, storageError(err))
+	}
+	return ToObject(attrs), nil
+}
+
+// ListObjects lists all objects in bucket whose name starts with prefix.
+func (o *ObjectStore) ListObjects(ctx context.Context, bucket, prefix string) ([]*cloud.Object, error) {
+	return cloud.Collect(o.Objects(ctx, bucket, &cloud.ListOptions{Prefix: prefix}))
+}
+
+// Objects iterates over the objects in bucket whose name starts with
+// opts.Prefix, fetching pages as the iteration proceeds.
+func (o *ObjectStore) Objects(ctx context.Context, bucket string, opts *cloud.ListOptions) iter.Seq2[*cloud.Object, error] {
+	return func(yield func(*cloud.Object, error) bool) {
+		yield = helper.Limit(opts, yield)
+		it := o.client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: helper.ListPrefix(opts)})
+		it.PageInfo().MaxSize = helper.PageSize(opts)
+		for {
+			attrs, err := it.Next()
+			if errors.Is(err, iterator.Done) {
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list objects in bucket %q, %w", bucket, storageError(err)))
+				return
+			}
+			if !yield(ToObject(attrs), nil) {
+				return
+			}
+		}
+	}
+}
+
+// DeleteObject deletes bucket/key.
+func (o *ObjectStore) DeleteObject(ctx context.Context, bucket, key string) error {
+	if err := o.client.Bucket(bucket).Object(key).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete object %q from bucket %q, %w", key, bucket, storageError(err))
+	}
+	return nil
+}
+
+// CopyObject copies srcBucket/srcKey to dstBucket/dstKey on the server side.
+func (o *ObjectStore) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	src := o.client.Bucket(srcBucket).Object(srcKey)
+	dst := o.client.Bucket(dstBucket).Object(dstKey)
+	if _, err := dst.CopierFrom(src).Run(ctx); err != nil {
+		return fmt.Errorf("failed to copy object %q from bucket %q to %q in bucket %q, %w", srcKey, srcBucket, dstKey, dstBucket, storageError(err))
+	}
+	return nil
+}
+
+// SignURL returns a V4 signed URL for bucket/key. It is signed with the
+// private key of the client's service account credentials or, lacking one,
+// through the IAM signBlob API.
+func (o *ObjectStore) SignURL(ctx context.Context, bucket, key string, opts *cloud.SignOptions) (*cloud.SignedURL, error) {
+	if opts == nil {
+		opts = &cloud.SignOptions{}
+	}
+	expires, err := helper.SignExpiry(opts.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %q in bucket %q, %w", key, bucket, err)
+	}
+	method, err := helper.SignMethod(opts.Method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %q in bucket %q, %w", key, bucket, err)
+	}
+
+	headers := make(http.Header)
+	if opts.ContentType != "" {
+		headers.Set("Content-Type", opts.ContentType)
+	}
+	if opts.ContentMD5 != "" {
+		headers.Set("Content-Md5", opts.ContentMD5)
+	}
+	var extra []string
+	for name, value := range opts.Headers {
+		headers.Set(name, value)
+		extra = append(extra, strings.ToLower(name)+":"+value)
+	}
+
+	expiry := time.Now().Add(expires)
+	signed, err := o.client.Bucket(bucket).SignedURL(key, &storage.SignedURLOptions{
+		Scheme:      storage.SigningSchemeV4,
+		Method:      method,
+		Expires:     expiry,
+		ContentType: opts.ContentType,
+		MD5:         opts.ContentMD5,
+		Headers:     extra,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %q in bucket %q, %w", key, bucket, storageError(err))
+	}
+	return &cloud.SignedURL{
+		URL:     signed,
+		Method:  method,
+		Headers: headers,
+		Expires: expiry,
+	}, nil
+}
+
+// SignPost returns a V4 POST policy for bucket/key, signed like SignURL.
+func (o *ObjectStore) SignPost(ctx context.Context, bucket, key string, opts *cloud.PostOptions) (*cloud.PostPolicy, error) {
+	if opts == nil {
+		opts = &cloud.PostOptions{}
+	}
+	expires, err := helper.SignExpiry(opts.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign a POST policy for %q in bucket %q, %w", key, bucket, err)
+	}
+	status, err := helper.SuccessStatus(opts.SuccessStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign a POST policy for %q in bucket %q, %w", key, bucket, err)
+	}
+
+	fields := &storage.PolicyV4Fields{
+		ContentType:         opts.ContentType,
+		StatusCodeOnSuccess: status,
+	}
+	if len(opts.Metadata) > 0 {
+		fields.Metadata = make(map[string]string, len(opts.Metadata))
+		for name, value := range opts.Metadata {
+			fields.Metadata["x-goog-meta-"+strings.ToLower(name)] = value
+		}
+	}
+	var conditions []storage.PostPolicyV4Condition
+	object := key
+	if opts.KeyPrefix {
+		// The form sets the key, which the policy only constrains.
+		object = ""
+		conditions = append(conditions, storage.ConditionStartsWith("$key", key))
+	}
+	if opts.MaxSize > 0 {
+		conditions = append(conditions, storage.ConditionContentLengthRange(uint64(opts.MinSize), uint64(opts.MaxSize)))
+	}
+
+	expiry := time.Now().Add(expires)
+	policy, err := o.client.Bucket(bucket).GenerateSignedPostPolicyV4(object, &storage.PostPolicyV4Options{
+		Expires:    expiry,
+		Fields:     fields,
+		Conditions: conditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign a POST policy for %q in bucket %q, %w", key, bucket, storageError(err))
+	}
+	policy.Fields["key"] = key
+	return &cloud.PostPolicy{
+		URL:     policy.URL,
+		Fields:  policy.Fields,
+		Expires: expiry,
+	}, nil
+}
+
+// storageError classifies a GCS error. The storage client reports missing
+// buckets and objects with its own sentinel errors rather than googleapi
+// errors.
+func storageError(err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		return helper.GoogleNotFound(err)
+	}
+	return helper.GoogleError(err)
+}
+
+// RetryOptions translates policy into retry options for a storage.Client, for
+// use with Client.SetRetry. The client keeps its own idempotency rules and
+// always applies full jitter. A nil policy disables retries. Transport errors
+// the client considers transient are retried unless the policy overrides the
+// classification.
+func RetryOptions(policy *retry.Policy) []storage.RetryOption {
+	if policy == nil {
+		return []storage.RetryOption{storage.WithPolicy(storage.RetryNever)}
+	}
+	return []storage.RetryOption{
+		storage.WithMaxAttempts(policy.Attempts()),
+		storage.WithBackoff(gax.Backoff{
+			Initial:    policy.InitialBackoff,
+			Max:        policy.MaxBackoff,
+			Multiplier: policy.Multiplier,
+		}),
+		storage.WithErrorFunc(func(err error) bool {
+			if policy.ShouldRetry(storageError(err), true) {
+				return true
+			}
+			return policy.Retryable == nil && storage.ShouldRetry(err)
+		}),
+	}
+}
@@ -0,0 +1,208 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	gcpstorage "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/storage"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// newStore returns an ObjectStore whose client holds service account
+// credentials with a generated key, so URLs are signed locally.
+func newStore(t *testing.T) *gcpstorage.ObjectStore {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "c2loud-test",
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"client_email":   "signer@c2loud-test.iam.gserviceaccount.com",
+		"client_id":      "1",
+		"token_uri":      "https://oauth2.googleapis.com/token",
+	})
+	client, err := storage.NewClient(context.Background(), option.WithCredentialsJSON(credentials))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return gcpstorage.NewObjectStore(client, "c2loud-test")
+}
+
+func TestSignURL(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	signed, err := store.SignURL(ctx, "artifacts", "build/report.pdf", &cloud.SignOptions{
+		Method:      "PUT",
+		Expires:     10 * time.Minute,
+		ContentType: "application/pdf",
+		Headers:     map[string]string{"x-goog-meta-owner": "ops"},
+	})
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+	u, err := url.Parse(signed.URL)
+	if err != nil {
+		t.Fatalf("signed URL %q: %v", signed.URL, err)
+	}
+	query := u.Query()
+	if query.Get("X-Goog-Algorithm") != "GOOG4-RSA-SHA256" || query.Get("X-Goog-Signature") == "" {
+		t.Errorf("signed URL = %s", signed.URL)
+	}
+	// The expiry is computed from the deadline, so a second may have passed.
+	if expires, _ := strconv.Atoi(query.Get("X-Goog-Expires")); expires < 595 || expires > 600 {
+		t.Errorf("X-Goog-Expires = %q, want 600", query.Get("X-Goog-Expires"))
+	}
+	if !strings.HasPrefix(query.Get("X-Goog-Credential"), "signer@c2loud-test.iam.gserviceaccount.com/") {
+		t.Errorf("X-Goog-Credential = %q", query.Get("X-Goog-Credential"))
+	}
+	if got := query.Get("X-Goog-SignedHeaders"); !strings.Contains(got, "content-type") || !strings.Contains(got, "x-goog-meta-owner") {
+		t.Errorf("X-Goog-SignedHeaders = %q", got)
+	}
+	if signed.Headers.Get("Content-Type") != "application/pdf" || signed.Headers.Get("X-Goog-Meta-Owner") != "ops" {
+		t.Errorf("Headers = %v", signed.Headers)
+	}
+
+	_, err = store.SignURL(ctx, "artifacts", "report.pdf", &cloud.SignOptions{Expires: -time.Minute})
+	if !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("SignURL with a negative expiry: got %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestSignPost(t *testing.T) {
+	store := newStore(t)
+
+	policy, err := store.SignPost(context.Background(), "artifacts", "uploads/", &cloud.PostOptions{
+		KeyPrefix:   true,
+		ContentType: "image/png",
+		MaxSize:     1 << 20,
+		Metadata:    map[string]string{"Owner": "ops"},
+	})
+	if err != nil {
+		t.Fatalf("SignPost: %v", err)
+	}
+	if !strings.Contains(policy.URL, "artifacts") {
+		t.Errorf("URL = %q", policy.URL)
+	}
+	fields := policy.Fields
+	if fields["key"] != "uploads/" || fields["content-type"] != "image/png" || fields["x-goog-meta-owner"] != "ops" || fields["x-goog-signature"] == "" {
+		t.Errorf("Fields = %v", fields)
+	}
+}
+
+// emulator serves the uploads and metadata reads of the GCS JSON API, like
+// a storage emulator, keeping the names of the objects uploaded.
+type emulator struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (e *emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/"):
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		parts := multipart.NewReader(r.Body, params["boundary"])
+		var attrs struct {
+			Name   string `json:"name"`
+			Bucket string `json:"bucket"`
+		}
+		part, err := parts.NextPart()
+		if err == nil {
+			err = json.NewDecoder(part).Decode(&attrs)
+		}
+		if err == nil {
+			part, err = parts.NextPart()
+		}
+		var data []byte
+		if err == nil {
+			data, err = io.ReadAll(part)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		e.objects[attrs.Name] = data
+		json.NewEncoder(w).Encode(map[string]any{"name": attrs.Name, "bucket": attrs.Bucket, "size": strconv.Itoa(len(data))})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/"):
+		_, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"), "/o/")
+		data, ok := e.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 404, "message": "No such object"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"name": name, "bucket": "artifacts", "size": strconv.Itoa(len(data))})
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotImplemented)
+	}
+}
+
+// failingReader returns data, then err.
+type failingReader struct {
+	data io.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestPutObjectFailedBody(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(&emulator{objects: make(map[string][]byte)})
+	defer server.Close()
+	t.Setenv("STORAGE_EMULATOR_HOST", server.URL)
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+	store := gcpstorage.NewObjectStore(client, "c2loud-test")
+
+	if err := store.PutObject(ctx, "artifacts", "complete.txt", strings.NewReader("complete"), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if _, err := store.HeadObject(ctx, "artifacts", "complete.txt"); err != nil {
+		t.Fatalf("HeadObject of an uploaded object: %v", err)
+	}
+
+	broken := errors.New("connection reset")
+	body := &failingReader{data: bytes.NewReader([]byte("partial")), err: broken}
+	if err := store.PutObject(ctx, "artifacts", "partial.txt", body, nil); !errors.Is(err, broken) {
+		t.Fatalf("PutObject with a failing body: got %v, want %v", err, broken)
+	}
+	if _, err := store.HeadObject(ctx, "artifacts", "partial.txt"); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("HeadObject after a failed upload: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,317 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+	"github.com/Akshay-Verma-CS/c2loud/internal/helper"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// apiVersion matches the version segment of Google REST API paths, after
+// which resource paths start.
+var apiVersion = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+
+// restCollections are the collections of the REST APIs wrapped by c2loud.
+// A segment following a resource ID that is not one of them is taken to be a
+// custom method, such as "start" in instances/{instance}/start.
+var restCollections = map[string]bool{
+	"projects": true, "zones": true, "regions": true, "locations": true,
+	"instances": true, "disks": true, "operations": true,
+	"apps": true, "services": true, "versions": true,
+	"clusters": true, "nodePools": true, "functions": true,
+	"b": true, "o": true, "acl": true, "defaultObjectAcl": true, "notificationConfigs": true,
+}
+
+// restAliases expands the abbreviated collection names of Cloud Storage.
+var restAliases = map[string]string{
+	"b": "buckets",
+	"o": "objects",
+}
+
+// resourceKeys maps the collections whose IDs identify the resource of a
+// call, in REST paths and Pub/Sub request fields, to span attributes.
+var resourceKeys = map[string]attribute.Key{
+	"b":            telemetry.BucketKey,
+	"instances":    telemetry.InstanceKey,
+	"topic":        telemetry.TopicKey,
+	"subscription": telemetry.QueueKey,
+}
+
+// telemetryOptions returns the instrumentation options of the provider.
+func (c *GCPConfig) telemetryOptions() telemetry.Options {
+	return telemetry.Options{
+		TracerProvider:   c.TracerProvider,
+		Registerer:       c.MetricsRegisterer,
+		Logger:           c.Logger,
+		ServiceLogLevels: c.ServiceLogLevels,
+		LogBodies:        c.LogBodies,
+	}
+}
+
+// instrumentOptions returns the client options that record the RPCs of the gRPC
+// service with in.
+func instrumentOptions(in *telemetry.Instrumentation, service string) []option.ClientOption {
+	if in == nil {
+		return nil
+	}
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unaryInterceptor(in, service))),
+	}
+}
+
+// unaryInterceptor records each attempt of a unary RPC as a call named after
+// the RPC method, such as "Publish".
+func unaryInterceptor(in *telemetry.Instrumentation, service string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, call := startAttempt(ctx, in, service, path.Base(method), messageAttributes(req))
+		logsBodies := in.LogsBodies(ctx, service)
+		if logsBodies {
+			logMessage(call, "request", method, req)
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if logsBodies && err == nil {
+			logMessage(call, "response", method, reply)
+		}
+		call.End("", helper.GoogleError(err))
+		return err
+	}
+}
+
+// logMessage logs a request or response message of a gRPC call as JSON.
+func logMessage(call *telemetry.Call, kind, method string, msg any) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+	if data, err := protojson.Marshal(m); err == nil {
+		call.LogBody(kind, method, "application/json", data)
+	}
+}
+
+// messageAttributes returns the span attributes of the resource names in the
+// fields of a request message.
+func messageAttributes(req any) []attribute.KeyValue {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for name, key := range resourceKeys {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+			continue
+		}
+		if value := m.Get(fd).String(); value != "" {
+			attrs = append(attrs, key.String(value))
+		}
+	}
+	return attrs
+}
+
+// instrumentedTransport records each HTTP request of a service as a call named
+// like the API method, such as "instances.get". The call ends when the
+// response headers are received.
+type instrumentedTransport struct {
+	in      *telemetry.Instrumentation
+	service string
+	base    http.RoundTripper
+}
+
+// instrumentTransport returns base wrapped to record the requests of service
+// with in, or base itself when in is nil.
+func instrumentTransport(in *telemetry.Instrumentation, service string, base http.RoundTripper) http.RoundTripper {
+	if in == nil {
+		return base
+	}
+	return &instrumentedTransport{in: in, service: service, base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation, attrs := restOperation(t.service, req)
+	ctx, call := startAttempt(req.Context(), t.in, t.service, operation, attrs)
+
+	req = req.WithContext(ctx)
+	logsBodies := t.in.LogsBodies(ctx, t.service)
+	if logsBodies && req.Body != nil && req.Body != http.NoBody {
+		body, rest, err := telemetry.PeekBody(req.Body, telemetry.MaxLoggedBody)
+		req.Body = rest
+		if err == nil {
+			call.LogBody("request", req.URL.String(), req.Header.Get("Content-Type"), body)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		call.End("", helper.GoogleError(err))
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		// The error, and the request ID in its details, is read from a copy
+		// of the start of the body, which is left for the client.
+		body, rest, _ := telemetry.PeekBody(resp.Body, telemetry.MaxLoggedBody)
+		resp.Body = rest
+		err = helper.GoogleError(googleapi.CheckResponse(&http.Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		}))
+	}
+	if logsBodies {
+		body, rest, err := telemetry.PeekBody(resp.Body, telemetry.MaxLoggedBody)
+		resp.Body = rest
+		if err == nil {
+			call.LogBody("response", req.URL.String(), resp.Header.Get("Content-Type"), body)
+		}
+	}
+	// Google APIs report no request ID on successful responses.
+	call.End("", err)
+	return resp, nil
+}
+
+// startAttempt starts the call recording one attempt of a request. Attempts
+// after the first made by c2loud's retry policy are recorded as retries;
+// those made by the Cloud Storage and Pub/Sub client libraries are recorded
+// as separate calls only.
+func startAttempt(ctx context.Context, in *telemetry.Instrumentation, service, operation string, attrs []attribute.KeyValue) (context.Context, *telemetry.Call) {
+	ctx, call := in.Start(ctx, service, operation, attrs...)
+	if retry.Attempt(ctx) > 1 {
+		call.Retry(nil)
+	}
+	return ctx, call
+}
+
+// restOperation derives the name of the API method of a REST request, in the
+// form of the discovery document, from its path: a GET of
+// /compute/v1/projects/{project}/zones/{zone}/instances/{instance} is
+// "instances.get". It also returns the attributes of the resource IDs in the
+// path.
+func restOperation(service string, req *http.Request) (string, []attribute.KeyValue) {
+	segments := strings.Split(strings.Trim(req.URL.EscapedPath(), "/"), "/")
+	for i, segment := range segments {
+		if apiVersion.MatchString(segment) {
+			segments = segments[i+1:]
+			break
+		}
+		if i == len(segments)-1 && service == "storage" {
+			// Object media is read through the XML API, at /{bucket}/{object}.
+			var attrs []attribute.KeyValue
+			if bucket := unescape(segments[0]); bucket != "" {
+				attrs = append(attrs, telemetry.BucketKey.String(bucket))
+			}
+			return "objects." + restVerb(req.Method, len(segments) > 1), attrs
+		}
+	}
+
+	var verb string
+	if last := segments[len(segments)-1]; strings.Contains(last, ":") {
+		segments[len(segments)-1], verb, _ = strings.Cut(last, ":")
+	}
+
+	var attrs []attribute.KeyValue
+	var collection string
+	hasID := false
+	for i := 0; i < len(segments); i++ {
+		segment := segments[i]
+		switch {
+		case segment == "aggregated":
+			verb = "aggregatedList"
+			continue
+		case hasID && i == len(segments)-1 && !restCollections[segment] && verb == "":
+			verb = segment
+			continue
+		}
+
+		collection, hasID = segment, false
+		if alias, ok := restAliases[segment]; ok {
+			collection = alias
+		}
+		if i+1 < len(segments) {
+			i++
+			hasID = true
+			if key, ok := resourceKeys[segment]; ok {
+				attrs = append(attrs, key.String(unescape(segments[i])))
+			}
+		}
+	}
+	if verb == "" {
+		verb = restVerb(req.Method, hasID)
+	}
+	return collection + "." + verb, attrs
+}
+
+// restVerb returns the standard method name of a request with the given HTTP
+// method, on a single resource or on a collection.
+func restVerb(method string, single bool) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		if single {
+			return "get"
+		}
+		return "list"
+	case http.MethodPost:
+		if single {
+			return "update"
+		}
+		return "insert"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	}
+	return strings.ToLower(method)
+}
+
+func unescape(segment string) string {
+	if s, err := url.PathUnescape(segment); err == nil {
+		return s
+	}
+	return segment
+}
+
+// LogValue implements slog.LogValuer, so that logging the configuration never
+// reveals inline credentials.
+func (c *GCPConfig) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("project_id", c.ProjectID),
+		slog.String("region", c.Region),
+		slog.String("zone", c.Zone),
+		slog.Bool("use_iam_role", c.UseIAMRole),
+	}
+	if c.Credentials != "" {
+		credentials := c.Credentials
+		if strings.HasPrefix(strings.TrimSpace(credentials), "{") {
+			credentials = telemetry.Redacted
+		}
+		attrs = append(attrs, slog.String("credentials", credentials))
+	}
+	if c.ImpersonateServiceAccount != "" {
+		attrs = append(attrs, slog.String("impersonate_service_account", c.ImpersonateServiceAccount))
+	}
+	if len(c.Endpoints) > 0 {
+		attrs = append(attrs, slog.Any("endpoints", c.Endpoints))
+	}
+	return slog.GroupValue(attrs...)
+}
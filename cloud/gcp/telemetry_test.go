@@ -0,0 +1,205 @@
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	gcpcompute "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/compute"
+	gcpstorage "github.com/Akshay-Verma-CS/c2loud/cloud/gcp/storage"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/telemetry"
+
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeGoogleAPIs answers the Compute Engine and Cloud Storage requests of the
+// tests. The first instance listing fails with 503, reporting a request ID
+// in its error details.
+type fakeGoogleAPIs struct {
+	mu    sync.Mutex
+	lists int
+}
+
+func (f *fakeGoogleAPIs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/instances"):
+		f.mu.Lock()
+		f.lists++
+		n := f.lists
+		f.mu.Unlock()
+		if n == 1 {
+			w.Header().Set("X-Guploader-Uploadid", "upload-1")
+			http.Error(w, `{"error":{"code":503,"message":"try again","details":[{"@type":"type.googleapis.com/google.rpc.RequestInfo","requestId":"req-1"}]}}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"items":[{"name":"vm-1"}]}`))
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func tracedProvider(t *testing.T) (*GCPProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	t.Setenv("PUBSUB_EMULATOR_HOST", "")
+	server := httptest.NewServer(&fakeGoogleAPIs{})
+	t.Cleanup(server.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := NewGCPProvider(context.Background(), &GCPConfig{
+		ProjectID: "my-project",
+		Endpoints: map[string]string{
+			"compute":        server.URL + "/compute/v1/",
+			"appengine":      server.URL + "/",
+			"container":      server.URL + "/",
+			"cloudfunctions": server.URL + "/",
+			"storage":        server.URL + "/storage/v1/",
+			"pubsub":         server.URL,
+		},
+		Retry:          &retry.Policy{MaxAttempts: 3},
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { provider.Close() })
+	return provider, exporter
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracingRecordsEachAttempt(t *testing.T) {
+	provider, exporter := tracedProvider(t)
+	service := gcpcompute.NewComputeEngineService(provider.ComputeService, &retry.Policy{MaxAttempts: 3})
+
+	if _, err := service.ListVMInstances(context.Background(), "my-project", "us-central1-a"); err != nil {
+		t.Fatalf("ListVMInstances() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want one per attempt", len(spans))
+	}
+	for i, span := range spans {
+		if span.Name != "compute.instances.list" {
+			t.Errorf("span %d name = %q, want compute.instances.list", i, span.Name)
+		}
+		attrs := spanAttributes(span)
+		if got := attrs[telemetry.ProviderKey].AsString(); got != "gcp" {
+			t.Errorf("span %d provider = %q, want gcp", i, got)
+		}
+		if got := attrs[telemetry.RetriesKey].AsInt64(); got != int64(i) {
+			t.Errorf("span %d retries = %d, want %d", i, got, i)
+		}
+	}
+	if got := spanAttributes(spans[0])[telemetry.ErrorClassKey].AsString(); got != "unavailable" {
+		t.Errorf("failed attempt error class = %q, want unavailable", got)
+	}
+	if got := spanAttributes(spans[0])[telemetry.RequestIDKey].AsString(); got != "req-1" {
+		t.Errorf("failed attempt request ID = %q, want req-1", got)
+	}
+	if id, ok := spanAttributes(spans[1])[telemetry.RequestIDKey]; ok {
+		t.Errorf("successful attempt request ID = %q, want none", id.AsString())
+	}
+	if spans[1].Status.Code == codes.Error {
+		t.Errorf("successful attempt recorded as failed: %v", spans[1].Status)
+	}
+}
+
+func TestTracingRecordsBucket(t *testing.T) {
+	provider, exporter := tracedProvider(t)
+	store := gcpstorage.NewObjectStore(provider.StorageClient, "my-project")
+
+	if err := store.DeleteObject(context.Background(), "my-bucket", "reports/today.csv"); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Name != "storage.objects.delete" {
+		t.Errorf("span name = %q, want storage.objects.delete", spans[0].Name)
+	}
+	if got := spanAttributes(spans[0])[telemetry.BucketKey].AsString(); got != "my-bucket" {
+		t.Errorf("bucket = %q, want my-bucket", got)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	in, err := telemetry.New(cloud.GCPProvider, telemetry.Options{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := unaryInterceptor(in, "pubsub")
+
+	req := &pubsubpb.PublishRequest{Topic: "projects/my-project/topics/orders"}
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(grpccodes.Unavailable, "try again")
+	}
+	if err := interceptor(context.Background(), "/google.pubsub.v1.Publisher/Publish", req, &pubsubpb.PublishResponse{}, nil, invoker); err == nil {
+		t.Fatal("interceptor swallowed the RPC error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Name != "pubsub.Publish" {
+		t.Errorf("span name = %q, want pubsub.Publish", spans[0].Name)
+	}
+	attrs := spanAttributes(spans[0])
+	if got := attrs[telemetry.TopicKey].AsString(); got != req.Topic {
+		t.Errorf("topic = %q, want %q", got, req.Topic)
+	}
+	if got := attrs[telemetry.ErrorClassKey].AsString(); got != "unavailable" {
+		t.Errorf("error class = %q, want unavailable", got)
+	}
+}
+
+func TestRESTOperation(t *testing.T) {
+	tests := []struct {
+		service, method, path string
+		operation             string
+		attrs                 []attribute.KeyValue
+	}{
+		{"compute", "GET", "/compute/v1/projects/p/zones/z/instances/vm-1", "instances.get", []attribute.KeyValue{telemetry.InstanceKey.String("vm-1")}},
+		{"compute", "POST", "/compute/v1/projects/p/zones/z/instances", "instances.insert", nil},
+		{"compute", "POST", "/compute/v1/projects/p/zones/z/instances/vm-1/start", "instances.start", []attribute.KeyValue{telemetry.InstanceKey.String("vm-1")}},
+		{"compute", "GET", "/compute/v1/projects/p/aggregated/instances", "instances.aggregatedList", nil},
+		{"cloudfunctions", "POST", "/v1/projects/p/locations/l/functions/f:call", "functions.call", nil},
+		{"storage", "POST", "/upload/storage/v1/b/bkt/o", "objects.insert", []attribute.KeyValue{telemetry.BucketKey.String("bkt")}},
+		{"storage", "GET", "/storage/v1/b/bkt/o/a%2Fb", "objects.get", []attribute.KeyValue{telemetry.BucketKey.String("bkt")}},
+		{"storage", "GET", "/bkt/a/b", "objects.get", []attribute.KeyValue{telemetry.BucketKey.String("bkt")}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "https://example.com"+tt.path, nil)
+		operation, attrs := restOperation(tt.service, req)
+		if operation != tt.operation {
+			t.Errorf("restOperation(%s %s) = %q, want %q", tt.method, tt.path, operation, tt.operation)
+		}
+		if len(attrs) != len(tt.attrs) || (len(attrs) > 0 && attrs[0] != tt.attrs[0]) {
+			t.Errorf("restOperation(%s %s) attributes = %v, want %v", tt.method, tt.path, attrs, tt.attrs)
+		}
+	}
+}
@@ -1,48 +1,117 @@
 package cloud
 
 import (
-    // Import provider-specific packages
-    "github.com/Akshay-Verma-CS/c2loud/cloud/aws"
-    // "github.com/path/to/azure"
-    // "github.com/path/to/gcp"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
 )
 
 type ProviderType string
 
 const (
-    AWSProvider   ProviderType = "AWS"
-    AzureProvider ProviderType = "Azure"
-    GCPProvider   ProviderType = "GCP"
-    // Add other providers as needed
+	AWSProvider   ProviderType = "AWS"
+	AzureProvider ProviderType = "Azure"
+	GCPProvider   ProviderType = "GCP"
+	// FakeProvider is the in-memory provider of package cloud/fake, for
+	// unit tests.
+	FakeProvider ProviderType = "Fake"
+	// Add other providers as needed
 )
 
+// CloudProvider exposes the provider-neutral services built by a registered
+// Factory. Services a provider does not support are left nil.
 type CloudProvider struct {
-    // Define fields common across all providers
+	// Type is the provider type the instance was created for.
+	Type ProviderType
+	// ObjectStore provides bucket and object storage.
+	ObjectStore ObjectStore
+	// Queue provides point-to-point message queues.
+	Queue Queue
+	// Topic provides publish/subscribe fan-out.
+	Topic Topic
+	// Compute provides VM instances.
+	Compute Compute
+	// Native holds the provider-specific value (for example *aws.AWSProvider)
+	// for callers that need functionality not covered by the neutral services.
+	Native interface{}
+	// Closer releases the resources of the provider, such as the gRPC
+	// connections of GCP, when the provider is closed. Factories set it when
+	// the provider holds any.
+	Closer io.Closer
 }
 
-// NewCloudProvider creates and returns an instance of the specified cloud provider.
-func NewCloudProvider(providerType ProviderType, config interface{}) (*CloudProvider, error) {
-    switch providerType {
-    case AWSProvider:
-        // Initialize AWS provider
-        awsProvider, err := aws.NewAWSProvider(config.(*aws.AWSConfig))
-        if err != nil {
-            return nil, err
-        }
-        return &CloudProvider{/* initialize with awsProvider */}, nil
-
-    case AzureProvider:
-        // Initialize Azure provider
-        // ...
-
-    case GCPProvider:
-        // Initialize GCP provider
-        // ...
-
-    // Add cases for other providers
-
-    default:
-        return nil, fmt.Errorf("unsupported provider type: %s", providerType)
-    }
+// Close releases the resources held by the provider. The provider must not be
+// used afterwards.
+func (p *CloudProvider) Close() error {
+	if p.Closer == nil {
+		return nil
+	}
+	return p.Closer.Close()
+}
+
+// Factory builds a CloudProvider from a provider-specific configuration. ctx
+// bounds the creation of the provider's clients.
+type Factory func(ctx context.Context, config interface{}) (*CloudProvider, error)
+
+var (
+	// factories holds the registered provider factories
+	factories = make(map[ProviderType]Factory)
+	// factoriesMu guards access to the factories map
+	factoriesMu sync.RWMutex
+)
+
+// Register makes a provider factory available to NewCloudProvider. Providers
+// normally call it from an init function, so importing the provider package is
+// enough to enable it. Register panics if factory is nil or if a factory is
+// already registered for providerType.
+func Register(providerType ProviderType, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("cloud: Register factory is nil for " + string(providerType))
+	}
+	if _, dup := factories[providerType]; dup {
+		panic("cloud: Register called twice for " + string(providerType))
+	}
+	factories[providerType] = factory
 }
 
+// Providers returns the registered provider types in sorted order.
+func Providers() []ProviderType {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	types := make([]ProviderType, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// NewCloudProvider creates and returns an instance of the specified cloud provider.
+// The provider package must be imported (for example with a blank import of
+// github.com/Akshay-Verma-CS/c2loud/cloud/aws) so its factory is registered.
+// The provider should be closed when no longer needed.
+func NewCloudProvider(ctx context.Context, providerType ProviderType, config interface{}) (*CloudProvider, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[providerType]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
+	}
+
+	provider, err := factory(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", providerType, err)
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("failed to create %s provider: factory returned no provider", providerType)
+	}
+	provider.Type = providerType
+	return provider, nil
+}
@@ -0,0 +1,56 @@
+package cloud_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+)
+
+func TestNewCloudProviderNilProvider(t *testing.T) {
+	const nilProvider cloud.ProviderType = "NilProvider"
+	cloud.Register(nilProvider, func(ctx context.Context, config interface{}) (*cloud.CloudProvider, error) {
+		return nil, nil
+	})
+
+	provider, err := cloud.NewCloudProvider(context.Background(), nilProvider, nil)
+	if err == nil || provider != nil {
+		t.Errorf("NewCloudProvider = %v, %v; want an error", provider, err)
+	}
+}
+
+type closer struct {
+	closed int
+}
+
+func (c *closer) Close() error {
+	c.closed++
+	return nil
+}
+
+type ctxKey struct{}
+
+func TestNewCloudProviderClose(t *testing.T) {
+	const closingProvider cloud.ProviderType = "ClosingProvider"
+	c := &closer{}
+	var got any
+	cloud.Register(closingProvider, func(ctx context.Context, config interface{}) (*cloud.CloudProvider, error) {
+		got = ctx.Value(ctxKey{})
+		return &cloud.CloudProvider{Closer: c}, nil
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	provider, err := cloud.NewCloudProvider(ctx, closingProvider, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "value" {
+		t.Errorf("factory got context value %v, want the caller's context", got)
+	}
+	if err := provider.Close(); err != nil || c.closed != 1 {
+		t.Errorf("Close() = %v with %d closes, want the Closer closed once", err, c.closed)
+	}
+	if err := (&cloud.CloudProvider{}).Close(); err != nil {
+		t.Errorf("Close() without a Closer = %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package cloud
+
+import "iter"
+
+// ListOptions controls listings returned as iterators, such as
+// ObjectStore.Objects and Compute.Instances. A nil *ListOptions lists every
+// item using the provider's default page size.
+type ListOptions struct {
+	// Prefix restricts the listing to items whose name starts with Prefix.
+	Prefix string
+	// PageSize is the number of items requested per call. Zero uses the
+	// provider default; providers may cap it.
+	PageSize int
+	// MaxItems stops the listing after that many items. Zero means no limit.
+	MaxItems int
+}
+
+// Collect gathers the items of seq into a slice. It stops at the first error.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
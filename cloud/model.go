@@ -0,0 +1,72 @@
+package cloud
+
+import "github.com/Akshay-Verma-CS/c2loud/internal/model"
+
+// Provider-neutral resource types. They are defined in internal/model so the
+// provider packages can convert to them without importing this package.
+type (
+	Bucket            = model.Bucket
+	VersioningState   = model.VersioningState
+	Object            = model.Object
+	QueueInfo         = model.Queue
+	Message           = model.Message
+	TopicInfo         = model.Topic
+	Subscription      = model.Subscription
+	SubscriptionState = model.SubscriptionState
+	Instance          = model.Instance
+	InstanceState     = model.InstanceState
+	User              = model.User
+	Cluster           = model.Cluster
+	ClusterState      = model.ClusterState
+
+	Application          = model.Application
+	ApplicationState     = model.ApplicationState
+	Function             = model.Function
+	FunctionState        = model.FunctionState
+	ConfigurationProfile = model.ConfigurationProfile
+	Environment          = model.Environment
+	EnvironmentState     = model.EnvironmentState
+)
+
+const (
+	VersioningDisabled  = model.VersioningDisabled
+	VersioningEnabled   = model.VersioningEnabled
+	VersioningSuspended = model.VersioningSuspended
+
+	SubscriptionActive  = model.SubscriptionActive
+	SubscriptionPending = model.SubscriptionPending
+	SubscriptionError   = model.SubscriptionError
+	SubscriptionUnknown = model.SubscriptionUnknown
+
+	InstancePending     = model.InstancePending
+	InstanceRunning     = model.InstanceRunning
+	InstanceStopping    = model.InstanceStopping
+	InstanceStopped     = model.InstanceStopped
+	InstanceTerminating = model.InstanceTerminating
+	InstanceTerminated  = model.InstanceTerminated
+	InstanceUnknown     = model.InstanceUnknown
+
+	ClusterProvisioning = model.ClusterProvisioning
+	ClusterRunning      = model.ClusterRunning
+	ClusterReconciling  = model.ClusterReconciling
+	ClusterStopping     = model.ClusterStopping
+	ClusterError        = model.ClusterError
+	ClusterDegraded     = model.ClusterDegraded
+	ClusterUnknown      = model.ClusterUnknown
+
+	ApplicationServing  = model.ApplicationServing
+	ApplicationDisabled = model.ApplicationDisabled
+	ApplicationUnknown  = model.ApplicationUnknown
+
+	FunctionDeploying = model.FunctionDeploying
+	FunctionActive    = model.FunctionActive
+	FunctionDeleting  = model.FunctionDeleting
+	FunctionError     = model.FunctionError
+	FunctionUnknown   = model.FunctionUnknown
+
+	EnvironmentReady       = model.EnvironmentReady
+	EnvironmentDeploying   = model.EnvironmentDeploying
+	EnvironmentRollingBack = model.EnvironmentRollingBack
+	EnvironmentRolledBack  = model.EnvironmentRolledBack
+	EnvironmentUnknown     = model.EnvironmentUnknown
+)
@@ -0,0 +1,112 @@
+package cloud
+
+import (
+	"context"
+	"io"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// PutOptions holds optional settings for ObjectStore.PutObject.
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+// Lifetimes of signed URLs and POST policies.
+const (
+	// DefaultSignExpiry is the lifetime used when none is given.
+	DefaultSignExpiry = 15 * time.Minute
+	// MaxSignExpiry is the longest lifetime S3 and GCS accept.
+	MaxSignExpiry = 7 * 24 * time.Hour
+)
+
+// SignOptions holds optional settings for ObjectStore.SignURL.
+type SignOptions struct {
+	// Method is the HTTP method the URL is valid for: GET, PUT or DELETE.
+	// Empty means GET.
+	Method string
+	// Expires is how long the URL is valid. Zero means DefaultSignExpiry.
+	Expires time.Duration
+	// ContentType and ContentMD5, the base64 MD5 of the body, are signed
+	// when set, so the request must send them with these values.
+	ContentType string
+	ContentMD5  string
+	// Headers are signed in addition, such as "x-amz-meta-owner" on S3 or
+	// "x-goog-meta-owner" on GCS. S3 signs only x-amz-* and standard
+	// headers.
+	Headers map[string]string
+}
+
+// SignedURL is a URL granting temporary access to an object without
+// credentials.
+type SignedURL struct {
+	URL    string
+	Method string
+	// Headers must be sent with the request, as they are part of the
+	// signature.
+	Headers http.Header
+	Expires time.Time
+}
+
+// PostOptions holds optional settings for ObjectStore.SignPost.
+type PostOptions struct {
+	// Expires is how long the policy is valid. Zero means DefaultSignExpiry.
+	Expires time.Duration
+	// KeyPrefix allows the form to upload any key starting with the key
+	// given, rather than that key only. The form sets the "key" field.
+	KeyPrefix bool
+	// ContentType, when set, is the only content type the form may upload.
+	ContentType string
+	// MinSize and MaxSize bound the size of the upload when MaxSize is
+	// positive.
+	MinSize, MaxSize int64
+	// Metadata is the user metadata of the uploaded object.
+	Metadata map[string]string
+	// SuccessStatus is the status of the response to a successful upload:
+	// 200, 201 or 204. Zero means 204.
+	SuccessStatus int
+}
+
+// PostPolicy is a signed policy for uploading an object from an HTML form,
+// such as in a browser. The form posts to URL with Fields, followed by the
+// content in a "file" field.
+type PostPolicy struct {
+	URL     string
+	Fields  map[string]string
+	Expires time.Time
+}
+
+// ObjectStore is a provider-neutral interface over bucket storage such as
+// Amazon S3 and Google Cloud Storage.
+type ObjectStore interface {
+	// CreateBucket creates a new bucket.
+	CreateBucket(ctx context.Context, bucket string) error
+	// DeleteBucket deletes an empty bucket.
+	DeleteBucket(ctx context.Context, bucket string) error
+	// ListBuckets lists the names of all buckets visible to the caller.
+	ListBuckets(ctx context.Context) ([]string, error)
+
+	// PutObject writes body to bucket/key, replacing any existing object.
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, opts *PutOptions) error
+	// GetObject opens bucket/key for reading. The caller must close the reader.
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *Object, error)
+	// HeadObject returns the metadata of bucket/key without its content.
+	HeadObject(ctx context.Context, bucket, key string) (*Object, error)
+	// ListObjects lists all objects in bucket whose key starts with prefix.
+	ListObjects(ctx context.Context, bucket, prefix string) ([]*Object, error)
+	// Objects iterates over the objects in bucket whose key starts with
+	// opts.Prefix, fetching pages lazily.
+	Objects(ctx context.Context, bucket string, opts *ListOptions) iter.Seq2[*Object, error]
+	// DeleteObject deletes bucket/key.
+	DeleteObject(ctx context.Context, bucket, key string) error
+	// CopyObject copies srcBucket/srcKey to dstBucket/dstKey.
+	CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error
+
+	// SignURL returns a URL granting temporary access to bucket/key.
+	SignURL(ctx context.Context, bucket, key string, opts *SignOptions) (*SignedURL, error)
+	// SignPost returns a policy for uploading to bucket/key from an HTML
+	// form.
+	SignPost(ctx context.Context, bucket, key string, opts *PostOptions) (*PostPolicy, error)
+}
@@ -0,0 +1,48 @@
+package cloud
+
+import (
+	"context"
+	"time"
+)
+
+// ReceiveOptions holds optional settings for Queue.Receive.
+type ReceiveOptions struct {
+	// MaxMessages caps the number of messages returned. Zero means one.
+	// Providers may return fewer: SQS returns at most 10 per call.
+	MaxMessages int
+	// WaitTime is how long to wait for messages before returning an empty
+	// result. Zero returns immediately. SQS waits in whole seconds, rounding
+	// WaitTime up, and at most 20 seconds, lowering longer waits to 20
+	// seconds.
+	WaitTime time.Duration
+	// VisibilityTimeout hides the received messages from other consumers for
+	// the given duration, rounded up to whole seconds. Zero uses the queue
+	// default.
+	VisibilityTimeout time.Duration
+}
+
+// Queue is a provider-neutral interface over point-to-point message queues
+// such as Amazon SQS and Google Pub/Sub pull subscriptions. The queue argument
+// is a queue URL for SQS and a subscription for Pub/Sub.
+//
+// Pub/Sub has no queues: Send publishes to the topic of the subscription, and
+// every subscription of that topic gets the message. To keep messages
+// point-to-point, the Pub/Sub Queue requires a topic with no other
+// subscription, and Send fails with ErrInvalidArgument otherwise.
+type Queue interface {
+	// Send enqueues msg and returns the provider-assigned message ID.
+	Send(ctx context.Context, queue string, msg *Message) (string, error)
+	// Receive returns up to opts.MaxMessages messages. Each message stays
+	// invisible to other consumers until it is acked, nacked or its
+	// visibility timeout expires.
+	Receive(ctx context.Context, queue string, opts *ReceiveOptions) ([]*Message, error)
+	// Ack removes a received message from the queue.
+	Ack(ctx context.Context, queue, ackHandle string) error
+	// Nack makes a received message visible again for immediate redelivery.
+	Nack(ctx context.Context, queue, ackHandle string) error
+	// ExtendVisibility resets a received message's visibility timeout to
+	// timeout from now, rounded up to whole seconds.
+	ExtendVisibility(ctx context.Context, queue, ackHandle string, timeout time.Duration) error
+	// Attributes returns the provider-specific attributes of queue.
+	Attributes(ctx context.Context, queue string) (map[string]string, error)
+}
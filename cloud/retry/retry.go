@@ -0,0 +1,173 @@
+// Package retry defines the retry policy shared by all c2loud providers.
+//
+// A Policy decides whether a failed call is retried and how long to wait
+// before the next attempt. Retryability is driven by the error taxonomy in
+// package cloud: provider errors are classified from AWS error codes and
+// googleapi or gRPC status codes, and the policy retries throttling and
+// transient unavailability. Operations that are not idempotent, such as
+// creating a resource or publishing a message, are only retried on
+// throttling, since the provider rejected those requests before acting on
+// them.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+)
+
+// Policy configures exponential backoff with jitter. A nil *Policy makes a
+// single attempt.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 1 are treated as 1.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier scales the delay after each retry. Values below 1 are
+	// treated as 1.
+	Multiplier float64
+	// Jitter is the fraction of each delay that is randomized, between 0
+	// (none) and 1 (full jitter).
+	Jitter float64
+	// Retryable overrides the default classification of retryable errors.
+	Retryable func(err error, idempotent bool) bool
+}
+
+// DefaultPolicy returns the policy used when a provider is not configured
+// with one: 3 attempts, backing off from 100ms up to 20s with full jitter.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     20 * time.Second,
+		Multiplier:     2,
+		Jitter:         1,
+	}
+}
+
+// ForService returns the policy for the named service: its entry in
+// overrides if there is one, otherwise def, otherwise DefaultPolicy.
+func ForService(service string, def *Policy, overrides map[string]*Policy) *Policy {
+	if p, ok := overrides[service]; ok && p != nil {
+		return p
+	}
+	if def != nil {
+		return def
+	}
+	return DefaultPolicy()
+}
+
+// Attempts returns the total number of attempts allowed by the policy.
+func (p *Policy) Attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Backoff returns the delay before retry number retry, counting from 1.
+func (p *Policy) Backoff(retry int) time.Duration {
+	if p == nil || p.InitialBackoff <= 0 || retry < 1 {
+		return 0
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		d *= multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter > 0 {
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// ShouldRetry reports whether a call that failed with err should be retried.
+// idempotent reports whether repeating the call is safe.
+func (p *Policy) ShouldRetry(err error, idempotent bool) bool {
+	if p == nil || err == nil {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err, idempotent)
+	}
+	return Retryable(err, idempotent)
+}
+
+// Retryable is the default classification. It retries throttled calls, and
+// calls that failed because the provider was unavailable if they are
+// idempotent. Cancellation and deadline errors are never retried.
+func Retryable(err error, idempotent bool) bool {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, cloud.ErrThrottled):
+		return true
+	case errors.Is(err, cloud.ErrUnavailable):
+		return idempotent
+	}
+	return false
+}
+
+// attemptKey is the context key of the attempt number set by Do.
+type attemptKey struct{}
+
+// Attempt returns the number of the attempt, counting from 1, of the Do call
+// that passed ctx to its function. It returns 0 for other contexts.
+func Attempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// Do calls fn until it succeeds, returns an error the policy does not retry,
+// or the attempts are exhausted. It returns the last error from fn, or the
+// context error if ctx is done while waiting between attempts. The context
+// passed to fn reports the attempt number through Attempt.
+func (p *Policy) Do(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(context.WithValue(ctx, attemptKey{}, attempt)); err == nil {
+			return nil
+		}
+		if attempt >= p.Attempts() || !p.ShouldRetry(err, idempotent) {
+			return err
+		}
+
+		timer := time.NewTimer(p.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// DoValue is like Policy.Do for calls that return a value. It returns the
+// value from the last attempt.
+func DoValue[T any](ctx context.Context, p *Policy, idempotent bool, fn func(ctx context.Context) (T, error)) (T, error) {
+	var v T
+	err := p.Do(ctx, idempotent, func(ctx context.Context) error {
+		var err error
+		v, err = fn(ctx)
+		return err
+	})
+	return v, err
+}
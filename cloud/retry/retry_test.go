@@ -0,0 +1,271 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/retry"
+)
+
+// classified returns a provider error of the given kind.
+func classified(kind error) error {
+	return &cloud.Error{Kind: kind, Provider: cloud.AWSProvider, Err: errors.New("request failed")}
+}
+
+func TestBackoff(t *testing.T) {
+	p := &retry.Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{0, 0},
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.Backoff(tt.retry); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+
+	if got := (&retry.Policy{InitialBackoff: time.Second, Multiplier: 0.5}).Backoff(3); got != time.Second {
+		t.Errorf("Backoff with a multiplier below 1 = %v, want 1s", got)
+	}
+	if got := (&retry.Policy{InitialBackoff: time.Second, Multiplier: 10}).Backoff(4); got != 1000*time.Second {
+		t.Errorf("Backoff without a cap = %v, want 1000s", got)
+	}
+	var nilPolicy *retry.Policy
+	if got := nilPolicy.Backoff(1); got != 0 {
+		t.Errorf("nil Backoff = %v, want 0", got)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	tests := []struct {
+		jitter   float64
+		min, max time.Duration
+	}{
+		{1, 0, 800 * time.Millisecond},
+		{0.25, 600 * time.Millisecond, 800 * time.Millisecond},
+		{2, 0, 800 * time.Millisecond},
+		{-1, 800 * time.Millisecond, 800 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.jitter), func(t *testing.T) {
+			p := &retry.Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: tt.jitter}
+			seen := make(map[time.Duration]bool)
+			for range 1000 {
+				d := p.Backoff(4)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("Backoff(4) = %v, want within [%v, %v]", d, tt.min, tt.max)
+				}
+				seen[d] = true
+			}
+			if tt.min != tt.max && len(seen) < 100 {
+				t.Errorf("Backoff(4) took %d distinct values in 1000 calls", len(seen))
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"throttled", classified(cloud.ErrThrottled), false, true},
+		{"throttled idempotent", classified(cloud.ErrThrottled), true, true},
+		{"unavailable", classified(cloud.ErrUnavailable), false, false},
+		{"unavailable idempotent", classified(cloud.ErrUnavailable), true, true},
+		{"wrapped unavailable", fmt.Errorf("list: %w", classified(cloud.ErrUnavailable)), true, true},
+		{"not found", classified(cloud.ErrNotFound), true, false},
+		{"conflict", classified(cloud.ErrConflict), true, false},
+		{"unclassified", errors.New("boom"), true, false},
+		{"canceled", context.Canceled, true, false},
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), true, false},
+		{"throttled and canceled", errors.Join(classified(cloud.ErrThrottled), context.Canceled), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retry.Retryable(tt.err, tt.idempotent); got != tt.want {
+				t.Errorf("Retryable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	var nilPolicy *retry.Policy
+	if nilPolicy.ShouldRetry(classified(cloud.ErrThrottled), true) {
+		t.Error("a nil policy retries")
+	}
+	if retry.DefaultPolicy().ShouldRetry(nil, true) {
+		t.Error("a nil error is retried")
+	}
+
+	custom := &retry.Policy{Retryable: func(err error, idempotent bool) bool {
+		return errors.Is(err, cloud.ErrConflict) && idempotent
+	}}
+	if !custom.ShouldRetry(classified(cloud.ErrConflict), true) || custom.ShouldRetry(classified(cloud.ErrConflict), false) {
+		t.Error("Retryable override not applied")
+	}
+	if custom.ShouldRetry(classified(cloud.ErrThrottled), true) {
+		t.Error("Retryable override falls back to the default classification")
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	var nilPolicy *retry.Policy
+	for _, tt := range []struct {
+		p    *retry.Policy
+		want int
+	}{
+		{nilPolicy, 1},
+		{&retry.Policy{}, 1},
+		{&retry.Policy{MaxAttempts: -2}, 1},
+		{&retry.Policy{MaxAttempts: 5}, 5},
+		{retry.DefaultPolicy(), 3},
+	} {
+		if got := tt.p.Attempts(); got != tt.want {
+			t.Errorf("Attempts(%+v) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestForService(t *testing.T) {
+	def := &retry.Policy{MaxAttempts: 2}
+	s3 := &retry.Policy{MaxAttempts: 7}
+	overrides := map[string]*retry.Policy{"s3": s3, "sqs": nil}
+	if got := retry.ForService("s3", def, overrides); got != s3 {
+		t.Errorf("ForService(s3) = %+v, want the override", got)
+	}
+	if got := retry.ForService("sqs", def, overrides); got != def {
+		t.Errorf("ForService(sqs) with a nil override = %+v, want the default", got)
+	}
+	if got := retry.ForService("ec2", nil, overrides); got.Attempts() != retry.DefaultPolicy().Attempts() {
+		t.Errorf("ForService(ec2) without a default = %+v, want DefaultPolicy", got)
+	}
+}
+
+func TestDo(t *testing.T) {
+	p := &retry.Policy{MaxAttempts: 4, InitialBackoff: time.Millisecond, Multiplier: 2}
+	tests := []struct {
+		name       string
+		errs       []error
+		idempotent bool
+		calls      int
+		wantErr    bool
+	}{
+		{"success", nil, false, 1, false},
+		{"recovers", []error{classified(cloud.ErrThrottled), classified(cloud.ErrUnavailable)}, true, 3, false},
+		{"not retryable", []error{classified(cloud.ErrNotFound)}, true, 1, true},
+		{"not idempotent", []error{classified(cloud.ErrUnavailable)}, false, 1, true},
+		{"throttled not idempotent", []error{classified(cloud.ErrThrottled)}, false, 2, false},
+		{"exhausted", []error{
+			classified(cloud.ErrThrottled), classified(cloud.ErrThrottled),
+			classified(cloud.ErrThrottled), classified(cloud.ErrThrottled),
+			classified(cloud.ErrThrottled),
+		}, true, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			err := p.Do(context.Background(), tt.idempotent, func(ctx context.Context) error {
+				calls++
+				if got := retry.Attempt(ctx); got != calls {
+					t.Errorf("Attempt = %d on call %d", got, calls)
+				}
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.calls {
+				t.Errorf("fn called %d times, want %d", calls, tt.calls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && err != tt.errs[calls-1] {
+				t.Errorf("Do = %v, want the error of the last attempt", err)
+			}
+		})
+	}
+
+	if got := retry.Attempt(context.Background()); got != 0 {
+		t.Errorf("Attempt outside Do = %d, want 0", got)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	p := &retry.Policy{MaxAttempts: 10, InitialBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Do(ctx, true, func(ctx context.Context) error {
+			calls++
+			return classified(cloud.ErrThrottled)
+		})
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Do = %v, want context.Canceled", err)
+		}
+		if calls != 1 {
+			t.Errorf("fn called %d times, want 1", calls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Do kept waiting after the context was canceled")
+	}
+
+	// A call that fails because the context is done is not retried.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	calls = 0
+	_, err := retry.DoValue(ctx, retry.DefaultPolicy(), true, func(ctx context.Context) (int, error) {
+		calls++
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+		t.Errorf("DoValue = %v after %d calls, want DeadlineExceeded after 1", err, calls)
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	p := &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	calls := 0
+	v, err := retry.DoValue(context.Background(), p, true, func(ctx context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "partial", classified(cloud.ErrUnavailable)
+		}
+		return "done", nil
+	})
+	if err != nil || v != "done" || calls != 3 {
+		t.Errorf("DoValue = %q, %v after %d calls; want done after 3", v, err, calls)
+	}
+
+	var nilPolicy *retry.Policy
+	v, err = retry.DoValue(context.Background(), nilPolicy, true, func(ctx context.Context) (string, error) {
+		return "last", classified(cloud.ErrThrottled)
+	})
+	if !errors.Is(err, cloud.ErrThrottled) || v != "last" {
+		t.Errorf("DoValue with a nil policy = %q, %v; want the value and error of the single attempt", v, err)
+	}
+}
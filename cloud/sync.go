@@ -0,0 +1,560 @@
+package cloud
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncCompare is how Sync decides that a file is up to date at the
+// destination. Files of different sizes are always copied.
+type SyncCompare string
+
+const (
+	// CompareModTime skips files whose copy at the destination is not older
+	// than the source. It is the default.
+	CompareModTime SyncCompare = "modtime"
+	// CompareSize skips files of the same size.
+	CompareSize SyncCompare = "size"
+	// CompareETag skips files with the same ETag. Local files are given the
+	// ETag of an object uploaded in a single part, their quoted MD5, so
+	// objects uploaded in several parts are always copied from or to local
+	// directories.
+	CompareETag SyncCompare = "etag"
+	// CompareChecksum skips files with the same MD5 digest. The digest of an
+	// object is the one Sync records in its SyncDigestMetadata metadata when
+	// uploading, or else its ETag when that is an MD5. Objects without a
+	// known digest are always copied.
+	CompareChecksum SyncCompare = "checksum"
+)
+
+// SyncDigestMetadata is the metadata key holding the MD5 digest, in hex, of
+// the files Sync uploads with CompareChecksum.
+const SyncDigestMetadata = "c2loud-md5"
+
+// DefaultSyncConcurrency is the number of files Sync compares, copies or
+// deletes at a time by default.
+const DefaultSyncConcurrency = 8
+
+// SyncOptions holds optional settings for CloudProvider.Sync.
+type SyncOptions struct {
+	// Compare is how files are compared. Empty means CompareModTime.
+	Compare SyncCompare
+	// Include, when set, restricts the sync to files matching one of its
+	// patterns, and Exclude leaves out files matching one of its patterns.
+	// Patterns use the syntax of path.Match and are matched against the
+	// slash-separated path relative to the source or destination. Patterns
+	// without a slash are matched against the base name too, and patterns
+	// ending in "/**" match everything below a directory.
+	Include, Exclude []string
+	// Delete deletes the files at the destination that are not at the
+	// source. Excluded files are kept.
+	Delete bool
+	// DryRun reports what would be copied and deleted without doing it.
+	DryRun bool
+	// Concurrency is the number of files compared, copied or deleted at a
+	// time. Zero means DefaultSyncConcurrency.
+	Concurrency int
+}
+
+// SyncItem is a file handled by Sync. Path is relative to the source, or to
+// the destination for deleted files, and slash-separated.
+type SyncItem struct {
+	Path string
+	Size int64
+	// Err is why the file could not be copied or deleted, for
+	// SyncReport.Failed.
+	Err error
+}
+
+// SyncReport lists the files handled by Sync, sorted by path. With
+// SyncOptions.DryRun, Copied and Deleted list the files that would be.
+type SyncReport struct {
+	Copied  []SyncItem
+	Skipped []SyncItem
+	Deleted []SyncItem
+	Failed  []SyncItem
+}
+
+// Bytes returns the total size of the copied files.
+func (r *SyncReport) Bytes() int64 {
+	var total int64
+	for _, item := range r.Copied {
+		total += item.Size
+	}
+	return total
+}
+
+// syncLocation is the source or destination of a sync: a local directory, or
+// the objects of a bucket under a prefix.
+type syncLocation struct {
+	bucket string
+	// root is the local directory, or the object prefix ending in a slash
+	// unless empty.
+	root string
+}
+
+// parseSyncLocation parses a URL such as "s3://bucket/prefix" or
+// "gs://bucket/prefix" as a bucket location, and anything else as a local
+// directory.
+func parseSyncLocation(location string) (syncLocation, error) {
+	_, rest, ok := strings.Cut(location, "://")
+	if !ok {
+		return syncLocation{root: location}, nil
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return syncLocation{}, fmt.Errorf("location %q has no bucket: %w", location, ErrInvalidArgument)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return syncLocation{bucket: bucket, root: prefix}, nil
+}
+
+func (l syncLocation) local() bool {
+	return l.bucket == ""
+}
+
+// path returns the local path or object key of rel. Object keys can name
+// any path, so a local path is rejected unless it stays under the root and
+// has no ".." elements.
+func (l syncLocation) path(rel string) (string, error) {
+	if !l.local() {
+		return l.root + rel, nil
+	}
+	name := filepath.FromSlash(rel)
+	if !filepath.IsLocal(name) || slices.Contains(strings.Split(rel, "/"), "..") {
+		return "", fmt.Errorf("path %q is outside %q: %w", rel, l.root, ErrInvalidArgument)
+	}
+	return filepath.Join(l.root, name), nil
+}
+
+// syncFile is a file found at a sync location.
+type syncFile struct {
+	size     int64
+	modTime  time.Time
+	etag     string
+	metadata map[string]string
+}
+
+// Sync makes dst a copy of src. Each is either a local directory or a
+// location in a bucket of ObjectStore written as a URL, such as
+// "s3://bucket/prefix" or "gs://bucket/prefix"; the scheme is not checked
+// against the provider. Syncing a local directory to another is not
+// supported.
+//
+// Files are downloaded to a temporary file renamed into place, with the
+// modification time of their object. Objects whose key would leave the
+// local directory, being absolute or having ".." elements, fail with
+// ErrInvalidArgument. Directories left empty by deletions are kept. Files
+// that cannot be copied or deleted are reported in SyncReport.Failed, and
+// their errors are also returned, joined.
+func (p *CloudProvider) Sync(ctx context.Context, src, dst string, opts *SyncOptions) (*SyncReport, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	from, err := parseSyncLocation(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync %q to %q, %w", src, dst, err)
+	}
+	to, err := parseSyncLocation(dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync %q to %q, %w", src, dst, err)
+	}
+	if from.local() && to.local() {
+		return nil, fmt.Errorf("failed to sync %q to %q, both are local directories: %w", src, dst, ErrInvalidArgument)
+	}
+	if p.ObjectStore == nil {
+		return nil, fmt.Errorf("failed to sync %q to %q, the provider has no object store: %w", src, dst, ErrInvalidArgument)
+	}
+	for _, pattern := range slices.Concat(opts.Include, opts.Exclude) {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return nil, fmt.Errorf("failed to sync %q to %q, invalid pattern %q: %w", src, dst, pattern, ErrInvalidArgument)
+		}
+	}
+
+	sources, err := p.syncList(ctx, from, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync %q to %q, %w", src, dst, err)
+	}
+	if len(sources) == 0 && from.local() {
+		// A missing destination is empty, a missing source a mistake.
+		if _, err := os.Stat(from.root); err != nil {
+			return nil, fmt.Errorf("failed to sync %q to %q, %w", src, dst, err)
+		}
+	}
+	targets, err := p.syncList(ctx, to, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync %q to %q, %w", src, dst, err)
+	}
+
+	s := &syncer{provider: p, from: from, to: to, opts: opts, report: &SyncReport{}}
+	var deletes []string
+	if opts.Delete {
+		for rel := range targets {
+			if _, ok := sources[rel]; !ok {
+				deletes = append(deletes, rel)
+			}
+		}
+	}
+
+	// Files are compared with their target in the workers, as comparing
+	// checksums reads the files or heads the objects.
+	s.run(ctx, slices.Collect(maps.Keys(sources)), func(ctx context.Context, rel string) {
+		file := sources[rel]
+		item := SyncItem{Path: rel, Size: file.size}
+		if target, ok := targets[rel]; ok {
+			same, err := s.same(ctx, rel, file, target)
+			if err != nil || same {
+				s.finish(&s.report.Skipped, item, err)
+				return
+			}
+		}
+		s.finish(&s.report.Copied, item, s.copy(ctx, rel, file))
+	})
+	s.run(ctx, deletes, func(ctx context.Context, rel string) {
+		item := SyncItem{Path: rel, Size: targets[rel].size}
+		s.finish(&s.report.Deleted, item, s.delete(ctx, rel))
+	})
+
+	var errs []error
+	for _, items := range [][]SyncItem{s.report.Copied, s.report.Skipped, s.report.Deleted, s.report.Failed} {
+		sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	}
+	for _, item := range s.report.Failed {
+		errs = append(errs, item.Err)
+	}
+	return s.report, errors.Join(errs...)
+}
+
+// syncList lists the files at l that opts selects, by relative path.
+func (p *CloudProvider) syncList(ctx context.Context, l syncLocation, opts *SyncOptions) (map[string]*syncFile, error) {
+	files := make(map[string]*syncFile)
+	if !l.local() {
+		for object, err := range p.ObjectStore.Objects(ctx, l.bucket, &ListOptions{Prefix: l.root}) {
+			if err != nil {
+				return nil, err
+			}
+			rel := strings.TrimPrefix(object.Key, l.root)
+			// Keys ending in a slash are the folder markers of consoles.
+			if rel == "" || strings.HasSuffix(rel, "/") || !opts.selects(rel) {
+				continue
+			}
+			files[rel] = &syncFile{size: object.Size, modTime: object.LastModified, etag: object.ETag, metadata: object.Metadata}
+		}
+		return files, nil
+	}
+
+	err := filepath.WalkDir(l.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == l.root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !opts.selects(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[rel] = &syncFile{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// selects reports whether rel is included and not excluded.
+func (o *SyncOptions) selects(rel string) bool {
+	included := len(o.Include) == 0
+	for _, pattern := range o.Include {
+		included = included || matchSyncPattern(pattern, rel)
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range o.Exclude {
+		if matchSyncPattern(pattern, rel) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchSyncPattern(pattern, rel string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		for parent := path.Dir(rel); parent != "."; parent = path.Dir(parent) {
+			if matched, _ := path.Match(dir, parent); matched {
+				return true
+			}
+		}
+		return false
+	}
+	if matched, _ := path.Match(pattern, rel); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(rel))
+		return matched
+	}
+	return false
+}
+
+// syncer copies and deletes the files of a Sync.
+type syncer struct {
+	provider *CloudProvider
+	from, to syncLocation
+	opts     *SyncOptions
+
+	mu     sync.Mutex
+	report *SyncReport
+	// digests caches the MD5 digests of source files, computed when
+	// comparing them, for the metadata of their upload.
+	digests map[string]string
+}
+
+// done adds item to the list of the report.
+func (s *syncer) done(list *[]SyncItem, item SyncItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*list = append(*list, item)
+}
+
+// finish reports item in list, or in Failed if err is not nil.
+func (s *syncer) finish(list *[]SyncItem, item SyncItem, err error) {
+	if err != nil {
+		item.Err = err
+		list = &s.report.Failed
+	}
+	s.done(list, item)
+}
+
+// run calls fn for each path, Concurrency at a time. fn reports the item of
+// its path; paths left when ctx is done are reported in Failed.
+func (s *syncer) run(ctx context.Context, paths []string, fn func(context.Context, string)) {
+	concurrency := s.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultSyncConcurrency
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, rel := range paths {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			s.done(&s.report.Failed, SyncItem{Path: rel, Err: ctx.Err()})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(ctx, rel)
+		}()
+	}
+	wg.Wait()
+}
+
+// same reports whether target is up to date with file.
+func (s *syncer) same(ctx context.Context, rel string, file, target *syncFile) (bool, error) {
+	if file.size != target.size {
+		return false, nil
+	}
+	switch s.opts.Compare {
+	case CompareSize:
+		return true, nil
+	case CompareETag:
+		source, err := s.etag(rel, s.from, file)
+		if err != nil {
+			return false, err
+		}
+		dest, err := s.etag(rel, s.to, target)
+		return err == nil && source != "" && source == dest, err
+	case CompareChecksum:
+		source, err := s.digest(ctx, rel, s.from, file)
+		if err != nil {
+			return false, err
+		}
+		dest, err := s.digest(ctx, rel, s.to, target)
+		return err == nil && source != "" && source == dest, err
+	default:
+		// Last-Modified headers have a precision of one second.
+		return !target.modTime.Before(file.modTime.Truncate(time.Second)), nil
+	}
+}
+
+// etag returns the ETag of rel at l, without quotes.
+func (s *syncer) etag(rel string, l syncLocation, file *syncFile) (string, error) {
+	if !l.local() {
+		return strings.Trim(file.etag, `"`), nil
+	}
+	return s.hash(rel, l)
+}
+
+// digest returns the MD5 digest of rel at l in hex, or "" if it is unknown.
+func (s *syncer) digest(ctx context.Context, rel string, l syncLocation, file *syncFile) (string, error) {
+	if l.local() {
+		return s.hash(rel, l)
+	}
+	metadata := file.metadata
+	if metadata == nil {
+		// Listings leave out the metadata of objects.
+		key, _ := l.path(rel)
+		object, err := s.provider.ObjectStore.HeadObject(ctx, l.bucket, key)
+		if err != nil {
+			return "", err
+		}
+		metadata = object.Metadata
+	}
+	for key, value := range metadata {
+		if strings.EqualFold(key, SyncDigestMetadata) {
+			return value, nil
+		}
+	}
+	if etag := strings.Trim(file.etag, `"`); len(etag) == 2*md5.Size {
+		if _, err := hex.DecodeString(etag); err == nil {
+			return etag, nil
+		}
+	}
+	return "", nil
+}
+
+// hash returns the MD5 digest of the local file rel at l in hex, caching
+// those of the source.
+func (s *syncer) hash(rel string, l syncLocation) (string, error) {
+	name, err := l.path(rel)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if l == s.from {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.digests == nil {
+			s.digests = make(map[string]string)
+		}
+		s.digests[rel] = digest
+	}
+	return digest, nil
+}
+
+// copy copies rel from the source to the destination.
+func (s *syncer) copy(ctx context.Context, rel string, file *syncFile) error {
+	from, err := s.from.path(rel)
+	if err != nil {
+		return err
+	}
+	to, err := s.to.path(rel)
+	if err != nil {
+		return err
+	}
+	if s.opts.DryRun {
+		return nil
+	}
+	store := s.provider.ObjectStore
+	switch {
+	case !s.from.local() && !s.to.local():
+		return store.CopyObject(ctx, s.from.bucket, from, s.to.bucket, to)
+
+	case s.from.local():
+		f, err := os.Open(from)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts := &PutOptions{ContentType: mime.TypeByExtension(path.Ext(rel))}
+		if s.opts.Compare == CompareChecksum {
+			s.mu.Lock()
+			digest, ok := s.digests[rel]
+			s.mu.Unlock()
+			if !ok {
+				if digest, err = s.hash(rel, s.from); err != nil {
+					return err
+				}
+			}
+			opts.Metadata = map[string]string{SyncDigestMetadata: digest}
+		}
+		return store.PutObject(ctx, s.to.bucket, to, f, opts)
+
+	default:
+		body, object, err := store.GetObject(ctx, s.from.bucket, from)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(filepath.Dir(to), "."+filepath.Base(to)+".c2loud-sync-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		_, err = io.Copy(f, body)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		// The modification time of the object lets CompareModTime skip the
+		// file on the next sync.
+		if err := os.Chtimes(f.Name(), object.LastModified, object.LastModified); err != nil {
+			return err
+		}
+		return os.Rename(f.Name(), to)
+	}
+}
+
+// delete deletes rel from the destination.
+func (s *syncer) delete(ctx context.Context, rel string) error {
+	name, err := s.to.path(rel)
+	if err != nil {
+		return err
+	}
+	if s.opts.DryRun {
+		return nil
+	}
+	if s.to.local() {
+		return os.Remove(name)
+	}
+	return s.provider.ObjectStore.DeleteObject(ctx, s.to.bucket, name)
+}
@@ -0,0 +1,271 @@
+package cloud_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/fake"
+)
+
+func newSyncProvider(t *testing.T) (*cloud.CloudProvider, *fake.Provider) {
+	t.Helper()
+	provider, err := cloud.NewCloudProvider(context.Background(), cloud.FakeProvider, &fake.Config{})
+	if err != nil {
+		t.Fatalf("NewCloudProvider: %v", err)
+	}
+	if err := provider.ObjectStore.CreateBucket(context.Background(), "artifacts"); err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+	return provider, provider.Native.(*fake.Provider)
+}
+
+// writeFiles writes files, keyed by slash-separated paths, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// paths returns the paths of items.
+func paths(items []cloud.SyncItem) string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Path)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSyncUpload(t *testing.T) {
+	ctx := context.Background()
+	provider, p := newSyncProvider(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"index.html":     "<html></html>",
+		"js/app.js":      "main()",
+		"js/app.js.map":  "{}",
+		"cache/data.bin": "cached",
+	})
+	opts := &cloud.SyncOptions{Exclude: []string{"*.map", "cache/**"}}
+
+	report, err := provider.Sync(ctx, dir, "s3://artifacts/site", opts)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := paths(report.Copied); got != "index.html,js/app.js" {
+		t.Errorf("Copied = %s, want index.html,js/app.js", got)
+	}
+	if got, _ := p.S3.Object("artifacts", "site/js/app.js"); string(got) != "main()" {
+		t.Errorf("site/js/app.js = %q", got)
+	}
+	if report.Bytes() != int64(len("<html></html>")+len("main()")) {
+		t.Errorf("Bytes = %d", report.Bytes())
+	}
+
+	// Nothing changed, so a second sync copies nothing.
+	report, err = provider.Sync(ctx, dir, "s3://artifacts/site", opts)
+	if err != nil || len(report.Copied) != 0 || len(report.Skipped) != 2 {
+		t.Fatalf("second Sync = %+v, %v; want 2 skipped", report, err)
+	}
+
+	writeFiles(t, dir, map[string]string{"js/app.js": "main(); init()"})
+	if err := os.Remove(filepath.Join(dir, "index.html")); err != nil {
+		t.Fatal(err)
+	}
+	opts.Delete, opts.DryRun = true, true
+	report, err = provider.Sync(ctx, dir, "s3://artifacts/site/", opts)
+	if err != nil || paths(report.Copied) != "js/app.js" || paths(report.Deleted) != "index.html" {
+		t.Fatalf("dry run Sync = %+v, %v", report, err)
+	}
+	if _, ok := p.S3.Object("artifacts", "site/index.html"); !ok {
+		t.Error("dry run deleted site/index.html")
+	}
+
+	opts.DryRun = false
+	if _, err := provider.Sync(ctx, dir, "s3://artifacts/site", opts); err != nil {
+		t.Fatalf("Sync with Delete: %v", err)
+	}
+	if _, ok := p.S3.Object("artifacts", "site/index.html"); ok {
+		t.Error("site/index.html not deleted")
+	}
+	if got, _ := p.S3.Object("artifacts", "site/js/app.js"); string(got) != "main(); init()" {
+		t.Errorf("site/js/app.js = %q after a change", got)
+	}
+}
+
+func TestSyncDownload(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newSyncProvider(t)
+	for key, content := range map[string]string{"logs/a.log": "a", "logs/2024/b.log": "bb", "other.txt": "x"} {
+		if err := provider.ObjectStore.PutObject(ctx, "artifacts", key, strings.NewReader(content), nil); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+	}
+	dir := filepath.Join(t.TempDir(), "logs")
+	writeFiles(t, dir, map[string]string{"stale.log": "old"})
+
+	report, err := provider.Sync(ctx, "s3://artifacts/logs", dir, &cloud.SyncOptions{Delete: true, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if paths(report.Copied) != "2024/b.log,a.log" || paths(report.Deleted) != "stale.log" {
+		t.Errorf("Sync = %+v", report)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "2024", "b.log")); string(got) != "bb" {
+		t.Errorf("2024/b.log = %q", got)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*", ".*")); len(matches) != 0 {
+		t.Errorf("temporary files left: %q", matches)
+	}
+
+	// Downloaded files take the modification time of their object.
+	report, err = provider.Sync(ctx, "s3://artifacts/logs", dir, nil)
+	if err != nil || len(report.Copied) != 0 || len(report.Skipped) != 2 {
+		t.Errorf("second Sync = %+v, %v; want 2 skipped", report, err)
+	}
+}
+
+func TestSyncChecksum(t *testing.T) {
+	ctx := context.Background()
+	provider, p := newSyncProvider(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "aaaa", "b.txt": "bbbb"})
+	opts := &cloud.SyncOptions{Compare: cloud.CompareChecksum}
+
+	if _, err := provider.Sync(ctx, dir, "s3://artifacts/src", opts); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if _, err := provider.Sync(ctx, "s3://artifacts/src", "s3://artifacts/dst", opts); err != nil {
+		t.Fatalf("Sync between prefixes: %v", err)
+	}
+	if got, _ := p.S3.Object("artifacts", "dst/b.txt"); string(got) != "bbbb" {
+		t.Errorf("dst/b.txt = %q", got)
+	}
+
+	// A change keeping the size is only seen by comparing checksums.
+	writeFiles(t, dir, map[string]string{"a.txt": "AAAA"})
+	report, err := provider.Sync(ctx, dir, "s3://artifacts/src", &cloud.SyncOptions{Compare: cloud.CompareSize})
+	if err != nil || len(report.Copied) != 0 {
+		t.Errorf("Sync comparing sizes = %+v, %v; want nothing copied", report, err)
+	}
+	report, err = provider.Sync(ctx, dir, "s3://artifacts/src", opts)
+	if err != nil || paths(report.Copied) != "a.txt" || paths(report.Skipped) != "b.txt" {
+		t.Errorf("Sync comparing checksums = %+v, %v; want a.txt copied", report, err)
+	}
+	report, err = provider.Sync(ctx, "s3://artifacts/src", "s3://artifacts/dst", &cloud.SyncOptions{Compare: cloud.CompareETag})
+	if err != nil || paths(report.Copied) != "a.txt" {
+		t.Errorf("Sync comparing ETags = %+v, %v; want a.txt copied", report, err)
+	}
+	if got, _ := p.S3.Object("artifacts", "dst/a.txt"); !bytes.Equal(got, []byte("AAAA")) {
+		t.Errorf("dst/a.txt = %q", got)
+	}
+}
+
+// barrierStore holds HeadObject calls until n of them are in flight.
+type barrierStore struct {
+	cloud.ObjectStore
+	n int
+
+	mu      sync.Mutex
+	arrived int
+	all     chan struct{}
+}
+
+func (s *barrierStore) HeadObject(ctx context.Context, bucket, key string) (*cloud.Object, error) {
+	s.mu.Lock()
+	if s.arrived++; s.arrived == s.n {
+		close(s.all)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.all:
+		return s.ObjectStore.HeadObject(ctx, bucket, key)
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("HeadObject calls were not concurrent")
+	}
+}
+
+func TestSyncComparesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newSyncProvider(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c", "d.txt": "d"})
+	opts := &cloud.SyncOptions{Compare: cloud.CompareChecksum, Concurrency: 4}
+	if _, err := provider.Sync(ctx, dir, "s3://artifacts", opts); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	// Listings leave out the digests of the objects, so each comparison
+	// heads its object.
+	provider.ObjectStore = &barrierStore{ObjectStore: provider.ObjectStore, n: 4, all: make(chan struct{})}
+	report, err := provider.Sync(ctx, dir, "s3://artifacts", opts)
+	if err != nil || len(report.Skipped) != 4 {
+		t.Errorf("Sync = %+v, %v; want 4 files compared at once and skipped", report, err)
+	}
+}
+
+func TestSyncErrors(t *testing.T) {
+	ctx := context.Background()
+	provider, p := newSyncProvider(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	if _, err := provider.Sync(ctx, dir, t.TempDir(), nil); !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("Sync between local directories: got %v, want ErrInvalidArgument", err)
+	}
+	if _, err := provider.Sync(ctx, dir, "s3://artifacts", &cloud.SyncOptions{Include: []string{"["}}); !errors.Is(err, cloud.ErrInvalidArgument) {
+		t.Errorf("Sync with a bad pattern: got %v, want ErrInvalidArgument", err)
+	}
+
+	p.InjectError("s3", "PutObject", fake.Error("AccessDenied", 403), 1)
+	report, err := provider.Sync(ctx, dir, "s3://artifacts", &cloud.SyncOptions{Concurrency: 1})
+	if !errors.Is(err, cloud.ErrPermissionDenied) {
+		t.Fatalf("Sync: got %v, want ErrPermissionDenied", err)
+	}
+	if len(report.Failed) != 1 || len(report.Copied) != 1 {
+		t.Errorf("Sync = %+v; want one copied and one failed", report)
+	}
+}
+
+func TestSyncRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newSyncProvider(t)
+	for _, key := range []string{"logs/ok.log", "logs/../evil", "logs/sub/../../evil", "logs/a/../b.log"} {
+		if err := provider.ObjectStore.PutObject(ctx, "artifacts", key, strings.NewReader("x"), nil); err != nil {
+			t.Fatalf("PutObject(%s): %v", key, err)
+		}
+	}
+	root := t.TempDir()
+	dir := filepath.Join(root, "logs")
+
+	for _, dryRun := range []bool{true, false} {
+		report, err := provider.Sync(ctx, "s3://artifacts/logs", dir, &cloud.SyncOptions{DryRun: dryRun})
+		if !errors.Is(err, cloud.ErrInvalidArgument) {
+			t.Errorf("Sync(DryRun %v): got %v, want ErrInvalidArgument", dryRun, err)
+		}
+		if paths(report.Copied) != "ok.log" || paths(report.Failed) != "../evil,a/../b.log,sub/../../evil" {
+			t.Errorf("Sync(DryRun %v) = %+v; want ok.log copied and the others failed", dryRun, report)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Sync wrote outside the destination: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 || entries[0].Name() != "ok.log" {
+		t.Errorf("destination holds %v, want only ok.log", entries)
+	}
+}
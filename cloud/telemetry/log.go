@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// MaxLoggedBody is the number of bytes of each request and response body
+// logged when Options.LogBodies is set.
+const MaxLoggedBody = 4096
+
+// logEnabled reports whether calls to service are logged at level.
+func (in *Instrumentation) logEnabled(ctx context.Context, service string, level slog.Level) bool {
+	if in == nil || in.logger == nil {
+		return false
+	}
+	if min, ok := in.serviceLevels[service]; ok && level < min {
+		return false
+	}
+	return in.logger.Enabled(ctx, level)
+}
+
+// LogsBodies reports whether the request and response bodies of service are
+// logged: Options.LogBodies is set and debug records of service are enabled.
+func (in *Instrumentation) LogsBodies(ctx context.Context, service string) bool {
+	return in != nil && in.logBodies && in.logEnabled(ctx, service, slog.LevelDebug)
+}
+
+// log records the completion of the call at debug level, or at warning level
+// if it failed.
+func (c *Call) log(duration time.Duration, requestID string, err error) {
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !c.in.logEnabled(c.ctx, c.labels["service"], level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("provider", c.labels["provider"]),
+		slog.String("service", c.labels["service"]),
+		slog.String("operation", c.labels["operation"]),
+	}
+	for _, kv := range c.resources {
+		attrs = append(attrs, slog.String(strings.TrimPrefix(string(kv.Key), "c2loud."), kv.Value.Emit()))
+	}
+	attrs = append(attrs, slog.Duration("duration", duration), slog.Int("retries", c.retries))
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error_class", ErrorClass(err)), slog.String("error", err.Error()))
+	}
+	c.in.logger.LogAttrs(c.ctx, level, "cloud call", attrs...)
+}
+
+// LogBody logs, at debug level, the start of the body of a request or
+// response of the call, given by kind, with its URL. Sensitive fields are
+// redacted with RedactBody and RedactURL, and bodies that cannot be redacted
+// are not logged.
+func (c *Call) LogBody(kind, rawURL, contentType string, body []byte) {
+	if c == nil || !c.in.LogsBodies(c.ctx, c.labels["service"]) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("service", c.labels["service"]),
+		slog.String("operation", c.labels["operation"]),
+	}
+	if rawURL != "" {
+		attrs = append(attrs, slog.String("url", RedactURL(rawURL)))
+	}
+	if len(body) > MaxLoggedBody {
+		body = body[:MaxLoggedBody]
+	}
+	if redacted, ok := RedactBody(contentType, body); ok {
+		attrs = append(attrs, slog.String("body", string(redacted)))
+	} else if len(body) > 0 {
+		attrs = append(attrs, slog.String("content_type", contentType))
+	}
+	c.in.logger.LogAttrs(c.ctx, slog.LevelDebug, "cloud "+kind, attrs...)
+}
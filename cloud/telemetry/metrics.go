@@ -0,0 +1,118 @@
+package telemetry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// labels are the labels of every c2loud metric.
+var labels = []string{"provider", "service", "operation"}
+
+// metrics are the Prometheus collectors of calls. A nil *metrics records
+// nothing.
+type metrics struct {
+	// duration is the latency of calls, retries included.
+	duration *prometheus.HistogramVec
+	// calls counts completed calls by outcome: "success" or the error
+	// class.
+	calls *prometheus.CounterVec
+	// retries counts retried attempts.
+	retries *prometheus.CounterVec
+	// throttles counts attempts rejected because of throttling.
+	throttles *prometheus.CounterVec
+	// inFlight is the number of calls in progress.
+	inFlight *prometheus.GaugeVec
+}
+
+// newMetrics registers the call metrics on reg, or reuses those already
+// registered there by another provider.
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{}
+	var err error
+	if m.duration, err = register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "c2loud_operation_duration_seconds",
+		Help:    "Latency of calls to cloud services, retries included.",
+		Buckets: prometheus.DefBuckets,
+	}, labels)); err != nil {
+		return nil, err
+	}
+	if m.calls, err = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "c2loud_operations_total",
+		Help: "Calls to cloud services by outcome, either success or the class of the error.",
+	}, []string{"provider", "service", "operation", "outcome"})); err != nil {
+		return nil, err
+	}
+	if m.retries, err = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "c2loud_retries_total",
+		Help: "Retried attempts of calls to cloud services.",
+	}, labels)); err != nil {
+		return nil, err
+	}
+	if m.throttles, err = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "c2loud_throttles_total",
+		Help: "Attempts of calls to cloud services rejected because of throttling.",
+	}, labels)); err != nil {
+		return nil, err
+	}
+	if m.inFlight, err = register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "c2loud_operations_in_flight",
+		Help: "Calls to cloud services in progress.",
+	}, labels)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers c on reg. If an identical collector is already
+// registered, it is returned instead.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+func (m *metrics) started(c *Call) {
+	if m == nil {
+		return
+	}
+	m.inFlight.With(c.labels).Inc()
+}
+
+func (m *metrics) retried(c *Call, err error) {
+	if m == nil {
+		return
+	}
+	m.retries.With(c.labels).Inc()
+	if errors.Is(err, cloud.ErrThrottled) {
+		m.throttles.With(c.labels).Inc()
+	}
+}
+
+func (m *metrics) ended(c *Call, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.inFlight.With(c.labels).Dec()
+	m.duration.With(c.labels).Observe(duration.Seconds())
+	if errors.Is(err, cloud.ErrThrottled) {
+		m.throttles.With(c.labels).Inc()
+	}
+
+	outcome := "success"
+	if err != nil {
+		outcome = ErrorClass(err)
+	}
+	m.calls.WithLabelValues(c.labels["provider"], c.labels["service"], c.labels["operation"], outcome).Inc()
+}
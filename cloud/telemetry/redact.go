@@ -0,0 +1,107 @@
+package telemetry
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in logged values.
+const Redacted = "REDACTED"
+
+// sensitiveFields are the names of request and response fields, in AWS query
+// parameters, XML and JSON, whose values are never logged: credentials,
+// tokens and message bodies.
+var sensitiveFields = []string{
+	"AccessKey", "SecretKey", "SecretAccessKey", "SessionToken", "Password", "NewPassword", "OldPassword",
+	"WebIdentityToken", "SAMLAssertion", "TokenCode", "PrivateKey", "privateKeyData",
+	"access_token", "refresh_token", "id_token", "client_secret",
+	"MessageBody", "Message", "Body", "Subject", "data",
+}
+
+// sensitiveQueryParams are the query parameters of presigned and signed URLs
+// that grant access, compared case-insensitively.
+var sensitiveQueryParams = map[string]bool{
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"x-goog-signature":     true,
+	"x-goog-credential":    true,
+	"signature":            true,
+	"awsaccesskeyid":       true,
+	"access_token":         true,
+	"key":                  true,
+}
+
+var (
+	fieldNames = strings.Join(sensitiveFields, "|")
+	// formField matches fields such as "MessageBody=..." or
+	// "SendMessageBatchRequestEntry.1.MessageBody=..." in AWS query bodies.
+	// The XML and JSON patterns also match values cut off by the end of a
+	// truncated body.
+	formField = regexp.MustCompile(`(^|&)((?:[^=&]*\.)?(?:` + fieldNames + `))=[^&]*`)
+	xmlField  = regexp.MustCompile(`<(` + fieldNames + `)>[^<]*(</|$)`)
+	jsonField = regexp.MustCompile(`"(` + fieldNames + `)"(\s*:\s*)"(?:[^"\\]|\\.)*("|\\?$)`)
+)
+
+// RedactURL returns rawURL with the credentials of signed URLs, such as
+// X-Amz-Signature or X-Goog-Credential, and any user password replaced by
+// Redacted. URLs that cannot be parsed are redacted entirely.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		if sensitiveQueryParams[strings.ToLower(name)] {
+			query.Set(name, Redacted)
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// RedactBody returns body, of the given content type, with the values of
+// sensitive fields replaced by Redacted. Only form, XML and JSON bodies can be
+// redacted; for other content types, which may be arbitrary object data,
+// RedactBody returns false.
+func RedactBody(contentType string, body []byte) ([]byte, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return formField.ReplaceAll(body, []byte("${1}${2}="+Redacted)), true
+	case strings.HasSuffix(mediaType, "xml"):
+		return xmlField.ReplaceAll(body, []byte("<${1}>"+Redacted+"${2}")), true
+	case strings.Contains(mediaType, "json"):
+		return jsonField.ReplaceAll(body, []byte(`"${1}"${2}"`+Redacted+`"`)), true
+	}
+	return nil, false
+}
+
+// PeekBody reads up to n bytes from the start of body. It returns them with
+// a body that still reads from the start, and closes the original body when
+// closed.
+func PeekBody(body io.ReadCloser, n int) ([]byte, io.ReadCloser, error) {
+	buf := make([]byte, n)
+	m, err := io.ReadFull(body, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	buf = buf[:m]
+	return buf, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), body), body}, err
+}
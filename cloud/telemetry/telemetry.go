@@ -0,0 +1,239 @@
+// Package telemetry instruments the calls c2loud providers make to cloud
+// services.
+//
+// Providers build an Instrumentation from their configuration and wrap every
+// request they send in a Call, from the middleware of the underlying SDK:
+// request handlers for AWS, and the HTTP transport and gRPC interceptors for
+// GCP, so that new services are instrumented without further work. Each call
+// is traced as an OpenTelemetry client span named "<service>.<operation>",
+// such as "s3.PutObject" or "compute.instances.get", carrying the attributes
+// below, counted in the c2loud_* Prometheus metrics, labelled by provider,
+// service and operation, and logged with log/slog.
+//
+// Logs never contain credentials or message bodies: request and response
+// bodies, which are only logged on demand, and signed URLs are redacted.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// ScopeName is the instrumentation scope of the c2loud tracer.
+const ScopeName = "github.com/Akshay-Verma-CS/c2loud"
+
+// Span attributes.
+const (
+	// ProviderKey is the lowercase provider type, such as "aws" or "gcp".
+	ProviderKey = attribute.Key("cloud.provider")
+	// ServiceKey is the service name used in the provider configuration,
+	// such as "s3" or "pubsub".
+	ServiceKey = attribute.Key("rpc.service")
+	// OperationKey is the API operation, such as "PutObject".
+	OperationKey = attribute.Key("rpc.method")
+
+	// BucketKey is the bucket of an object storage call.
+	BucketKey = attribute.Key("c2loud.bucket")
+	// QueueKey is the SQS queue URL or Pub/Sub subscription of a call.
+	QueueKey = attribute.Key("c2loud.queue")
+	// TopicKey is the SNS topic ARN or Pub/Sub topic of a call.
+	TopicKey = attribute.Key("c2loud.topic")
+	// InstanceKey is the ID of the VM instances of a call, comma-separated
+	// when there are several.
+	InstanceKey = attribute.Key("c2loud.instance_id")
+
+	// RetriesKey is the number of retries made before the call completed.
+	RetriesKey = attribute.Key("c2loud.retries")
+	// RequestIDKey is the request ID returned by the provider.
+	RequestIDKey = attribute.Key("c2loud.request_id")
+	// ErrorClassKey is the class of a failed call, from ErrorClass.
+	ErrorClassKey = attribute.Key("error.type")
+)
+
+// errorClasses names the sentinel errors of package cloud.
+var errorClasses = []struct {
+	kind  error
+	class string
+}{
+	{cloud.ErrNotFound, "not_found"},
+	{cloud.ErrAlreadyExists, "already_exists"},
+	{cloud.ErrPermissionDenied, "permission_denied"},
+	{cloud.ErrThrottled, "throttled"},
+	{cloud.ErrConflict, "conflict"},
+	{cloud.ErrInvalidArgument, "invalid_argument"},
+	{cloud.ErrUnavailable, "unavailable"},
+	{context.Canceled, "canceled"},
+	{context.DeadlineExceeded, "deadline_exceeded"},
+}
+
+// ErrorClass returns the class of err, such as "not_found" or "throttled",
+// from the sentinel errors of package cloud. It returns "" for a nil error and
+// "other" for errors that are not classified.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range errorClasses {
+		if errors.Is(err, c.kind) {
+			return c.class
+		}
+	}
+	return "other"
+}
+
+// Options selects what an Instrumentation records.
+type Options struct {
+	// TracerProvider traces calls when set.
+	TracerProvider trace.TracerProvider
+	// Registerer is where the call metrics are registered when set. The
+	// metrics are shared by every provider registered on the same
+	// Registerer.
+	Registerer prometheus.Registerer
+	// Logger logs calls when set: successful calls at debug level and
+	// failed ones at warning level.
+	Logger *slog.Logger
+	// ServiceLogLevels sets the minimum level of the records of calls to a
+	// service, on top of the level of the Logger's handler.
+	ServiceLogLevels map[string]slog.Level
+	// LogBodies logs the start of request and response bodies at debug
+	// level, for debugging.
+	LogBodies bool
+}
+
+// Instrumentation records the calls of one provider. A nil *Instrumentation
+// records nothing.
+type Instrumentation struct {
+	provider string
+	tracer   trace.Tracer
+	metrics  *metrics
+
+	logger        *slog.Logger
+	serviceLevels map[string]slog.Level
+	logBodies     bool
+}
+
+// New returns the instrumentation of provider. It returns nil when opts
+// enable nothing, and fails if the metrics cannot be registered.
+func New(provider cloud.ProviderType, opts Options) (*Instrumentation, error) {
+	if opts.TracerProvider == nil && opts.Registerer == nil && opts.Logger == nil {
+		return nil, nil
+	}
+
+	in := &Instrumentation{
+		provider:      strings.ToLower(string(provider)),
+		logger:        opts.Logger,
+		serviceLevels: opts.ServiceLogLevels,
+		logBodies:     opts.LogBodies,
+	}
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	in.tracer = tp.Tracer(ScopeName)
+	if opts.Registerer != nil {
+		m, err := newMetrics(opts.Registerer)
+		if err != nil {
+			return nil, err
+		}
+		in.metrics = m
+	}
+	return in, nil
+}
+
+// Call is a call to a cloud service in progress.
+type Call struct {
+	in        *Instrumentation
+	ctx       context.Context
+	span      trace.Span
+	labels    prometheus.Labels
+	resources []attribute.KeyValue
+	start     time.Time
+	retries   int
+}
+
+// Start starts a call to operation of service, annotated with attrs such as
+// the resource it acts on. The returned context carries the call's span.
+func (in *Instrumentation) Start(ctx context.Context, service, operation string, attrs ...attribute.KeyValue) (context.Context, *Call) {
+	if in == nil {
+		return ctx, nil
+	}
+
+	ctx, span := in.tracer.Start(ctx, service+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(ProviderKey.String(in.provider), ServiceKey.String(service), OperationKey.String(operation)),
+		trace.WithAttributes(attrs...),
+	)
+	call := &Call{
+		in:        in,
+		ctx:       ctx,
+		span:      span,
+		labels:    prometheus.Labels{"provider": in.provider, "service": service, "operation": operation},
+		resources: attrs,
+		start:     time.Now(),
+	}
+	in.metrics.started(call)
+	return ctx, call
+}
+
+// SetAttributes annotates the call with attrs.
+func (c *Call) SetAttributes(attrs ...attribute.KeyValue) {
+	if c == nil {
+		return
+	}
+	c.span.SetAttributes(attrs...)
+}
+
+// Retry records that the call is retried after an attempt failed with err.
+// err is nil when the cause is not known, as when a transport sees the retry
+// of an earlier request.
+func (c *Call) Retry(err error) {
+	if c == nil {
+		return
+	}
+
+	c.retries++
+	event := []attribute.KeyValue{RetriesKey.Int(c.retries)}
+	if err != nil {
+		event = append(event, ErrorClassKey.String(ErrorClass(err)))
+	}
+	c.span.AddEvent("retry", trace.WithAttributes(event...))
+	c.in.metrics.retried(c, err)
+}
+
+// End completes the call. requestID is the provider's request ID if known,
+// and err the classified error the call failed with, if any.
+func (c *Call) End(requestID string, err error) {
+	if c == nil {
+		return
+	}
+
+	duration := time.Since(c.start)
+	var cerr *cloud.Error
+	if requestID == "" && errors.As(err, &cerr) {
+		requestID = cerr.RequestID
+	}
+	c.in.metrics.ended(c, duration, err)
+	c.log(duration, requestID, err)
+
+	c.span.SetAttributes(RetriesKey.Int(c.retries))
+	if requestID != "" {
+		c.span.SetAttributes(RequestIDKey.String(requestID))
+	}
+	if err != nil {
+		c.span.SetAttributes(ErrorClassKey.String(ErrorClass(err)))
+		c.span.RecordError(err)
+		c.span.SetStatus(codes.Error, err.Error())
+	}
+	c.span.End()
+}
@@ -0,0 +1,170 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewDisabled(t *testing.T) {
+	in, err := New(cloud.AWSProvider, Options{})
+	if err != nil || in != nil {
+		t.Fatalf("New() = %v, %v, want nil, nil", in, err)
+	}
+	// A nil Instrumentation starts calls that record nothing.
+	ctx, call := in.Start(context.Background(), "s3", "PutObject")
+	call.Retry(cloud.ErrThrottled)
+	call.End("", nil)
+	if ctx != context.Background() {
+		t.Error("Start() changed the context of a nil Instrumentation")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	in, err := New(cloud.AWSProvider, Options{Registerer: reg})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, call := in.Start(context.Background(), "sqs", "SendMessage")
+	if got := testutil.ToFloat64(in.metrics.inFlight.WithLabelValues("aws", "sqs", "SendMessage")); got != 1 {
+		t.Errorf("in flight = %v, want 1", got)
+	}
+	call.Retry(&cloud.Error{Kind: cloud.ErrThrottled, Err: errors.New("Throttling")})
+	call.Retry(&cloud.Error{Kind: cloud.ErrUnavailable, Err: errors.New("InternalError")})
+	call.End("", nil)
+
+	_, call = in.Start(context.Background(), "sqs", "SendMessage")
+	call.End("", &cloud.Error{Kind: cloud.ErrThrottled, Err: errors.New("Throttling")})
+
+	want := `
+# HELP c2loud_operations_in_flight Calls to cloud services in progress.
+# TYPE c2loud_operations_in_flight gauge
+c2loud_operations_in_flight{operation="SendMessage",provider="aws",service="sqs"} 0
+# HELP c2loud_operations_total Calls to cloud services by outcome, either success or the class of the error.
+# TYPE c2loud_operations_total counter
+c2loud_operations_total{operation="SendMessage",outcome="success",provider="aws",service="sqs"} 1
+c2loud_operations_total{operation="SendMessage",outcome="throttled",provider="aws",service="sqs"} 1
+# HELP c2loud_retries_total Retried attempts of calls to cloud services.
+# TYPE c2loud_retries_total counter
+c2loud_retries_total{operation="SendMessage",provider="aws",service="sqs"} 2
+# HELP c2loud_throttles_total Attempts of calls to cloud services rejected because of throttling.
+# TYPE c2loud_throttles_total counter
+c2loud_throttles_total{operation="SendMessage",provider="aws",service="sqs"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"c2loud_operations_in_flight", "c2loud_operations_total", "c2loud_retries_total", "c2loud_throttles_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(in.metrics.duration); n != 1 {
+		t.Errorf("duration has %d series, want 1", n)
+	}
+}
+
+func TestMetricsSharedByProviders(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	aws, err := New(cloud.AWSProvider, Options{Registerer: reg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcp, err := New(cloud.GCPProvider, Options{Registerer: reg})
+	if err != nil {
+		t.Fatalf("New() on a shared Registerer error = %v", err)
+	}
+
+	_, call := aws.Start(context.Background(), "s3", "GetObject")
+	call.End("", nil)
+	_, call = gcp.Start(context.Background(), "storage", "objects.get")
+	call.End("", nil)
+
+	if n := testutil.CollectAndCount(aws.metrics.calls); n != 2 {
+		t.Errorf("calls has %d series, want one per provider", n)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&cloud.Error{Kind: cloud.ErrNotFound, Err: errors.New("NoSuchKey")}, "not_found"},
+		{context.DeadlineExceeded, "deadline_exceeded"},
+		{errors.New("boom"), "other"},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	got := RedactURL("https://bkt.s3.amazonaws.com/a.txt?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKID%2F20260101&X-Amz-Signature=abc123&X-Amz-Security-Token=tok")
+	for _, secret := range []string{"AKID", "abc123", "tok"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("RedactURL() = %q, contains %q", got, secret)
+		}
+	}
+	if !strings.Contains(got, "X-Amz-Algorithm=AWS4-HMAC-SHA256") {
+		t.Errorf("RedactURL() = %q, dropped the algorithm", got)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		contentType, body, want string
+	}{
+		{"application/x-www-form-urlencoded", "Action=SendMessageBatch&SendMessageBatchRequestEntry.1.MessageBody=secret&Version=2012-11-05",
+			"Action=SendMessageBatch&SendMessageBatchRequestEntry.1.MessageBody=REDACTED&Version=2012-11-05"},
+		{"text/xml", "<AccessKey><AccessKeyId>AKID</AccessKeyId><SecretAccessKey>s3cr3t</SecretAccessKey></AccessKey>",
+			"<AccessKey><AccessKeyId>AKID</AccessKeyId><SecretAccessKey>REDACTED</SecretAccessKey></AccessKey>"},
+		{"application/json; charset=UTF-8", `{"messages":[{"data":"c2VjcmV0"}],"topic":"t"}`,
+			`{"messages":[{"data":"REDACTED"}],"topic":"t"}`},
+		// A body truncated in the middle of a secret.
+		{"application/x-amz-json-1.0", `{"QueueUrl":"q","MessageBody":"sec`, `{"QueueUrl":"q","MessageBody":"REDACTED"`},
+	}
+	for _, tt := range tests {
+		got, ok := RedactBody(tt.contentType, []byte(tt.body))
+		if !ok || string(got) != tt.want {
+			t.Errorf("RedactBody(%q) = %q, %v, want %q", tt.body, got, ok, tt.want)
+		}
+	}
+	if _, ok := RedactBody("application/octet-stream", []byte("object data")); ok {
+		t.Error("RedactBody() accepted object data")
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	var logs bytes.Buffer
+	in, err := New(cloud.GCPProvider, Options{
+		Logger:           slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		ServiceLogLevels: map[string]slog.Level{"storage": slog.LevelWarn},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, call := in.Start(context.Background(), "storage", "objects.get", BucketKey.String("bkt"))
+	call.End("", nil)
+	if logs.Len() > 0 {
+		t.Errorf("successful storage call logged below its level: %s", logs.String())
+	}
+
+	_, call = in.Start(context.Background(), "storage", "objects.get", BucketKey.String("bkt"))
+	call.End("", &cloud.Error{Kind: cloud.ErrNotFound, Err: errors.New("notFound")})
+	for _, want := range []string{"level=WARN", "operation=objects.get", "bucket=bkt", "error_class=not_found"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log %q does not contain %q", logs.String(), want)
+		}
+	}
+}
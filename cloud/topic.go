@@ -0,0 +1,38 @@
+package cloud
+
+import "context"
+
+// SubscribeOptions describes a subscription created by Topic.Subscribe.
+type SubscribeOptions struct {
+	// Protocol is the delivery protocol. SNS accepts its own protocols
+	// ("sqs", "http", "https", "email", ...). Pub/Sub accepts "pull", and
+	// "http" or "https" for push delivery to an Endpoint of that scheme. Both
+	// providers report HTTP subscriptions with the same protocols.
+	Protocol string
+	// Endpoint is where messages are delivered: a queue ARN, URL or address.
+	// It is ignored for Pub/Sub pull subscriptions.
+	Endpoint string
+	// Name is the subscription ID. Pub/Sub requires it; SNS ignores it.
+	Name string
+	// Attributes holds provider-specific subscription settings, for example
+	// FilterPolicy or RawMessageDelivery for SNS and Filter or
+	// AckDeadlineSeconds for Pub/Sub.
+	Attributes map[string]string
+}
+
+// Topic is a provider-neutral interface over publish/subscribe fan-out such
+// as Amazon SNS and Google Pub/Sub topics. Topics and subscriptions are
+// identified by ARN for SNS and by ID or full resource name for Pub/Sub.
+type Topic interface {
+	// CreateTopic creates a topic and returns its identifier.
+	CreateTopic(ctx context.Context, name string) (string, error)
+	// DeleteTopic deletes a topic.
+	DeleteTopic(ctx context.Context, topic string) error
+	// Publish publishes body with the given attributes and returns the
+	// provider-assigned message ID.
+	Publish(ctx context.Context, topic string, body []byte, attributes map[string]string) (string, error)
+	// Subscribe attaches a subscription to topic and returns its identifier.
+	Subscribe(ctx context.Context, topic string, opts *SubscribeOptions) (string, error)
+	// Unsubscribe removes a subscription.
+	Unsubscribe(ctx context.Context, subscription string) error
+}
@@ -0,0 +1,563 @@
+// Package config loads provider configuration from files, environment
+// variables and named profiles.
+//
+// Configuration is layered. From lowest to highest precedence:
+//
+//  1. the top-level provider sections of each file, in the order given;
+//  2. the selected profile of each file, in the order given;
+//  3. environment variables prefixed with C2LOUD_.
+//
+// A file looks like this (JSON files use the same keys):
+//
+//	provider: aws
+//	profile: dev            # profile used when none is selected
+//	aws:
+//	  region: us-east-1
+//	profiles:
+//	  dev:
+//	    aws:
+//	      access_key: AKIA...
+//	      secret_key: ...
+//	  prod:
+//	    aws:
+//	      use_iam_role: true
+//	      iam_role_arn: arn:aws:iam::123456789012:role/deployer
+//	      external_id: c2loud
+//	      role_duration: 1h
+//	  analytics:
+//	    provider: gcp
+//	    gcp:
+//	      project_id: my-project
+//	      credentials: /etc/c2loud/gcp.json  # or inline JSON; omit for ADC
+//	      impersonate_service_account: reader@my-project.iam.gserviceaccount.com
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/gcp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvPrefix prefixes the environment variables read by the loader.
+const DefaultEnvPrefix = "C2LOUD"
+
+// Config is the resolved configuration of a single provider.
+type Config struct {
+	Provider cloud.ProviderType
+	// Profile is the name of the selected profile, if any.
+	Profile string
+	AWS     *aws.AWSConfig
+	GCP     *gcp.GCPConfig
+}
+
+// Loader reads and layers configuration sources.
+type Loader struct {
+	// Files are read in order; later files override earlier ones. When empty,
+	// the file named by $C2LOUD_CONFIG is read if it is set.
+	Files []string
+	// Profile selects a profile. It takes precedence over $C2LOUD_PROFILE and
+	// the files' default profile.
+	Profile string
+	// EnvPrefix replaces DefaultEnvPrefix.
+	EnvPrefix string
+	// LookupEnv replaces os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+}
+
+// FieldError reports an invalid or missing configuration value.
+type FieldError struct {
+	// Field is the dotted key of the value, such as "aws.iam_role_arn".
+	Field string
+	Msg   string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("config: %s: %s", e.Field, e.Msg)
+}
+
+// Load reads files with the default Loader.
+func Load(files ...string) (*Config, error) {
+	return (&Loader{Files: files}).Load()
+}
+
+// NewCloudProvider loads configuration from files and builds the configured
+// provider with cloud.NewCloudProvider.
+func NewCloudProvider(ctx context.Context, files ...string) (*cloud.CloudProvider, error) {
+	cfg, err := Load(files...)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewCloudProvider(ctx)
+}
+
+// Load reads every source, layers them and validates the result.
+func (l *Loader) Load() (*Config, error) {
+	files := l.Files
+	if len(files) == 0 {
+		if path, ok := l.lookupEnv("CONFIG"); ok && path != "" {
+			files = []string{path}
+		}
+	}
+
+	var base layer
+	var defaultProfile string
+	profiles := make(map[string]*layer)
+	for _, path := range files {
+		f, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		base.merge(&f.layer)
+		if f.Profile != "" {
+			defaultProfile = f.Profile
+		}
+		for name, p := range f.Profiles {
+			if profiles[name] == nil {
+				profiles[name] = &layer{}
+			}
+			profiles[name].merge(p)
+		}
+	}
+
+	profile := defaultProfile
+	if env, ok := l.lookupEnv("PROFILE"); ok && env != "" {
+		profile = env
+	}
+	if l.Profile != "" {
+		profile = l.Profile
+	}
+	if profile != "" {
+		p, ok := profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("config: profile %q not found", profile)
+		}
+		base.merge(p)
+	}
+
+	env, err := l.envLayer()
+	if err != nil {
+		return nil, err
+	}
+	base.merge(env)
+
+	cfg, err := base.config()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Profile = profile
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ProviderConfig returns the configuration of the selected provider, in the
+// form expected by its cloud.Factory.
+func (c *Config) ProviderConfig() (interface{}, error) {
+	switch c.Provider {
+	case cloud.AWSProvider:
+		return c.AWS, nil
+	case cloud.GCPProvider:
+		return c.GCP, nil
+	}
+	return nil, fmt.Errorf("config: provider %q is not supported", c.Provider)
+}
+
+// NewCloudProvider builds the selected provider with cloud.NewCloudProvider.
+func (c *Config) NewCloudProvider(ctx context.Context) (*cloud.CloudProvider, error) {
+	providerConfig, err := c.ProviderConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cloud.NewCloudProvider(ctx, c.Provider, providerConfig)
+}
+
+// Validate checks the configuration of the selected provider. It returns all
+// problems found, joined, as *FieldError values.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field, msg string) {
+		errs = append(errs, &FieldError{Field: field, Msg: msg})
+	}
+
+	switch c.Provider {
+	case "":
+		invalid("provider", "is required")
+	case cloud.AWSProvider:
+		if c.AWS == nil {
+			invalid("aws", "is required when provider is aws")
+			break
+		}
+		// aws.region is optional: the AWS SDK then reads it from
+		// $AWS_REGION or the shared config profile.
+		if c.AWS.UseIAMRole {
+			if c.AWS.IAMRoleARN == "" {
+				invalid("aws.iam_role_arn", "is required when aws.use_iam_role is true")
+			} else if !strings.HasPrefix(c.AWS.IAMRoleARN, "arn:") {
+				invalid("aws.iam_role_arn", fmt.Sprintf("%q is not an ARN", c.AWS.IAMRoleARN))
+			}
+		} else if c.AWS.IAMRoleARN != "" {
+			invalid("aws.iam_role_arn", "is set but aws.use_iam_role is false")
+		}
+		if c.AWS.RoleDuration < 0 {
+			invalid("aws.role_duration", "must not be negative")
+		}
+		if (c.AWS.AccessKey == "") != (c.AWS.SecretKey == "") {
+			invalid("aws.access_key", "aws.access_key and aws.secret_key must be set together")
+		}
+	case cloud.GCPProvider:
+		if c.GCP == nil {
+			invalid("gcp", "is required when provider is gcp")
+			break
+		}
+		if c.GCP.ProjectID == "" {
+			invalid("gcp.project_id", "is required")
+		}
+		if c.GCP.UseIAMRole && c.GCP.Credentials != "" {
+			invalid("gcp.credentials", "is set but gcp.use_iam_role is true")
+		}
+		if len(c.GCP.Delegates) > 0 && c.GCP.ImpersonateServiceAccount == "" {
+			invalid("gcp.delegates", "requires gcp.impersonate_service_account")
+		}
+		if c.GCP.ImpersonationLifetime < 0 {
+			invalid("gcp.impersonation_lifetime", "must not be negative")
+		}
+	default:
+		invalid("provider", fmt.Sprintf("%q is not supported", c.Provider))
+	}
+	return errors.Join(errs...)
+}
+
+// file is the content of a configuration file.
+type file struct {
+	layer    `yaml:",inline"`
+	Profile  string            `yaml:"profile" json:"profile"`
+	Profiles map[string]*layer `yaml:"profiles" json:"profiles"`
+}
+
+// layer is one source of configuration. Unset values leave lower layers
+// untouched.
+type layer struct {
+	Provider string    `yaml:"provider" json:"provider"`
+	AWS      *awsLayer `yaml:"aws" json:"aws"`
+	GCP      *gcpLayer `yaml:"gcp" json:"gcp"`
+}
+
+type awsLayer struct {
+	UseIAMRole  *bool  `yaml:"use_iam_role" json:"use_iam_role"`
+	AccessKey   string `yaml:"access_key" json:"access_key"`
+	SecretKey   string `yaml:"secret_key" json:"secret_key"`
+	IAMRoleARN  string `yaml:"iam_role_arn" json:"iam_role_arn"`
+	SessionName string `yaml:"session_name" json:"session_name"`
+	Region      string `yaml:"region" json:"region"`
+	// Profile names the shared AWS config profile, not a c2loud profile.
+	Profile    string `yaml:"profile" json:"profile"`
+	ExternalID string `yaml:"external_id" json:"external_id"`
+	MFASerial  string `yaml:"mfa_serial" json:"mfa_serial"`
+	// RoleDuration is a time.ParseDuration string such as "1h".
+	RoleDuration     string `yaml:"role_duration" json:"role_duration"`
+	S3ForcePathStyle *bool  `yaml:"s3_force_path_style" json:"s3_force_path_style"`
+	transportLayer   `yaml:",inline"`
+}
+
+type gcpLayer struct {
+	UseIAMRole  *bool  `yaml:"use_iam_role" json:"use_iam_role"`
+	Credentials string `yaml:"credentials" json:"credentials"`
+	ProjectID   string `yaml:"project_id" json:"project_id"`
+	Region      string `yaml:"region" json:"region"`
+	Zone        string `yaml:"zone" json:"zone"`
+
+	ImpersonateServiceAccount string   `yaml:"impersonate_service_account" json:"impersonate_service_account"`
+	Delegates                 []string `yaml:"delegates" json:"delegates"`
+	// ImpersonationLifetime is a time.ParseDuration string such as "30m".
+	ImpersonationLifetime string `yaml:"impersonation_lifetime" json:"impersonation_lifetime"`
+	transportLayer        `yaml:",inline"`
+}
+
+// transportLayer holds the endpoint and TLS settings shared by all providers.
+type transportLayer struct {
+	Endpoints          map[string]string `yaml:"endpoints" json:"endpoints"`
+	CABundle           string            `yaml:"ca_bundle" json:"ca_bundle"`
+	InsecureSkipVerify *bool             `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+}
+
+// readFile decodes a YAML or JSON file, chosen by extension. Unknown keys are
+// rejected so that typos do not go unnoticed.
+func readFile(path string) (*file, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	var f file
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&f)
+	default:
+		return nil, fmt.Errorf("config: %s: unsupported file type, want .yaml, .yml or .json", path)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// envLayer builds a layer from the prefixed environment variables.
+func (l *Loader) envLayer() (*layer, error) {
+	var env layer
+	var errs []error
+	str := func(key string, dst *string) {
+		if v, ok := l.lookupEnv(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	boolean := func(key string, dst **bool) {
+		v, ok := l.lookupEnv(key)
+		if !ok || v == "" {
+			return
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("config: %s: %q is not a boolean", l.envName(key), v))
+			return
+		}
+		*dst = &b
+	}
+
+	// endpoints reads a comma-separated list of service=url pairs.
+	endpoints := func(key string, dst *map[string]string) {
+		v, ok := l.lookupEnv(key)
+		if !ok || v == "" {
+			return
+		}
+		*dst = make(map[string]string)
+		for _, pair := range strings.Split(v, ",") {
+			service, url, ok := strings.Cut(pair, "=")
+			if !ok || service == "" {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not a service=url pair", l.envName(key), pair))
+				continue
+			}
+			(*dst)[strings.TrimSpace(service)] = strings.TrimSpace(url)
+		}
+	}
+
+	str("PROVIDER", &env.Provider)
+
+	var a awsLayer
+	boolean("AWS_USE_IAM_ROLE", &a.UseIAMRole)
+	str("AWS_ACCESS_KEY", &a.AccessKey)
+	str("AWS_SECRET_KEY", &a.SecretKey)
+	str("AWS_IAM_ROLE_ARN", &a.IAMRoleARN)
+	str("AWS_SESSION_NAME", &a.SessionName)
+	str("AWS_REGION", &a.Region)
+	str("AWS_PROFILE", &a.Profile)
+	str("AWS_EXTERNAL_ID", &a.ExternalID)
+	str("AWS_MFA_SERIAL", &a.MFASerial)
+	str("AWS_ROLE_DURATION", &a.RoleDuration)
+	boolean("AWS_S3_FORCE_PATH_STYLE", &a.S3ForcePathStyle)
+	endpoints("AWS_ENDPOINTS", &a.Endpoints)
+	str("AWS_CA_BUNDLE", &a.CABundle)
+	boolean("AWS_INSECURE_SKIP_VERIFY", &a.InsecureSkipVerify)
+	if !reflect.ValueOf(a).IsZero() {
+		env.AWS = &a
+	}
+
+	var g gcpLayer
+	boolean("GCP_USE_IAM_ROLE", &g.UseIAMRole)
+	str("GCP_CREDENTIALS", &g.Credentials)
+	str("GCP_PROJECT_ID", &g.ProjectID)
+	str("GCP_REGION", &g.Region)
+	str("GCP_ZONE", &g.Zone)
+	str("GCP_IMPERSONATE_SERVICE_ACCOUNT", &g.ImpersonateServiceAccount)
+	if v, ok := l.lookupEnv("GCP_DELEGATES"); ok && v != "" {
+		g.Delegates = strings.Split(v, ",")
+	}
+	str("GCP_IMPERSONATION_LIFETIME", &g.ImpersonationLifetime)
+	endpoints("GCP_ENDPOINTS", &g.Endpoints)
+	str("GCP_CA_BUNDLE", &g.CABundle)
+	boolean("GCP_INSECURE_SKIP_VERIFY", &g.InsecureSkipVerify)
+	if !reflect.ValueOf(g).IsZero() {
+		env.GCP = &g
+	}
+
+	return &env, errors.Join(errs...)
+}
+
+// envName returns the full name of the environment variable key.
+func (l *Loader) envName(key string) string {
+	prefix := l.EnvPrefix
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	return prefix + "_" + key
+}
+
+func (l *Loader) lookupEnv(key string) (string, bool) {
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	return lookup(l.envName(key))
+}
+
+// merge overlays the values set in o.
+func (l *layer) merge(o *layer) {
+	if o == nil {
+		return
+	}
+	setString(&l.Provider, o.Provider)
+	if o.AWS != nil {
+		if l.AWS == nil {
+			l.AWS = &awsLayer{}
+		}
+		l.AWS.merge(o.AWS)
+	}
+	if o.GCP != nil {
+		if l.GCP == nil {
+			l.GCP = &gcpLayer{}
+		}
+		l.GCP.merge(o.GCP)
+	}
+}
+
+func (a *awsLayer) merge(o *awsLayer) {
+	if o.UseIAMRole != nil {
+		a.UseIAMRole = o.UseIAMRole
+	}
+	setString(&a.AccessKey, o.AccessKey)
+	setString(&a.SecretKey, o.SecretKey)
+	setString(&a.IAMRoleARN, o.IAMRoleARN)
+	setString(&a.SessionName, o.SessionName)
+	setString(&a.Region, o.Region)
+	setString(&a.Profile, o.Profile)
+	setString(&a.ExternalID, o.ExternalID)
+	setString(&a.MFASerial, o.MFASerial)
+	setString(&a.RoleDuration, o.RoleDuration)
+	if o.S3ForcePathStyle != nil {
+		a.S3ForcePathStyle = o.S3ForcePathStyle
+	}
+	a.transportLayer.merge(&o.transportLayer)
+}
+
+func (g *gcpLayer) merge(o *gcpLayer) {
+	if o.UseIAMRole != nil {
+		g.UseIAMRole = o.UseIAMRole
+	}
+	setString(&g.Credentials, o.Credentials)
+	setString(&g.ProjectID, o.ProjectID)
+	setString(&g.Region, o.Region)
+	setString(&g.Zone, o.Zone)
+	setString(&g.ImpersonateServiceAccount, o.ImpersonateServiceAccount)
+	if o.Delegates != nil {
+		g.Delegates = o.Delegates
+	}
+	setString(&g.ImpersonationLifetime, o.ImpersonationLifetime)
+	g.transportLayer.merge(&o.transportLayer)
+}
+
+// merge overlays the values set in o. Endpoints are merged per service.
+func (t *transportLayer) merge(o *transportLayer) {
+	for service, url := range o.Endpoints {
+		if t.Endpoints == nil {
+			t.Endpoints = make(map[string]string)
+		}
+		t.Endpoints[service] = url
+	}
+	setString(&t.CABundle, o.CABundle)
+	if o.InsecureSkipVerify != nil {
+		t.InsecureSkipVerify = o.InsecureSkipVerify
+	}
+}
+
+func setString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+// config converts the merged layer into a Config.
+func (l *layer) config() (*Config, error) {
+	cfg := &Config{}
+	switch strings.ToLower(l.Provider) {
+	case "":
+	case "aws":
+		cfg.Provider = cloud.AWSProvider
+	case "gcp":
+		cfg.Provider = cloud.GCPProvider
+	case "azure":
+		cfg.Provider = cloud.AzureProvider
+	default:
+		return nil, &FieldError{Field: "provider", Msg: fmt.Sprintf("unknown provider %q, want aws, gcp or azure", l.Provider)}
+	}
+
+	if a := l.AWS; a != nil {
+		cfg.AWS = &aws.AWSConfig{
+			UseIAMRole:  a.UseIAMRole != nil && *a.UseIAMRole,
+			AccessKey:   a.AccessKey,
+			SecretKey:   a.SecretKey,
+			IAMRoleARN:  a.IAMRoleARN,
+			SessionName: a.SessionName,
+			Region:      a.Region,
+			Profile:     a.Profile,
+			ExternalID:  a.ExternalID,
+			MFASerial:   a.MFASerial,
+
+			S3ForcePathStyle:   a.S3ForcePathStyle != nil && *a.S3ForcePathStyle,
+			Endpoints:          a.Endpoints,
+			CABundle:           a.CABundle,
+			InsecureSkipVerify: a.InsecureSkipVerify != nil && *a.InsecureSkipVerify,
+		}
+		if a.RoleDuration != "" {
+			d, err := time.ParseDuration(a.RoleDuration)
+			if err != nil {
+				return nil, &FieldError{Field: "aws.role_duration", Msg: fmt.Sprintf("%q is not a duration", a.RoleDuration)}
+			}
+			cfg.AWS.RoleDuration = d
+		}
+	}
+	if g := l.GCP; g != nil {
+		cfg.GCP = &gcp.GCPConfig{
+			UseIAMRole:  g.UseIAMRole != nil && *g.UseIAMRole,
+			Credentials: g.Credentials,
+			ProjectID:   g.ProjectID,
+			Region:      g.Region,
+			Zone:        g.Zone,
+
+			ImpersonateServiceAccount: g.ImpersonateServiceAccount,
+			Delegates:                 g.Delegates,
+
+			Endpoints:          g.Endpoints,
+			CABundle:           g.CABundle,
+			InsecureSkipVerify: g.InsecureSkipVerify != nil && *g.InsecureSkipVerify,
+		}
+		if g.ImpersonationLifetime != "" {
+			d, err := time.ParseDuration(g.ImpersonationLifetime)
+			if err != nil {
+				return nil, &FieldError{Field: "gcp.impersonation_lifetime", Msg: fmt.Sprintf("%q is not a duration", g.ImpersonationLifetime)}
+			}
+			cfg.GCP.ImpersonationLifetime = d
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/aws"
+	"github.com/Akshay-Verma-CS/c2loud/cloud/gcp"
+)
+
+// writeConfig writes content to a file named name in a temporary directory.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// env returns a LookupEnv reading from vars.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+// fields returns the sorted fields of the *FieldError values joined in err.
+func fields(err error) []string {
+	var names []string
+	var joined interface{ Unwrap() []error }
+	errs := []error{err}
+	if errors.As(err, &joined) {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			names = append(names, fe.Field)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+const baseConfig = `
+provider: aws
+profile: dev
+aws:
+  region: us-east-1
+  session_name: base
+  endpoints:
+    s3: http://base:9000
+profiles:
+  dev:
+    aws:
+      region: eu-west-1
+      access_key: AKIADEV
+      secret_key: dev-secret
+  prod:
+    aws:
+      region: eu-central-1
+      use_iam_role: true
+      iam_role_arn: arn:aws:iam::123456789012:role/deployer
+      role_duration: 1h
+`
+
+const overrideConfig = `{
+  "aws": {
+    "session_name": "override",
+    "endpoints": {"sqs": "http://override:4566"}
+  },
+  "profiles": {
+    "dev": {"aws": {"access_key": "AKIAOVERRIDE", "secret_key": "override-secret"}}
+  }
+}`
+
+func TestLoadPrecedence(t *testing.T) {
+	files := []string{
+		writeConfig(t, "base.yaml", baseConfig),
+		writeConfig(t, "override.json", overrideConfig),
+	}
+	tests := []struct {
+		name    string
+		profile string
+		env     map[string]string
+		want    *aws.AWSConfig
+	}{
+		{
+			name: "default profile",
+			env:  map[string]string{},
+			want: &aws.AWSConfig{
+				Region:      "eu-west-1",
+				SessionName: "override",
+				AccessKey:   "AKIAOVERRIDE",
+				SecretKey:   "override-secret",
+				Endpoints:   map[string]string{"s3": "http://base:9000", "sqs": "http://override:4566"},
+			},
+		},
+		{
+			name: "profile from the environment",
+			env:  map[string]string{"C2LOUD_PROFILE": "prod"},
+			want: &aws.AWSConfig{
+				Region:       "eu-central-1",
+				SessionName:  "override",
+				UseIAMRole:   true,
+				IAMRoleARN:   "arn:aws:iam::123456789012:role/deployer",
+				RoleDuration: time.Hour,
+				Endpoints:    map[string]string{"s3": "http://base:9000", "sqs": "http://override:4566"},
+			},
+		},
+		{
+			name:    "selected profile over the environment",
+			profile: "dev",
+			env: map[string]string{
+				"C2LOUD_PROFILE":       "prod",
+				"C2LOUD_AWS_REGION":    "ap-south-1",
+				"C2LOUD_AWS_ENDPOINTS": "s3=http://env:9000",
+			},
+			want: &aws.AWSConfig{
+				Region:      "ap-south-1",
+				SessionName: "override",
+				AccessKey:   "AKIAOVERRIDE",
+				SecretKey:   "override-secret",
+				Endpoints:   map[string]string{"s3": "http://env:9000", "sqs": "http://override:4566"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&Loader{Files: files, Profile: tt.profile, LookupEnv: env(tt.env)}).Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Provider != cloud.AWSProvider {
+				t.Errorf("Provider = %q, want aws", cfg.Provider)
+			}
+			if !reflect.DeepEqual(cfg.AWS, tt.want) {
+				t.Errorf("AWS = %+v, want %+v", cfg.AWS, tt.want)
+			}
+		})
+	}
+
+	_, err := (&Loader{Files: files, Profile: "staging", LookupEnv: env(nil)}).Load()
+	if err == nil || !strings.Contains(err.Error(), `"staging" not found`) {
+		t.Errorf("Load of a missing profile: got %v", err)
+	}
+}
+
+func TestLoadConfigFromEnvironment(t *testing.T) {
+	path := writeConfig(t, "c2loud.yml", "provider: gcp\ngcp:\n  project_id: from-file\n")
+	vars := map[string]string{"C2LOUD_CONFIG": path, "C2LOUD_GCP_ZONE": "europe-west1-b"}
+	cfg, err := (&Loader{LookupEnv: env(vars)}).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.GCP.ProjectID != "from-file" || cfg.GCP.Zone != "europe-west1-b" {
+		t.Errorf("GCP = %+v", cfg.GCP)
+	}
+
+	// A custom prefix replaces C2LOUD.
+	vars = map[string]string{"APP_PROVIDER": "gcp", "APP_GCP_PROJECT_ID": "from-env", "C2LOUD_GCP_PROJECT_ID": "ignored"}
+	cfg, err = (&Loader{EnvPrefix: "APP", LookupEnv: env(vars)}).Load()
+	if err != nil || cfg.GCP.ProjectID != "from-env" {
+		t.Errorf("Load with a prefix = %+v, %v", cfg, err)
+	}
+}
+
+func TestEnvTypes(t *testing.T) {
+	vars := map[string]string{
+		"C2LOUD_PROVIDER":                        "GCP",
+		"C2LOUD_GCP_PROJECT_ID":                  "my-project",
+		"C2LOUD_GCP_USE_IAM_ROLE":                "true",
+		"C2LOUD_GCP_INSECURE_SKIP_VERIFY":        "1",
+		"C2LOUD_GCP_IMPERSONATE_SERVICE_ACCOUNT": "reader@my-project.iam.gserviceaccount.com",
+		"C2LOUD_GCP_DELEGATES":                   "a@my-project.iam.gserviceaccount.com,b@my-project.iam.gserviceaccount.com",
+		"C2LOUD_GCP_IMPERSONATION_LIFETIME":      "30m",
+		"C2LOUD_GCP_ENDPOINTS":                   "storage = http://localhost:4443 , pubsub=localhost:8085",
+		"C2LOUD_GCP_CA_BUNDLE":                   "/etc/ssl/ca.pem",
+	}
+	cfg, err := (&Loader{LookupEnv: env(vars)}).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := &gcp.GCPConfig{
+		ProjectID:                 "my-project",
+		UseIAMRole:                true,
+		InsecureSkipVerify:        true,
+		ImpersonateServiceAccount: "reader@my-project.iam.gserviceaccount.com",
+		Delegates:                 []string{"a@my-project.iam.gserviceaccount.com", "b@my-project.iam.gserviceaccount.com"},
+		ImpersonationLifetime:     30 * time.Minute,
+		Endpoints:                 map[string]string{"storage": "http://localhost:4443", "pubsub": "localhost:8085"},
+		CABundle:                  "/etc/ssl/ca.pem",
+	}
+	if cfg.Provider != cloud.GCPProvider || !reflect.DeepEqual(cfg.GCP, want) {
+		t.Errorf("Load = %s %+v, want gcp %+v", cfg.Provider, cfg.GCP, want)
+	}
+
+	// A false boolean in the environment overrides a true one in a file.
+	path := writeConfig(t, "c2loud.yaml", "provider: aws\naws:\n  s3_force_path_style: true\n")
+	cfg, err = (&Loader{Files: []string{path}, LookupEnv: env(map[string]string{"C2LOUD_AWS_S3_FORCE_PATH_STYLE": "false"})}).Load()
+	if err != nil || cfg.AWS.S3ForcePathStyle {
+		t.Errorf("Load = %+v, %v; want S3ForcePathStyle false", cfg.AWS, err)
+	}
+}
+
+func TestEnvErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"boolean", map[string]string{"C2LOUD_AWS_USE_IAM_ROLE": "maybe"}, `C2LOUD_AWS_USE_IAM_ROLE: "maybe" is not a boolean`},
+		{"endpoint", map[string]string{"C2LOUD_AWS_ENDPOINTS": "s3=http://s3,sqs"}, `C2LOUD_AWS_ENDPOINTS: "sqs" is not a service=url pair`},
+		{"duration", map[string]string{"C2LOUD_AWS_ROLE_DURATION": "an hour"}, `aws.role_duration: "an hour" is not a duration`},
+		{"lifetime", map[string]string{"C2LOUD_GCP_IMPERSONATION_LIFETIME": "10"}, `gcp.impersonation_lifetime: "10" is not a duration`},
+		{"provider", map[string]string{"C2LOUD_PROVIDER": "oracle"}, `unknown provider "oracle"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.vars["C2LOUD_PROVIDER"] = cmp.Or(tt.vars["C2LOUD_PROVIDER"], "aws")
+			_, err := (&Loader{LookupEnv: env(tt.vars)}).Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load: got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{"unknown YAML key", "c2loud.yaml", "provider: aws\naws:\n  regoin: us-east-1\n", "field regoin not found"},
+		{"unknown JSON key", "c2loud.json", `{"provider": "aws", "aws": {"regoin": "us-east-1"}}`, `unknown field "regoin"`},
+		{"extension", "c2loud.toml", "provider = 'aws'", "unsupported file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&Loader{Files: []string{writeConfig(t, tt.file, tt.content)}, LookupEnv: env(nil)}).Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load: got %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	// An empty file is valid, and leaves the provider to the environment.
+	cfg, err := (&Loader{
+		Files:     []string{writeConfig(t, "empty.yaml", "")},
+		LookupEnv: env(map[string]string{"C2LOUD_PROVIDER": "aws", "C2LOUD_AWS_REGION": "us-east-1"}),
+	}).Load()
+	if err != nil || cfg.Provider != cloud.AWSProvider {
+		t.Errorf("Load of an empty file = %+v, %v", cfg, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	role := "arn:aws:iam::123456789012:role/deployer"
+	tests := []struct {
+		name string
+		cfg  *Config
+		want []string
+	}{
+		{"no provider", &Config{}, []string{"provider"}},
+		{"unsupported provider", &Config{Provider: cloud.AzureProvider}, []string{"provider"}},
+		{"aws missing", &Config{Provider: cloud.AWSProvider}, []string{"aws"}},
+		{"aws without region", &Config{Provider: cloud.AWSProvider, AWS: &aws.AWSConfig{}}, nil},
+		{"aws role", &Config{Provider: cloud.AWSProvider, AWS: &aws.AWSConfig{UseIAMRole: true, IAMRoleARN: role, RoleDuration: time.Hour}}, nil},
+		{"aws role missing", &Config{Provider: cloud.AWSProvider, AWS: &aws.AWSConfig{UseIAMRole: true}}, []string{"aws.iam_role_arn"}},
+		{"aws role not an ARN", &Config{Provider: cloud.AWSProvider, AWS: &aws.AWSConfig{UseIAMRole: true, IAMRoleARN: "deployer"}}, []string{"aws.iam_role_arn"}},
+		{"aws role unused", &Config{Provider: cloud.AWSProvider, AWS: &aws.AWSConfig{IAMRoleARN: role}}, []string{"aws.iam_role_arn"}},
+		{
+			"aws several",
+			&Config{Provider: cloud.AWSProvider, AWS: &aws.AWSConfig{AccessKey: "AKIA", RoleDuration: -time.Minute}},
+			[]string{"aws.access_key", "aws.role_duration"},
+		},
+		{"gcp missing", &Config{Provider: cloud.GCPProvider}, []string{"gcp"}},
+		{"gcp", &Config{Provider: cloud.GCPProvider, GCP: &gcp.GCPConfig{ProjectID: "p"}}, nil},
+		{
+			"gcp several",
+			&Config{Provider: cloud.GCPProvider, GCP: &gcp.GCPConfig{
+				UseIAMRole:            true,
+				Credentials:           "/etc/gcp.json",
+				Delegates:             []string{"a@p.iam.gserviceaccount.com"},
+				ImpersonationLifetime: -time.Minute,
+			}},
+			[]string{"gcp.credentials", "gcp.delegates", "gcp.impersonation_lifetime", "gcp.project_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if got := fields(err); !slices.Equal(got, tt.want) {
+				t.Errorf("Validate = %v, want errors for %v", err, tt.want)
+			}
+		})
+	}
+}
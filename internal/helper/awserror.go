@@ -0,0 +1,144 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// awsErrorKinds maps AWS error codes to sentinel errors. Codes are checked
+// before HTTP status codes because several services reuse 400 and 409 for
+// unrelated failures.
+var awsErrorKinds = map[string]error{
+	// Not found
+	"NotFound":     cloud.ErrNotFound,
+	"NoSuchBucket": cloud.ErrNotFound,
+	"NoSuchKey":    cloud.ErrNotFound,
+	"NoSuchUpload": cloud.ErrNotFound,
+	"NoSuchEntity": cloud.ErrNotFound,
+	"AWS.SimpleQueueService.NonExistentQueue": cloud.ErrNotFound,
+	"QueueDoesNotExist":                       cloud.ErrNotFound,
+	"ResourceNotFoundException":               cloud.ErrNotFound,
+	"InvalidInstanceID.NotFound":              cloud.ErrNotFound,
+	"InvalidAMIID.NotFound":                   cloud.ErrNotFound,
+
+	// Already exists
+	"BucketAlreadyExists":     cloud.ErrAlreadyExists,
+	"BucketAlreadyOwnedByYou": cloud.ErrAlreadyExists,
+	"EntityAlreadyExists":     cloud.ErrAlreadyExists,
+	"QueueAlreadyExists":      cloud.ErrAlreadyExists,
+	"QueueNameExists":         cloud.ErrAlreadyExists,
+
+	// Permission denied
+	"AccessDenied":          cloud.ErrPermissionDenied,
+	"AccessDeniedException": cloud.ErrPermissionDenied,
+	"AuthorizationError":    cloud.ErrPermissionDenied,
+	"UnauthorizedOperation": cloud.ErrPermissionDenied,
+	"InvalidClientTokenId":  cloud.ErrPermissionDenied,
+	"SignatureDoesNotMatch": cloud.ErrPermissionDenied,
+	"ExpiredToken":          cloud.ErrPermissionDenied,
+	"InvalidAccessKeyId":    cloud.ErrPermissionDenied,
+
+	// Throttled
+	"Throttling":                             cloud.ErrThrottled,
+	"ThrottlingException":                    cloud.ErrThrottled,
+	"ThrottledException":                     cloud.ErrThrottled,
+	"RequestThrottled":                       cloud.ErrThrottled,
+	"RequestThrottledException":              cloud.ErrThrottled,
+	"RequestLimitExceeded":                   cloud.ErrThrottled,
+	"TooManyRequestsException":               cloud.ErrThrottled,
+	"SlowDown":                               cloud.ErrThrottled,
+	"ProvisionedThroughputExceededException": cloud.ErrThrottled,
+	"BandwidthLimitExceeded":                 cloud.ErrThrottled,
+
+	// Conflict
+	"ConflictException":                           cloud.ErrConflict,
+	"AWS.SimpleQueueService.QueueDeletedRecently": cloud.ErrConflict,
+	"OperationAborted":                            cloud.ErrConflict,
+	"BucketNotEmpty":                              cloud.ErrConflict,
+	"DeleteConflict":                              cloud.ErrConflict,
+	"IncorrectInstanceState":                      cloud.ErrConflict,
+	"IncorrectState":                              cloud.ErrConflict,
+	"PreconditionFailed":                          cloud.ErrConflict,
+
+	// Invalid argument
+	"InvalidParameter":            cloud.ErrInvalidArgument,
+	"InvalidParameterValue":       cloud.ErrInvalidArgument,
+	"InvalidParameterException":   cloud.ErrInvalidArgument,
+	"InvalidParameterCombination": cloud.ErrInvalidArgument,
+	"MissingParameter":            cloud.ErrInvalidArgument,
+	"ValidationError":             cloud.ErrInvalidArgument,
+	"ValidationException":         cloud.ErrInvalidArgument,
+	"BadRequestException":         cloud.ErrInvalidArgument,
+	"InvalidArgument":             cloud.ErrInvalidArgument,
+	"InvalidBucketName":           cloud.ErrInvalidArgument,
+	"MalformedPolicyDocument":     cloud.ErrInvalidArgument,
+	"InvalidAttributeName":        cloud.ErrInvalidArgument,
+	"InvalidAttributeValue":       cloud.ErrInvalidArgument,
+	"EntityTooLarge":              cloud.ErrInvalidArgument,
+
+	// Unavailable
+	"InternalError":                cloud.ErrUnavailable,
+	"InternalFailure":              cloud.ErrUnavailable,
+	"InternalServerException":      cloud.ErrUnavailable,
+	"ServiceUnavailable":           cloud.ErrUnavailable,
+	"ServiceUnavailableException":  cloud.ErrUnavailable,
+	"RequestTimeout":               cloud.ErrUnavailable,
+	"RequestTimeoutException":      cloud.ErrUnavailable,
+	request.ErrCodeRequestError:    cloud.ErrUnavailable,
+	request.ErrCodeResponseTimeout: cloud.ErrUnavailable,
+}
+
+// AWSError classifies an aws-sdk-go error and wraps it in a *cloud.Error that
+// keeps the error code, HTTP status code and request ID. It returns nil for a
+// nil error and leaves errors that are already classified unchanged.
+func AWSError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *cloud.Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	e := &cloud.Error{Provider: cloud.AWSProvider, Err: err}
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		e.Code = aerr.Code()
+	}
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) {
+		e.StatusCode = reqErr.StatusCode()
+		e.RequestID = reqErr.RequestID()
+	}
+
+	if kind, ok := awsErrorKinds[e.Code]; ok {
+		e.Kind = kind
+	} else {
+		e.Kind = statusKind(e.StatusCode)
+	}
+	return e
+}
+
+// statusKind classifies an HTTP status code. It returns nil for codes that do
+// not identify a failure class on their own.
+func statusKind(status int) error {
+	switch status {
+	case http.StatusNotFound:
+		return cloud.ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return cloud.ErrPermissionDenied
+	case http.StatusTooManyRequests:
+		return cloud.ErrThrottled
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return cloud.ErrConflict
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return cloud.ErrInvalidArgument
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return cloud.ErrUnavailable
+	}
+	return nil
+}
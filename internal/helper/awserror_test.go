@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+func TestAWSErrorCodes(t *testing.T) {
+	for code, want := range awsErrorKinds {
+		t.Run(code, func(t *testing.T) {
+			// The code decides, whatever the status says.
+			err := AWSError(awserr.NewRequestFailure(awserr.New(code, "failed", nil), http.StatusTeapot, "req-1"))
+			if !errors.Is(err, want) {
+				t.Errorf("AWSError(%s) = %v, want %v", code, err, want)
+			}
+		})
+	}
+}
+
+func TestAWSError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		code      string
+		status    int
+		requestID string
+	}{
+		{"code", awserr.NewRequestFailure(awserr.New("NoSuchKey", "missing", nil), http.StatusNotFound, "req-1"), cloud.ErrNotFound, "NoSuchKey", http.StatusNotFound, "req-1"},
+		{"code over status", awserr.NewRequestFailure(awserr.New("Throttling", "slow down", nil), http.StatusBadRequest, "req-2"), cloud.ErrThrottled, "Throttling", http.StatusBadRequest, "req-2"},
+		{"unknown code", awserr.NewRequestFailure(awserr.New("Teapot", "", nil), http.StatusServiceUnavailable, "req-3"), cloud.ErrUnavailable, "Teapot", http.StatusServiceUnavailable, "req-3"},
+		{"unknown status", awserr.NewRequestFailure(awserr.New("Teapot", "", nil), http.StatusTeapot, ""), nil, "Teapot", http.StatusTeapot, ""},
+		{"no response", awserr.New(request.ErrCodeRequestError, "connection reset", nil), cloud.ErrUnavailable, request.ErrCodeRequestError, 0, ""},
+		{"wrapped", fmt.Errorf("put: %w", awserr.NewRequestFailure(awserr.New("AccessDenied", "", nil), http.StatusForbidden, "req-4")), cloud.ErrPermissionDenied, "AccessDenied", http.StatusForbidden, "req-4"},
+		{"not an AWS error", errors.New("boom"), nil, "", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AWSError(tt.err)
+			var e *cloud.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("AWSError = %T, want *cloud.Error", err)
+			}
+			if e.Kind != tt.kind || e.Code != tt.code || e.StatusCode != tt.status || e.RequestID != tt.requestID || e.Provider != cloud.AWSProvider {
+				t.Errorf("AWSError = %+v, want kind %v, code %q, status %d, request ID %q", e, tt.kind, tt.code, tt.status, tt.requestID)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("AWSError does not wrap the original error")
+			}
+		})
+	}
+
+	if AWSError(nil) != nil {
+		t.Error("AWSError(nil) != nil")
+	}
+	classified := AWSError(awserr.New("NoSuchKey", "", nil))
+	if again := AWSError(classified); again != classified {
+		t.Errorf("AWSError reclassified %v", classified)
+	}
+}
+
+func TestStatusKind(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusBadRequest, cloud.ErrInvalidArgument},
+		{http.StatusUnauthorized, cloud.ErrPermissionDenied},
+		{http.StatusForbidden, cloud.ErrPermissionDenied},
+		{http.StatusNotFound, cloud.ErrNotFound},
+		{http.StatusConflict, cloud.ErrConflict},
+		{http.StatusPreconditionFailed, cloud.ErrConflict},
+		{http.StatusRequestEntityTooLarge, cloud.ErrInvalidArgument},
+		{http.StatusTooManyRequests, cloud.ErrThrottled},
+		{http.StatusInternalServerError, cloud.ErrUnavailable},
+		{http.StatusBadGateway, cloud.ErrUnavailable},
+		{http.StatusServiceUnavailable, cloud.ErrUnavailable},
+		{http.StatusGatewayTimeout, cloud.ErrUnavailable},
+		{http.StatusOK, nil},
+		{http.StatusTeapot, nil},
+		{0, nil},
+	}
+	for _, tt := range tests {
+		if got := statusKind(tt.status); got != tt.want {
+			t.Errorf("statusKind(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
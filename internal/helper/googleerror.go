@@ -0,0 +1,188 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// googleReasonKinds maps googleapi error reasons to sentinel errors. Reasons
+// are checked before HTTP status codes because Google APIs report rate limits
+// as 403.
+var googleReasonKinds = map[string]error{
+	"notFound":                       cloud.ErrNotFound,
+	"alreadyExists":                  cloud.ErrAlreadyExists,
+	"duplicate":                      cloud.ErrAlreadyExists,
+	"forbidden":                      cloud.ErrPermissionDenied,
+	"insufficientPermissions":        cloud.ErrPermissionDenied,
+	"rateLimitExceeded":              cloud.ErrThrottled,
+	"userRateLimitExceeded":          cloud.ErrThrottled,
+	"quotaExceeded":                  cloud.ErrThrottled,
+	"conflict":                       cloud.ErrConflict,
+	"conditionNotMet":                cloud.ErrConflict,
+	"resourceNotReady":               cloud.ErrConflict,
+	"resourceInUseByAnotherResource": cloud.ErrConflict,
+	"invalid":                        cloud.ErrInvalidArgument,
+	"invalidParameter":               cloud.ErrInvalidArgument,
+	"required":                       cloud.ErrInvalidArgument,
+	"backendError":                   cloud.ErrUnavailable,
+	"internalError":                  cloud.ErrUnavailable,
+}
+
+// googleOperationKinds maps the error codes of failed long-running
+// operations, such as Compute Engine zone operations, to sentinel errors.
+var googleOperationKinds = map[string]error{
+	"RESOURCE_NOT_FOUND":                  cloud.ErrNotFound,
+	"NOT_FOUND":                           cloud.ErrNotFound,
+	"RESOURCE_ALREADY_EXISTS":             cloud.ErrAlreadyExists,
+	"ALREADY_EXISTS":                      cloud.ErrAlreadyExists,
+	"PERMISSIONS_ERROR":                   cloud.ErrPermissionDenied,
+	"PERMISSION_DENIED":                   cloud.ErrPermissionDenied,
+	"QUOTA_EXCEEDED":                      cloud.ErrThrottled,
+	"RATE_LIMIT_EXCEEDED":                 cloud.ErrThrottled,
+	"RESOURCE_IN_USE_BY_ANOTHER_RESOURCE": cloud.ErrConflict,
+	"RESOURCE_NOT_READY":                  cloud.ErrConflict,
+	"CONDITION_NOT_MET":                   cloud.ErrConflict,
+	"INVALID_FIELD_VALUE":                 cloud.ErrInvalidArgument,
+	"INVALID_ARGUMENT":                    cloud.ErrInvalidArgument,
+	"REQUIRED_FIELD_MISSING":              cloud.ErrInvalidArgument,
+	"ZONE_RESOURCE_POOL_EXHAUSTED":        cloud.ErrUnavailable,
+	"INTERNAL_ERROR":                      cloud.ErrUnavailable,
+}
+
+// grpcCodeKinds maps gRPC status codes, used by the Pub/Sub clients, to
+// sentinel errors.
+var grpcCodeKinds = map[codes.Code]error{
+	codes.NotFound:           cloud.ErrNotFound,
+	codes.AlreadyExists:      cloud.ErrAlreadyExists,
+	codes.PermissionDenied:   cloud.ErrPermissionDenied,
+	codes.Unauthenticated:    cloud.ErrPermissionDenied,
+	codes.ResourceExhausted:  cloud.ErrThrottled,
+	codes.Aborted:            cloud.ErrConflict,
+	codes.FailedPrecondition: cloud.ErrConflict,
+	codes.InvalidArgument:    cloud.ErrInvalidArgument,
+	codes.OutOfRange:         cloud.ErrInvalidArgument,
+	codes.Unavailable:        cloud.ErrUnavailable,
+	codes.Internal:           cloud.ErrUnavailable,
+}
+
+// GoogleError classifies a Google API error, either a *googleapi.Error from
+// the REST clients or a gRPC status error, and wraps it in a *cloud.Error. It
+// returns nil for a nil error and leaves errors that are already classified
+// unchanged.
+func GoogleError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *cloud.Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	e := &cloud.Error{Provider: cloud.GCPProvider, Err: err}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		e.StatusCode = gerr.Code
+		e.RequestID = googleRequestID(gerr.Details)
+		for _, item := range gerr.Errors {
+			if kind, ok := googleReasonKinds[item.Reason]; ok {
+				e.Code = item.Reason
+				e.Kind = kind
+				return e
+			}
+		}
+		if len(gerr.Errors) > 0 {
+			e.Code = gerr.Errors[0].Reason
+		}
+		e.Kind = statusKind(e.StatusCode)
+		return e
+	}
+
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		e.Code = s.Code().String()
+		e.StatusCode = grpcHTTPStatus(s.Code())
+		e.Kind = grpcCodeKinds[s.Code()]
+		for _, detail := range s.Details() {
+			if info, ok := detail.(*errdetails.RequestInfo); ok {
+				e.RequestID = info.GetRequestId()
+			}
+		}
+	}
+	return e
+}
+
+// requestInfoType is the type of the error detail carrying the request ID in
+// Google API errors.
+const requestInfoType = "type.googleapis.com/google.rpc.RequestInfo"
+
+// googleRequestID returns the request ID in the details of a REST API error,
+// or "" when the API reported none.
+func googleRequestID(details []interface{}) string {
+	for _, detail := range details {
+		if fields, ok := detail.(map[string]interface{}); ok && fields["@type"] == requestInfoType {
+			if id, ok := fields["requestId"].(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// GoogleOperationError classifies the first error, with code and message, of
+// a failed long-running operation, whose HTTP status is statusCode, and
+// wraps it in a *cloud.Error. Codes not known are classified by statusCode.
+func GoogleOperationError(code, message string, statusCode int) error {
+	e := &cloud.Error{
+		Provider:   cloud.GCPProvider,
+		Code:       code,
+		StatusCode: statusCode,
+		Err:        errors.New(message),
+	}
+	if kind, ok := googleOperationKinds[code]; ok {
+		e.Kind = kind
+	} else {
+		e.Kind = statusKind(statusCode)
+	}
+	return e
+}
+
+// GoogleNotFound wraps err, which the caller has identified as a not-found
+// condition (such as storage.ErrObjectNotExist), as cloud.ErrNotFound.
+func GoogleNotFound(err error) error {
+	return &cloud.Error{
+		Kind:       cloud.ErrNotFound,
+		Provider:   cloud.GCPProvider,
+		StatusCode: http.StatusNotFound,
+		Err:        err,
+	}
+}
+
+// grpcHTTPStatus returns the HTTP status code equivalent to a gRPC code.
+func grpcHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition, codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Internal:
+		return http.StatusInternalServerError
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGoogleErrorReasons(t *testing.T) {
+	for reason, want := range googleReasonKinds {
+		t.Run(reason, func(t *testing.T) {
+			err := GoogleError(&googleapi.Error{Code: http.StatusTeapot, Errors: []googleapi.ErrorItem{{Reason: reason}}})
+			if !errors.Is(err, want) {
+				t.Errorf("GoogleError(%s) = %v, want %v", reason, err, want)
+			}
+		})
+	}
+}
+
+func TestGoogleErrorCodes(t *testing.T) {
+	for code, want := range grpcCodeKinds {
+		t.Run(code.String(), func(t *testing.T) {
+			err := GoogleError(status.Error(code, "failed"))
+			if !errors.Is(err, want) {
+				t.Errorf("GoogleError(%s) = %v, want %v", code, err, want)
+			}
+			var e *cloud.Error
+			if errors.As(err, &e) && e.StatusCode != grpcHTTPStatus(code) {
+				t.Errorf("StatusCode = %d, want %d", e.StatusCode, grpcHTTPStatus(code))
+			}
+		})
+	}
+}
+
+func TestGoogleOperationErrorCodes(t *testing.T) {
+	for code, want := range googleOperationKinds {
+		t.Run(code, func(t *testing.T) {
+			if err := GoogleOperationError(code, "failed", 0); !errors.Is(err, want) {
+				t.Errorf("GoogleOperationError(%s) = %v, want %v", code, err, want)
+			}
+		})
+	}
+	if err := GoogleOperationError("UNDOCUMENTED", "failed", http.StatusConflict); !errors.Is(err, cloud.ErrConflict) {
+		t.Errorf("GoogleOperationError of an unknown code = %v, want ErrConflict", err)
+	}
+}
+
+func TestGoogleError(t *testing.T) {
+	withRequestInfo, _ := status.New(codes.NotFound, "no topic").WithDetails(&errdetails.RequestInfo{RequestId: "grpc-req-1"})
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		code      string
+		status    int
+		requestID string
+	}{
+		{
+			name: "reason over status",
+			err:  &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}},
+			kind: cloud.ErrThrottled, code: "rateLimitExceeded", status: http.StatusForbidden,
+		},
+		{
+			name: "first known reason",
+			err:  &googleapi.Error{Code: http.StatusBadRequest, Errors: []googleapi.ErrorItem{{Reason: "teapot"}, {Reason: "required"}}},
+			kind: cloud.ErrInvalidArgument, code: "required", status: http.StatusBadRequest,
+		},
+		{
+			name: "unknown reason",
+			err:  &googleapi.Error{Code: http.StatusServiceUnavailable, Errors: []googleapi.ErrorItem{{Reason: "teapot"}}},
+			kind: cloud.ErrUnavailable, code: "teapot", status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "request ID in details",
+			err: &googleapi.Error{
+				Code: http.StatusNotFound,
+				Details: []interface{}{
+					map[string]interface{}{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "NOT_FOUND"},
+					map[string]interface{}{"@type": "type.googleapis.com/google.rpc.RequestInfo", "requestId": "rest-req-1"},
+				},
+			},
+			kind: cloud.ErrNotFound, status: http.StatusNotFound, requestID: "rest-req-1",
+		},
+		{
+			name: "upload ID is not a request ID",
+			err:  &googleapi.Error{Code: http.StatusNotFound, Header: http.Header{"X-Guploader-Uploadid": {"upload-1"}}},
+			kind: cloud.ErrNotFound, status: http.StatusNotFound,
+		},
+		{
+			name: "gRPC",
+			err:  status.Error(codes.ResourceExhausted, "quota"),
+			kind: cloud.ErrThrottled, code: "ResourceExhausted", status: http.StatusTooManyRequests,
+		},
+		{
+			name: "gRPC request ID",
+			err:  withRequestInfo.Err(),
+			kind: cloud.ErrNotFound, code: "NotFound", status: http.StatusNotFound, requestID: "grpc-req-1",
+		},
+		{
+			name: "not a Google error",
+			err:  errors.New("boom"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GoogleError(tt.err)
+			var e *cloud.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("GoogleError = %T, want *cloud.Error", err)
+			}
+			if e.Kind != tt.kind || e.Code != tt.code || e.StatusCode != tt.status || e.RequestID != tt.requestID || e.Provider != cloud.GCPProvider {
+				t.Errorf("GoogleError = %+v, want kind %v, code %q, status %d, request ID %q", e, tt.kind, tt.code, tt.status, tt.requestID)
+			}
+		})
+	}
+
+	if GoogleError(nil) != nil {
+		t.Error("GoogleError(nil) != nil")
+	}
+	if err := GoogleNotFound(errors.New("object doesn't exist")); !errors.Is(err, cloud.ErrNotFound) {
+		t.Errorf("GoogleNotFound = %v, want ErrNotFound", err)
+	}
+}
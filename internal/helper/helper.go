@@ -0,0 +1,2 @@
+// Package helper contains utilities shared by the provider packages.
+package helper
@@ -0,0 +1,40 @@
+package helper
+
+import "github.com/Akshay-Verma-CS/c2loud/cloud"
+
+// ListPrefix returns the prefix of opts, which may be nil.
+func ListPrefix(opts *cloud.ListOptions) string {
+	if opts == nil {
+		return ""
+	}
+	return opts.Prefix
+}
+
+// PageSize returns the page size to request for opts: PageSize, lowered to
+// MaxItems when fewer items are wanted. Zero means the provider default.
+func PageSize(opts *cloud.ListOptions) int {
+	if opts == nil {
+		return 0
+	}
+	size := opts.PageSize
+	if opts.MaxItems > 0 && (size == 0 || opts.MaxItems < size) {
+		size = opts.MaxItems
+	}
+	return size
+}
+
+// Limit wraps the yield function of an iterator so that it reports false,
+// ending the iteration, once opts.MaxItems items have been yielded.
+func Limit[T any](opts *cloud.ListOptions, yield func(T, error) bool) func(T, error) bool {
+	if opts == nil || opts.MaxItems <= 0 {
+		return yield
+	}
+	n := 0
+	return func(item T, err error) bool {
+		if err != nil {
+			return yield(item, err)
+		}
+		n++
+		return yield(item, nil) && n < opts.MaxItems
+	}
+}
@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+)
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *cloud.ListOptions
+		want int
+	}{
+		{"nil", nil, 0},
+		{"zero", &cloud.ListOptions{}, 0},
+		{"page size", &cloud.ListOptions{PageSize: 50}, 50},
+		{"max items", &cloud.ListOptions{MaxItems: 7}, 7},
+		{"fewer items than a page", &cloud.ListOptions{PageSize: 50, MaxItems: 7}, 7},
+		{"more items than a page", &cloud.ListOptions{PageSize: 5, MaxItems: 7}, 5},
+		{"prefix only", &cloud.ListOptions{Prefix: "logs/"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PageSize(tt.opts); got != tt.want {
+				t.Errorf("PageSize(%+v) = %d, want %d", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name  string
+		opts  *cloud.ListOptions
+		items []int
+		err   error // yielded after items
+		stop  int   // the consumer stops after this many items; 0 never
+		want  []int
+		calls int
+	}{
+		{"nil", nil, []int{1, 2, 3}, nil, 0, []int{1, 2, 3}, 3},
+		{"unlimited", &cloud.ListOptions{PageSize: 2}, []int{1, 2, 3}, nil, 0, []int{1, 2, 3}, 3},
+		{"max items", &cloud.ListOptions{MaxItems: 2}, []int{1, 2, 3}, nil, 0, []int{1, 2}, 2},
+		{"fewer items than max", &cloud.ListOptions{MaxItems: 5}, []int{1, 2}, nil, 0, []int{1, 2}, 2},
+		{"consumer stops first", &cloud.ListOptions{MaxItems: 3}, []int{1, 2, 3}, nil, 1, []int{1}, 1},
+		{"error passes through", &cloud.ListOptions{MaxItems: 3}, []int{1}, boom, 0, []int{1}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			var gotErr error
+			calls := 0
+			yield := Limit(tt.opts, func(item int, err error) bool {
+				calls++
+				if err != nil {
+					gotErr = err
+					return false
+				}
+				got = append(got, item)
+				return tt.stop == 0 || len(got) < tt.stop
+			})
+			more := true
+			for _, item := range tt.items {
+				if more = yield(item, nil); !more {
+					break
+				}
+			}
+			if more && tt.err != nil {
+				yield(0, tt.err)
+			}
+			if !slices.Equal(got, tt.want) || calls != tt.calls {
+				t.Errorf("yielded %v in %d calls, want %v in %d", got, calls, tt.want, tt.calls)
+			}
+			if gotErr != tt.err {
+				t.Errorf("error = %v, want %v", gotErr, tt.err)
+			}
+		})
+	}
+}
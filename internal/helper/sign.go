@@ -0,0 +1,46 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Akshay-Verma-CS/c2loud/cloud"
+)
+
+// SignExpiry returns the lifetime of a signed URL or POST policy: expires, or
+// cloud.DefaultSignExpiry when zero.
+func SignExpiry(expires time.Duration) (time.Duration, error) {
+	if expires == 0 {
+		return cloud.DefaultSignExpiry, nil
+	}
+	if expires < 0 || expires > cloud.MaxSignExpiry {
+		return 0, fmt.Errorf("expiry %s is not between 0 and %s: %w", expires, cloud.MaxSignExpiry, cloud.ErrInvalidArgument)
+	}
+	return expires, nil
+}
+
+// SignMethod returns the upper-case HTTP method of a signed URL: method, or
+// GET when empty.
+func SignMethod(method string) (string, error) {
+	switch method = strings.ToUpper(method); method {
+	case "":
+		return http.MethodGet, nil
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return method, nil
+	}
+	return "", fmt.Errorf("method %q cannot be signed: %w", method, cloud.ErrInvalidArgument)
+}
+
+// SuccessStatus returns the status of the response to a successful POST
+// upload: status, or 204 when zero.
+func SuccessStatus(status int) (int, error) {
+	switch status {
+	case 0:
+		return http.StatusNoContent, nil
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return status, nil
+	}
+	return 0, fmt.Errorf("success status %d is not 200, 201 or 204: %w", status, cloud.ErrInvalidArgument)
+}
@@ -0,0 +1,63 @@
+package helper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSConfig returns a TLS configuration that trusts the PEM certificates in
+// the caBundle file in addition to the system roots and, if insecure is set,
+// skips server certificate verification. It returns nil when neither is
+// requested.
+func TLSConfig(caBundle string, insecure bool) (*tls.Config, error) {
+	if caBundle == "" && !insecure {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: insecure}
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no certificates", caBundle)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// HTTPClient returns base with tlsConfig applied to a copy of its transport.
+// base may be nil, in which case a client with the default transport is
+// used. It returns base unchanged when tlsConfig is nil, and fails when the
+// transport of base is not an *http.Transport.
+func HTTPClient(base *http.Client, tlsConfig *tls.Config) (*http.Client, error) {
+	if tlsConfig == nil {
+		return base, nil
+	}
+
+	client := &http.Client{}
+	if base != nil {
+		*client = *base
+	}
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply TLS settings to HTTP transport %T", rt)
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return client, nil
+}
@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes data to a file in a temporary directory.
+func writeFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// tlsServer returns a started TLS server with config, which does not log
+// the handshakes that fail on purpose.
+func tlsServer(t *testing.T, config *tls.Config) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = config
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// get requests url with a client configured by TLSConfig and HTTPClient.
+func get(t *testing.T, url, caBundle string, insecure bool) error {
+	t.Helper()
+	tlsConfig, err := TLSConfig(caBundle, insecure)
+	if err != nil {
+		t.Fatalf("TLSConfig: %v", err)
+	}
+	client, err := HTTPClient(nil, tlsConfig)
+	if err != nil {
+		t.Fatalf("HTTPClient: %v", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestTLSOptions(t *testing.T) {
+	server := tlsServer(t, nil)
+	caBundle := writeFile(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	if err := get(t, server.URL, "", false); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("GET without the CA bundle: got %v, want a certificate error", err)
+	}
+	if err := get(t, server.URL, caBundle, false); err != nil {
+		t.Errorf("GET trusting the CA bundle: %v", err)
+	}
+	if err := get(t, server.URL, "", true); err != nil {
+		t.Errorf("GET skipping verification: %v", err)
+	}
+
+	// Neither option lowers the minimum TLS version of the client.
+	old := tlsServer(t, &tls.Config{MaxVersion: tls.VersionTLS11})
+	if err := get(t, old.URL, "", true); err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Errorf("GET from a TLS 1.1 server: got %v, want a protocol version error", err)
+	}
+	if err := get(t, old.URL, caBundle, false); err == nil {
+		t.Error("GET from a TLS 1.1 server with the CA bundle succeeded")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	cfg, err := TLSConfig("", false)
+	if cfg != nil || err != nil {
+		t.Errorf("TLSConfig without options = %v, %v; want nil", cfg, err)
+	}
+	cfg, err = TLSConfig("", true)
+	if err != nil || !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Errorf("TLSConfig(insecure) = %+v, %v", cfg, err)
+	}
+	if cfg.MinVersion != 0 {
+		t.Errorf("MinVersion = %x, want the default", cfg.MinVersion)
+	}
+
+	if _, err := TLSConfig(filepath.Join(t.TempDir(), "missing.pem"), false); err == nil || !strings.Contains(err.Error(), "reading CA bundle") {
+		t.Errorf("TLSConfig with a missing bundle: got %v", err)
+	}
+	if _, err := TLSConfig(writeFile(t, []byte("not a certificate")), false); err == nil || !strings.Contains(err.Error(), "contains no certificates") {
+		t.Errorf("TLSConfig with an empty bundle: got %v", err)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	base := &http.Client{Timeout: time.Minute, Transport: &http.Transport{MaxIdleConns: 7}}
+	if client, err := HTTPClient(base, nil); client != base || err != nil {
+		t.Errorf("HTTPClient without TLS settings = %p, %v; want the base client", client, err)
+	}
+	if client, err := HTTPClient(nil, nil); client != nil || err != nil {
+		t.Errorf("HTTPClient(nil, nil) = %v, %v; want nil", client, err)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	client, err := HTTPClient(base, tlsConfig)
+	if err != nil {
+		t.Fatalf("HTTPClient: %v", err)
+	}
+	if client == base || client.Timeout != time.Minute {
+		t.Errorf("HTTPClient = %+v, want a copy of the base client", client)
+	}
+	transport := client.Transport.(*http.Transport)
+	if transport.TLSClientConfig != tlsConfig || transport.MaxIdleConns != 7 {
+		t.Errorf("transport = %+v, want a clone with the TLS settings", transport)
+	}
+	if cfg := base.Transport.(*http.Transport).TLSClientConfig; cfg == tlsConfig || cfg != nil && cfg.InsecureSkipVerify {
+		t.Error("HTTPClient applied the TLS settings to the transport of the base client")
+	}
+
+	client, err = HTTPClient(nil, tlsConfig)
+	if err != nil || client.Transport == http.DefaultTransport {
+		t.Errorf("HTTPClient(nil) = %+v, %v; want a clone of the default transport", client, err)
+	}
+
+	roundTripper := &http.Client{Transport: http.NewFileTransport(http.Dir("."))}
+	if _, err := HTTPClient(roundTripper, tlsConfig); err == nil {
+		t.Error("HTTPClient applied TLS settings to a transport that is not an *http.Transport")
+	}
+}
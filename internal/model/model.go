@@ -0,0 +1,262 @@
+// Package model defines the provider-neutral resource types returned by
+// c2loud. Every type carries a Raw field holding the SDK value it was
+// converted from, for callers that need provider-specific details. The types
+// are re-exported by package cloud.
+package model
+
+import (
+	"log/slog"
+	"time"
+)
+
+// VersioningState is the normalized versioning state of a bucket.
+type VersioningState string
+
+const (
+	VersioningDisabled  VersioningState = "disabled"
+	VersioningEnabled   VersioningState = "enabled"
+	VersioningSuspended VersioningState = "suspended"
+)
+
+// Bucket is a storage bucket.
+type Bucket struct {
+	Name       string
+	Location   string
+	Versioning VersioningState
+	CreatedAt  time.Time
+	Labels     map[string]string
+	Raw        interface{}
+}
+
+// Object is an object stored in a bucket.
+type Object struct {
+	Bucket       string
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+	Metadata     map[string]string
+	Raw          interface{}
+}
+
+// Queue is a point-to-point message queue: an SQS queue or a Pub/Sub
+// subscription.
+type Queue struct {
+	// ID is the queue URL for SQS and the full subscription name for Pub/Sub.
+	ID   string
+	Name string
+	// ApproximateMessages is the approximate number of visible messages, when
+	// the provider reports it.
+	ApproximateMessages int64
+	Attributes          map[string]string
+	Raw                 interface{}
+}
+
+// Message is a message sent to or received from a queue.
+type Message struct {
+	// ID is the provider-assigned message ID.
+	ID         string
+	Body       []byte
+	Attributes map[string]string
+	// AckHandle identifies a received message in calls to Ack, Nack and
+	// ExtendVisibility. It is empty for messages that have not been received.
+	AckHandle string
+	// ReceiveCount is the number of times the message has been delivered,
+	// when the provider reports it.
+	ReceiveCount int
+	SentAt       time.Time
+	Raw          interface{}
+}
+
+// LogValue implements slog.LogValuer. Message bodies and attributes may hold
+// sensitive data, so only their sizes are logged.
+func (m *Message) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", m.ID),
+		slog.Int("body_bytes", len(m.Body)),
+		slog.Int("attributes", len(m.Attributes)),
+		slog.Int("receive_count", m.ReceiveCount),
+	)
+}
+
+// Topic is a publish/subscribe topic.
+type Topic struct {
+	// ID is the topic ARN for SNS and the full topic name for Pub/Sub.
+	ID   string
+	Name string
+	Raw  interface{}
+}
+
+// SubscriptionState is the normalized state of a topic subscription.
+type SubscriptionState string
+
+const (
+	SubscriptionActive  SubscriptionState = "active"
+	SubscriptionPending SubscriptionState = "pending"
+	SubscriptionError   SubscriptionState = "error"
+	SubscriptionUnknown SubscriptionState = "unknown"
+)
+
+// Subscription is a subscription attached to a topic.
+type Subscription struct {
+	// ID is the subscription ARN for SNS and the full subscription name for
+	// Pub/Sub.
+	ID       string
+	Topic    string
+	Protocol string
+	Endpoint string
+	State    SubscriptionState
+	Raw      interface{}
+}
+
+// InstanceState is the normalized lifecycle state of a VM instance.
+type InstanceState string
+
+const (
+	InstancePending     InstanceState = "pending"
+	InstanceRunning     InstanceState = "running"
+	InstanceStopping    InstanceState = "stopping"
+	InstanceStopped     InstanceState = "stopped"
+	InstanceTerminating InstanceState = "terminating"
+	InstanceTerminated  InstanceState = "terminated"
+	InstanceUnknown     InstanceState = "unknown"
+)
+
+// Instance is a VM instance.
+type Instance struct {
+	// ID identifies the instance in Compute calls: the instance ID for EC2
+	// and "zone/name" for Compute Engine.
+	ID          string
+	Name        string
+	State       InstanceState
+	MachineType string
+	// Image is the AMI ID for EC2. Compute Engine does not report it on the
+	// instance, so it is empty there.
+	Image      string
+	Zone       string
+	PrivateIPs []string
+	PublicIPs  []string
+	// Labels holds EC2 tags or Compute Engine labels.
+	Labels     map[string]string
+	LaunchTime time.Time
+	Raw        interface{}
+}
+
+// User is an identity principal such as an IAM user.
+type User struct {
+	ID        string
+	Name      string
+	ARN       string
+	Path      string
+	CreatedAt time.Time
+	Labels    map[string]string
+	Raw       interface{}
+}
+
+// ClusterState is the normalized state of a Kubernetes cluster.
+type ClusterState string
+
+const (
+	ClusterProvisioning ClusterState = "provisioning"
+	ClusterRunning      ClusterState = "running"
+	ClusterReconciling  ClusterState = "reconciling"
+	ClusterStopping     ClusterState = "stopping"
+	ClusterError        ClusterState = "error"
+	ClusterDegraded     ClusterState = "degraded"
+	ClusterUnknown      ClusterState = "unknown"
+)
+
+// Cluster is a managed Kubernetes cluster.
+type Cluster struct {
+	Name      string
+	Location  string
+	State     ClusterState
+	Version   string
+	Endpoint  string
+	NodeCount int64
+	Labels    map[string]string
+	CreatedAt time.Time
+	Raw       interface{}
+}
+
+// ApplicationState is the normalized serving state of an application.
+type ApplicationState string
+
+const (
+	ApplicationServing  ApplicationState = "serving"
+	ApplicationDisabled ApplicationState = "disabled"
+	ApplicationUnknown  ApplicationState = "unknown"
+)
+
+// Application is a hosted application such as an App Engine application.
+type Application struct {
+	ID       string
+	Name     string
+	Location string
+	// Hostname is the default hostname the application is served on.
+	Hostname string
+	State    ApplicationState
+	Raw      interface{}
+}
+
+// FunctionState is the normalized deployment state of a serverless function.
+type FunctionState string
+
+const (
+	FunctionDeploying FunctionState = "deploying"
+	FunctionActive    FunctionState = "active"
+	FunctionDeleting  FunctionState = "deleting"
+	FunctionError     FunctionState = "error"
+	FunctionUnknown   FunctionState = "unknown"
+)
+
+// Function is a serverless function such as a Cloud Function.
+type Function struct {
+	// ID is the full resource name of the function.
+	ID         string
+	Name       string
+	Runtime    string
+	EntryPoint string
+	State      FunctionState
+	// URL is the HTTPS endpoint of functions triggered over HTTP.
+	URL       string
+	Labels    map[string]string
+	UpdatedAt time.Time
+	Raw       interface{}
+}
+
+// ConfigurationProfile is an AppConfig configuration profile: where the
+// configuration data of an application is kept.
+type ConfigurationProfile struct {
+	ID          string
+	Application string
+	Name        string
+	Description string
+	LocationURI string
+	// Type is the kind of profile, such as AWS.Freeform or
+	// AWS.AppConfig.FeatureFlags.
+	Type string
+	Raw  interface{}
+}
+
+// EnvironmentState is the normalized deployment state of an environment.
+type EnvironmentState string
+
+const (
+	EnvironmentReady       EnvironmentState = "ready"
+	EnvironmentDeploying   EnvironmentState = "deploying"
+	EnvironmentRollingBack EnvironmentState = "rolling_back"
+	EnvironmentRolledBack  EnvironmentState = "rolled_back"
+	EnvironmentUnknown     EnvironmentState = "unknown"
+)
+
+// Environment is an AppConfig environment configuration is deployed to.
+type Environment struct {
+	ID          string
+	Application string
+	Name        string
+	Description string
+	State       EnvironmentState
+	Raw         interface{}
+}